@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/fakegithub"
+	"github.com/spf13/cobra"
+)
+
+// demoServerCmd represents the demo-server command
+var demoServerCmd = &cobra.Command{
+	Use:   "demo-server",
+	Short: "Serve a fake GitHub API of a synthetic enterprise to demo the reports against",
+	Run: func(cmd *cobra.Command, args []string) {
+		addr, _ := cmd.Flags().GetString("addr")
+		fixturePath, _ := cmd.Flags().GetString("fixture")
+		pageSize, _ := cmd.Flags().GetInt("page-size")
+		latency, _ := cmd.Flags().GetDuration("latency")
+
+		var fixture *fakegithub.Fixture
+		var err error
+		if fixturePath == "" {
+			fixture, err = fakegithub.DemoFixture()
+		} else {
+			fixture, err = fakegithub.LoadFixture(fixturePath)
+		}
+		if err != nil {
+			slog.Error("failed to load fixture", "error", err)
+			os.Exit(1)
+		}
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			slog.Error("failed to listen", "addr", addr, "error", err)
+			os.Exit(1)
+		}
+		server := &http.Server{
+			Handler:           fakegithub.NewServer(fixture, fakegithub.Options{PageSize: pageSize, Latency: latency}),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			<-cmd.Context().Done()
+			if err := server.Close(); err != nil {
+				slog.Warn("failed to close demo server", "error", err)
+			}
+		}()
+
+		baseURL := "http://" + listener.Addr().String() + "/"
+		slog.Info("serving demo enterprise", "enterprise", fixture.Enterprise, "url", baseURL)
+		slog.Info("run the reports against it with",
+			"flags", "--base-url "+baseURL+" --auth-method token --token demo --enterprise "+fixture.Enterprise+" --organizations")
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("demo server failed", "error", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	demoServerCmd.Flags().String("addr", "127.0.0.1:8080", "Address to serve the fake API on")
+	demoServerCmd.Flags().String("fixture", "", "JSON fixture describing the enterprise to serve (default: the built-in demo enterprise)")
+	demoServerCmd.Flags().Int("page-size", 0, "Largest page the API returns, to exercise pagination (default: 100)")
+	demoServerCmd.Flags().Duration("latency", 0, "Delay added to every response, e.g. 200ms")
+}
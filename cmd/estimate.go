@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/config"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/report"
+	"github.com/spf13/cobra"
+)
+
+// estimateCmd represents the estimate command
+var estimateCmd = &cobra.Command{
+	Use:   "estimate",
+	Short: "Estimate the API points and time the selected reports need, without running them",
+	Long: `Estimate fetches only the number of organizations, repositories, teams and users of the
+enterprise, and predicts the REST, GraphQL and audit log points and the wall-clock time of each
+selected report from them, the current rate limits and the number of workers and credentials.`,
+	PreRunE: loadConfig,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		providers := profileProviders
+		if len(providers) == 0 {
+			providers = []*config.ManagerProvider{configProvider}
+		}
+		for _, provider := range providers {
+			if len(profileProviders) > 0 {
+				fmt.Printf("Profile %s:\n", provider.GetProfile())
+			}
+			if err := runEstimate(ctx, provider); err != nil {
+				slog.Error("estimate failed", "profile", provider.GetProfile(), "error", err)
+				os.Exit(1)
+			}
+			fmt.Println()
+		}
+	},
+}
+
+// runEstimate creates the clients for the provider's configuration and prints the estimated cost
+// of its selected reports.
+func runEstimate(ctx context.Context, provider *config.ManagerProvider) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(provider.GetLogLevel())); err != nil {
+		slog.Warn("invalid log level specified, defaulting to info", "error", err)
+		level = slog.LevelInfo
+	}
+	setLogLevel(level)
+
+	restClient, err := provider.CreateRESTClient()
+	if err != nil {
+		return fmt.Errorf("creating rest client: %w", err)
+	}
+	graphQLClient, err := provider.CreateGraphQLClient()
+	if err != nil {
+		return fmt.Errorf("creating graphql client: %w", err)
+	}
+
+	estimate, err := report.NewReportExecutor(provider).Estimate(ctx, restClient, graphQLClient)
+	if err != nil {
+		return err
+	}
+	return estimate.Write(os.Stdout)
+}
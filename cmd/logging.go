@@ -1,12 +1,20 @@
 package cmd
 
 import (
+	"io"
 	"log/slog"
 	"os"
 
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/logging"
 )
 
+var (
+	// logLevel is the current log level
+	logLevel = slog.LevelInfo
+	// console receives the terminal logs; a progress display while reports run
+	console io.Writer = os.Stderr
+)
+
 // SetupLogging initializes logging to file and terminal
 func SetupLogging() error {
 	var err error
@@ -32,5 +40,12 @@ func CloseLogging() {
 
 // setLogLevel updates the current log level
 func setLogLevel(level slog.Level) {
-	logging.SetupLogging(logFile, level)
+	logLevel = level
+	logging.SetupLoggingWithConsole(logFile, console, level)
+}
+
+// setConsole writes the terminal logs to w, keeping the current log level
+func setConsole(w io.Writer) {
+	console = w
+	logging.SetupLoggingWithConsole(logFile, console, logLevel)
 }
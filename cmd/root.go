@@ -3,12 +3,15 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/config"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/progress"
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/report"
 	"github.com/spf13/cobra"
 )
@@ -16,70 +19,187 @@ import (
 var (
 	configProvider *config.ManagerProvider
 	logFile        *os.File
+
+	// profileProviders hold the configuration of each profile of a batch run
+	profileProviders []*config.ManagerProvider
 )
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "gh-enterprise-reports",
-	Short: "A CLI extension to generate GitHub Enterprise reports",
-	PreRunE: func(cmd *cobra.Command, args []string) error {
-		return configProvider.LoadConfig()
-	},
+	Use:     "gh-enterprise-reports",
+	Short:   "A CLI extension to generate GitHub Enterprise reports",
+	PreRunE: loadConfig,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Set log level from configuration.
-		var level slog.Level
-		if err := level.UnmarshalText([]byte(configProvider.GetLogLevel())); err != nil {
-			slog.Warn("invalid log level specified, defaulting to info", "error", err)
-			level = slog.LevelInfo
+		ctx := cmd.Context()
+
+		if len(profileProviders) > 0 {
+			if err := runBatch(ctx, profileProviders); err != nil {
+				slog.Error("batch run failed", "error", err)
+				os.Exit(1)
+			}
+			return
 		}
-		// reconfigure slog at chosen level
-		setLogLevel(level)
 
-		// Create REST and GraphQL clients with retry mechanism using the new interface
-		restClient, err := configProvider.CreateRESTClient()
-		if err != nil {
-			slog.Error("creating rest client", "error", err)
+		if _, err := runReports(ctx, configProvider); err != nil {
+			slog.Error("report execution failed", "error", err)
 			os.Exit(1)
 		}
-		// Create retryable REST client
-		retryableREST := api.NewRetryableRESTClient(restClient, 3, 500*time.Millisecond)
-		slog.Debug("created retryable REST client", "maxRetries", retryableREST.MaxRetries)
+	},
+}
 
-		graphQLClient, err := configProvider.CreateGraphQLClient()
+// loadConfig loads the configuration, or the configuration of each profile of a batch run.
+func loadConfig(cmd *cobra.Command, args []string) error {
+	// A batch run loads each of its profiles rather than a single configuration
+	if len(configProvider.GetProfiles()) > 0 {
+		providers, err := configProvider.LoadProfiles()
 		if err != nil {
-			slog.Error("creating graphql client", "error", err)
-			os.Exit(1)
+			return err
 		}
-		// Create retryable GraphQL client
-		retryableGraphQL := api.NewRetryableGraphQLClient(graphQLClient, 3, 500*time.Millisecond)
-		slog.Debug("created retryable GraphQL client", "maxRetries", retryableGraphQL.MaxRetries)
-
-		// Log the configuration details with a standout banner.
-		slog.Info("==================================================")
-		slog.Info("configuration values:",
-			"auth_method", configProvider.GetAuthMethod(),
-			"base_url", configProvider.GetBaseURL(),
-			"enterprise", configProvider.GetEnterpriseSlug(),
-			"output_format", configProvider.GetOutputFormat(),
-			"output_dir", configProvider.GetOutputDir(),
-			"profile", configProvider.GetProfile(),
-		)
-		slog.Info("==================================================")
-
-		ctx := cmd.Context()
+		profileProviders = providers
+		return nil
+	}
+	return configProvider.LoadConfig()
+}
 
-		// Ensure rate limits are sufficient before proceeding.
-		api.EnsureRateLimits(ctx, restClient)
+// runReports creates the clients for the provider's configuration and runs its selected reports.
+// It returns the reports that failed or were skipped.
+func runReports(ctx context.Context, provider *config.ManagerProvider) ([]string, error) {
+	// Set log level from configuration.
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(provider.GetLogLevel())); err != nil {
+		slog.Warn("invalid log level specified, defaulting to info", "error", err)
+		level = slog.LevelInfo
+	}
+	// reconfigure slog at chosen level
+	setLogLevel(level)
+
+	// Create REST and GraphQL clients with retry mechanism using the new interface
+	restClient, err := provider.CreateRESTClient()
+	if err != nil {
+		return nil, fmt.Errorf("creating rest client: %w", err)
+	}
+	// Create retryable REST client
+	retryableREST := api.NewRetryableRESTClient(restClient, 3, 500*time.Millisecond)
+	slog.Debug("created retryable REST client", "maxRetries", retryableREST.MaxRetries)
+
+	graphQLClient, err := provider.CreateGraphQLClient()
+	if err != nil {
+		return nil, fmt.Errorf("creating graphql client: %w", err)
+	}
+	// Create retryable GraphQL client
+	retryableGraphQL := api.NewRetryableGraphQLClient(graphQLClient, 3, 500*time.Millisecond)
+	slog.Debug("created retryable GraphQL client", "maxRetries", retryableGraphQL.MaxRetries)
+
+	// Log the configuration details with a standout banner.
+	slog.Info("==================================================")
+	slog.Info("configuration values:",
+		"auth_method", provider.GetAuthMethod(),
+		"base_url", provider.GetBaseURL(),
+		"hostname", provider.GetHostname(),
+		"enterprise", provider.GetEnterpriseSlug(),
+		"output_format", provider.GetOutputFormat(),
+		"output_dir", provider.GetOutputDir(),
+		"profile", provider.GetProfile(),
+	)
+	slog.Info("==================================================")
+
+	// Stop monitoring this configuration's rate limits once its reports are done
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Waits for a rate limit reset consider the other credentials of the pool
+	ctx = api.WithCredentialPool(ctx, provider.CredentialPool())
+
+	// Show the progress of the reports, redrawn in a terminal and logged otherwise
+	tracker := progress.NewTracker()
+	ctx = progress.WithTracker(ctx, tracker)
+	display := progress.NewDisplay(tracker, os.Stderr, progress.IsTerminal(os.Stderr))
+	if display.Terminal() {
+		setConsole(display)
+		defer setConsole(os.Stderr)
+	}
+	display.Start()
+	defer display.Stop()
+
+	// Ensure rate limits are sufficient before proceeding.
+	api.EnsureRateLimits(ctx, restClient)
+
+	// Start monitoring rate limits every 30 seconds asynchronously.
+	go api.MonitorRateLimits(ctx, restClient.RateLimit, graphQLClient, 30*time.Second)
+
+	// Create a new report executor using our configuration provider
+	reportExecutor := report.NewReportExecutor(provider)
+
+	// Execute the selected reports using the new interface-based approach
+	err = reportExecutor.Execute(ctx, restClient, graphQLClient)
+	return reportExecutor.Failed(), err
+}
 
-		// Start monitoring rate limits every 30 seconds asynchronously.
-		go api.MonitorRateLimits(ctx, restClient.RateLimit, graphQLClient, 30*time.Second)
+// profileResult is the outcome of one profile of a batch run.
+type profileResult struct {
+	profile    string
+	enterprise string
+	outputDir  string
+	duration   time.Duration
+	failed     []string // Reports that failed or were skipped
+	err        error
+}
 
-		// Create a new report executor using our configuration provider
-		reportExecutor := report.NewReportExecutor(configProvider)
+// runBatch runs the reports of each profile in turn, each with its own clients. A failed profile
+// does not stop the others. The combined summary is logged at the end, and an error is returned
+// when any profile failed.
+func runBatch(ctx context.Context, providers []*config.ManagerProvider) error {
+	results := make([]profileResult, 0, len(providers))
+	for _, provider := range providers {
+		if ctx.Err() != nil {
+			break
+		}
+		slog.Info("running profile", "profile", provider.GetProfile(), "enterprise", provider.GetEnterpriseSlug())
 
-		// Execute the selected reports using the new interface-based approach
-		reportExecutor.Execute(ctx, restClient, graphQLClient)
-	},
+		startTime := time.Now()
+		failed, err := runReports(ctx, provider)
+		if err != nil {
+			slog.Error("profile failed", "profile", provider.GetProfile(), "error", err)
+		}
+		results = append(results, profileResult{
+			profile:    provider.GetProfile(),
+			enterprise: provider.GetEnterpriseSlug(),
+			outputDir:  provider.GetOutputDir(),
+			duration:   time.Since(startTime).Round(time.Second),
+			failed:     failed,
+			err:        err,
+		})
+	}
+
+	var failedProfiles []string
+	slog.Info("==================================================")
+	slog.Info("batch run summary:", "profiles", len(providers), "completed", len(results))
+	for _, r := range results {
+		attrs := []any{
+			"profile", r.profile,
+			"enterprise", r.enterprise,
+			"output_dir", r.outputDir,
+			"duration", r.duration,
+		}
+		switch {
+		case r.err != nil:
+			failedProfiles = append(failedProfiles, r.profile)
+			slog.Error("profile failed", append(attrs, "failed_reports", strings.Join(r.failed, ","), "error", r.err)...)
+		case len(r.failed) > 0:
+			slog.Warn("profile completed with failed reports", append(attrs, "failed_reports", strings.Join(r.failed, ","))...)
+		default:
+			slog.Info("profile completed", attrs...)
+		}
+	}
+	slog.Info("==================================================")
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("batch run canceled after %d of %d profiles: %w", len(results), len(providers), err)
+	}
+	if len(failedProfiles) > 0 {
+		return fmt.Errorf("%d of %d profiles failed: %s", len(failedProfiles), len(providers), strings.Join(failedProfiles, ", "))
+	}
+	return nil
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -99,4 +219,6 @@ func Init() {
 
 	// Add subcommands
 	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(demoServerCmd)
+	rootCmd.AddCommand(estimateCmd)
 }
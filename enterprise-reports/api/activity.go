@@ -0,0 +1,337 @@
+// Package api provides functionality for interacting with GitHub's REST and GraphQL APIs.
+// It includes rate limiting, client wrapper methods, and utilities for efficient API consumption.
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+)
+
+// ContributionBatchSize is the number of users whose contributions are fetched in one aliased GraphQL query.
+// Contribution calendars are expensive to compute, so batches are kept smaller than the cost
+// estimate alone would allow to avoid query timeouts.
+const ContributionBatchSize = 25
+
+// maxContributionSpan is the longest range a contributionsCollection may cover.
+const maxContributionSpan = 365 * 24 * time.Hour
+
+// UserActivity maps dormancy signal names to the most recent activity observed for a user.
+type UserActivity map[string]time.Time
+
+// SignalError reports that a source of activity could not be read for a user, so its signals
+// are unknown rather than inactive.
+type SignalError struct {
+	Source  utils.SignalSource
+	Signals []string // Names of the enabled signals read from the source
+	Err     error
+}
+
+// Error returns the source and the cause of the failure.
+func (e *SignalError) Error() string {
+	return fmt.Sprintf("%s activity: %v", e.Source, e.Err)
+}
+
+// Unwrap returns the cause of the failure.
+func (e *SignalError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownSignals returns the names of the signals of the errors.
+func UnknownSignals(errs []*SignalError) []string {
+	var names []string
+	for _, e := range errs {
+		names = append(names, e.Signals...)
+	}
+	return names
+}
+
+// ActivityResolver determines the most recent activity of enterprise users for the signals
+// enabled in a dormancy policy. It combines:
+//   - the enterprise audit log, grouped by actor, which records git and pull request activity
+//     in private repositories,
+//   - Copilot seat activity,
+//   - contributions collections, fetched for many users per query and split into ranges of at most a year,
+//     including the latest restricted contribution to private repositories the caller cannot see, and
+//   - each user's event stream.
+//
+// The events API only returns another user's private events to that user, and its organization
+// events only serve the authenticated user's own dashboard. Activity in private organization
+// repositories is therefore taken from the audit log, which records it for every member, and
+// from restricted contributions instead.
+//
+// Sources that cannot be read are reported by Resolve as SignalErrors, so that their signals are
+// evaluated as unknown rather than inactive.
+//
+// Call Prefetch once with every login before calling Resolve; Resolve is safe for concurrent use.
+type ActivityResolver struct {
+	restClient     *github.Client
+	graphQLClient  *githubv4.Client
+	enterpriseSlug string
+	policy         utils.DormancyPolicy
+	since          time.Time
+	until          time.Time
+
+	auditLog      map[string]UserActivity
+	copilot       map[string]time.Time
+	contributions map[string]time.Time
+
+	copilotErr       error // Failure to read Copilot seats, leaving every user's copilot signals unknown
+	contributionsErr error // Failure to read contributions, leaving users without any unknown
+}
+
+// NewActivityResolver creates an ActivityResolver looking back over the policy window from now.
+func NewActivityResolver(restClient *github.Client, graphQLClient *githubv4.Client, enterpriseSlug string, policy utils.DormancyPolicy, now time.Time) *ActivityResolver {
+	return &ActivityResolver{
+		restClient:     restClient,
+		graphQLClient:  graphQLClient,
+		enterpriseSlug: enterpriseSlug,
+		policy:         policy,
+		since:          now.Add(-policy.Window),
+		until:          now,
+	}
+}
+
+// Prefetch loads the sources that can be fetched in bulk: the audit log and Copilot seats for
+// the whole enterprise, and contributions for the given logins in batches.
+// Failing to read the audit log is an error; the other sources are skipped with a warning, and
+// reported by Resolve for the users they could not be read for.
+func (r *ActivityResolver) Prefetch(ctx context.Context, logins []string) error {
+	if len(r.policy.Enabled(utils.SourceAuditLog)) > 0 {
+		slog.Info("fetching audit log activity", "enterprise", r.enterpriseSlug, "actions", r.policy.AuditLogActions())
+		auditLog, err := r.fetchAuditLogActivity(ctx)
+		if err != nil {
+			return fmt.Errorf("fetching audit log activity for enterprise %q: %w", r.enterpriseSlug, err)
+		}
+		r.auditLog = auditLog
+	}
+
+	if len(r.policy.Enabled(utils.SourceCopilot)) > 0 {
+		slog.Info("fetching copilot seat activity", "enterprise", r.enterpriseSlug)
+		copilot, err := FetchCopilotLastActivity(ctx, r.restClient, r.enterpriseSlug)
+		switch {
+		case notApplicable(err):
+			// Copilot is not enabled for the enterprise, so no user has Copilot activity
+			slog.Info("copilot is not enabled for the enterprise", "enterprise", r.enterpriseSlug)
+		case err != nil:
+			slog.Warn("failed to fetch copilot seat activity, continuing without it", "enterprise", r.enterpriseSlug, "error", err)
+			r.copilotErr = err
+		}
+		r.copilot = copilot
+	}
+
+	if len(r.policy.Enabled(utils.SourceContributions)) > 0 {
+		slog.Info("fetching contributions", "users", len(logins), "batch_size", ContributionBatchSize)
+		contributions, err := FetchLastContributionTimes(ctx, r.graphQLClient, logins, r.since, r.until)
+		if err != nil {
+			slog.Warn("failed to fetch contributions, continuing without them", "error", err)
+			r.contributionsErr = err
+		}
+		r.contributions = contributions
+	}
+
+	return nil
+}
+
+// Resolve returns the most recent activity per enabled signal for the user, and an error for each
+// source that could not be read for them, whose signals are unknown.
+// Prefetched sources are read from memory; the user's event stream is queried directly
+// because the events API has no bulk form.
+func (r *ActivityResolver) Resolve(ctx context.Context, login string) (UserActivity, []*SignalError) {
+	activity := make(UserActivity)
+	var errs []*SignalError
+	for name, t := range r.auditLog[login] {
+		activity[name] = t
+	}
+
+	if last, ok := r.copilot[login]; ok {
+		for _, signal := range r.policy.Enabled(utils.SourceCopilot) {
+			activity[signal.Name] = last
+		}
+	} else if r.copilotErr != nil {
+		errs = append(errs, r.signalError(utils.SourceCopilot, r.copilotErr))
+	}
+
+	if last, ok := r.contributions[login]; ok {
+		for _, signal := range r.policy.Enabled(utils.SourceContributions) {
+			activity[signal.Name] = last
+		}
+	} else if r.contributionsErr != nil {
+		// Users whose batch failed are left out of the contributions, like users without any
+		errs = append(errs, r.signalError(utils.SourceContributions, r.contributionsErr))
+	}
+
+	if eventSignals := r.policy.Enabled(utils.SourceEvents); len(eventSignals) > 0 {
+		lastEvent, err := FetchLastEventTime(ctx, r.restClient, login, r.since)
+		if err != nil {
+			slog.Warn("failed to fetch recent events", "user", login, "error", err)
+			errs = append(errs, r.signalError(utils.SourceEvents, err))
+		} else {
+			for _, signal := range eventSignals {
+				activity[signal.Name] = lastEvent
+			}
+		}
+	}
+
+	return activity, errs
+}
+
+// signalError returns the error reading a source, naming the enabled signals read from it.
+func (r *ActivityResolver) signalError(source utils.SignalSource, err error) *SignalError {
+	e := &SignalError{Source: source, Err: err}
+	for _, signal := range r.policy.Enabled(source) {
+		e.Signals = append(e.Signals, signal.Name)
+	}
+	return e
+}
+
+// fetchAuditLogActivity queries the enterprise audit log for every action used by the policy's
+// enabled audit-log signals within the window. It returns, per actor, the most recent time
+// each signal was observed. Each action is searched separately, as the qualifiers of a search
+// phrase must all match, and its entries are folded into the result a page at a time so the
+// window's entries are never held in memory together.
+func (r *ActivityResolver) fetchAuditLogActivity(ctx context.Context) (map[string]UserActivity, error) {
+	signals := r.policy.Enabled(utils.SourceAuditLog)
+	activity := make(map[string]UserActivity)
+
+	for _, action := range r.policy.AuditLogActions() {
+		query := AuditLogQuery{
+			Phrase:  fmt.Sprintf("action:%s created:>=%s", action, r.since.Format(time.RFC3339)),
+			Include: auditLogInclude(action),
+		}
+		err := StreamAuditLog(ctx, r.restClient, r.enterpriseSlug, query, func(entries []*github.AuditEntry, _ string) error {
+			for _, entry := range entries {
+				if entry.Actor == nil || entry.CreatedAt == nil || entry.GetAction() != action {
+					continue
+				}
+				eventTime := entry.CreatedAt.UTC()
+				viaToken := entry.HashedToken != nil || entry.TokenID != nil
+				for _, signal := range signals {
+					if signal.TokenOnly && !viaToken {
+						continue
+					}
+					if !slices.Contains(signal.Actions, action) {
+						continue
+					}
+					byActor := activity[entry.GetActor()]
+					if byActor == nil {
+						byActor = make(UserActivity)
+						activity[entry.GetActor()] = byActor
+					}
+					if eventTime.After(byActor[signal.Name]) {
+						byActor[signal.Name] = eventTime
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slog.Debug("mapped audit logs to user activity", "unique_users", len(activity))
+	return activity, nil
+}
+
+// auditLogInclude returns the event types to search for an audit log action: git events, such as
+// git.push and git.clone, are only returned when requested, and web events are the default.
+func auditLogInclude(action string) string {
+	if strings.HasPrefix(action, "git.") {
+		return "git"
+	}
+	return "web"
+}
+
+// contributionsNode is the per-user part of an aliased contributions query.
+type contributionsNode struct {
+	ContributionsCollection struct {
+		LatestRestrictedContributionDate string
+		ContributionCalendar             struct {
+			Weeks []struct {
+				ContributionDays []struct {
+					Date              string
+					ContributionCount int
+				}
+			}
+		}
+	} `graphql:"contributionsCollection(from: $from, to: $to)"`
+}
+
+// lastContribution returns the most recent day with contributions in the node, or the zero time.
+// Dates are returned as ISO-8601 calendar dates, e.g. "2024-05-01".
+func (n contributionsNode) lastContribution() time.Time {
+	const dateLayout = "2006-01-02"
+	contrib := n.ContributionsCollection
+	var last time.Time
+	for _, week := range contrib.ContributionCalendar.Weeks {
+		for _, day := range week.ContributionDays {
+			if day.ContributionCount == 0 {
+				continue
+			}
+			if date, err := time.Parse(dateLayout, day.Date); err == nil && date.After(last) {
+				last = date
+			}
+		}
+	}
+	if contrib.LatestRestrictedContributionDate != "" {
+		if date, err := time.Parse(dateLayout, contrib.LatestRestrictedContributionDate); err == nil && date.After(last) {
+			last = date
+		}
+	}
+	return last
+}
+
+// FetchLastContributionTimes returns the most recent day each user made contributions between since and until.
+// Users are queried in batches with BatchQuery, and windows longer than a year are split into
+// yearly ranges, the longest a contributions collection allows. Users whose lookup fails, and users
+// without contributions, are left out of the result.
+func FetchLastContributionTimes(ctx context.Context, graphQLClient *githubv4.Client, logins []string, since, until time.Time) (map[string]time.Time, error) {
+	result := make(map[string]time.Time, len(logins))
+
+	for _, r := range contributionRanges(since, until) {
+		spec := BatchSpec{
+			Field: func(i int) string { return fmt.Sprintf("u%d: user(login: $login%d)", i, i) },
+			Variables: func(i int, login string) map[string]any {
+				return map[string]any{fmt.Sprintf("login%d", i): githubv4.String(login)}
+			},
+			Shared: map[string]any{
+				"from": githubv4.DateTime{Time: r[0]},
+				"to":   githubv4.DateTime{Time: r[1]},
+			},
+			MaxSize: ContributionBatchSize,
+		}
+
+		nodes, err := BatchQuery[contributionsNode](ctx, graphQLClient, spec, logins)
+		if err != nil {
+			return result, fmt.Errorf("fetching contributions: %w", err)
+		}
+		for login, node := range nodes {
+			if last := node.lastContribution(); !last.IsZero() && last.After(result[login]) {
+				result[login] = last
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// contributionRanges splits [since, until] into consecutive ranges no longer than maxContributionSpan.
+func contributionRanges(since, until time.Time) [][2]time.Time {
+	var ranges [][2]time.Time
+	for from := since; from.Before(until); from = from.Add(maxContributionSpan) {
+		to := from.Add(maxContributionSpan)
+		if to.After(until) {
+			to = until
+		}
+		ranges = append(ranges, [2]time.Time{from, to})
+	}
+	return ranges
+}
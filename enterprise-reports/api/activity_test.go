@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/fakegithub"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// graphQLRequest is the body of a GraphQL request as sent by githubv4.
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+// contributionsJSON renders a user node with a single contribution day, or no contributions when day is empty.
+func contributionsJSON(day, restricted string) string {
+	days := `[]`
+	if day != "" {
+		days = fmt.Sprintf(`[{"date":%q,"contributionCount":3}]`, day)
+	}
+	restrictedJSON := "null"
+	if restricted != "" {
+		restrictedJSON = fmt.Sprintf("%q", restricted)
+	}
+	return fmt.Sprintf(`{"contributionsCollection":{"latestRestrictedContributionDate":%s,"contributionCalendar":{"weeks":[{"contributionDays":%s}]}}}`, restrictedJSON, days)
+}
+
+func TestFetchLastContributionTimes_Batched(t *testing.T) {
+	var queries int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		var req graphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Contains(t, req.Query, "u0: user(login: $login0)")
+		assert.Contains(t, req.Query, "u1: user(login: $login1)")
+		assert.Equal(t, "alice", req.Variables["login0"])
+		assert.Equal(t, "bob", req.Variables["login1"])
+
+		_, err := fmt.Fprintf(w, `{"data":{"u0":%s,"u1":%s,"rateLimit":{"cost":1,"limit":5000,"remaining":4999}}}`,
+			contributionsJSON("2024-05-01", ""), contributionsJSON("", "2024-05-03"))
+		require.NoError(t, err)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := githubv4.NewEnterpriseClient(srv.URL+"/graphql", srv.Client())
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	last, err := FetchLastContributionTimes(context.Background(), client, []string{"alice", "bob"}, since, until)
+	require.NoError(t, err)
+	assert.Equal(t, 1, queries, "both users should be fetched in one query")
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), last["alice"])
+	assert.Equal(t, time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC), last["bob"], "restricted contributions count as activity")
+}
+
+func TestFetchLastContributionTimes_FallbackOnBatchError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		var body string
+		switch {
+		case strings.Contains(req.Query, "$login1"):
+			body = `{"data":{"u0":null,"u1":null},"errors":[{"message":"Could not resolve to a User with the login of 'ghost'."}]}`
+		case req.Variables["login0"] == "alice":
+			body = fmt.Sprintf(`{"data":{"u0":%s}}`, contributionsJSON("2024-05-01", ""))
+		default:
+			body = `{"data":{"u0":null},"errors":[{"message":"Could not resolve to a User with the login of 'ghost'."}]}`
+		}
+		_, err := fmt.Fprint(w, body)
+		require.NoError(t, err)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := githubv4.NewEnterpriseClient(srv.URL+"/graphql", srv.Client())
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	last, err := FetchLastContributionTimes(context.Background(), client, []string{"alice", "ghost"}, since, until)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), last["alice"])
+	assert.NotContains(t, last, "ghost")
+}
+
+func TestContributionRanges(t *testing.T) {
+	since := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(800 * 24 * time.Hour)
+
+	ranges := contributionRanges(since, until)
+	require.Len(t, ranges, 3)
+	assert.Equal(t, since, ranges[0][0])
+	assert.Equal(t, until, ranges[2][1])
+	for i, r := range ranges {
+		assert.LessOrEqual(t, r[1].Sub(r[0]), maxContributionSpan)
+		if i > 0 {
+			assert.Equal(t, ranges[i-1][1], r[0], "ranges must be contiguous")
+		}
+	}
+}
+
+// TestFetchAuditLogActivity tests that audit log activity is read page by page, searching git
+// actions among git events, and grouped by actor and signal.
+func TestFetchAuditLogActivity(t *testing.T) {
+	fixture, err := fakegithub.DemoFixture()
+	require.NoError(t, err)
+	srv := fakegithub.NewServer(fixture, fakegithub.Options{PageSize: 1})
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	resolver := NewActivityResolver(srv.RESTClient(), srv.GraphQLClient(), "octodemo", utils.DefaultDormancyPolicy(), time.Now())
+	activity, err := resolver.fetchAuditLogActivity(context.Background())
+	require.NoError(t, err)
+
+	assert.Contains(t, activity["linus"], utils.SignalGit)
+	assert.NotContains(t, activity["linus"], utils.SignalToken)
+	assert.Contains(t, activity["hubot"], utils.SignalToken)
+	assert.Contains(t, activity["mona"], utils.SignalLogin)
+	assert.NotContains(t, activity, "dormant-dan")
+
+	var loginPages, gitPushRequests int
+	for _, req := range srv.Requests() {
+		switch {
+		case strings.Contains(req, "user.login"):
+			loginPages++
+		case strings.Contains(req, "git.push"):
+			gitPushRequests++
+			assert.Contains(t, req, "include=git")
+		}
+	}
+	assert.Greater(t, loginPages, 1)
+	assert.Positive(t, gitPushRequests)
+}
@@ -0,0 +1,249 @@
+// Package api provides functionality for interacting with GitHub's REST and GraphQL APIs.
+// It includes rate limiting, client wrapper methods, and utilities for efficient API consumption.
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+)
+
+const (
+	// MaxGraphQLNodes is the most nodes a single GraphQL query may request.
+	MaxGraphQLNodes = 500000
+
+	// DefaultBatchSize is the number of lookups combined into one query when a spec sets no MaxSize.
+	DefaultBatchSize = 50
+
+	// DefaultBatchPoints is the highest estimated rate limit cost a batched query may have
+	// when a spec sets no MaxPoints.
+	DefaultBatchPoints = 10
+)
+
+// BatchSpec describes a lookup that can be repeated under different aliases in one GraphQL query.
+// For example, fetching users by login uses the field `u0: user(login: $login0)`, `u1: user(login: $login1)`, ...
+type BatchSpec struct {
+	// Path lists the fields that enclose the aliased lookups, outermost first,
+	// e.g. "enterprise(slug: $slug)", "ownerInfo". Empty for top-level lookups.
+	Path []string
+
+	// Field returns the aliased field for the i-th lookup in a batch, e.g. `u3: user(login: $login3)`.
+	Field func(i int) string
+
+	// Variables returns the variables used by the i-th lookup for the given key.
+	Variables func(i int, key string) map[string]any
+
+	// Shared holds variables used by every lookup or by Path, such as a date range or enterprise slug.
+	Shared map[string]any
+
+	// Group, when set, returns the group of a key; keys of different groups never share a query.
+	// Lookups by repository are grouped by owner, so that an InstallationRouter can send each
+	// query through the installation on the organization it addresses.
+	Group func(key string) string
+
+	// Nodes estimates the nodes a single lookup requests: the product of the first/last
+	// arguments along each connection, summed over its connections. Defaults to 1.
+	Nodes int
+
+	// Requests estimates the connection requests a single lookup makes, used for the point cost.
+	// Defaults to 1.
+	Requests int
+
+	// MaxSize caps the lookups per query. Defaults to DefaultBatchSize.
+	MaxSize int
+
+	// MaxPoints caps the estimated point cost per query. Defaults to DefaultBatchPoints.
+	MaxPoints int
+}
+
+// EstimateCost returns the estimated node count and rate limit point cost of a query with n lookups.
+// Points follow GitHub's formula: connection requests divided by 100, rounded up, with a minimum of 1.
+func (s BatchSpec) EstimateCost(n int) (nodes, points int) {
+	nodes = n * max(s.Nodes, 1)
+	requests := n * max(s.Requests, 1)
+	points = max((requests+99)/100, 1)
+	return nodes, points
+}
+
+// BatchSize returns the largest number of lookups per query whose estimated cost stays within
+// the node limit and the spec's point budget, capped at MaxSize.
+func (s BatchSpec) BatchSize() int {
+	size := s.MaxSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	maxPoints := s.MaxPoints
+	if maxPoints <= 0 {
+		maxPoints = DefaultBatchPoints
+	}
+	for size > 1 {
+		nodes, points := s.EstimateCost(size)
+		if nodes <= MaxGraphQLNodes && points <= maxPoints {
+			break
+		}
+		size--
+	}
+	return size
+}
+
+// BatchQuery looks up every key with as few GraphQL queries as the spec's cost estimate allows,
+// decoding each aliased result into a value of type N. It returns the results by key.
+// Keys are batched within their group when the spec groups them.
+//
+// When a batch fails because of its nodes, for example because one of its keys does not resolve,
+// its keys are retried one at a time so one bad key does not lose the rest of the batch. Keys that
+// still fail are left out of the result; an error is returned only if every lookup failed. Failures
+// of the whole query, such as rate limits, authentication, transport errors or a canceled context,
+// would fail every single lookup as well, so they end the lookup and are returned without retries.
+func BatchQuery[N any](ctx context.Context, graphQLClient *githubv4.Client, spec BatchSpec, keys []string) (map[string]N, error) {
+	result := make(map[string]N, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	size := spec.BatchSize()
+	var succeeded bool
+	var lastErr error
+
+	var batches [][]string
+	for _, group := range groupKeys(spec, keys) {
+		for start := 0; start < len(group); start += size {
+			batches = append(batches, group[start:min(start+size, len(group))])
+		}
+	}
+
+	for _, batch := range batches {
+		nodes, err := runBatch[N](ctx, graphQLClient, spec, batch)
+		if err != nil {
+			if !nodeError(err) {
+				return result, err
+			}
+			slog.Debug("batched query failed, retrying lookups individually", "lookups", len(batch), "error", err)
+			for _, key := range batch {
+				single, err := runBatch[N](ctx, graphQLClient, spec, []string{key})
+				if err != nil {
+					if !nodeError(err) {
+						return result, err
+					}
+					slog.Debug("lookup failed", "key", key, "error", err)
+					lastErr = err
+					continue
+				}
+				succeeded = true
+				result[key] = single[0]
+			}
+			continue
+		}
+
+		succeeded = true
+		for i, key := range batch {
+			result[key] = nodes[i]
+		}
+	}
+
+	if !succeeded {
+		return result, fmt.Errorf("all %d lookups failed: %w", len(keys), lastErr)
+	}
+	return result, nil
+}
+
+// nodeError reports whether a failed query failed because of some of its nodes, such as a
+// NOT_FOUND or FORBIDDEN error on an alias, so that its lookups may succeed one at a time. Only
+// the errors of a GraphQL response qualify, other than rate limits: the client returns them as
+// its unexported errors list, and transport, HTTP status and decoding errors as other errors.
+func nodeError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if utils.ClassifyError(err) == utils.ErrorTypeRateLimit {
+		return false
+	}
+	for ; err != nil; err = errors.Unwrap(err) {
+		t := reflect.TypeOf(err)
+		if t.PkgPath() == "github.com/shurcooL/graphql" && t.Name() == "errors" {
+			return true
+		}
+	}
+	return false
+}
+
+// groupKeys splits the keys by the spec's Group, keeping their order within each group and ordering
+// the groups by their first key. Without a Group, all keys form one group.
+func groupKeys(spec BatchSpec, keys []string) [][]string {
+	if spec.Group == nil {
+		return [][]string{keys}
+	}
+	var groups [][]string
+	index := make(map[string]int)
+	for _, key := range keys {
+		g := spec.Group(key)
+		i, ok := index[g]
+		if !ok {
+			i = len(groups)
+			index[g] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], key)
+	}
+	return groups
+}
+
+// runBatch runs a single aliased query for the keys and returns the decoded nodes in key order.
+func runBatch[N any](ctx context.Context, graphQLClient *githubv4.Client, spec BatchSpec, keys []string) ([]N, error) {
+	nodeType := reflect.TypeOf((*N)(nil)).Elem()
+
+	fields := make([]reflect.StructField, 0, len(keys))
+	vars := make(map[string]any, len(spec.Shared)+len(keys))
+	for k, v := range spec.Shared {
+		vars[k] = v
+	}
+	for i, key := range keys {
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("A%d", i),
+			Type: nodeType,
+			Tag:  reflect.StructTag(fmt.Sprintf(`graphql:"%s"`, spec.Field(i))),
+		})
+		for k, v := range spec.Variables(i, key) {
+			vars[k] = v
+		}
+	}
+
+	// Wrap the aliased lookups in their enclosing fields, innermost first
+	for i := len(spec.Path) - 1; i >= 0; i-- {
+		fields = []reflect.StructField{{
+			Name: fmt.Sprintf("P%d", i),
+			Type: reflect.StructOf(fields),
+			Tag:  reflect.StructTag(fmt.Sprintf(`graphql:"%s"`, spec.Path[i])),
+		}}
+	}
+	queryType := reflect.StructOf(append(fields, reflect.StructField{
+		Name: "RateLimit",
+		Type: reflect.TypeOf(rateLimitQuery{}),
+	}))
+
+	query := reflect.New(queryType)
+	if err := graphQLClient.Query(ctx, query.Interface(), vars); err != nil {
+		return nil, fmt.Errorf("batched query of %d lookups failed: %w", len(keys), err)
+	}
+
+	// Check for rate limits, comparing the estimate with the actual cost.
+	rateLimit := query.Elem().FieldByName("RateLimit").Interface().(rateLimitQuery)
+	_, estimated := spec.EstimateCost(len(keys))
+	slog.Debug("batched query completed", "lookups", len(keys), "estimated_cost", estimated, "cost", rateLimit.Cost)
+	handleGraphQLRateLimit(ctx, &rateLimit)
+
+	value := query.Elem()
+	for range spec.Path {
+		value = value.Field(0)
+	}
+	nodes := make([]N, len(keys))
+	for i := range keys {
+		nodes[i] = value.Field(i).Interface().(N)
+	}
+	return nodes, nil
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchSpec_BatchSize(t *testing.T) {
+	// Cheap lookups are limited by MaxSize
+	assert.Equal(t, DefaultBatchSize, BatchSpec{}.BatchSize())
+	assert.Equal(t, 10, BatchSpec{MaxSize: 10}.BatchSize())
+
+	// Each lookup requests 100 nodes per page across 20 pages: 2,000 nodes
+	heavy := BatchSpec{Nodes: 2000, MaxSize: 1000, MaxPoints: 1000}
+	assert.Equal(t, MaxGraphQLNodes/2000, heavy.BatchSize())
+
+	// Point budget: 30 connection requests per lookup, at most 3 points (300 requests) per query
+	chatty := BatchSpec{Requests: 30, MaxPoints: 3, MaxSize: 100}
+	assert.Equal(t, 10, chatty.BatchSize())
+	_, points := chatty.EstimateCost(chatty.BatchSize())
+	assert.LessOrEqual(t, points, 3)
+
+	// Never below one lookup per query
+	assert.Equal(t, 1, BatchSpec{Nodes: MaxGraphQLNodes * 2}.BatchSize())
+}
+
+func TestFetchUserEmails_Batched(t *testing.T) {
+	var queries int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		var req graphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.True(t, strings.HasPrefix(req.Query, "query($login0:String!$login1:String!$login2:String!$slug:String!)"), req.Query)
+		assert.Contains(t, req.Query, "enterprise(slug: $slug){ownerInfo{samlIdentityProvider{i0: externalIdentities(first: 1, login: $login0)")
+
+		identity := func(login, saml, scim string) string {
+			return fmt.Sprintf(`{"nodes":[{"user":{"login":%q},"samlIdentity":{"emails":[%s]},"scimIdentity":{"emails":[%s]}}]}`, login, saml, scim)
+		}
+		_, err := fmt.Fprintf(w, `{"data":{"enterprise":{"ownerInfo":{"samlIdentityProvider":{"i0":%s,"i1":%s,"i2":{"nodes":[]}}}}}}`,
+			identity("alice", `{"value":"alice@example.com"}`, ""),
+			identity("bob", "", `{"value":"bob@scim.example.com"}`))
+		require.NoError(t, err)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := githubv4.NewEnterpriseClient(srv.URL+"/graphql", srv.Client())
+	emails, err := FetchUserEmails(context.Background(), client, "ent", []string{"alice", "bob", "carol"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, queries, "all users should be fetched in one query")
+	assert.Equal(t, map[string]string{
+		"alice": "alice@example.com",
+		"bob":   "bob@scim.example.com",
+		"carol": "N/A",
+	}, emails)
+}
+
+func TestBatchQuery_AllLookupsFail(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		_, err := fmt.Fprint(w, `{"data":null,"errors":[{"message":"Something went wrong"}]}`)
+		require.NoError(t, err)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := githubv4.NewEnterpriseClient(srv.URL+"/graphql", srv.Client())
+	_, err := FetchUserEmail(context.Background(), client, "ent", "alice")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Something went wrong")
+}
+
+func TestBatchQuery_QueryErrorsNotRetried(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "rate limited", status: http.StatusOK, body: `{"data":null,"errors":[{"type":"RATE_LIMITED","message":"API rate limit exceeded for user ID 1."}]}`},
+		{name: "unauthorized", status: http.StatusUnauthorized, body: `{"message":"Bad credentials"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries int
+			mux := http.NewServeMux()
+			mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+				queries++
+				w.WriteHeader(tt.status)
+				_, err := fmt.Fprint(w, tt.body)
+				require.NoError(t, err)
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			client := githubv4.NewEnterpriseClient(srv.URL+"/graphql", srv.Client())
+			_, err := FetchUserEmails(context.Background(), client, "ent", []string{"alice", "bob", "carol"})
+			require.Error(t, err)
+			assert.Equal(t, 1, queries, "the failed batch should not be retried one lookup at a time")
+		})
+	}
+}
+
+func TestBatchQuery_NodeErrorsRetried(t *testing.T) {
+	var queries int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		var req graphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if _, batched := req.Variables["login1"]; batched || req.Variables["login0"] == "ghost" {
+			_, err := fmt.Fprint(w, `{"data":null,"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a User with the login of 'ghost'."}]}`)
+			require.NoError(t, err)
+			return
+		}
+		_, err := fmt.Fprintf(w, `{"data":{"enterprise":{"ownerInfo":{"samlIdentityProvider":{"i0":{"nodes":[{"user":{"login":%q},"samlIdentity":{"emails":[{"value":"%s@example.com"}]},"scimIdentity":{"emails":[]}}]}}}}}}`,
+			req.Variables["login0"], req.Variables["login0"])
+		require.NoError(t, err)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := githubv4.NewEnterpriseClient(srv.URL+"/graphql", srv.Client())
+	emails, err := FetchUserEmails(context.Background(), client, "ent", []string{"alice", "ghost"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, queries, "the batch should be retried one lookup at a time")
+	assert.Equal(t, "alice@example.com", emails["alice"])
+}
+
+func TestFetchRepositoriesActivity_GroupedByOwner(t *testing.T) {
+	var owners [][]string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var queried []string
+		repos := make(map[string]any)
+		for i := 0; ; i++ {
+			owner, ok := req.Variables[fmt.Sprintf("owner%d", i)].(string)
+			if !ok {
+				break
+			}
+			queried = append(queried, owner)
+			repos[fmt.Sprintf("r%d", i)] = map[string]any{"isEmpty": false}
+		}
+		owners = append(owners, queried)
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"data": repos}))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := githubv4.NewEnterpriseClient(srv.URL+"/graphql", srv.Client())
+	activity, err := FetchRepositoriesActivity(context.Background(), client, []string{"platform/api", "apps/web", "Platform/cli", "apps/mobile"})
+	require.NoError(t, err)
+	assert.Len(t, activity, 4)
+
+	// Each query addresses a single organization, so it can be routed to its installation
+	assert.Equal(t, [][]string{{"platform", "Platform"}, {"apps", "apps"}}, owners)
+}
@@ -0,0 +1,306 @@
+// Package api provides functionality for interacting with GitHub's REST and GraphQL APIs.
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// redacted replaces the values of scrubbed headers in a cassette.
+const redacted = "[REDACTED]"
+
+// scrubbedHeaders are the headers that carry credentials, which are never written to a cassette.
+var scrubbedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-GitHub-OTP"}
+
+// Interaction is a request and its response as recorded in a cassette.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a recorded API request.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a recorded API response.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// Recorder writes the API traffic of the transports it creates to a cassette directory, one JSON
+// file per interaction, with the credentials of their headers scrubbed.
+type Recorder struct {
+	dir string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewRecorder creates a recorder writing to the directory, creating it if needed. An existing
+// cassette in the directory is replaced.
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	old, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cassette directory: %w", err)
+	}
+	for _, path := range old {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to replace cassette: %w", err)
+		}
+	}
+	slog.Info("recording API traffic", "dir", dir)
+	return &Recorder{dir: dir}, nil
+}
+
+// Transport returns a transport sending requests through base and recording them with their
+// responses. The transports of a recorder share its cassette.
+func (r *Recorder) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &recordingTransport{recorder: r, base: base}
+}
+
+// recordingTransport is a transport of a Recorder.
+type recordingTransport struct {
+	recorder *Recorder
+	base     http.RoundTripper
+}
+
+// RoundTrip sends the request and records it with its response. Requests that fail without a
+// response are not recorded.
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if err := req.Body.Close(); err != nil {
+			return nil, err
+		}
+		reqBody = body
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	if closeErr := resp.Body.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: scrubHeader(req.Header),
+			Body:   string(reqBody),
+		},
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: scrubHeader(resp.Header),
+			Body:   string(respBody),
+		},
+	}
+	if err := t.recorder.write(req, interaction); err != nil {
+		// The report can still complete; only the cassette is incomplete
+		slog.Warn("failed to record API interaction", "method", req.Method, "url", req.URL.String(), "error", err)
+	}
+	return resp, nil
+}
+
+// unsafeFileChars are the characters replaced in the file names of interactions.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// write saves an interaction to the next file of the cassette, named by its sequence number and
+// request path so the cassette can be browsed, e.g. "00000012-GET-orgs-acme-repos.json".
+func (r *Recorder) write(req *http.Request, interaction Interaction) error {
+	data, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.seq++
+	seq := r.seq
+	r.mu.Unlock()
+
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(req.URL.Path, "-"), "-")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	path := filepath.Join(r.dir, fmt.Sprintf("%08d-%s-%s.json", seq, req.Method, name))
+	return os.WriteFile(path, data, 0o600)
+}
+
+// scrubHeader returns a copy of the header with the values of credential headers redacted.
+func scrubHeader(h http.Header) http.Header {
+	scrubbed := h.Clone()
+	for _, name := range scrubbedHeaders {
+		if scrubbed.Get(name) != "" {
+			scrubbed.Set(name, redacted)
+		}
+	}
+	return scrubbed
+}
+
+// Replayer is an http.RoundTripper that answers requests from a cassette written by a Recorder,
+// without sending them. A request is answered with the recorded response of the same method, path,
+// query and body. Failing that, it is answered with that of a request of the same shape: the same
+// path, query parameters and GraphQL query, with other values. This matches requests that only
+// differ in a date relative to the time of the run, such as a contribution window. Requests
+// recorded several times are answered with their responses in the recorded order, and the last
+// response is repeated after that.
+type Replayer struct {
+	mu    sync.Mutex
+	exact map[string]*replayQueue
+	shape map[string]*replayQueue
+}
+
+// replayQueue is the recorded responses to requests with the same key.
+type replayQueue struct {
+	responses []*RecordedResponse
+	next      int
+}
+
+// pop returns the next response, repeating the last one once all were returned.
+func (q *replayQueue) pop() *RecordedResponse {
+	resp := q.responses[min(q.next, len(q.responses)-1)]
+	q.next++
+	return resp
+}
+
+// NewReplayer loads the cassette in the directory.
+func NewReplayer(dir string) (*Replayer, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cassette directory: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("cassette directory %q holds no recorded interactions", dir)
+	}
+	// The file names start with the sequence number, so sorting restores the recorded order
+	slices.Sort(paths)
+
+	r := &Replayer{exact: make(map[string]*replayQueue), shape: make(map[string]*replayQueue)}
+	for _, path := range paths {
+		// #nosec G304  // the cassette directory is chosen by the user running the replay
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(data, &interaction); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette file %s: %w", filepath.Base(path), err)
+		}
+		u, err := url.Parse(interaction.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("cassette file %s: invalid url: %w", filepath.Base(path), err)
+		}
+		resp := &interaction.Response
+		enqueue(r.exact, exactKey(interaction.Request.Method, u, interaction.Request.Body), resp)
+		enqueue(r.shape, shapeKey(interaction.Request.Method, u, interaction.Request.Body), resp)
+	}
+	slog.Info("replaying API traffic", "dir", dir, "interactions", len(paths))
+	return r, nil
+}
+
+// enqueue adds a recorded response to the queue of its key.
+func enqueue(queues map[string]*replayQueue, key string, resp *RecordedResponse) {
+	q, ok := queues[key]
+	if !ok {
+		q = &replayQueue{}
+		queues[key] = q
+	}
+	q.responses = append(q.responses, resp)
+}
+
+// RoundTrip answers the request with its recorded response.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if err := req.Body.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	var recorded *RecordedResponse
+	if q, ok := r.exact[exactKey(req.Method, req.URL, string(body))]; ok {
+		recorded = q.pop()
+	} else if q, ok := r.shape[shapeKey(req.Method, req.URL, string(body))]; ok {
+		recorded = q.pop()
+	}
+	r.mu.Unlock()
+	if recorded == nil {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL.RequestURI())
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// exactKey identifies a request by its method, path, query and body. The host is left out, so a
+// cassette recorded against one URL can be replayed with another.
+func exactKey(method string, u *url.URL, body string) string {
+	return method + " " + u.Path + "?" + u.Query().Encode() + "\n" + body
+}
+
+// shapeKey identifies a request by its method, path, query parameter names and, for GraphQL
+// requests, the query without its variables.
+func shapeKey(method string, u *url.URL, body string) string {
+	names := make([]string, 0, len(u.Query()))
+	for name := range u.Query() {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var graphQL struct {
+		Query string `json:"query"`
+	}
+	if body != "" && json.Unmarshal([]byte(body), &graphQL) == nil && graphQL.Query != "" {
+		body = graphQL.Query
+	}
+	return method + " " + u.Path + "?" + strings.Join(names, "&") + "\n" + body
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenTransport adds a token to every request, like the authenticated transports.
+type tokenTransport struct{}
+
+func (tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer ghp_secret")
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestRecorderReplayer(t *testing.T) {
+	var calls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rate_limit", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, err := fmt.Fprintf(w, `{"call":%d}`, calls)
+		require.NoError(t, err)
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if strings.Contains(string(body), "2024-05-01") {
+			_, err = fmt.Fprint(w, `{"data":{"viewer":{"login":"may"}}}`)
+		} else {
+			_, err = fmt.Fprint(w, `{"data":{"viewer":{"login":"other"}}}`)
+		}
+		require.NoError(t, err)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir)
+	require.NoError(t, err)
+	client := &http.Client{Transport: recorder.Transport(tokenTransport{})}
+
+	get := func(client *http.Client, url string) string {
+		resp, err := client.Get(url)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, resp.Body.Close()) }()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	assert.Equal(t, `{"call":1}`, get(client, srv.URL+"/rate_limit"))
+	assert.Equal(t, `{"call":2}`, get(client, srv.URL+"/rate_limit"))
+
+	var query struct {
+		Viewer struct{ Login string }
+	}
+	gql := githubv4.NewEnterpriseClient(srv.URL+"/graphql", client)
+	require.NoError(t, gql.Query(context.Background(), &query, map[string]any{"since": githubv4.String("2024-05-01")}))
+	assert.Equal(t, "may", query.Viewer.Login)
+
+	// Credentials are scrubbed from the cassette
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.Equal(t, "00000001-GET-rate_limit.json", filepath.Base(files[0]))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "secret")
+	}
+
+	// Replaying answers from the cassette without reaching the server, at another URL
+	srv.Close()
+	replayer, err := NewReplayer(dir)
+	require.NoError(t, err)
+	client = &http.Client{Transport: replayer}
+	assert.Equal(t, `{"call":1}`, get(client, "https://api.example.com/rate_limit"))
+	assert.Equal(t, `{"call":2}`, get(client, "https://api.example.com/rate_limit"))
+	assert.Equal(t, `{"call":2}`, get(client, "https://api.example.com/rate_limit")) // The last response repeats
+
+	// A query with other variables is answered with the response of the same query
+	gql = githubv4.NewEnterpriseClient("https://api.example.com/graphql", client)
+	require.NoError(t, gql.Query(context.Background(), &query, map[string]any{"since": githubv4.String("2025-01-01")}))
+	assert.Equal(t, "may", query.Viewer.Login)
+
+	_, err = client.Get("https://api.example.com/orgs/acme")
+	assert.ErrorContains(t, err, "no recorded response for GET /orgs/acme")
+
+	_, err = NewReplayer(t.TempDir())
+	assert.ErrorContains(t, err, "holds no recorded interactions")
+}
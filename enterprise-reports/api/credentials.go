@@ -0,0 +1,204 @@
+// Package api provides functionality for interacting with GitHub's REST and GraphQL APIs.
+// It includes rate limiting, client wrapper methods, and utilities for efficient API consumption.
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credential is one of the credentials a CredentialPool spreads requests over.
+type Credential struct {
+	Name      string            // Identifies the credential in logs, e.g. "token 2"; never the secret
+	Transport http.RoundTripper // Authenticates requests as the credential
+}
+
+// budget is the rate limit of a credential for one API resource, as last reported by GitHub.
+type budget struct {
+	remaining int
+	limit     int
+	reset     time.Time
+}
+
+// CredentialPool is an http.RoundTripper that spreads requests over several credentials, each with
+// its own rate limits. It reads the remaining budget of each credential from the rate limit headers
+// of its responses, and sends requests in turn through the credentials whose budget for the requested
+// resource is above the threshold at which the API functions wait for a reset. Only when every
+// credential is below it does a request go through the one that resets first.
+//
+// Attach the pool to the context with WithCredentialPool, so the API functions only wait for a reset
+// when no credential has budget left.
+type CredentialPool struct {
+	credentials []Credential
+
+	mu      sync.Mutex
+	budgets []map[string]budget // Budget of each credential by resource
+	next    int                 // Credential the next request starts looking from
+}
+
+// NewCredentialPool creates a pool over the credentials.
+func NewCredentialPool(credentials []Credential) (*CredentialPool, error) {
+	if len(credentials) == 0 {
+		return nil, fmt.Errorf("a credential pool needs at least one credential")
+	}
+	p := &CredentialPool{
+		credentials: credentials,
+		budgets:     make([]map[string]budget, len(credentials)),
+	}
+	for i := range p.budgets {
+		p.budgets[i] = make(map[string]budget)
+	}
+	return p, nil
+}
+
+// RoundTrip sends the request through the next credential with budget for the requested resource.
+func (p *CredentialPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	i := p.pick(requestResource(req))
+	resp, err := p.credentials[i].Transport.RoundTrip(req)
+	if err == nil {
+		p.observe(i, resp.Header)
+	}
+	return resp, err
+}
+
+// Available reports whether any credential has budget above the threshold for the resource.
+// The resource is a rate limit resource as GitHub names it, e.g. "core" or "graphql".
+func (p *CredentialPool) Available(resource string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.credentials {
+		if p.hasBudget(i, resource, time.Now()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset returns the earliest time at which a credential's budget for the resource resets,
+// or the zero time when none is known.
+func (p *CredentialPool) Reset(resource string) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var earliest time.Time
+	for i := range p.credentials {
+		if b, ok := p.budgets[i][resource]; ok && (earliest.IsZero() || b.reset.Before(earliest)) {
+			earliest = b.reset
+		}
+	}
+	return earliest
+}
+
+// LogBudgets logs the last known budget of each credential.
+func (p *CredentialPool) LogBudgets() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, c := range p.credentials {
+		kv := []any{"credential", c.Name}
+		for _, resource := range []string{"core", "graphql", "audit_log"} {
+			if b, ok := p.budgets[i][resource]; ok {
+				kv = append(kv, resource, fmt.Sprintf("%d/%d", b.remaining, b.limit))
+			}
+		}
+		slog.Info("credential rate limits", kv...)
+	}
+}
+
+// pick returns the credential to send a request for the resource through: the next one in turn with
+// budget, or the one whose budget resets first when none has any.
+func (p *CredentialPool) pick(resource string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	n := len(p.credentials)
+	for k := range n {
+		i := (p.next + k) % n
+		if p.hasBudget(i, resource, now) {
+			if k > 0 {
+				slog.Debug("rate limit low, switching credential", "resource", resource, "credential", p.credentials[i].Name)
+			}
+			p.next = (i + 1) % n
+			return i
+		}
+	}
+
+	first := 0
+	for i := 1; i < n; i++ {
+		if p.budgets[i][resource].reset.Before(p.budgets[first][resource].reset) {
+			first = i
+		}
+	}
+	return first
+}
+
+// hasBudget reports whether credential i has budget above the threshold for the resource. A budget
+// that was never reported, or whose reset has passed, counts as available. The caller holds p.mu.
+func (p *CredentialPool) hasBudget(i int, resource string, now time.Time) bool {
+	b, ok := p.budgets[i][resource]
+	return !ok || !now.Before(b.reset) || b.remaining >= resourceThreshold(resource)
+}
+
+// observe records the budget reported in a response's rate limit headers.
+func (p *CredentialPool) observe(i int, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	resource := header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.budgets[i][resource] = budget{remaining: remaining, limit: limit, reset: time.Unix(reset, 0)}
+}
+
+// requestResource returns the rate limit resource a request draws from.
+func requestResource(req *http.Request) string {
+	switch path := req.URL.Path; {
+	case strings.HasSuffix(path, "/graphql"):
+		return "graphql"
+	case strings.Contains(path, "/audit-log"):
+		return "audit_log"
+	case strings.Contains(path, "/search/"):
+		return "search"
+	}
+	return "core"
+}
+
+// resourceThreshold returns the remaining budget below which the API functions wait for a reset.
+func resourceThreshold(resource string) int {
+	switch resource {
+	case "graphql":
+		return GraphQLRateLimitThreshold
+	case "audit_log":
+		return AuditLogRateLimitThreshold
+	}
+	return RESTRateLimitThreshold
+}
+
+type credentialPoolKey struct{}
+
+// WithCredentialPool returns a context carrying the pool, so the API functions waiting for a rate
+// limit reset first check whether another credential of the pool has budget left.
+func WithCredentialPool(ctx context.Context, pool *CredentialPool) context.Context {
+	if pool == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, credentialPoolKey{}, pool)
+}
+
+// credentialPoolFrom returns the pool carried by the context, or nil.
+func credentialPoolFrom(ctx context.Context) *CredentialPool {
+	pool, _ := ctx.Value(credentialPoolKey{}).(*CredentialPool)
+	return pool
+}
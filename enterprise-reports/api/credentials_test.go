@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// budgetTransport answers with the rate limit headers of a credential with the given budget,
+// and counts the requests it receives.
+type budgetTransport struct {
+	remaining int
+	reset     time.Time
+	requests  int
+}
+
+func (b *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	b.requests++
+	b.remaining--
+	rec := httptest.NewRecorder()
+	rec.Header().Set("X-RateLimit-Remaining", strconv.Itoa(b.remaining))
+	rec.Header().Set("X-RateLimit-Limit", "5000")
+	rec.Header().Set("X-RateLimit-Reset", strconv.FormatInt(b.reset.Unix(), 10))
+	if requestResource(req) == "graphql" {
+		rec.Header().Set("X-RateLimit-Resource", "graphql")
+	}
+	return rec.Result(), nil
+}
+
+func TestCredentialPool(t *testing.T) {
+	reset := time.Now().Add(time.Hour)
+	first := &budgetTransport{remaining: RESTRateLimitThreshold + 1, reset: reset}
+	second := &budgetTransport{remaining: 5000, reset: reset.Add(time.Minute)}
+	pool, err := NewCredentialPool([]Credential{{Name: "first", Transport: first}, {Name: "second", Transport: second}})
+	require.NoError(t, err)
+	client := &http.Client{Transport: pool}
+
+	get := func(path string) {
+		resp, err := client.Get("https://api.github.com" + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// Requests alternate while both credentials have budget
+	get("/orgs/a")
+	get("/orgs/a")
+	assert.Equal(t, 1, first.requests)
+	assert.Equal(t, 1, second.requests)
+
+	// The first credential drops below the threshold and is skipped
+	get("/orgs/a")
+	get("/orgs/a")
+	get("/orgs/a")
+	assert.Equal(t, 2, first.requests)
+	assert.Equal(t, 3, second.requests)
+	assert.True(t, pool.Available("core"))
+
+	// GraphQL budgets are tracked apart from the REST ones
+	resp, err := client.Post("https://api.github.com/graphql", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 3, first.requests)
+
+	// Once every credential is low, requests go through the one that resets first
+	second.remaining = 1
+	get("/orgs/a")
+	assert.False(t, pool.Available("core"))
+	assert.Equal(t, time.Unix(reset.Unix(), 0), pool.Reset("core"))
+	get("/orgs/a")
+	assert.Equal(t, 4, first.requests)
+
+	_, err = NewCredentialPool(nil)
+	assert.Error(t, err)
+}
+
+func TestWaitForLimitReset_CredentialPool(t *testing.T) {
+	low := &budgetTransport{remaining: 1, reset: time.Now().Add(time.Hour)}
+	spare := &budgetTransport{remaining: 5000, reset: time.Now().Add(time.Hour)}
+	pool, err := NewCredentialPool([]Credential{{Name: "low", Transport: low}, {Name: "spare", Transport: spare}})
+	require.NoError(t, err)
+	for range 2 {
+		resp, err := (&http.Client{Transport: pool}).Get("https://api.github.com/orgs/a")
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// Another credential has budget, so there is no wait for the low one to reset
+	ctx := WithCredentialPool(context.Background(), pool)
+	start := time.Now()
+	waitForLimitReset(ctx, "rest", 0, 5000, time.Now().Add(time.Hour))
+	assert.Less(t, time.Since(start), time.Second, fmt.Sprintf("waited %v", time.Since(start)))
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/go-github/v70/github"
@@ -23,6 +24,8 @@ type rateLimitQuery struct {
 // FetchOrganizationMembershipsWithRole fetches all organization memberships with roles
 // in the given organization using the GraphQL API with pagination.
 // It returns a list of users with their login, name, database ID, and role in the organization.
+// Roles use the REST API's names ("admin" or "member"). TwoFactorAuthentication is set when the
+// token can see the members' two-factor status, which requires organization owner access.
 func FetchOrganizationMembershipsWithRole(ctx context.Context, graphQLClient *githubv4.Client, orgLogin string) ([]*github.User, error) {
 	slog.Debug("fetching organization memberships", "organization", orgLogin)
 	// Define the GraphQL query to fetch organization memberships.
@@ -32,8 +35,9 @@ func FetchOrganizationMembershipsWithRole(ctx context.Context, graphQLClient *gi
 		Organization struct {
 			MembersWithRole struct {
 				Edges []struct {
-					Role string
-					Node struct {
+					Role                string
+					HasTwoFactorEnabled *bool
+					Node                struct {
 						Name       string
 						Login      string
 						DatabaseID int64
@@ -44,27 +48,34 @@ func FetchOrganizationMembershipsWithRole(ctx context.Context, graphQLClient *gi
 					EndCursor   githubv4.String
 				}
 			} `graphql:"membersWithRole(first: 100, after: $cursor)"`
-		} `graphql:"organization(login: $login)"`
+		} `graphql:"organization(login: $org)"`
 		RateLimit rateLimitQuery
 	}
 	variables := map[string]interface{}{
-		"login":  githubv4.String(orgLogin),
+		"org":    githubv4.String(orgLogin),
 		"cursor": (*githubv4.String)(nil),
 	}
 
-	memberMap := make(map[string]*github.User)
+	// Members are kept in API order; the map only guards against a member repeated across pages
+	seen := make(map[string]bool)
+	var allMemberships []*github.User
 	for {
 		err := graphQLClient.Query(ctx, &query, variables)
 		if err != nil {
 			return nil, fmt.Errorf("query organization memberships for %q failed: %w", orgLogin, err)
 		}
 		for _, edge := range query.Organization.MembersWithRole.Edges {
-			memberMap[edge.Node.Login] = &github.User{
-				Login:    &edge.Node.Login,
-				Name:     &edge.Node.Name,
-				ID:       &edge.Node.DatabaseID,
-				RoleName: &edge.Role,
+			if seen[edge.Node.Login] {
+				continue
 			}
+			seen[edge.Node.Login] = true
+			allMemberships = append(allMemberships, &github.User{
+				Login:                   github.Ptr(edge.Node.Login),
+				Name:                    github.Ptr(edge.Node.Name),
+				ID:                      github.Ptr(edge.Node.DatabaseID),
+				RoleName:                github.Ptr(strings.ToLower(edge.Role)),
+				TwoFactorAuthentication: edge.HasTwoFactorEnabled,
+			})
 		}
 		// Check rate limit
 		handleGraphQLRateLimit(ctx, &query.RateLimit)
@@ -75,10 +86,6 @@ func FetchOrganizationMembershipsWithRole(ctx context.Context, graphQLClient *gi
 		}
 		variables["cursor"] = query.Organization.MembersWithRole.PageInfo.EndCursor
 	}
-	allMemberships := make([]*github.User, 0, len(memberMap))
-	for _, member := range memberMap {
-		allMemberships = append(allMemberships, member)
-	}
 	slog.Debug("fetched all memberships",
 		"organization", orgLogin,
 		"totalMemberships", len(allMemberships),
@@ -86,13 +93,149 @@ func FetchOrganizationMembershipsWithRole(ctx context.Context, graphQLClient *gi
 	return allMemberships, nil
 }
 
-// FetchEnterpriseUsers retrieves all enterprise cloud users via the GraphQL API.
+// FetchEnterpriseAdmins retrieves the enterprise owners and billing managers via the GraphQL API.
+// It returns a list of users with their login, name, database ID, and enterprise role
+// (OWNER or BILLING_MANAGER). The enterprise's owner info is only visible to enterprise owners,
+// so an error is returned when the token cannot see it.
+func FetchEnterpriseAdmins(ctx context.Context, graphQLClient *githubv4.Client, enterpriseSlug string) ([]*github.User, error) {
+	slog.Debug("fetching enterprise admins", "enterprise", enterpriseSlug)
+	var query struct {
+		Enterprise struct {
+			OwnerInfo *struct {
+				Admins struct {
+					Edges []struct {
+						Role string
+						Node struct {
+							Name       string
+							Login      string
+							DatabaseID int64
+						}
+					}
+					PageInfo struct {
+						HasNextPage bool
+						EndCursor   githubv4.String
+					}
+				} `graphql:"admins(first: 100, after: $cursor)"`
+			}
+		} `graphql:"enterprise(slug: $enterpriseSlug)"`
+		RateLimit rateLimitQuery
+	}
+	variables := map[string]interface{}{
+		"enterpriseSlug": githubv4.String(enterpriseSlug),
+		"cursor":         (*githubv4.String)(nil),
+	}
+
+	var admins []*github.User
+	for {
+		err := graphQLClient.Query(ctx, &query, variables)
+		if err != nil {
+			return nil, fmt.Errorf("query enterprise admins for %q failed: %w", enterpriseSlug, err)
+		}
+		if query.Enterprise.OwnerInfo == nil {
+			return nil, fmt.Errorf("owner info for enterprise %q is not visible; the token must belong to an enterprise owner", enterpriseSlug)
+		}
+		for _, edge := range query.Enterprise.OwnerInfo.Admins.Edges {
+			admins = append(admins, &github.User{
+				Login:    github.Ptr(edge.Node.Login),
+				Name:     github.Ptr(edge.Node.Name),
+				ID:       github.Ptr(edge.Node.DatabaseID),
+				RoleName: github.Ptr(edge.Role),
+			})
+		}
+
+		// Check rate limit
+		handleGraphQLRateLimit(ctx, &query.RateLimit)
+
+		// Check if there are more pages
+		if !query.Enterprise.OwnerInfo.Admins.PageInfo.HasNextPage {
+			break
+		}
+		variables["cursor"] = query.Enterprise.OwnerInfo.Admins.PageInfo.EndCursor
+	}
+
+	slog.Debug("fetched all enterprise admins", "enterprise", enterpriseSlug, "total", len(admins))
+	return admins, nil
+}
+
+// SecuritySettings holds identity and network settings of an enterprise or organization
+// that are only exposed by the GraphQL API.
+type SecuritySettings struct {
+	IPAllowListEnabled bool // Whether the IP allow list is enabled
+	// SAMLConfigured is whether a SAML identity provider is configured.
+	// The API does not expose whether SAML single sign-on is enforced.
+	SAMLConfigured bool
+}
+
+// FetchEnterpriseSecuritySettings retrieves the enterprise-wide IP allow list and SAML settings,
+// which apply to every organization in the enterprise. The settings are only visible to
+// enterprise owners, so an error is returned when the token cannot see them.
+func FetchEnterpriseSecuritySettings(ctx context.Context, graphQLClient *githubv4.Client, enterpriseSlug string) (*SecuritySettings, error) {
+	slog.Debug("fetching enterprise security settings", "enterprise", enterpriseSlug)
+	var query struct {
+		Enterprise struct {
+			OwnerInfo *struct {
+				IPAllowListEnabledSetting string `graphql:"ipAllowListEnabledSetting"`
+				SAMLIdentityProvider      *struct {
+					ID string
+				} `graphql:"samlIdentityProvider"`
+			}
+		} `graphql:"enterprise(slug: $enterpriseSlug)"`
+		RateLimit rateLimitQuery
+	}
+	variables := map[string]interface{}{
+		"enterpriseSlug": githubv4.String(enterpriseSlug),
+	}
+
+	if err := graphQLClient.Query(ctx, &query, variables); err != nil {
+		return nil, fmt.Errorf("query security settings for enterprise %q failed: %w", enterpriseSlug, err)
+	}
+	handleGraphQLRateLimit(ctx, &query.RateLimit)
+
+	owner := query.Enterprise.OwnerInfo
+	if owner == nil {
+		return nil, fmt.Errorf("owner info for enterprise %q is not visible; the token must belong to an enterprise owner", enterpriseSlug)
+	}
+	return &SecuritySettings{
+		IPAllowListEnabled: owner.IPAllowListEnabledSetting == "ENABLED",
+		SAMLConfigured:     owner.SAMLIdentityProvider != nil,
+	}, nil
+}
+
+// FetchOrganizationSecuritySettings retrieves the IP allow list and SAML settings configured on an
+// organization itself. The settings are only visible to organization owners.
+func FetchOrganizationSecuritySettings(ctx context.Context, graphQLClient *githubv4.Client, orgLogin string) (*SecuritySettings, error) {
+	slog.Debug("fetching organization security settings", "organization", orgLogin)
+	var query struct {
+		Organization struct {
+			IPAllowListEnabledSetting string `graphql:"ipAllowListEnabledSetting"`
+			SAMLIdentityProvider      *struct {
+				ID string
+			} `graphql:"samlIdentityProvider"`
+		} `graphql:"organization(login: $org)"`
+		RateLimit rateLimitQuery
+	}
+	variables := map[string]interface{}{
+		"org": githubv4.String(orgLogin),
+	}
+
+	if err := graphQLClient.Query(ctx, &query, variables); err != nil {
+		return nil, fmt.Errorf("query security settings for organization %q failed: %w", orgLogin, err)
+	}
+	handleGraphQLRateLimit(ctx, &query.RateLimit)
+
+	return &SecuritySettings{
+		IPAllowListEnabled: query.Organization.IPAllowListEnabledSetting == "ENABLED",
+		SAMLConfigured:     query.Organization.SAMLIdentityProvider != nil,
+	}, nil
+}
+
+// FetchEnterpriseUsers retrieves all enterprise users of the given deployment via the GraphQL API.
 // It uses pagination to fetch all users associated with the specified enterprise slug
 // and includes their login, name, database ID, and creation date.
-func FetchEnterpriseUsers(ctx context.Context, graphQLClient *githubv4.Client, enterpriseSlug string) ([]*github.User, error) {
-	slog.Debug("fetching enterprise users", "enterprise", enterpriseSlug)
+func FetchEnterpriseUsers(ctx context.Context, graphQLClient *githubv4.Client, enterpriseSlug string, deployment githubv4.EnterpriseUserDeployment) ([]*github.User, error) {
+	slog.Debug("fetching enterprise users", "enterprise", enterpriseSlug, "deployment", deployment)
 	// Define the GraphQL query to fetch enterprise users.
-	// The query fetches the first 100 Cloud members and uses pagination to retrieve all users.
+	// The query fetches the first 100 members and uses pagination to retrieve all users.
 	// It also checks for rate limits after each request.
 	var query struct {
 		Enterprise struct {
@@ -111,7 +254,7 @@ func FetchEnterpriseUsers(ctx context.Context, graphQLClient *githubv4.Client, e
 					HasNextPage bool
 					EndCursor   githubv4.String
 				}
-			} `graphql:"members(first: 100, deployment: CLOUD, after: $cursor)"`
+			} `graphql:"members(first: 100, deployment: $deployment, after: $cursor)"`
 		} `graphql:"enterprise(slug: $enterpriseSlug)"`
 		RateLimit rateLimitQuery
 	}
@@ -120,6 +263,7 @@ func FetchEnterpriseUsers(ctx context.Context, graphQLClient *githubv4.Client, e
 
 	variables := map[string]interface{}{
 		"enterpriseSlug": githubv4.String(enterpriseSlug),
+		"deployment":     deployment,
 		"cursor":         (*githubv4.String)(nil), // nil for first request.
 	}
 
@@ -162,122 +306,198 @@ func FetchEnterpriseUsers(ctx context.Context, graphQLClient *githubv4.Client, e
 	return allUsers, nil
 }
 
-// HasRecentContributions checks if a user has any contributions since the provided time.
-// It uses the GraphQL API to fetch contribution statistics for the specified user,
-// including commits, issues, pull requests, and reviews.
-func HasRecentContributions(ctx context.Context, graphQLClient *githubv4.Client, user string, since time.Time) (bool, error) {
-	slog.Debug("checking recent contributions",
-		"user", user,
-		"since", since,
-	)
+// FetchUserEmail queries the enterprise GraphQL API to retrieve the email address for the specified user.
+// It attempts to find the user's email from SAML or SCIM identity providers
+// and returns "N/A" if no email is found.
+func FetchUserEmail(ctx context.Context, graphQLClient *githubv4.Client, slug string, user string) (string, error) {
+	emails, err := FetchUserEmails(ctx, graphQLClient, slug, []string{user})
+	if err != nil {
+		return "", fmt.Errorf("query user email for %q failed: %w", user, err)
+	}
+	return emails[user], nil
+}
 
-	var query struct {
+// externalIdentityConnection is the per-user part of a batched email query.
+type externalIdentityConnection struct {
+	Nodes []struct {
 		User struct {
-			ContributionsCollection struct {
-				TotalCommitContributions            int
-				TotalIssueContributions             int
-				TotalPullRequestContributions       int
-				TotalPullRequestReviewContributions int
-				HasAnyContributions                 bool
-				HasAnyRestrictedContributions       bool
-			} `graphql:"contributionsCollection(from: $since)"`
-		} `graphql:"user(login: $login)"`
-		RateLimit rateLimitQuery
+			Login githubv4.String
+		}
+		ScimIdentity struct {
+			Username githubv4.String
+			Emails   []struct {
+				Value githubv4.String
+			}
+		}
+		SamlIdentity struct {
+			Username githubv4.String
+			Emails   []struct {
+				Value githubv4.String
+			}
+		}
 	}
+}
 
-	vars := map[string]interface{}{
-		"login": githubv4.String(user),
-		"since": githubv4.DateTime{Time: since},
+// FetchUserEmails retrieves the email addresses of the specified users from the enterprise's
+// SAML or SCIM identity provider, looking up many users per query with BatchQuery.
+// Users without an email map to "N/A"; users whose lookup failed are left out of the result.
+func FetchUserEmails(ctx context.Context, graphQLClient *githubv4.Client, slug string, users []string) (map[string]string, error) {
+	slog.Debug("fetching emails for users", "users", len(users))
+
+	spec := BatchSpec{
+		Path: []string{"enterprise(slug: $slug)", "ownerInfo", "samlIdentityProvider"},
+		Field: func(i int) string {
+			return fmt.Sprintf("i%d: externalIdentities(first: 1, login: $login%d)", i, i)
+		},
+		Variables: func(i int, login string) map[string]any {
+			return map[string]any{fmt.Sprintf("login%d", i): githubv4.String(login)}
+		},
+		Shared: map[string]any{"slug": githubv4.String(slug)},
 	}
 
-	if err := graphQLClient.Query(ctx, &query, vars); err != nil {
-		return false, fmt.Errorf("query recent contributions for %q failed: %w", user, err)
+	identities, err := BatchQuery[externalIdentityConnection](ctx, graphQLClient, spec, users)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check for rate limits.
-	handleGraphQLRateLimit(ctx, &query.RateLimit)
-
-	contrib := query.User.ContributionsCollection
-	total := contrib.TotalCommitContributions +
-		contrib.TotalIssueContributions +
-		contrib.TotalPullRequestContributions +
-		contrib.TotalPullRequestReviewContributions
-
-	// check for contributions
-	if total > 0 {
-		slog.Debug("detected contributions for user",
-			"user", user,
-			"totalContributions", total,
-		)
+	emails := make(map[string]string, len(identities))
+	for user, identity := range identities {
+		emails[user] = "N/A"
+		for _, node := range identity.Nodes {
+			if string(node.User.Login) != user {
+				continue
+			}
+			// Prefer SamlIdentity emails over ScimIdentity.
+			if len(node.SamlIdentity.Emails) > 0 {
+				emails[user] = string(node.SamlIdentity.Emails[0].Value)
+			} else if len(node.ScimIdentity.Emails) > 0 {
+				emails[user] = string(node.ScimIdentity.Emails[0].Value)
+			}
+			break
+		}
+		if emails[user] == "N/A" {
+			slog.Debug("no email found for user", "user", user)
+		}
 	}
 
-	active := contrib.HasAnyContributions || contrib.HasAnyRestrictedContributions || total > 0
+	return emails, nil
+}
 
-	return active, nil
+// ExternalIdentity is an identity the enterprise's identity provider linked through SAML single sign-on
+// or provisioned through SCIM, with the GitHub user it is linked to.
+type ExternalIdentity struct {
+	GUID         string   // Identifier of the identity; for SCIM-provisioned identities, the SCIM user ID
+	Login        string   // Linked GitHub user; empty when the identity is not linked to a user
+	UserID       int64    // Database ID of the linked GitHub user
+	SAMLNameID   string   // SAML NameID of the identity
+	SAMLUsername string   // Username of the SAML identity
+	SAMLEmails   []string // Emails of the SAML identity
+	SCIMUsername string   // Username of the SCIM identity
+	SCIMEmails   []string // Emails of the SCIM identity
 }
 
-// FetchUserEmail queries the enterprise GraphQL API to retrieve the email address for the specified user.
-// It attempts to find the user's email from SAML or SCIM identity providers
-// and returns "N/A" if no email is found.
-func FetchUserEmail(ctx context.Context, graphQLClient *githubv4.Client, slug string, user string) (string, error) {
-	slog.Debug("fetching email for user", "user", user)
+// externalIdentitiesPage is a page of the external identities of an identity provider.
+type externalIdentitiesPage struct {
+	ExternalIdentities struct {
+		Nodes []struct {
+			GUID string `graphql:"guid"`
+			User *struct {
+				Login      string
+				DatabaseID int64
+			}
+			SamlIdentity *struct {
+				NameID   string `graphql:"nameId"`
+				Username string
+				Emails   []struct {
+					Value string
+				}
+			}
+			ScimIdentity *struct {
+				Username string
+				Emails   []struct {
+					Value string
+				}
+			}
+		}
+		PageInfo struct {
+			HasNextPage bool
+			EndCursor   githubv4.String
+		}
+	} `graphql:"externalIdentities(first: 100, after: $cursor)"`
+}
 
+// FetchEnterpriseExternalIdentities retrieves every external identity of the enterprise's SAML
+// or OIDC identity provider, including identities that are not linked to a GitHub user.
+// The identities are only visible to enterprise owners, so an error is returned when the token
+// cannot see them or the enterprise has no identity provider.
+func FetchEnterpriseExternalIdentities(ctx context.Context, graphQLClient *githubv4.Client, enterpriseSlug string) ([]*ExternalIdentity, error) {
+	slog.Debug("fetching enterprise external identities", "enterprise", enterpriseSlug)
 	var query struct {
 		Enterprise struct {
-			OwnerInfo struct {
-				SamlIdentityProvider struct {
-					ExternalIdentities struct {
-						Nodes []struct {
-							User struct {
-								Login githubv4.String
-							}
-							ScimIdentity struct {
-								Username githubv4.String
-								Emails   []struct {
-									Value githubv4.String
-								}
-							}
-							SamlIdentity struct {
-								Username githubv4.String
-								Emails   []struct {
-									Value githubv4.String
-								}
-							}
-						}
-					} `graphql:"externalIdentities(first: 1, login: $login)"`
-				}
+			OwnerInfo *struct {
+				SAMLIdentityProvider *externalIdentitiesPage `graphql:"samlIdentityProvider"`
+				OIDCProvider         *externalIdentitiesPage `graphql:"oidcProvider"`
 			}
-		} `graphql:"enterprise(slug: $slug)"`
+		} `graphql:"enterprise(slug: $enterpriseSlug)"`
 		RateLimit rateLimitQuery
 	}
 	variables := map[string]interface{}{
-		"slug":  githubv4.String(slug),
-		"login": githubv4.String(user),
-	}
-	if err := graphQLClient.Query(ctx, &query, variables); err != nil {
-		return "", fmt.Errorf("query user email for %q failed: %w", user, err)
+		"enterpriseSlug": githubv4.String(enterpriseSlug),
+		"cursor":         (*githubv4.String)(nil),
 	}
 
-	// Check for rate limits.
-	handleGraphQLRateLimit(ctx, &query.RateLimit)
+	var identities []*ExternalIdentity
+	for {
+		if err := graphQLClient.Query(ctx, &query, variables); err != nil {
+			return nil, fmt.Errorf("query external identities for enterprise %q failed: %w", enterpriseSlug, err)
+		}
+		handleGraphQLRateLimit(ctx, &query.RateLimit)
 
-	for _, node := range query.Enterprise.OwnerInfo.SamlIdentityProvider.ExternalIdentities.Nodes {
-		if string(node.User.Login) == user {
-			// Prefer SamlIdentity emails over ScimIdentity.
-			if len(node.SamlIdentity.Emails) > 0 {
-				slog.Debug("found email for user", "user", user, "email", node.SamlIdentity.Emails[0].Value)
-				return string(node.SamlIdentity.Emails[0].Value), nil
+		owner := query.Enterprise.OwnerInfo
+		if owner == nil {
+			return nil, fmt.Errorf("owner info for enterprise %q is not visible; the token must belong to an enterprise owner", enterpriseSlug)
+		}
+		// Enterprise managed users authenticate through OIDC rather than SAML
+		page := owner.SAMLIdentityProvider
+		if page == nil {
+			page = owner.OIDCProvider
+		}
+		if page == nil {
+			return nil, fmt.Errorf("enterprise %q has no SAML or OIDC identity provider configured", enterpriseSlug)
+		}
+
+		for _, node := range page.ExternalIdentities.Nodes {
+			identity := &ExternalIdentity{GUID: node.GUID}
+			if node.User != nil {
+				identity.Login = node.User.Login
+				identity.UserID = node.User.DatabaseID
+			}
+			if saml := node.SamlIdentity; saml != nil {
+				identity.SAMLNameID = saml.NameID
+				identity.SAMLUsername = saml.Username
+				for _, e := range saml.Emails {
+					identity.SAMLEmails = append(identity.SAMLEmails, e.Value)
+				}
 			}
-			if len(node.ScimIdentity.Emails) > 0 {
-				slog.Debug("found email for user", "user", user, "email", node.ScimIdentity.Emails[0].Value)
-				return string(node.ScimIdentity.Emails[0].Value), nil
+			if scim := node.ScimIdentity; scim != nil {
+				identity.SCIMUsername = scim.Username
+				for _, e := range scim.Emails {
+					identity.SCIMEmails = append(identity.SCIMEmails, e.Value)
+				}
 			}
+			identities = append(identities, identity)
 		}
-	}
+		slog.Debug("fetched a page of external identities", "identities", len(identities),
+			"hasNextPage", page.ExternalIdentities.PageInfo.HasNextPage)
 
-	slog.Debug("no email found for user", "user", user)
+		if !page.ExternalIdentities.PageInfo.HasNextPage {
+			break
+		}
+		variables["cursor"] = page.ExternalIdentities.PageInfo.EndCursor
+	}
 
-	return "N/A", nil
+	slog.Debug("fetched all external identities", "enterprise", enterpriseSlug, "identities", len(identities))
+	return identities, nil
 }
 
 // FetchEnterpriseOrgs retrieves all organizations for the specified enterprise using the GraphQL API.
@@ -336,3 +556,245 @@ func FetchEnterpriseOrgs(ctx context.Context, graphQLClient *githubv4.Client, en
 	slog.Debug("fetched all organizations", "total", len(orgs))
 	return orgs, nil
 }
+
+// EnterpriseCounts holds the sizes of an enterprise that the cost of the reports depends on.
+type EnterpriseCounts struct {
+	Users         int                  // Members of the enterprise's deployment
+	Organizations []OrganizationCounts // Organizations of the enterprise
+}
+
+// OrganizationCounts holds the sizes of an organization that the cost of the reports depends on.
+type OrganizationCounts struct {
+	Login        string
+	Repositories int
+	Teams        int
+	Members      int
+}
+
+// Totals returns the repositories, teams and memberships of all organizations.
+func (c *EnterpriseCounts) Totals() (repos, teams, members int) {
+	for _, org := range c.Organizations {
+		repos += org.Repositories
+		teams += org.Teams
+		members += org.Members
+	}
+	return repos, teams, members
+}
+
+// FetchEnterpriseCounts fetches the number of users of an enterprise's deployment and the number of
+// repositories, teams and members of each of its organizations. Only the total counts of the
+// connections are queried, so the cost is one GraphQL point per 100 organizations.
+func FetchEnterpriseCounts(ctx context.Context, graphQLClient *githubv4.Client, enterpriseSlug string, deployment githubv4.EnterpriseUserDeployment) (*EnterpriseCounts, error) {
+	slog.Debug("fetching enterprise counts", "enterprise", enterpriseSlug)
+	var query struct {
+		Enterprise struct {
+			Members struct {
+				TotalCount int
+			} `graphql:"members(deployment: $deployment)"`
+			Organizations struct {
+				Nodes []struct {
+					Login        string
+					Repositories struct {
+						TotalCount int
+					}
+					Teams struct {
+						TotalCount int
+					}
+					MembersWithRole struct {
+						TotalCount int
+					}
+				}
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   githubv4.String
+				}
+			} `graphql:"organizations(first: 100, after: $cursor)"`
+		} `graphql:"enterprise(slug: $enterpriseSlug)"`
+		RateLimit rateLimitQuery
+	}
+	variables := map[string]interface{}{
+		"enterpriseSlug": githubv4.String(enterpriseSlug),
+		"deployment":     deployment,
+		"cursor":         (*githubv4.String)(nil),
+	}
+
+	counts := &EnterpriseCounts{}
+	for {
+		if err := graphQLClient.Query(ctx, &query, variables); err != nil {
+			return nil, fmt.Errorf("fetch counts for enterprise %q failed: %w", enterpriseSlug, err)
+		}
+		counts.Users = query.Enterprise.Members.TotalCount
+		for _, node := range query.Enterprise.Organizations.Nodes {
+			counts.Organizations = append(counts.Organizations, OrganizationCounts{
+				Login:        node.Login,
+				Repositories: node.Repositories.TotalCount,
+				Teams:        node.Teams.TotalCount,
+				Members:      node.MembersWithRole.TotalCount,
+			})
+		}
+
+		handleGraphQLRateLimit(ctx, &query.RateLimit)
+
+		if !query.Enterprise.Organizations.PageInfo.HasNextPage {
+			break
+		}
+		variables["cursor"] = query.Enterprise.Organizations.PageInfo.EndCursor
+	}
+	slog.Debug("fetched enterprise counts", "users", counts.Users, "organizations", len(counts.Organizations))
+	return counts, nil
+}
+
+// RepositoryActivity describes the activity of a repository that the REST repository listing does not include.
+type RepositoryActivity struct {
+	IsEmpty                 bool      // The repository has no commits
+	Parent                  string    // Full name of the repository a fork was created from; empty for other repositories
+	ParentDefaultBranch     string    // Default branch of the parent repository
+	DefaultBranch           string    // Default branch of the repository
+	LatestRelease           time.Time // Publication of the latest release; zero when never released
+	OpenPullRequests        int       // Number of open pull requests
+	OpenIssues              int       // Number of open issues, excluding pull requests
+	LastOpenPullRequestEdit time.Time // Last update of an open pull request; zero when none are open
+	LastOpenIssueEdit       time.Time // Last update of an open issue; zero when none are open
+}
+
+// openItemConnection is the most recently updated open pull request or issue of a repository.
+type openItemConnection struct {
+	TotalCount int
+	Nodes      []struct {
+		UpdatedAt githubv4.DateTime
+	}
+}
+
+// repositoryActivityNode is the activity of a single repository looked up by FetchRepositoriesActivity.
+type repositoryActivityNode struct {
+	IsEmpty          bool
+	DefaultBranchRef *struct {
+		Name string
+	}
+	Parent *struct {
+		NameWithOwner    string
+		DefaultBranchRef *struct {
+			Name string
+		}
+	}
+	LatestRelease *struct {
+		PublishedAt *githubv4.DateTime
+	}
+	PullRequests openItemConnection `graphql:"pullRequests(states: OPEN, first: 1, orderBy: {field: UPDATED_AT, direction: DESC})"`
+	Issues       openItemConnection `graphql:"issues(states: OPEN, first: 1, orderBy: {field: UPDATED_AT, direction: DESC})"`
+}
+
+// FetchRepositoriesActivity retrieves the activity of the repositories with the given full names,
+// looking up many repositories of the same owner per query with BatchQuery.
+// Repositories whose lookup failed are left out of the result.
+func FetchRepositoriesActivity(ctx context.Context, graphQLClient *githubv4.Client, fullNames []string) (map[string]*RepositoryActivity, error) {
+	slog.Debug("fetching repository activity", "repositories", len(fullNames))
+
+	spec := BatchSpec{
+		Field: func(i int) string {
+			return fmt.Sprintf("r%d: repository(owner: $owner%d, name: $name%d)", i, i, i)
+		},
+		Variables: func(i int, fullName string) map[string]any {
+			owner, name, _ := strings.Cut(fullName, "/")
+			return map[string]any{
+				fmt.Sprintf("owner%d", i): githubv4.String(owner),
+				fmt.Sprintf("name%d", i):  githubv4.String(name),
+			}
+		},
+		Group: func(fullName string) string {
+			owner, _, _ := strings.Cut(fullName, "/")
+			return strings.ToLower(owner)
+		},
+		Nodes:    2,
+		Requests: 2,
+	}
+
+	nodes, err := BatchQuery[*repositoryActivityNode](ctx, graphQLClient, spec, fullNames)
+	if err != nil {
+		return nil, err
+	}
+
+	activity := make(map[string]*RepositoryActivity, len(nodes))
+	for fullName, node := range nodes {
+		if node == nil {
+			continue
+		}
+		a := &RepositoryActivity{
+			IsEmpty:          node.IsEmpty,
+			OpenPullRequests: node.PullRequests.TotalCount,
+			OpenIssues:       node.Issues.TotalCount,
+		}
+		if node.DefaultBranchRef != nil {
+			a.DefaultBranch = node.DefaultBranchRef.Name
+		}
+		if node.Parent != nil {
+			a.Parent = node.Parent.NameWithOwner
+			if node.Parent.DefaultBranchRef != nil {
+				a.ParentDefaultBranch = node.Parent.DefaultBranchRef.Name
+			}
+		}
+		if node.LatestRelease != nil && node.LatestRelease.PublishedAt != nil {
+			a.LatestRelease = node.LatestRelease.PublishedAt.Time
+		}
+		if len(node.PullRequests.Nodes) > 0 {
+			a.LastOpenPullRequestEdit = node.PullRequests.Nodes[0].UpdatedAt.Time
+		}
+		if len(node.Issues.Nodes) > 0 {
+			a.LastOpenIssueEdit = node.Issues.Nodes[0].UpdatedAt.Time
+		}
+		activity[fullName] = a
+	}
+	return activity, nil
+}
+
+// FetchActiveBranches retrieves the names of the branches of a repository whose latest commit was
+// committed at or after since. Older branches cannot hold commits made since then, so they need not be listed.
+func FetchActiveBranches(ctx context.Context, graphQLClient *githubv4.Client, owner, repo string, since time.Time) ([]string, error) {
+	slog.Debug("fetching active branches", "owner", owner, "repo", repo, "since", since)
+	var query struct {
+		Repository struct {
+			Refs struct {
+				Nodes []struct {
+					Name   string
+					Target struct {
+						Commit struct {
+							CommittedDate githubv4.DateTime
+						} `graphql:"... on Commit"`
+					}
+				}
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   githubv4.String
+				}
+			} `graphql:"refs(refPrefix: \"refs/heads/\", first: 100, after: $cursor)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit rateLimitQuery
+	}
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"name":   githubv4.String(repo),
+		"cursor": (*githubv4.String)(nil),
+	}
+
+	var branches []string
+	for {
+		if err := graphQLClient.Query(ctx, &query, variables); err != nil {
+			return nil, fmt.Errorf("query branches for %s/%s failed: %w", owner, repo, err)
+		}
+		handleGraphQLRateLimit(ctx, &query.RateLimit)
+
+		for _, node := range query.Repository.Refs.Nodes {
+			if !node.Target.Commit.CommittedDate.Before(since) {
+				branches = append(branches, node.Name)
+			}
+		}
+
+		if !query.Repository.Refs.PageInfo.HasNextPage {
+			break
+		}
+		variables["cursor"] = query.Repository.Refs.PageInfo.EndCursor
+	}
+
+	slog.Debug("found active branches", "owner", owner, "repo", repo, "branches", len(branches))
+	return branches, nil
+}
@@ -0,0 +1,191 @@
+// Package api provides functionality for interacting with GitHub's REST and GraphQL APIs.
+// It includes rate limiting, client wrapper methods, and utilities for efficient API consumption.
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/google/go-github/v70/github"
+)
+
+// Installation is an installation of a GitHub App on an account.
+type Installation struct {
+	ID      int64
+	Account string // Login of the account the app is installed on; empty for an enterprise
+	Type    string // Type of the account: Organization, User or Enterprise
+}
+
+// FetchAppInstallations lists the installations of the GitHub App that appClient authenticates as,
+// with the app's JWT.
+func FetchAppInstallations(ctx context.Context, appClient *github.Client) ([]Installation, error) {
+	slog.Debug("fetching app installations")
+	var installations []Installation
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := appClient.Apps.ListInstallations(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list app installations: %w", err)
+		}
+		for _, inst := range page {
+			installations = append(installations, Installation{
+				ID:      inst.GetID(),
+				Account: inst.GetAccount().GetLogin(),
+				Type:    inst.GetTargetType(),
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	slog.Debug("fetched app installations", "installations", len(installations))
+	return installations, nil
+}
+
+// graphQLAccountVariable matches the GraphQL variables that name the account a query addresses,
+// including those of aliased batch lookups such as owner0. Login variables are left out: they name
+// the users of user lookups, which may share a login with an organization.
+var graphQLAccountVariable = regexp.MustCompile(`^(org|organization|owner)\d*$`)
+
+// InstallationRouter is an http.RoundTripper that authenticates each request as the installation of
+// a GitHub App on the organization the request addresses. It lets an app that is installed on each
+// organization, rather than on the enterprise, read all of them. Installation tokens are minted the
+// first time an organization is addressed and refreshed as they expire.
+//
+// REST requests address the organization in their /orgs/{org} or /repos/{owner} path. GraphQL
+// requests address it with an org, organization or owner variable. Batched lookups by
+// repository are grouped by owner (see BatchSpec.Group), so a batch addresses a single organization;
+// a request over several organizations is routed as one that addresses none. Requests that address
+// no organization the app is installed on, such as enterprise queries, are sent through the
+// fallback installation.
+type InstallationRouter struct {
+	apps          *ghinstallation.AppsTransport
+	basePath      string           // Path prefix of the REST API, e.g. /api/v3
+	installations map[string]int64 // Installation ID by lowercase account login
+	logins        []string         // Logins of the accounts the app is installed on, sorted
+	fallback      int64
+
+	mu         sync.Mutex
+	transports map[int64]*ghinstallation.Transport
+}
+
+// NewInstallationRouter creates a router over the app's installations. The fallback installation is
+// the one on the enterprise if there is one, and otherwise the one on the first account by login.
+// basePath is the path of the REST API on the server, which GitHub.com serves from the root.
+func NewInstallationRouter(apps *ghinstallation.AppsTransport, installations []Installation, basePath string) (*InstallationRouter, error) {
+	if len(installations) == 0 {
+		return nil, fmt.Errorf("the GitHub App has no installations")
+	}
+
+	r := &InstallationRouter{
+		apps:          apps,
+		basePath:      strings.TrimSuffix(basePath, "/"),
+		installations: make(map[string]int64, len(installations)),
+		transports:    make(map[int64]*ghinstallation.Transport),
+	}
+	sorted := slices.Clone(installations)
+	slices.SortFunc(sorted, func(a, b Installation) int { return strings.Compare(a.Account, b.Account) })
+	for _, inst := range sorted {
+		if inst.Account != "" {
+			r.installations[strings.ToLower(inst.Account)] = inst.ID
+			r.logins = append(r.logins, inst.Account)
+		}
+		if r.fallback == 0 || inst.Type == "Enterprise" {
+			r.fallback = inst.ID
+		}
+	}
+	return r, nil
+}
+
+// Organizations returns the logins of the organizations the app is installed on, sorted.
+func (r *InstallationRouter) Organizations() []string {
+	return slices.Clone(r.logins)
+}
+
+// RoundTrip sends the request through the installation of the organization it addresses.
+func (r *InstallationRouter) RoundTrip(req *http.Request) (*http.Response, error) {
+	account, req, err := r.account(req)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := r.installations[account]
+	if !ok {
+		id = r.fallback
+	}
+	return r.transport(id).RoundTrip(req)
+}
+
+// account returns the lowercase login of the installed account the request addresses, or "" when it
+// addresses none or several, with the request to send. A GraphQL request body is read, so it is sent
+// as a copy of the request with the body replaced.
+func (r *InstallationRouter) account(req *http.Request) (string, *http.Request, error) {
+	path := strings.TrimPrefix(req.URL.Path, r.basePath)
+	if !strings.HasSuffix(path, "/graphql") {
+		segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+		if len(segments) >= 2 && (segments[0] == "orgs" || segments[0] == "repos") {
+			return strings.ToLower(segments[1]), req, nil
+		}
+		return "", req, nil
+	}
+
+	if req.Body == nil {
+		return "", req, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if closeErr := req.Body.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read GraphQL request: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+
+	var query struct {
+		Variables map[string]any `json:"variables"`
+	}
+	if err := json.Unmarshal(body, &query); err != nil {
+		return "", req, nil
+	}
+	var account string
+	for name, value := range query.Variables {
+		login, ok := value.(string)
+		if !ok || !graphQLAccountVariable.MatchString(name) {
+			continue
+		}
+		login = strings.ToLower(login)
+		if _, installed := r.installations[login]; !installed {
+			continue
+		}
+		if account != "" && account != login {
+			return "", req, nil
+		}
+		account = login
+	}
+	return account, req, nil
+}
+
+// transport returns the transport authenticating as the installation, creating it on first use.
+func (r *InstallationRouter) transport(id int64) *ghinstallation.Transport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.transports[id]; ok {
+		return t
+	}
+	slog.Debug("creating transport for app installation", "installation_id", id)
+	t := ghinstallation.NewFromAppsTransport(r.apps, id)
+	r.transports[id] = t
+	return t
+}
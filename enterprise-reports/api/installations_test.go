@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/google/go-github/v70/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newInstallationServer serves the app's installations, mints a token named after each installation,
+// and answers other requests with the token they were sent with.
+func newInstallationServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /app/installations", func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "Bearer "), "installations are listed with the app JWT")
+		fmt.Fprint(w, `[
+			{"id":1,"target_type":"Organization","account":{"login":"Alpha"}},
+			{"id":2,"target_type":"Organization","account":{"login":"beta"}}
+		]`)
+	})
+	mux.HandleFunc("POST /app/installations/{id}/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"token":"token-%s","expires_at":%q}`, r.PathValue("id"), time.Now().Add(time.Hour).Format(time.RFC3339))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/graphql") {
+			var query map[string]any
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&query), "the GraphQL body is sent after routing")
+		}
+		fmt.Fprint(w, strings.TrimPrefix(r.Header.Get("Authorization"), "token "))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestInstallationRouter(t *testing.T) {
+	srv := newInstallationServer(t)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	apps := ghinstallation.NewAppsTransportFromPrivateKey(http.DefaultTransport, 42, key)
+	apps.BaseURL = srv.URL
+
+	appClient := github.NewClient(&http.Client{Transport: apps})
+	appClient.BaseURL, _ = url.Parse(srv.URL + "/")
+	installations, err := FetchAppInstallations(context.Background(), appClient)
+	require.NoError(t, err)
+	assert.Equal(t, []Installation{{ID: 1, Account: "Alpha", Type: "Organization"}, {ID: 2, Account: "beta", Type: "Organization"}}, installations)
+
+	router, err := NewInstallationRouter(apps, installations, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alpha", "beta"}, router.Organizations())
+	client := &http.Client{Transport: router}
+
+	get := func(path string) string {
+		resp, err := client.Get(srv.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return readBody(t, resp)
+	}
+	graphQL := func(variables string) string {
+		resp, err := client.Post(srv.URL+"/graphql", "application/json", strings.NewReader(`{"query":"query{}","variables":`+variables+`}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return readBody(t, resp)
+	}
+
+	tests := []struct {
+		name string
+		got  func() string
+		want string
+	}{
+		{name: "REST organization", got: func() string { return get("/orgs/alpha/members") }, want: "token-1"},
+		{name: "REST repository", got: func() string { return get("/repos/Beta/app/teams") }, want: "token-2"},
+		{name: "REST enterprise falls back", got: func() string { return get("/enterprises/ent/consumed-licenses") }, want: "token-1"},
+		{name: "GraphQL organization", got: func() string { return graphQL(`{"org":"beta","cursor":null}`) }, want: "token-2"},
+		{name: "GraphQL batch within an organization", got: func() string { return graphQL(`{"owner0":"beta","name0":"a","owner1":"beta","name1":"b"}`) }, want: "token-2"},
+		{name: "GraphQL batch across organizations falls back", got: func() string { return graphQL(`{"owner0":"alpha","owner1":"beta"}`) }, want: "token-1"},
+		{name: "GraphQL user login falls back", got: func() string { return graphQL(`{"login":"octocat"}`) }, want: "token-1"},
+		{name: "GraphQL user lookups named like an organization fall back", got: func() string { return graphQL(`{"login0":"beta","login1":"beta"}`) }, want: "token-1"},
+		{name: "GraphQL enterprise falls back", got: func() string { return graphQL(`{"enterpriseSlug":"beta"}`) }, want: "token-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.got())
+		})
+	}
+
+	_, err = NewInstallationRouter(apps, nil, "")
+	assert.ErrorContains(t, err, "no installations")
+}
+
+func TestInstallationRouter_EnterpriseFallback(t *testing.T) {
+	router, err := NewInstallationRouter(nil, []Installation{
+		{ID: 1, Account: "alpha", Type: "Organization"},
+		{ID: 3, Type: "Enterprise"},
+		{ID: 2, Account: "beta", Type: "Organization"},
+	}, "/api/v3/")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), router.fallback)
+	assert.Equal(t, []string{"alpha", "beta"}, router.Organizations())
+
+	// The REST path is matched below the server's API prefix
+	req := httptest.NewRequest(http.MethodGet, "https://ghes.example.com/api/v3/orgs/Beta/teams", nil)
+	account, _, err := router.account(req)
+	require.NoError(t, err)
+	assert.Equal(t, "beta", account)
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
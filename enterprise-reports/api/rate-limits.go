@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/progress"
 	"github.com/shurcooL/githubv4"
 )
 
@@ -51,7 +52,24 @@ func checkRateLimit(ctx context.Context, rlService rateLimiter) (*github.RateLim
 
 // waitForLimitReset waits until the given limit resets.
 // It logs the wait duration formatted as minutes and seconds and the reset time in UTC.
+// It does not wait while a credential pool carried by the context has another credential with budget.
 func waitForLimitReset(ctx context.Context, name string, remaining, limit int, resetTime time.Time) {
+	// With a credential pool, requests move on to a credential with budget left,
+	// and otherwise only need to wait for the first credential to reset
+	if pool := credentialPoolFrom(ctx); pool != nil {
+		resource := name
+		if resource == "rest" {
+			resource = "core"
+		}
+		if pool.Available(resource) {
+			slog.Debug("rate limit low, continuing with another credential", "api", name, "remaining", remaining)
+			return
+		}
+		if reset := pool.Reset(resource); !reset.IsZero() && reset.Before(resetTime) {
+			resetTime = reset
+		}
+	}
+
 	now := time.Now().UTC() // Ensure UTC
 	waitDuration := resetTime.Sub(now) + time.Second
 	if waitDuration > 0 {
@@ -79,6 +97,7 @@ func waitForLimitReset(ctx context.Context, name string, remaining, limit int, r
 
 // handleRESTRateLimit logs a warning and waits if the REST rate limit is below the threshold.
 func handleRESTRateLimit(ctx context.Context, rate *github.Rate) {
+	progress.TrackerFrom(ctx).SetBudget("rest", rate.Remaining, rate.Limit, rate.Reset.Time)
 	if rate.Remaining < RESTRateLimitThreshold {
 		slog.Warn("rest rate limit low", "remaining", rate.Remaining, "limit", rate.Limit)
 		waitForLimitReset(ctx, "rest", rate.Remaining, rate.Limit, rate.Reset.Time)
@@ -87,6 +106,7 @@ func handleRESTRateLimit(ctx context.Context, rate *github.Rate) {
 
 // handleGraphQLRateLimit logs a warning and waits if the GraphQL rate limit is below the threshold.
 func handleGraphQLRateLimit(ctx context.Context, rate *rateLimitQuery) {
+	progress.TrackerFrom(ctx).SetBudget("graphql", rate.Remaining, rate.Limit, rate.ResetAt.Time)
 	if rate.Remaining < GraphQLRateLimitThreshold {
 		slog.Warn("graphql rate limit low", "remaining", rate.Remaining, "limit", rate.Limit)
 		waitForLimitReset(ctx, "graphql", rate.Remaining, rate.Limit, rate.ResetAt.Time)
@@ -100,6 +120,8 @@ func EnsureRateLimits(ctx context.Context, restClient *github.Client) {
 		return // Error already logged in checkRateLimit
 	}
 
+	setProgressBudgets(ctx, rl)
+
 	if core := rl.GetCore(); core != nil && core.Remaining < RESTRateLimitThreshold {
 		waitForLimitReset(ctx, "rest", core.Remaining, core.Limit, core.Reset.Time)
 	}
@@ -140,6 +162,8 @@ func MonitorRateLimits(ctx context.Context, restSvc rateLimiter, graphQLClient *
 				continue
 			}
 
+			setProgressBudgets(ctx, rateLimits)
+
 			core := rateLimits.GetCore()
 			gql := rateLimits.GetGraphQL()
 			audit := rateLimits.GetAuditLog()
@@ -174,10 +198,24 @@ func MonitorRateLimits(ctx context.Context, restSvc rateLimiter, graphQLClient *
 			}
 
 			slog.Info("rate limits", kv...)
+			if pool := credentialPoolFrom(ctx); pool != nil {
+				pool.LogBudgets()
+			}
 		}
 	}
 }
 
+// setProgressBudgets records the REST and GraphQL budgets in the progress tracker carried by the context.
+func setProgressBudgets(ctx context.Context, rl *github.RateLimits) {
+	tracker := progress.TrackerFrom(ctx)
+	if core := rl.GetCore(); core != nil {
+		tracker.SetBudget("rest", core.Remaining, core.Limit, core.Reset.Time)
+	}
+	if gql := rl.GetGraphQL(); gql != nil {
+		tracker.SetBudget("graphql", gql.Remaining, gql.Limit, gql.Reset.Time)
+	}
+}
+
 // Helper functions to safely access rate limit fields.
 
 // getRemaining safely returns the Remaining value from a Rate.
@@ -4,16 +4,21 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/go-github/v70/github"
 )
 
-// HasRecentEvents determines whether a user has any recent events after the specified time.
-// It checks the user's public event stream and returns true if any events are found after the given time.
-func HasRecentEvents(ctx context.Context, restClient *github.Client, user string, since time.Time) (bool, error) {
+// FetchLastEventTime returns the time of the user's most recent event after the specified time.
+// It checks the first page of the user's event stream, which is ordered newest first,
+// and returns the zero time if no event is found after the given time.
+func FetchLastEventTime(ctx context.Context, restClient *github.Client, user string, since time.Time) (time.Time, error) {
 	slog.Debug("checking recent events", "user", user, "since", since)
 
 	opts := &github.ListOptions{
@@ -21,82 +26,163 @@ func HasRecentEvents(ctx context.Context, restClient *github.Client, user string
 		Page:    1,
 	}
 
-	events, _, err := restClient.Activity.ListEventsPerformedByUser(ctx, user, false, opts)
+	events, resp, err := restClient.Activity.ListEventsPerformedByUser(ctx, user, false, opts)
 	if err != nil {
-		return false, fmt.Errorf("failed to fetch events for %q: %w", user, err)
+		return time.Time{}, fmt.Errorf("failed to fetch events for %q: %w", user, err)
 	}
 
+	// Check rate limit
+	handleRESTRateLimit(ctx, &resp.Rate)
+
+	var last time.Time
 	for _, event := range events {
-		if event.GetCreatedAt().After(since) {
-			slog.Debug("detected recent activity", "user", user, "event_type", event.GetType(), "event_time", event.GetCreatedAt())
-			return true, nil
+		created := event.GetCreatedAt().UTC()
+		if created.After(since) && created.After(last) {
+			slog.Debug("detected recent activity", "user", user, "event_type", event.GetType(), "event_time", created)
+			last = created
 		}
 	}
 
-	return false, nil
+	return last, nil
 }
 
-// FetchUserLogins retrieves audit log events for user login actions over the past 90 days
-// and returns a mapping of login names to their most recent login times.
-// This helps identify recently active vs dormant users.
-func FetchUserLogins(ctx context.Context, restClient *github.Client, enterpriseSlug string, referenceTime time.Time) (map[string]time.Time, error) {
-	slog.Debug("fetching user login audit logs", "enterprise", enterpriseSlug)
+// AuditLogQuery selects the enterprise audit log entries to fetch.
+type AuditLogQuery struct {
+	// Phrase is an audit log search phrase, e.g. "action:repo.create actor:octocat created:>=2024-01-01".
+	Phrase string
+	// Include selects the event types to search ("web", "git" or "all"); empty uses the API default of "web".
+	Include string
+	// Order sorts entries by creation time ("asc" or "desc"); empty uses the API default of "desc".
+	Order string
+	// After is the cursor to resume from; empty starts at the first page.
+	After string
+}
+
+// StreamAuditLog retrieves the enterprise audit log entries matching the query page by page,
+// following cursors and handling rate limits. Each page is passed to handle together with the
+// cursor of the next page, which is empty after the last page, so entries never have to be held
+// in memory at once. An error returned by handle stops the stream and is returned.
+func StreamAuditLog(ctx context.Context, restClient *github.Client, enterpriseSlug string, query AuditLogQuery, handle func(entries []*github.AuditEntry, next string) error) error {
+	slog.Debug("streaming audit logs", "enterprise", enterpriseSlug, "phrase", query.Phrase, "include", query.Include, "order", query.Order)
 
-	// Only pull login events on or after the reference time
-	phrase := fmt.Sprintf("action:user.login created:>=%s", referenceTime.Format(time.RFC3339))
 	opts := &github.GetAuditLogOptions{
-		Phrase: &phrase,
 		ListCursorOptions: github.ListCursorOptions{
-			After:   "",
+			After:   query.After,
 			PerPage: 100,
 		},
 	}
-
-	var allAuditLogs []*github.AuditEntry
+	if query.Phrase != "" {
+		opts.Phrase = &query.Phrase
+	}
+	if query.Include != "" {
+		opts.Include = &query.Include
+	}
+	if query.Order != "" {
+		opts.Order = &query.Order
+	}
 
 	for {
-
 		// Fetch audit logs with pagination.
 		auditLogs, resp, err := restClient.Enterprise.GetAuditLog(ctx, enterpriseSlug, opts)
 		if err != nil {
-			return nil, fmt.Errorf("get audit log for enterprise %q failed: %w", enterpriseSlug, err)
+			return fmt.Errorf("get audit log for enterprise %q failed: %w", enterpriseSlug, err)
 		}
 
 		// Log added after fetching a page of audit logs.
 		slog.Debug("fetched audit logs page", "count", len(auditLogs), "after_cursor", resp.After)
 
-		allAuditLogs = append(allAuditLogs, auditLogs...)
+		if err := handle(auditLogs, resp.After); err != nil {
+			return err
+		}
 
 		// Check rate limits after fetching a page of audit logs.
 		handleRESTRateLimit(ctx, &resp.Rate)
 
 		if resp.After == "" {
-			break
+			return nil
 		}
 
 		// Update the cursor for the next page.
 		opts.After = resp.After
+	}
+}
+
+// FetchCopilotLastActivity retrieves the enterprise's Copilot seat assignments and returns
+// a mapping of login names to the last Copilot activity recorded on their seat.
+// Seats without recorded activity are omitted.
+func FetchCopilotLastActivity(ctx context.Context, restClient *github.Client, enterpriseSlug string) (map[string]time.Time, error) {
+	slog.Debug("fetching copilot seats", "enterprise", enterpriseSlug)
+
+	opts := &github.ListOptions{
+		PerPage: 100,
+		Page:    1,
+	}
+	activity := make(map[string]time.Time)
+
+	for {
+		seats, resp, err := restClient.Copilot.ListCopilotEnterpriseSeats(ctx, enterpriseSlug, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list copilot seats for enterprise %q failed: %w", enterpriseSlug, err)
+		}
+
+		for _, seat := range seats.Seats {
+			user, ok := seat.GetUser()
+			if !ok || seat.LastActivityAt == nil {
+				continue
+			}
+			last := seat.LastActivityAt.UTC()
+			if existing, found := activity[user.GetLogin()]; !found || last.After(existing) {
+				activity[user.GetLogin()] = last
+			}
+		}
+
+		// Check rate limits after fetching a page of seats.
+		handleRESTRateLimit(ctx, &resp.Rate)
 
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
 	}
 
-	slog.Debug("fetched all audit logs", "count", len(allAuditLogs))
+	slog.Debug("mapped copilot seats to last activity", "users", len(activity))
+	return activity, nil
+}
+
+// FetchOrgCopilotSeats retrieves every Copilot seat assigned in the specified organization.
+// The results are paginated and combined, with rate limit handling. When Copilot is not enabled
+// for the organization, where the API answers 404 or 422, the organization has no seats.
+func FetchOrgCopilotSeats(ctx context.Context, restClient *github.Client, org string) ([]*github.CopilotSeatDetails, error) {
+	slog.Debug("fetching copilot seats", "organization", org)
+
+	opts := &github.ListOptions{
+		PerPage: 100,
+		Page:    1,
+	}
+	allSeats := []*github.CopilotSeatDetails{}
 
-	loginMap := make(map[string]time.Time)
-	for _, logEntry := range allAuditLogs {
-		// Ensure both Actor and CreatedAt are non-nil.
-		if logEntry.Actor == nil || logEntry.CreatedAt == nil {
-			continue
+	for {
+		seats, resp, err := restClient.Copilot.ListCopilotSeats(ctx, org, opts)
+		if copilotNotEnabled(err) {
+			slog.Debug("copilot is not enabled for the organization", "organization", org, "err", err)
+			return []*github.CopilotSeatDetails{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("list copilot seats for organization %q failed: %w", org, err)
 		}
-		actor := *logEntry.Actor
-		eventTime := logEntry.CreatedAt.UTC() // Ensure UTC
-		// Store the latest event per user.
-		if existing, found := loginMap[actor]; !found || eventTime.After(existing) {
-			loginMap[actor] = eventTime
+		allSeats = append(allSeats, seats.Seats...)
+
+		// Check rate limits after fetching a page of seats.
+		handleRESTRateLimit(ctx, &resp.Rate)
+
+		if resp.NextPage == 0 {
+			break
 		}
+		opts.Page = resp.NextPage
 	}
 
-	slog.Debug("mapped audit logs to user logins", "unique_user_logins", len(loginMap))
-	return loginMap, nil
+	slog.Debug("found copilot seats", "count", len(allSeats), "organization", org)
+	return allSeats, nil
 }
 
 // FetchTeamsForOrganizations retrieves all teams for the specified organization.
@@ -135,10 +221,17 @@ func FetchTeamsForOrganizations(ctx context.Context, restClient *github.Client,
 // FetchTeamMembers retrieves all members for the specified team and organization.
 // The function handles pagination and rate limiting automatically.
 func FetchTeamMembers(ctx context.Context, restClient *github.Client, team *github.Team, org string) ([]*github.User, error) {
-	slog.Debug("getting members", "team", team.GetSlug())
+	return FetchTeamMembersByRole(ctx, restClient, team, org, "all")
+}
+
+// FetchTeamMembersByRole retrieves the members of the specified team with the given role:
+// "member", "maintainer" or "all". Members of child teams are included.
+// The function handles pagination and rate limiting automatically.
+func FetchTeamMembersByRole(ctx context.Context, restClient *github.Client, team *github.Team, org, role string) ([]*github.User, error) {
+	slog.Debug("getting members", "team", team.GetSlug(), "role", role)
 
 	opts := &github.TeamListTeamMembersOptions{
-		Role: "all",
+		Role: role,
 		ListOptions: github.ListOptions{
 			PerPage: 100,
 			Page:    1,
@@ -239,11 +332,17 @@ func FetchTeams(ctx context.Context, restClient *github.Client, owner, repo stri
 }
 
 // FetchExternalGroups retrieves external groups (such as SAML identity provider groups)
-// for the specified team.
+// for the specified team. Outside Enterprise Managed Users, where the API answers 400 or 404,
+// the team has no external groups.
 func FetchExternalGroups(ctx context.Context, restClient *github.Client, owner, teamSlug string) (*github.ExternalGroupList, error) {
 	slog.Debug("getting external groups", "teamSlug", teamSlug)
 
 	externalGroups, resp, err := restClient.Teams.ListExternalGroupsForTeamBySlug(ctx, owner, teamSlug)
+	if notApplicable(err) {
+		// External groups are only available to Enterprise Managed Users
+		slog.Debug("external groups not applicable", "teamSlug", teamSlug, "err", err)
+		return &github.ExternalGroupList{}, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("get external groups for team %q/%q: %w", owner, teamSlug, err)
 	}
@@ -256,6 +355,83 @@ func FetchExternalGroups(ctx context.Context, restClient *github.Client, owner,
 	return externalGroups, nil
 }
 
+// notApplicable reports whether a request failed because the feature it reads is not available to the
+// organization, such as external groups outside Enterprise Managed Users, which GitHub answers with
+// 400 Bad Request or 404 Not Found.
+func notApplicable(err error) bool {
+	var respErr *github.ErrorResponse
+	if !errors.As(err, &respErr) || respErr.Response == nil {
+		return false
+	}
+	return respErr.Response.StatusCode == http.StatusBadRequest || respErr.Response.StatusCode == http.StatusNotFound
+}
+
+// copilotNotEnabled reports whether a Copilot request failed because Copilot is not enabled for the
+// organization, which GitHub answers with 404 Not Found or 422 Unprocessable Entity.
+func copilotNotEnabled(err error) bool {
+	var respErr *github.ErrorResponse
+	if !errors.As(err, &respErr) || respErr.Response == nil {
+		return false
+	}
+	return respErr.Response.StatusCode == http.StatusNotFound || respErr.Response.StatusCode == http.StatusUnprocessableEntity
+}
+
+// FetchTeamIDPGroups retrieves the identity provider groups connected to a team through team synchronization.
+// Team synchronization is not available to Enterprise Managed Users, whose teams use external groups instead;
+// when the API answers 400 or 404 the team has no team sync groups.
+func FetchTeamIDPGroups(ctx context.Context, restClient *github.Client, org, teamSlug string) (*github.IDPGroupList, error) {
+	slog.Debug("getting team sync groups", "teamSlug", teamSlug)
+
+	groups, resp, err := restClient.Teams.ListIDPGroupsForTeamBySlug(ctx, org, teamSlug)
+	if notApplicable(err) {
+		slog.Debug("team sync groups not applicable", "teamSlug", teamSlug, "err", err)
+		return &github.IDPGroupList{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get team sync groups for team %q/%q: %w", org, teamSlug, err)
+	}
+
+	// Check rate limit
+	handleRESTRateLimit(ctx, &resp.Rate)
+
+	slog.Debug("fetched team sync groups", "count", len(groups.Groups))
+
+	return groups, nil
+}
+
+// FetchTeamRepositories retrieves the repositories a team has been granted access to, with the
+// team's permissions on each. Access inherited from parent teams is not included.
+// The results are paginated and combined, with rate limit handling.
+func FetchTeamRepositories(ctx context.Context, restClient *github.Client, org, teamSlug string) ([]*github.Repository, error) {
+	slog.Debug("getting team repositories", "teamSlug", teamSlug)
+
+	opts := &github.ListOptions{
+		PerPage: 100,
+		Page:    1,
+	}
+	allRepos := []*github.Repository{}
+
+	for {
+		repos, resp, err := restClient.Teams.ListTeamReposBySlug(ctx, org, teamSlug, opts)
+		if err != nil {
+			return nil, fmt.Errorf("get repositories for team %q/%q failed: %w", org, teamSlug, err)
+		}
+		allRepos = append(allRepos, repos...)
+
+		// Check rate limit
+		handleRESTRateLimit(ctx, &resp.Rate)
+
+		// Check if there are more pages
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	slog.Debug("found team repositories", "count", len(allRepos), "team", teamSlug)
+	return allRepos, nil
+}
+
 // FetchCustomProperties retrieves all custom properties for the specified repository.
 // Custom properties are organization-defined metadata fields attached to repositories.
 func FetchCustomProperties(ctx context.Context, restClient *github.Client, owner, repo string) ([]*github.CustomPropertyValue, error) {
@@ -274,98 +450,152 @@ func FetchCustomProperties(ctx context.Context, restClient *github.Client, owner
 	return customProperties, nil
 }
 
-// FetchOrganizationMemberships retrieves all organization members with their roles
-// for the specified organization using the REST API.
-// For each member, additional details are fetched including their role and display name.
-func FetchOrganizationMemberships(ctx context.Context, restClient *github.Client, orgLogin string) ([]*github.User, error) {
-	slog.Debug("fetching organization memberships", "organization", orgLogin)
+// FetchOrganizationMemberLogins retrieves the logins of all members of the specified organization.
+// It lists logins only, without roles or names, so it is suited to membership checks.
+func FetchOrganizationMemberLogins(ctx context.Context, restClient *github.Client, orgLogin string) ([]string, error) {
+	slog.Debug("fetching organization member logins", "organization", orgLogin)
 
-	allMemberships := []*github.User{}
 	opts := &github.ListMembersOptions{
 		ListOptions: github.ListOptions{
 			PerPage: 100,
 			Page:    1,
 		},
 	}
+	logins := []string{}
 
 	for {
-
-		memberships, resp, err := restClient.Organizations.ListMembers(ctx, orgLogin, opts)
+		members, resp, err := restClient.Organizations.ListMembers(ctx, orgLogin, opts)
 		if err != nil {
-			return nil, fmt.Errorf("fetch memberships for organization %q failed: %w", orgLogin, err)
+			return nil, fmt.Errorf("list members for organization %q failed: %w", orgLogin, err)
+		}
+		for _, m := range members {
+			logins = append(logins, m.GetLogin())
 		}
-
-		allMemberships = append(allMemberships, memberships...)
 
 		// Check rate limit
 		handleRESTRateLimit(ctx, &resp.Rate)
 
-		// Check if there are more pages
 		if resp.NextPage == 0 {
 			break
 		}
-
 		opts.Page = resp.NextPage
 	}
 
-	membershipList := make([]*github.User, 0, len(allMemberships))
-	for _, member := range allMemberships {
-		memberRole, err := FetchOrganizationMember(ctx, restClient, orgLogin, member.GetLogin())
+	slog.Debug("found organization members", "count", len(logins), "organization", orgLogin)
+	return logins, nil
+}
+
+// FetchOrganizationPendingInvitations retrieves the pending invitations to the specified organization,
+// with the invitee's login or email address, their role and who invited them.
+// The results are paginated and combined, with rate limit handling.
+func FetchOrganizationPendingInvitations(ctx context.Context, restClient *github.Client, orgLogin string) ([]*github.Invitation, error) {
+	slog.Debug("fetching pending organization invitations", "organization", orgLogin)
+
+	opts := &github.ListOptions{
+		PerPage: 100,
+		Page:    1,
+	}
+	invitations := []*github.Invitation{}
+
+	for {
+		page, resp, err := restClient.Organizations.ListPendingOrgInvitations(ctx, orgLogin, opts)
 		if err != nil {
-			return nil, fmt.Errorf("fetch user details for %q failed: %w", member.GetLogin(), err)
+			return nil, fmt.Errorf("list pending invitations for organization %q failed: %w", orgLogin, err)
 		}
+		invitations = append(invitations, page...)
 
-		memberName, err := FetchUserById(ctx, restClient, member.GetID())
-		if err != nil {
-			return nil, fmt.Errorf("fetch user by id %d failed: %w", member.GetID(), err)
+		// Check rate limit
+		handleRESTRateLimit(ctx, &resp.Rate)
+
+		if resp.NextPage == 0 {
+			break
 		}
+		opts.Page = resp.NextPage
+	}
 
-		member.RoleName = memberRole.Role
-		member.Name = memberName.Name
+	slog.Debug("fetched pending organization invitations", "organization", orgLogin, "count", len(invitations))
+	return invitations, nil
+}
+
+// FetchOrganizationActionsPermissions retrieves the organization's GitHub Actions policy:
+// which repositories may run Actions and which actions they may use.
+func FetchOrganizationActionsPermissions(ctx context.Context, restClient *github.Client, orgLogin string) (*github.ActionsPermissions, error) {
+	slog.Debug("fetching organization actions permissions", "organization", orgLogin)
 
-		membershipList = append(membershipList, member)
+	permissions, resp, err := restClient.Actions.GetActionsPermissions(ctx, orgLogin)
+	if err != nil {
+		return nil, fmt.Errorf("get actions permissions for organization %q failed: %w", orgLogin, err)
 	}
 
-	slog.Debug("fetched all memberships", "organization", orgLogin, "count", len(membershipList))
+	// Check rate limit
+	handleRESTRateLimit(ctx, &resp.Rate)
 
-	return membershipList, nil
+	return permissions, nil
 }
 
-// FetchOrganizationMember retrieves the membership details of a user in the given organization
-// via the REST API, including their role (admin, member, etc.).
-func FetchOrganizationMember(ctx context.Context, restClient *github.Client, orgLogin, userLogin string) (*github.Membership, error) {
-	slog.Debug("fetching organization membership", "organization", orgLogin, "user", userLogin)
+// FetchOrganizationWorkflowPermissions retrieves the default permissions granted to the GITHUB_TOKEN
+// in the organization's workflows, and whether workflows may approve pull requests.
+func FetchOrganizationWorkflowPermissions(ctx context.Context, restClient *github.Client, orgLogin string) (*github.DefaultWorkflowPermissionOrganization, error) {
+	slog.Debug("fetching organization workflow permissions", "organization", orgLogin)
 
-	membership, resp, err := restClient.Organizations.GetOrgMembership(ctx, userLogin, orgLogin)
+	permissions, resp, err := restClient.Actions.GetDefaultWorkflowPermissionsInOrganization(ctx, orgLogin)
 	if err != nil {
-		return nil, fmt.Errorf("fetch membership failed for user %q in organization %q: %w", userLogin, orgLogin, err)
+		return nil, fmt.Errorf("get default workflow permissions for organization %q failed: %w", orgLogin, err)
 	}
 
 	// Check rate limit
 	handleRESTRateLimit(ctx, &resp.Rate)
 
-	slog.Debug("fetched organization membership", "organization", orgLogin, "user", userLogin)
-
-	return membership, nil
+	return permissions, nil
 }
 
-// FetchUserById fetches a user by their numeric ID using the REST API.
-// This is useful when you need additional user details beyond what's available
-// in other API responses.
-func FetchUserById(ctx context.Context, restClient *github.Client, id int64) (*github.User, error) {
-	slog.Debug("fetching user by id", "userID", id)
+// FetchOrganizationRoles retrieves the organization roles defined in the specified organization,
+// both the predefined roles, such as security_manager, and the organization's custom roles.
+func FetchOrganizationRoles(ctx context.Context, restClient *github.Client, orgLogin string) ([]*github.CustomOrgRoles, error) {
+	slog.Debug("fetching organization roles", "organization", orgLogin)
 
-	user, resp, err := restClient.Users.GetByID(ctx, id)
+	roles, resp, err := restClient.Organizations.ListRoles(ctx, orgLogin)
 	if err != nil {
-		return nil, fmt.Errorf("fetch user by id %d failed: %w", id, err)
+		return nil, fmt.Errorf("list organization roles for %q failed: %w", orgLogin, err)
 	}
 
 	// Check rate limit
 	handleRESTRateLimit(ctx, &resp.Rate)
 
-	slog.Debug("fetched user", "userID", id)
+	slog.Debug("fetched organization roles", "organization", orgLogin, "count", len(roles.CustomRepoRoles))
+	return roles.CustomRepoRoles, nil
+}
+
+// FetchOrganizationRoleUsers retrieves the users assigned an organization role, whether directly or
+// through a team. Each user's Assignment and InheritedFrom fields describe how the role was granted.
+// The results are paginated and combined, with rate limit handling.
+func FetchOrganizationRoleUsers(ctx context.Context, restClient *github.Client, orgLogin string, roleID int64) ([]*github.User, error) {
+	slog.Debug("fetching organization role users", "organization", orgLogin, "roleID", roleID)
+
+	opts := &github.ListOptions{
+		PerPage: 100,
+		Page:    1,
+	}
+	users := []*github.User{}
+
+	for {
+		page, resp, err := restClient.Organizations.ListUsersAssignedToOrgRole(ctx, orgLogin, roleID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list users assigned to role %d in organization %q failed: %w", roleID, orgLogin, err)
+		}
+		users = append(users, page...)
+
+		// Check rate limit
+		handleRESTRateLimit(ctx, &resp.Rate)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
 
-	return user, nil
+	slog.Debug("fetched organization role users", "organization", orgLogin, "roleID", roleID, "count", len(users))
+	return users, nil
 }
 
 // FetchOrganization fetches the details for the specified organization.
@@ -421,12 +651,19 @@ func FetchRepoCollaborators(ctx context.Context, restClient *github.Client, repo
 	return allCollaborators, nil
 }
 
-// FetchRepositoryCommits retrieves commits for a repository within a specified time range.
+// FetchRepositoryCommits retrieves commits on the default branch of a repository within a specified time range.
 // This is used to find repositories that have recent commit activity and identify contributors.
 func FetchRepositoryCommits(ctx context.Context, restClient *github.Client, owner, repo string, since time.Time) ([]*github.RepositoryCommit, error) {
-	slog.Debug("fetching repository commits", "owner", owner, "repo", repo, "since", since)
+	return FetchBranchCommits(ctx, restClient, owner, repo, "", since)
+}
+
+// FetchBranchCommits retrieves the commits reachable from a branch of a repository within a specified
+// time range. An empty branch lists the default branch.
+func FetchBranchCommits(ctx context.Context, restClient *github.Client, owner, repo, branch string, since time.Time) ([]*github.RepositoryCommit, error) {
+	slog.Debug("fetching repository commits", "owner", owner, "repo", repo, "branch", branch, "since", since)
 
 	opts := &github.CommitsListOptions{
+		SHA:   branch,
 		Since: since,
 		ListOptions: github.ListOptions{
 			PerPage: 100,
@@ -458,3 +695,190 @@ func FetchRepositoryCommits(ctx context.Context, restClient *github.Client, owne
 	slog.Debug("found commits", "count", len(allCommits), "repo", fmt.Sprintf("%s/%s", owner, repo))
 	return allCommits, nil
 }
+
+// FetchBranchUniqueCommits retrieves the commits of a branch that are not reachable from the base
+// branch and were committed at or after since. Commits the branch shares with the base, such as the
+// history it was created from, are left to a listing of the base branch.
+func FetchBranchUniqueCommits(ctx context.Context, restClient *github.Client, owner, repo, base, branch string, since time.Time) ([]*github.RepositoryCommit, error) {
+	slog.Debug("comparing branch commits", "owner", owner, "repo", repo, "base", base, "branch", branch, "since", since)
+
+	opts := &github.ListOptions{PerPage: 100, Page: 1}
+	var commits []*github.RepositoryCommit
+
+	for {
+		comparison, resp, err := restClient.Repositories.CompareCommits(ctx, owner, repo, base, branch, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare %s with %s for %s/%s: %w", branch, base, owner, repo, err)
+		}
+		handleRESTRateLimit(ctx, &resp.Rate)
+
+		// The comparison cannot be limited to a time range, so older commits are dropped here
+		for _, commit := range comparison.Commits {
+			if !commit.GetCommit().GetCommitter().GetDate().Before(since) {
+				commits = append(commits, commit)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	slog.Debug("found branch commits", "count", len(commits), "repo", fmt.Sprintf("%s/%s", owner, repo), "branch", branch)
+	return commits, nil
+}
+
+// FetchLatestWorkflowRun returns the creation time of the most recent GitHub Actions workflow run
+// of a repository, or the zero time when the repository has never run a workflow.
+func FetchLatestWorkflowRun(ctx context.Context, restClient *github.Client, owner, repo string) (time.Time, error) {
+	slog.Debug("fetching latest workflow run", "owner", owner, "repo", repo)
+
+	// Runs are listed newest first, so the first run is the latest
+	opts := &github.ListWorkflowRunsOptions{ListOptions: github.ListOptions{PerPage: 1}}
+	runs, resp, err := restClient.Actions.ListRepositoryWorkflowRuns(ctx, owner, repo, opts)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to fetch workflow runs for %s/%s: %w", owner, repo, err)
+	}
+	handleRESTRateLimit(ctx, &resp.Rate)
+
+	if len(runs.WorkflowRuns) == 0 {
+		return time.Time{}, nil
+	}
+	return runs.WorkflowRuns[0].GetCreatedAt().Time, nil
+}
+
+// FetchForkAheadBy returns how many commits the head branch of a fork has that the base branch
+// of its parent does not. The comparison is made in the parent repository, whose network includes the fork.
+func FetchForkAheadBy(ctx context.Context, restClient *github.Client, parent, parentBranch, fork, forkBranch string) (int, error) {
+	slog.Debug("comparing fork with its parent", "parent", parent, "fork", fork)
+
+	parentOwner, parentName, _ := strings.Cut(parent, "/")
+	forkOwner, _, _ := strings.Cut(fork, "/")
+	head := fmt.Sprintf("%s:%s", forkOwner, forkBranch)
+	comparison, resp, err := restClient.Repositories.CompareCommits(ctx, parentOwner, parentName, parentBranch, head, &github.ListOptions{PerPage: 1})
+	if err != nil {
+		return 0, fmt.Errorf("failed to compare %s with its parent %s: %w", fork, parent, err)
+	}
+	handleRESTRateLimit(ctx, &resp.Rate)
+
+	return comparison.GetAheadBy(), nil
+}
+
+// FetchEnterpriseSCIMUsers retrieves the users provisioned to the enterprise through SCIM, including
+// users the identity provider deprovisioned, which are marked inactive. The endpoint is only available
+// to enterprises with managed users, and is not covered by go-github, so the response is decoded here.
+func FetchEnterpriseSCIMUsers(ctx context.Context, restClient *github.Client, enterpriseSlug string) ([]*github.SCIMUserAttributes, error) {
+	slog.Debug("fetching enterprise SCIM users", "enterprise", enterpriseSlug)
+
+	var users []*github.SCIMUserAttributes
+	// SCIM pages are addressed by the 1-based index of their first result
+	startIndex := 1
+	for {
+		u := fmt.Sprintf("scim/v2/enterprises/%s/Users?startIndex=%d&count=100", enterpriseSlug, startIndex)
+		req, err := restClient.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("create SCIM users request for enterprise %q failed: %w", enterpriseSlug, err)
+		}
+		req.Header.Set("Accept", "application/scim+json")
+
+		var page github.SCIMProvisionedIdentities
+		resp, err := restClient.Do(ctx, req, &page)
+		if err != nil {
+			return nil, fmt.Errorf("list SCIM users for enterprise %q failed: %w", enterpriseSlug, err)
+		}
+		users = append(users, page.Resources...)
+
+		// Check rate limits after fetching a page of users.
+		handleRESTRateLimit(ctx, &resp.Rate)
+
+		if len(page.Resources) == 0 || len(users) >= page.GetTotalResults() {
+			break
+		}
+		startIndex += len(page.Resources)
+	}
+
+	slog.Debug("fetched enterprise SCIM users", "enterprise", enterpriseSlug, "users", len(users))
+	return users, nil
+}
+
+// ConsumedLicenses is the response of the enterprise consumed-licenses endpoint.
+// The endpoint is not covered by go-github, so the response is decoded here.
+type ConsumedLicenses struct {
+	TotalSeatsConsumed  int             `json:"total_seats_consumed"`
+	TotalSeatsPurchased int             `json:"total_seats_purchased"`
+	Users               []*LicensedUser `json:"users"`
+}
+
+// LicensedUser describes a user consuming an enterprise license across GitHub Enterprise Cloud,
+// GitHub Enterprise Server and Visual Studio subscriptions.
+type LicensedUser struct {
+	GithubComLogin                  string   `json:"github_com_login"`
+	GithubComName                   string   `json:"github_com_name"`
+	GithubComProfile                string   `json:"github_com_profile"`
+	GithubComUser                   bool     `json:"github_com_user"`
+	GithubComMemberRoles            []string `json:"github_com_member_roles"`
+	GithubComEnterpriseRoles        []string `json:"github_com_enterprise_roles"`
+	GithubComVerifiedDomainEmails   []string `json:"github_com_verified_domain_emails"`
+	GithubComSamlNameID             string   `json:"github_com_saml_name_id"`
+	GithubComOrgsWithPendingInvites []string `json:"github_com_orgs_with_pending_invites"`
+	GithubComTwoFactorAuth          bool     `json:"github_com_two_factor_auth"`
+	EnterpriseServerUser            bool     `json:"enterprise_server_user"`
+	EnterpriseServerUserIDs         []string `json:"enterprise_server_user_ids"`
+	EnterpriseServerEmails          []string `json:"enterprise_server_emails"`
+	VisualStudioSubscriptionUser    bool     `json:"visual_studio_subscription_user"`
+	VisualStudioSubscriptionEmail   string   `json:"visual_studio_subscription_email"`
+	VisualStudioLicenseStatus       string   `json:"visual_studio_license_status"`
+	LicenseType                     string   `json:"license_type"`
+	TotalUserAccounts               int      `json:"total_user_accounts"`
+}
+
+// Organizations returns the organizations the user is a member of, parsed from
+// GithubComMemberRoles entries of the form "org:Role".
+func (u *LicensedUser) Organizations() []string {
+	orgs := make([]string, 0, len(u.GithubComMemberRoles))
+	for _, role := range u.GithubComMemberRoles {
+		org, _, _ := strings.Cut(role, ":")
+		if org != "" && !slices.Contains(orgs, org) {
+			orgs = append(orgs, org)
+		}
+	}
+	return orgs
+}
+
+// FetchConsumedLicenses retrieves the enterprise's license consumption, including every licensed user.
+// The results are paginated and combined, with rate limit handling.
+func FetchConsumedLicenses(ctx context.Context, restClient *github.Client, enterpriseSlug string) (*ConsumedLicenses, error) {
+	slog.Debug("fetching consumed licenses", "enterprise", enterpriseSlug)
+
+	result := &ConsumedLicenses{}
+	page := 1
+	for {
+		u := fmt.Sprintf("enterprises/%s/consumed-licenses?per_page=100&page=%d", enterpriseSlug, page)
+		req, err := restClient.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, fmt.Errorf("create consumed licenses request for enterprise %q failed: %w", enterpriseSlug, err)
+		}
+
+		var licenses ConsumedLicenses
+		resp, err := restClient.Do(ctx, req, &licenses)
+		if err != nil {
+			return nil, fmt.Errorf("get consumed licenses for enterprise %q failed: %w", enterpriseSlug, err)
+		}
+
+		result.TotalSeatsConsumed = licenses.TotalSeatsConsumed
+		result.TotalSeatsPurchased = licenses.TotalSeatsPurchased
+		result.Users = append(result.Users, licenses.Users...)
+
+		// Check rate limits after fetching a page of licenses.
+		handleRESTRateLimit(ctx, &resp.Rate)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		page = resp.NextPage
+	}
+
+	slog.Debug("fetched consumed licenses", "users", len(result.Users), "consumed", result.TotalSeatsConsumed, "purchased", result.TotalSeatsPurchased)
+	return result, nil
+}
@@ -86,7 +86,7 @@ func (c *RetryableRESTClient) ExecuteRESTWithRetry(ctx context.Context, fn func(
 		}
 
 		// Process the error to determine if it's retryable
-		var processedErr error
+		var processedErr *utils.AppError
 		var retryable bool
 
 		// Check if error is from GitHub API
@@ -117,6 +117,7 @@ func (c *RetryableRESTClient) ExecuteRESTWithRetry(ctx context.Context, fn func(
 		}
 
 		// Store the current error for potential return
+		processedErr.Retries = attempt
 		currentErr = processedErr
 
 		// If error is not retryable or this was our last attempt, return
@@ -167,7 +168,7 @@ func (c *RetryableGraphQLClient) ExecuteGraphQLWithRetry(ctx context.Context, fn
 		slog.Debug("GraphQL query error", "error", err, "attempt", attempt)
 
 		// Process the error to determine if it's retryable
-		var processedErr error
+		var processedErr *utils.AppError
 		var retryable bool
 
 		// GraphQL errors are more complex to parse, but we can do some basic checks
@@ -189,6 +190,7 @@ func (c *RetryableGraphQLClient) ExecuteGraphQLWithRetry(ctx context.Context, fn
 		}
 
 		// Store the current error for potential return
+		processedErr.Retries = attempt
 		currentErr = processedErr
 
 		// If error is not retryable or this was our last attempt, return
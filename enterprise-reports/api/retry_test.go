@@ -71,6 +71,11 @@ func TestRetryableRESTClient(t *testing.T) {
 		assert.Error(t, err)
 		assert.Equal(t, 4, attempts, "Should attempt exactly maxRetries+1 times")
 		assert.Contains(t, err.Error(), "max retries reached")
+		var appErr *utils.AppError
+		if assert.ErrorAs(t, err, &appErr) {
+			assert.Equal(t, 3, appErr.Retries)
+			assert.Equal(t, utils.ErrorTypeRateLimit, utils.ClassifyError(err))
+		}
 	})
 
 	t.Run("ContextCancellation", func(t *testing.T) {
@@ -133,5 +138,10 @@ func TestRetryableGraphQLClient(t *testing.T) {
 		assert.Error(t, err)
 		assert.Equal(t, 4, attempts, "Should attempt exactly maxRetries+1 times")
 		assert.Contains(t, err.Error(), "max retries reached")
+		var appErr *utils.AppError
+		if assert.ErrorAs(t, err, &appErr) {
+			assert.Equal(t, 3, appErr.Retries)
+			assert.Equal(t, utils.ErrorTypeRateLimit, utils.ClassifyError(err))
+		}
 	})
 }
@@ -0,0 +1,101 @@
+// Package api provides functionality for interacting with GitHub's REST and GraphQL APIs.
+// It includes rate limiting, client wrapper methods, and utilities for efficient API consumption.
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// UnknownVersion is the version assumed for GitHub Enterprise Server when a hostname is configured
+// but the installed version cannot be detected. Version checks against it fail.
+const UnknownVersion = "unknown"
+
+// ServerInfo describes the GitHub deployment the clients are connected to.
+// The zero value describes GitHub Enterprise Cloud.
+type ServerInfo struct {
+	// Version is the installed GitHub Enterprise Server version, e.g. "3.14.2".
+	// It is empty for GitHub Enterprise Cloud.
+	Version string
+}
+
+// IsServer reports whether the clients are connected to GitHub Enterprise Server.
+func (s *ServerInfo) IsServer() bool {
+	return s != nil && s.Version != ""
+}
+
+// AtLeast reports whether the server runs the given version or a later one, e.g. AtLeast("3.9").
+// GitHub Enterprise Cloud always runs the latest version; a server of UnknownVersion never does.
+func (s *ServerInfo) AtLeast(version string) bool {
+	if !s.IsServer() {
+		return true
+	}
+	have, want := parseVersion(s.Version), parseVersion(version)
+	for i := range want {
+		if have[i] != want[i] {
+			return have[i] > want[i]
+		}
+	}
+	return true
+}
+
+// Deployment returns the enterprise deployment whose members the clients can list.
+func (s *ServerInfo) Deployment() githubv4.EnterpriseUserDeployment {
+	if s.IsServer() {
+		return githubv4.EnterpriseUserDeploymentServer
+	}
+	return githubv4.EnterpriseUserDeploymentCloud
+}
+
+// String names the deployment, e.g. "GitHub Enterprise Server 3.14.2".
+func (s *ServerInfo) String() string {
+	if !s.IsServer() {
+		return "GitHub Enterprise Cloud"
+	}
+	if s.Version == UnknownVersion {
+		return "GitHub Enterprise Server (unknown version)"
+	}
+	return "GitHub Enterprise Server " + s.Version
+}
+
+// parseVersion splits a version into its major, minor and patch numbers.
+// Missing or non-numeric parts, such as release candidate suffixes, count as zero.
+func parseVersion(version string) [3]int {
+	var parts [3]int
+	for i, p := range strings.SplitN(version, ".", 3) {
+		digits := strings.IndexFunc(p, func(r rune) bool { return r < '0' || r > '9' })
+		if digits >= 0 {
+			p = p[:digits]
+		}
+		parts[i], _ = strconv.Atoi(p)
+	}
+	return parts
+}
+
+// DetectServer reads the meta endpoint to find out whether the REST client is connected to
+// GitHub Enterprise Server, and which version it runs. Only Server reports an installed version.
+func DetectServer(ctx context.Context, restClient *github.Client) (*ServerInfo, error) {
+	slog.Debug("detecting server version", "base_url", restClient.BaseURL.String())
+
+	// The installed version is not part of go-github's APIMeta, so the response is decoded here
+	req, err := restClient.NewRequest("GET", "meta", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create meta request: %w", err)
+	}
+	var meta struct {
+		InstalledVersion string `json:"installed_version"`
+	}
+	if _, err := restClient.Do(ctx, req, &meta); err != nil {
+		return nil, fmt.Errorf("failed to fetch meta from %s: %w", restClient.BaseURL, err)
+	}
+
+	server := &ServerInfo{Version: meta.InstalledVersion}
+	slog.Debug("detected server", "server", server.String())
+	return server, nil
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerInfo_AtLeast(t *testing.T) {
+	server := &ServerInfo{Version: "3.10.2"}
+	assert.True(t, server.IsServer())
+	assert.True(t, server.AtLeast("3.3"))
+	assert.True(t, server.AtLeast("3.10"))
+	assert.True(t, server.AtLeast("3.10.2"))
+	assert.False(t, server.AtLeast("3.10.3"))
+	assert.False(t, server.AtLeast("3.11"))
+	assert.False(t, server.AtLeast("4"))
+
+	// Release candidates count as their release
+	assert.True(t, (&ServerInfo{Version: "3.14.0.rc1"}).AtLeast("3.14"))
+
+	// Cloud always runs the latest version
+	var cloud *ServerInfo
+	assert.False(t, cloud.IsServer())
+	assert.True(t, cloud.AtLeast("99.0"))
+	assert.Equal(t, "GitHub Enterprise Cloud", (&ServerInfo{}).String())
+	assert.Equal(t, "GitHub Enterprise Server 3.10.2", server.String())
+
+	// A server of unknown version supports no version-gated feature
+	unknown := &ServerInfo{Version: UnknownVersion}
+	assert.True(t, unknown.IsServer())
+	assert.False(t, unknown.AtLeast("3.3"))
+	assert.Equal(t, "GitHub Enterprise Server (unknown version)", unknown.String())
+}
+
+func TestServerInfo_Deployment(t *testing.T) {
+	var cloud *ServerInfo
+	assert.Equal(t, githubv4.EnterpriseUserDeploymentCloud, cloud.Deployment())
+	assert.Equal(t, githubv4.EnterpriseUserDeploymentServer, (&ServerInfo{Version: "3.14.2"}).Deployment())
+	assert.Equal(t, githubv4.EnterpriseUserDeploymentServer, (&ServerInfo{Version: UnknownVersion}).Deployment())
+}
+
+func TestDetectServer(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "Enterprise Server", body: `{"verifiable_password_authentication":true,"installed_version":"3.12.4"}`, want: "3.12.4"},
+		{name: "Enterprise Cloud", body: `{"verifiable_password_authentication":true}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/v3/meta", r.URL.Path)
+				fmt.Fprint(w, tt.body)
+			}))
+			t.Cleanup(srv.Close)
+			client := github.NewClient(srv.Client())
+			client.BaseURL, _ = url.Parse(srv.URL + "/api/v3/")
+
+			server, err := DetectServer(context.Background(), client)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, server.Version)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Package config provides configuration interfaces and implementations for the GitHub Enterprise Reports tool.
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bradleyfalzon/ghinstallation/v2"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+)
+
+// appAuth authenticates the REST and GraphQL clients of a provider as a GitHub App. The clients
+// share it, so the app's installations are discovered once and their tokens are minted once.
+type appAuth struct {
+	once      sync.Once
+	transport http.RoundTripper
+	router    *api.InstallationRouter // Routes requests per organization installation; nil for a single installation
+	err       error
+}
+
+// client returns an HTTP client authenticated as the GitHub App. With an installation ID the client
+// authenticates as that installation. Without one, the app's installations are discovered with its
+// JWT, and each request is authenticated as the installation on the organization it addresses.
+func (a *appAuth) client(appID int64, keyFile string, installationID int64, e endpoints) (*http.Client, error) {
+	a.once.Do(func() {
+		a.transport, a.router, a.err = newAppTransport(appID, keyFile, installationID, e)
+	})
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &http.Client{Transport: a.transport}, nil
+}
+
+// organizations returns the organizations the app is installed on when requests are routed per
+// installation, or nil otherwise.
+func (a *appAuth) organizations() []string {
+	if a.router == nil {
+		return nil
+	}
+	return a.router.Organizations()
+}
+
+// newAppTransport creates the transport authenticating as the app's installation, or routing
+// between all of its installations when installationID is zero.
+func newAppTransport(appID int64, keyFile string, installationID int64, e endpoints) (http.RoundTripper, *api.InstallationRouter, error) {
+	if appID == 0 {
+		return nil, nil, fmt.Errorf("app-id is required for GitHub App authentication")
+	}
+	if keyFile == "" {
+		return nil, nil, fmt.Errorf("app-private-key-file is required for GitHub App authentication")
+	}
+
+	apps, err := ghinstallation.NewAppsTransportKeyFromFile(http.DefaultTransport, appID, keyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create GitHub App transport: %w", err)
+	}
+	// Installation tokens are minted by the configured server
+	if e.rest != "" {
+		apps.BaseURL = strings.TrimSuffix(e.rest, "/")
+	}
+	if installationID != 0 {
+		return ghinstallation.NewFromAppsTransport(apps, installationID), nil, nil
+	}
+
+	appClient, err := e.restClient(&http.Client{Transport: apps})
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	installations, err := api.FetchAppInstallations(ctx, appClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	basePath := ""
+	if e.rest != "" {
+		if u, err := url.Parse(e.rest); err == nil {
+			basePath = u.Path
+		}
+	}
+	router, err := api.NewInstallationRouter(apps, installations, basePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	slog.Info("routing requests through the GitHub App's installations", "organizations", router.Organizations())
+	return router, router, nil
+}
@@ -0,0 +1,88 @@
+// Package config provides configuration interfaces and implementations for the GitHub Enterprise Reports tool.
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+)
+
+// parseOrgBaseline converts the raw "org-baseline" configuration section into the baseline checked
+// by the org-settings report. Settings that are left out are not checked, for example:
+//
+//	org-baseline:
+//	  two-factor-required: true
+//	  default-repository-permission: read
+//	  members-can-create-public-repositories: false
+//	  allowed-actions: selected
+//	  sso-required: true
+//
+// Unknown keys are rejected so a misspelt setting cannot silently go unchecked.
+func parseOrgBaseline(raw any) (utils.OrgBaseline, error) {
+	var b utils.OrgBaseline
+	if raw == nil {
+		return b, nil
+	}
+
+	section, ok := raw.(map[string]any)
+	if !ok {
+		return b, fmt.Errorf("org-baseline must be a map")
+	}
+
+	bools := map[string]**bool{
+		"two-factor-required":                                          &b.TwoFactorRequired,
+		"members-can-create-public-repositories":                       &b.MembersCanCreatePublicRepos,
+		"members-can-create-private-repositories":                      &b.MembersCanCreatePrivateRepos,
+		"members-can-create-internal-repositories":                     &b.MembersCanCreateInternalRepos,
+		"members-can-fork-private-repositories":                        &b.MembersCanForkPrivateRepos,
+		"actions-can-approve-pull-requests":                            &b.ActionsCanApprovePullRequests,
+		"advanced-security-enabled-for-new-repositories":               &b.AdvancedSecurityForNewRepos,
+		"secret-scanning-enabled-for-new-repositories":                 &b.SecretScanningForNewRepos,
+		"secret-scanning-push-protection-enabled-for-new-repositories": &b.PushProtectionForNewRepos,
+		"dependabot-alerts-enabled-for-new-repositories":               &b.DependabotAlertsForNewRepos,
+		"web-commit-signoff-required":                                  &b.WebCommitSignoffRequired,
+		"ip-allow-list-enabled":                                        &b.IPAllowListEnabled,
+		"sso-required":                                                 &b.SSORequired,
+	}
+	levels := map[string]*string{
+		"default-repository-permission": &b.DefaultRepositoryPermission,
+		"allowed-actions":               &b.AllowedActions,
+		"default-workflow-permissions":  &b.DefaultWorkflowPermissions,
+	}
+
+	for key, v := range section {
+		if field, ok := bools[key]; ok {
+			value, ok := v.(bool)
+			if !ok {
+				return b, fmt.Errorf("org-baseline.%s must be true or false", key)
+			}
+			*field = &value
+			continue
+		}
+		if field, ok := levels[key]; ok {
+			value, ok := v.(string)
+			if !ok {
+				return b, fmt.Errorf("org-baseline.%s must be a string", key)
+			}
+			*field = value
+			continue
+		}
+
+		known := make([]string, 0, len(bools)+len(levels))
+		for k := range bools {
+			known = append(known, k)
+		}
+		for k := range levels {
+			known = append(known, k)
+		}
+		sort.Strings(known)
+		return b, fmt.Errorf("unknown org-baseline setting %q: known settings are %s", key, strings.Join(known, ", "))
+	}
+
+	if err := b.Validate(); err != nil {
+		return b, err
+	}
+	return b, nil
+}
@@ -0,0 +1,51 @@
+// Package config provides configuration interfaces and implementations for the GitHub Enterprise Reports tool.
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+)
+
+// cassetteAuth records the API traffic of a provider's REST and GraphQL clients to a cassette
+// directory, or replays it from one instead of reaching the API. The clients share it, so their
+// interactions land in one cassette.
+type cassetteAuth struct {
+	once     sync.Once
+	recorder *api.Recorder
+	replayer *api.Replayer
+	err      error
+}
+
+// record returns an HTTP client sending requests through httpClient and recording them to dir.
+func (c *cassetteAuth) record(httpClient *http.Client, dir string) (*http.Client, error) {
+	c.once.Do(func() {
+		c.recorder, c.err = api.NewRecorder(dir)
+	})
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &http.Client{Transport: c.recorder.Transport(httpClient.Transport)}, nil
+}
+
+// replay returns an HTTP client answering requests from the cassette in dir. It needs no
+// authentication, since no request leaves the machine.
+func (c *cassetteAuth) replay(dir string) (*http.Client, error) {
+	c.once.Do(func() {
+		c.replayer, c.err = api.NewReplayer(dir)
+	})
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &http.Client{Transport: c.replayer}, nil
+}
+
+// validateCassette checks that traffic is either recorded or replayed, not both.
+func validateCassette(record, replay string) error {
+	if record != "" && replay != "" {
+		return fmt.Errorf("record and replay cannot be used together")
+	}
+	return nil
+}
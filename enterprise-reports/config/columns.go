@@ -0,0 +1,108 @@
+// Package config provides configuration interfaces and implementations for the GitHub Enterprise Reports tool.
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReportNames lists the names of the reports that can be configured.
+// They match the names used for report selection flags and output files.
+var ReportNames = []string{
+	"organizations",
+	"repositories",
+	"teams",
+	"collaborators",
+	"users",
+	"active-repositories",
+	"stale-repositories",
+	"licenses",
+	"copilot",
+	"audit-log",
+	"admins",
+	"org-settings",
+	"identities",
+	"policy",
+}
+
+// ColumnConfig selects a report column and optionally renames its header.
+type ColumnConfig struct {
+	Name   string `mapstructure:"name"`   // Canonical column name as listed in the report's default header
+	Header string `mapstructure:"header"` // Optional header to write instead of Name
+}
+
+// parseColumns converts the raw "columns" configuration section into per-report column lists.
+// Each report maps to a list whose entries are either a column name or a map with
+// "name" and optional "header" keys, for example:
+//
+//	columns:
+//	  users:
+//	    - Login
+//	    - name: Name
+//	      header: Full Name
+func parseColumns(raw any) (map[string][]ColumnConfig, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	section, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("columns must be a map of report name to column list")
+	}
+
+	result := make(map[string][]ColumnConfig, len(section))
+	for report, entries := range section {
+		list, ok := entries.([]any)
+		if !ok {
+			return nil, fmt.Errorf("columns.%s must be a list", report)
+		}
+
+		columns := make([]ColumnConfig, 0, len(list))
+		for i, entry := range list {
+			switch e := entry.(type) {
+			case string:
+				columns = append(columns, ColumnConfig{Name: e})
+			case map[string]any:
+				name, _ := e["name"].(string)
+				header, _ := e["header"].(string)
+				if name == "" {
+					return nil, fmt.Errorf("columns.%s[%d] is missing a name", report, i)
+				}
+				columns = append(columns, ColumnConfig{Name: name, Header: header})
+			default:
+				return nil, fmt.Errorf("columns.%s[%d] must be a column name or a map with name and header", report, i)
+			}
+		}
+		result[strings.ToLower(report)] = columns
+	}
+
+	return result, nil
+}
+
+// validateColumns checks that column configuration only references known reports.
+// Column names themselves are validated by each report when it starts.
+func validateColumns(columns map[string][]ColumnConfig) error {
+	var unknown []string
+	for report := range columns {
+		if !isKnownReport(report) {
+			unknown = append(unknown, report)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("columns configured for unknown report(s) %s: must be one of %s",
+			strings.Join(unknown, ", "), strings.Join(ReportNames, ", "))
+	}
+	return nil
+}
+
+// isKnownReport reports whether name is one of ReportNames.
+func isKnownReport(name string) bool {
+	for _, r := range ReportNames {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
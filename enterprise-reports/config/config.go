@@ -4,6 +4,9 @@ package config
 import (
 	"fmt"
 	"strings"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/policy"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
 )
 
 // Config holds the configuration for the GitHub Enterprise Reports tool.
@@ -17,19 +20,46 @@ type Config struct {
 	Collaborators           bool
 	Users                   bool
 	ActiveRepositories      bool
+	StaleRepositories       bool
+	Licenses                bool
+	Copilot                 bool
+	AuditLog                bool
+	Admins                  bool
+	OrgSettings             bool
+	Identities              bool
+	Policy                  bool
 	Workers                 int
 	AuthMethod              string
 	Token                   string
+	TokenFile               string
+	TokenCommand            string
 	GithubAppID             int64
 	GithubAppPrivateKey     string
 	GithubAppInstallationID int64
+	Credentials             []CredentialConfig
 	EnterpriseSlug          string
 	LogLevel                string
 	BaseURL                 string
+	Hostname                string
+	RecordDir               string
+	ReplayDir               string
 	OutputFormat            string
 	OutputDir               string
+	Columns                 map[string][]ColumnConfig
+	Dormancy                DormancyConfig
+	CopilotInactiveDays     int
+	ActiveRepositoryDays    int
+	StaleRepositoryDays     int
+	AuditLogPhrase          string
+	AuditLogInclude         string
+	AuditLogCheckpoint      string
+	OrgBaseline             utils.OrgBaseline
+	PolicyRules             []policy.Rule
 }
 
+// validAuditLogIncludes lists the event types the audit-log report can export; empty uses the report default.
+var validAuditLogIncludes = map[string]bool{"": true, "web": true, "git": true, "all": true}
+
 // Validate checks for required flags based on the chosen authentication method.
 func (c *Config) Validate() error {
 	var errs []error
@@ -54,28 +84,72 @@ func (c *Config) Validate() error {
 	}
 
 	// If no report types are selected, report an error
-	if !c.Organizations && !c.Repositories && !c.Teams && !c.Collaborators && !c.Users && !c.ActiveRepositories {
+	if !c.Organizations && !c.Repositories && !c.Teams && !c.Collaborators && !c.Users && !c.ActiveRepositories && !c.StaleRepositories && !c.Licenses && !c.Copilot && !c.AuditLog && !c.Admins && !c.OrgSettings && !c.Identities {
 		errs = append(errs, fmt.Errorf("at least one report type must be selected"))
 	}
 
-	// Validate authentication method and required parameters
-	switch c.AuthMethod {
-	case "token":
-		if c.Token == "" {
-			errs = append(errs, fmt.Errorf("token is required when auth-method=token"))
+	// Validate authentication method and required parameters; replayed traffic needs none
+	switch {
+	case c.ReplayDir != "":
+	case c.AuthMethod == "token" || c.AuthMethod == "gh":
+		if err := c.tokenSource().validate(); err != nil {
+			errs = append(errs, err)
 		}
-	case "app":
+	case c.AuthMethod == "app":
 		if c.GithubAppID == 0 {
 			errs = append(errs, fmt.Errorf("github app id is required when auth-method=app"))
 		}
 		if c.GithubAppPrivateKey == "" {
 			errs = append(errs, fmt.Errorf("github app private key file is required when auth-method=app"))
 		}
-		if c.GithubAppInstallationID == 0 {
-			errs = append(errs, fmt.Errorf("github app installation id is required when auth-method=app"))
-		}
 	default:
-		errs = append(errs, fmt.Errorf("invalid auth-method: %q (must be 'token' or 'app')", c.AuthMethod))
+		errs = append(errs, fmt.Errorf("invalid auth-method: %q (must be 'token', 'app' or 'gh')", c.AuthMethod))
+	}
+
+	if err := validateCredentials(c.Credentials); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := validateCassette(c.RecordDir, c.ReplayDir); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := validateColumns(c.Columns); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := c.Dormancy.Policy(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.CopilotInactiveDays < 0 {
+		errs = append(errs, fmt.Errorf("copilot inactive days must not be negative"))
+	}
+
+	if c.ActiveRepositoryDays < 0 {
+		errs = append(errs, fmt.Errorf("active repository days must not be negative"))
+	}
+
+	if c.StaleRepositoryDays < 0 {
+		errs = append(errs, fmt.Errorf("stale repository days must not be negative"))
+	}
+
+	if _, err := resolveEndpoints(c.Hostname, c.BaseURL); err != nil {
+		errs = append(errs, err)
+	}
+
+	if !validAuditLogIncludes[c.AuditLogInclude] {
+		errs = append(errs, fmt.Errorf("invalid audit log include: %q (must be 'web', 'git' or 'all')", c.AuditLogInclude))
+	}
+
+	if err := c.OrgBaseline.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.Policy {
+		if err := validatePolicy(c.PolicyRules, c.selectedReports()); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	// Default to 5 workers if not specified or negative
@@ -93,3 +167,34 @@ func (c *Config) Validate() error {
 
 	return nil
 }
+
+// tokenSource returns where the token of token or gh authentication comes from.
+func (c *Config) tokenSource() tokenSource {
+	return tokenSource{
+		method:   c.AuthMethod,
+		token:    c.Token,
+		file:     c.TokenFile,
+		command:  c.TokenCommand,
+		hostname: c.Hostname,
+		baseURL:  c.BaseURL,
+	}
+}
+
+// selectedReports returns the names of the data reports selected to run.
+func (c *Config) selectedReports() map[string]bool {
+	return map[string]bool{
+		"organizations":       c.Organizations,
+		"repositories":        c.Repositories,
+		"teams":               c.Teams,
+		"collaborators":       c.Collaborators,
+		"users":               c.Users,
+		"active-repositories": c.ActiveRepositories,
+		"stale-repositories":  c.StaleRepositories,
+		"licenses":            c.Licenses,
+		"copilot":             c.Copilot,
+		"audit-log":           c.AuditLog,
+		"admins":              c.Admins,
+		"org-settings":        c.OrgSettings,
+		"identities":          c.Identities,
+	}
+}
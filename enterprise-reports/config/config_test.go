@@ -1,7 +1,13 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
+	"time"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
 )
 
 func TestConfig_Validate(t *testing.T) {
@@ -82,7 +88,7 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "Missing installation ID for GitHub App auth",
+			name: "GitHub App auth discovering installations",
 			config: &Config{
 				EnterpriseSlug:      "test-enterprise",
 				Organizations:       true,
@@ -90,7 +96,7 @@ func TestConfig_Validate(t *testing.T) {
 				GithubAppID:         12345,
 				GithubAppPrivateKey: "private-key.pem",
 			},
-			wantErr: true,
+			wantErr: false,
 		},
 		{
 			name: "Invalid auth method",
@@ -101,6 +107,28 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "Replay without a token",
+			config: &Config{
+				EnterpriseSlug: "test-enterprise",
+				Organizations:  true,
+				AuthMethod:     "token",
+				ReplayDir:      "cassette",
+			},
+			wantErr: false,
+		},
+		{
+			name: "Record and replay together",
+			config: &Config{
+				EnterpriseSlug: "test-enterprise",
+				Organizations:  true,
+				AuthMethod:     "token",
+				Token:          "test-token",
+				RecordDir:      "cassette",
+				ReplayDir:      "cassette",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -187,3 +215,364 @@ func TestNewStandardProviderWithConfig(t *testing.T) {
 		t.Errorf("ShouldRunUsersReport() returned true, want false")
 	}
 }
+
+func TestParseColumns(t *testing.T) {
+	raw := map[string]any{
+		"Repositories": []any{
+			"Repository",
+			map[string]any{"name": "Language", "header": "Primary Language"},
+		},
+	}
+
+	columns, err := parseColumns(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing columns: %v", err)
+	}
+	want := []ColumnConfig{{Name: "Repository"}, {Name: "Language", Header: "Primary Language"}}
+	got := columns["repositories"]
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("parseColumns() = %v, want %v", got, want)
+	}
+
+	invalid := []any{
+		"not-a-map",
+		map[string]any{"users": "Login"},
+		map[string]any{"users": []any{map[string]any{"header": "No Name"}}},
+		map[string]any{"users": []any{42}},
+	}
+	for _, in := range invalid {
+		if _, err := parseColumns(in); err == nil {
+			t.Errorf("parseColumns(%v) expected error, got nil", in)
+		}
+	}
+}
+
+func TestDormancyPolicy(t *testing.T) {
+	raw := map[string]any{
+		"window-days": 30,
+		"threshold":   1.5,
+		"signals": map[string]any{
+			"copilot": 0,
+			"deployments": map[string]any{
+				"weight":  0.5,
+				"actions": []any{"workflows.completed_workflow_run"},
+			},
+		},
+	}
+
+	d, err := parseDormancy(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing dormancy: %v", err)
+	}
+	policy, err := d.Policy()
+	if err != nil {
+		t.Fatalf("Unexpected error building dormancy policy: %v", err)
+	}
+	if policy.Window != 30*24*time.Hour {
+		t.Errorf("Window = %v, want 720h", policy.Window)
+	}
+	if policy.Threshold != 1.5 {
+		t.Errorf("Threshold = %v, want 1.5", policy.Threshold)
+	}
+	for _, s := range policy.Enabled("") {
+		if s.Name == utils.SignalCopilot {
+			t.Errorf("copilot signal should be disabled by a zero weight")
+		}
+	}
+	actions := policy.AuditLogActions()
+	if !slices.Contains(actions, "workflows.completed_workflow_run") || !slices.Contains(actions, "user.login") {
+		t.Errorf("AuditLogActions() = %v, want custom and built-in actions", actions)
+	}
+
+	// An unconfigured section yields the default policy
+	d, err = parseDormancy(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing empty dormancy: %v", err)
+	}
+	if policy, err := d.Policy(); err != nil || policy.Window != utils.DefaultDormancyWindow {
+		t.Errorf("Policy() = %v, %v, want the default policy", policy, err)
+	}
+
+	// Git events are only kept for a week, which the default window exceeds
+	if limited := utils.DefaultDormancyPolicy().RetentionLimited(); !slices.Equal(limited, []string{utils.SignalGit, utils.SignalToken}) {
+		t.Errorf("RetentionLimited() = %v, want the git and token signals", limited)
+	}
+	if week, err := (DormancyConfig{WindowDays: 7}).Policy(); err != nil || len(week.RetentionLimited()) != 0 {
+		t.Errorf("RetentionLimited() = %v, %v, want none within the retention", week.RetentionLimited(), err)
+	}
+
+	invalid := []DormancyConfig{
+		{Threshold: -1},
+		{Signals: []DormancySignalConfig{{Name: "custom", Weight: 1}}},
+		{Signals: []DormancySignalConfig{{Name: utils.SignalEvents, Weight: 1, Actions: []string{"user.login"}}}},
+		{Threshold: 100},
+	}
+	for _, in := range invalid {
+		if _, err := in.Policy(); err == nil {
+			t.Errorf("Policy(%+v) expected error, got nil", in)
+		}
+	}
+
+	if _, err := parseDormancy(map[string]any{"signals": map[string]any{"login": "high"}}); err == nil {
+		t.Errorf("parseDormancy() expected error for a non-numeric weight, got nil")
+	}
+}
+
+func TestParseOrgBaseline(t *testing.T) {
+	b, err := parseOrgBaseline(map[string]any{
+		"two-factor-required":                    true,
+		"members-can-create-public-repositories": false,
+		"default-repository-permission":          "read",
+		"allowed-actions":                        "selected",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error parsing org-baseline: %v", err)
+	}
+	if b.TwoFactorRequired == nil || !*b.TwoFactorRequired {
+		t.Errorf("TwoFactorRequired = %v, want true", b.TwoFactorRequired)
+	}
+	if b.MembersCanCreatePublicRepos == nil || *b.MembersCanCreatePublicRepos {
+		t.Errorf("MembersCanCreatePublicRepos = %v, want false", b.MembersCanCreatePublicRepos)
+	}
+	if b.DefaultRepositoryPermission != "read" || b.AllowedActions != "selected" {
+		t.Errorf("levels = %q, %q, want read, selected", b.DefaultRepositoryPermission, b.AllowedActions)
+	}
+	if b.SSORequired != nil {
+		t.Errorf("SSORequired = %v, want nil for an unset setting", *b.SSORequired)
+	}
+
+	if b, err := parseOrgBaseline(nil); err != nil || !b.IsZero() {
+		t.Errorf("parseOrgBaseline(nil) = %+v, %v, want an empty baseline", b, err)
+	}
+
+	invalid := []map[string]any{
+		{"two-factor-requird": true},
+		{"two-factor-required": "yes"},
+		{"default-repository-permission": "maintain"},
+		{"allowed-actions": "everything"},
+	}
+	for _, raw := range invalid {
+		if _, err := parseOrgBaseline(raw); err == nil {
+			t.Errorf("parseOrgBaseline(%v) expected error, got nil", raw)
+		}
+	}
+}
+
+func TestParsePolicyRules(t *testing.T) {
+	rules, err := parsePolicyRules([]any{
+		map[string]any{
+			"id":        "repo-without-team",
+			"report":    "repositories",
+			"condition": "size(row.Teams) == 0",
+			"resource":  "row.Repository.FullName",
+		},
+		map[string]any{
+			"id":               "admin-sprawl",
+			"report":           "collaborators",
+			"severity":         "high",
+			"for-each":         "row.Collaborators",
+			"condition":        `item.Permission == "admin"`,
+			"resource":         "item.Login",
+			"max-per-resource": 50,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error parsing policy-rules: %v", err)
+	}
+	if len(rules) != 2 || rules[0].ID != "repo-without-team" || rules[1].MaxPerResource != 50 || rules[1].ForEach != "row.Collaborators" {
+		t.Errorf("parsePolicyRules() = %+v", rules)
+	}
+
+	if err := validatePolicy(rules, map[string]bool{"repositories": true, "collaborators": true}); err != nil {
+		t.Errorf("validatePolicy() unexpected error: %v", err)
+	}
+	if err := validatePolicy(rules, map[string]bool{"repositories": true}); err == nil {
+		t.Errorf("validatePolicy() expected error for a rule over a report that is not selected, got nil")
+	}
+	if err := validatePolicy(nil, map[string]bool{"repositories": true}); err == nil {
+		t.Errorf("validatePolicy() expected error without rules, got nil")
+	}
+
+	invalid := []any{
+		map[string]any{},
+		[]any{"size(row.Teams) == 0"},
+		[]any{map[string]any{"id": "x", "conditon": "true"}},
+		[]any{map[string]any{"id": "x", "max-per-resource": "50"}},
+	}
+	for _, raw := range invalid {
+		if _, err := parsePolicyRules(raw); err == nil {
+			t.Errorf("parsePolicyRules(%v) expected error, got nil", raw)
+		}
+	}
+}
+
+func TestResolveEndpoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		hostname string
+		baseURL  string
+		want     endpoints
+		wantErr  bool
+	}{
+		{name: "GitHub.com by default"},
+		{name: "GitHub.com hostname", hostname: "github.com"},
+		{
+			name:     "Enterprise Server hostname",
+			hostname: "github.example.com",
+			want:     endpoints{rest: "https://github.example.com/api/v3/", upload: "https://github.example.com/api/uploads/", graphQL: "https://github.example.com/api/graphql"},
+		},
+		{
+			name:     "Enterprise Server hostname with scheme and port",
+			hostname: "http://ghes.internal:8080/",
+			want:     endpoints{rest: "http://ghes.internal:8080/api/v3/", upload: "http://ghes.internal:8080/api/uploads/", graphQL: "http://ghes.internal:8080/api/graphql"},
+		},
+		{
+			name:     "GHE.com hostname",
+			hostname: "octocorp.ghe.com",
+			want:     endpoints{rest: "https://api.octocorp.ghe.com/", upload: "https://api.octocorp.ghe.com/", graphQL: "https://api.octocorp.ghe.com/graphql"},
+		},
+		{
+			name:    "Enterprise Server base URL",
+			baseURL: "https://github.example.com/api/v3",
+			want:    endpoints{rest: "https://github.example.com/api/v3/", upload: "https://github.example.com/api/uploads/", graphQL: "https://github.example.com/api/graphql"},
+		},
+		{
+			name:    "Proxy base URL",
+			baseURL: "https://proxy.example.com/",
+			want:    endpoints{rest: "https://proxy.example.com/", upload: "https://proxy.example.com/", graphQL: "https://proxy.example.com/graphql"},
+		},
+		{name: "Hostname with a path", hostname: "github.example.com/api/v3", wantErr: true},
+		{name: "Hostname and base URL", hostname: "github.example.com", baseURL: "https://github.example.com/api/v3", wantErr: true},
+		{name: "Invalid base URL", baseURL: "not a url", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveEndpoints(tt.hostname, tt.baseURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveEndpoints() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveEndpoints() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCredentials(t *testing.T) {
+	creds, err := parseCredentials([]any{
+		map[string]any{"token": "ghp_third"},
+		map[string]any{"app-id": 12345, "app-private-key-file": "second-app.pem", "app-installation-id": 67890},
+	}, "ghp_first, ghp_second,")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing credentials: %v", err)
+	}
+	want := []CredentialConfig{
+		{Token: "ghp_first"},
+		{Token: "ghp_second"},
+		{Token: "ghp_third"},
+		{AppID: 12345, AppPrivateKeyFile: "second-app.pem", AppInstallationID: 67890},
+	}
+	if !slices.Equal(creds, want) {
+		t.Errorf("parseCredentials() = %+v, want %+v", creds, want)
+	}
+
+	if creds, err := parseCredentials(nil, []any{"ghp_first"}); err != nil || len(creds) != 1 {
+		t.Errorf("parseCredentials(nil, [ghp_first]) = %+v, %v, want one token", creds, err)
+	}
+
+	invalid := []any{
+		map[string]any{"tokn": "ghp_first"},
+		map[string]any{"app-id": "12345"},
+		"ghp_first",
+	}
+	for _, raw := range invalid {
+		if _, err := parseCredentials([]any{raw}, nil); err == nil {
+			t.Errorf("parseCredentials(%v) expected error, got nil", raw)
+		}
+	}
+
+	// A credential is either a token or an app
+	for _, c := range []CredentialConfig{{}, {Token: "ghp_first", AppID: 1}, {AppID: 1}, {AppID: 1, AppPrivateKeyFile: "missing.pem"}} {
+		if err := validateCredentials([]CredentialConfig{c}); err == nil {
+			t.Errorf("validateCredentials(%+v) expected error, got nil", c)
+		}
+	}
+}
+
+func TestTokenSource(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("ghp_file\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	sources := []struct {
+		name   string
+		source tokenSource
+		want   string
+	}{
+		{"token", tokenSource{method: "token", token: "ghp_plain"}, "ghp_plain"},
+		{"token-file", tokenSource{method: "token", file: tokenFile}, "ghp_file"},
+		{"token-command", tokenSource{method: "token", command: "echo ghp_command"}, "ghp_command"},
+	}
+	for _, tt := range sources {
+		if err := tt.source.validate(); err != nil {
+			t.Errorf("%s: validate() unexpected error: %v", tt.name, err)
+		}
+		if got, err := tt.source.resolve(); err != nil || got != tt.want {
+			t.Errorf("%s: resolve() = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	invalid := []tokenSource{
+		{method: "token"},
+		{method: "token", token: "ghp_plain", command: "echo ghp_command"},
+		{method: "token", file: filepath.Join(dir, "missing")},
+		{method: "gh", token: "ghp_plain"},
+	}
+	for _, s := range invalid {
+		if err := s.validate(); err == nil {
+			t.Errorf("validate(%+v) expected error, got nil", s)
+		}
+	}
+
+	// The gh CLI's environment variables depend on the host
+	t.Setenv("GH_TOKEN", "ghp_cloud")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "ghp_server")
+	gh := []struct {
+		source tokenSource
+		want   string
+	}{
+		{tokenSource{method: "gh"}, "ghp_cloud"},
+		{tokenSource{method: "gh", hostname: "octocorp.ghe.com"}, "ghp_cloud"},
+		{tokenSource{method: "gh", hostname: "github.example.com"}, "ghp_server"},
+	}
+	for _, tt := range gh {
+		if err := tt.source.validate(); err != nil {
+			t.Errorf("validate(%+v) unexpected error: %v", tt.source, err)
+		}
+		if got, err := tt.source.resolve(); err != nil || got != tt.want {
+			t.Errorf("resolve(%+v) = %q, %v, want %q", tt.source, got, err, tt.want)
+		}
+	}
+}
+
+func TestGHHost(t *testing.T) {
+	tests := []struct {
+		hostname string
+		baseURL  string
+		want     string
+	}{
+		{"", "", "github.com"},
+		{"", "https://api.github.com/", "github.com"},
+		{"GitHub.Example.com", "", "github.example.com"},
+		{"", "https://github.example.com/api/v3/", "github.example.com"},
+		{"", "https://api.octocorp.ghe.com/", "octocorp.ghe.com"},
+	}
+	for _, tt := range tests {
+		if got := ghHost(tt.hostname, tt.baseURL); got != tt.want {
+			t.Errorf("ghHost(%q, %q) = %q, want %q", tt.hostname, tt.baseURL, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Package config provides configuration interfaces and implementations for the GitHub Enterprise Reports tool.
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"golang.org/x/oauth2"
+)
+
+// CredentialConfig is an additional credential that shares the API requests with the configured
+// authentication, each with its own rate limits. It is either a token or a GitHub App installation;
+// an app without an installation ID uses every installation, like the configured app authentication.
+type CredentialConfig struct {
+	Token             string
+	AppID             int64
+	AppPrivateKeyFile string
+	AppInstallationID int64
+}
+
+// name identifies the credential in logs without revealing it.
+func (c CredentialConfig) name(i int) string {
+	if c.Token != "" {
+		return fmt.Sprintf("token %d", i)
+	}
+	if c.AppInstallationID != 0 {
+		return fmt.Sprintf("app %d installation %d", c.AppID, c.AppInstallationID)
+	}
+	return fmt.Sprintf("app %d", c.AppID)
+}
+
+// validate checks that the credential is either a complete token or app credential.
+func (c CredentialConfig) validate() error {
+	switch {
+	case c.Token != "" && (c.AppID != 0 || c.AppPrivateKeyFile != ""):
+		return fmt.Errorf("a credential is either a token or a GitHub App, not both")
+	case c.Token != "":
+		return nil
+	case c.AppID == 0 || c.AppPrivateKeyFile == "":
+		return fmt.Errorf("a credential needs a token, or an app-id and app-private-key-file")
+	}
+	if _, err := os.Stat(c.AppPrivateKeyFile); err != nil {
+		return fmt.Errorf("credential app-private-key-file %q: %w", c.AppPrivateKeyFile, err)
+	}
+	return nil
+}
+
+// parseCredentials converts the raw "credentials" configuration section and the "tokens" setting into
+// the additional credentials, for example:
+//
+//	tokens: ghp_second,ghp_third
+//	credentials:
+//	  - token: ghp_fourth
+//	  - app-id: 12345
+//	    app-private-key-file: second-app.pem
+//	    app-installation-id: 67890
+//
+// The tokens may be a list, or a comma-separated string as set by the --tokens flag or environment.
+func parseCredentials(rawCredentials, rawTokens any) ([]CredentialConfig, error) {
+	var creds []CredentialConfig
+
+	switch tokens := rawTokens.(type) {
+	case nil:
+	case string:
+		for _, t := range strings.Split(tokens, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				creds = append(creds, CredentialConfig{Token: t})
+			}
+		}
+	case []any:
+		for _, t := range tokens {
+			token, ok := t.(string)
+			if !ok || token == "" {
+				return nil, fmt.Errorf("tokens must be a list of tokens")
+			}
+			creds = append(creds, CredentialConfig{Token: token})
+		}
+	default:
+		return nil, fmt.Errorf("tokens must be a list of tokens")
+	}
+
+	if rawCredentials == nil {
+		return creds, nil
+	}
+	entries, ok := rawCredentials.([]any)
+	if !ok {
+		return nil, fmt.Errorf("credentials must be a list")
+	}
+	for i, entry := range entries {
+		section, ok := entry.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("credentials[%d] must be a map", i)
+		}
+		var c CredentialConfig
+		for key, v := range section {
+			switch key {
+			case "token", "app-private-key-file":
+				s, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("credentials[%d].%s must be a string", i, key)
+				}
+				if key == "token" {
+					c.Token = s
+				} else {
+					c.AppPrivateKeyFile = s
+				}
+			case "app-id", "app-installation-id":
+				n, ok := toFloat(v)
+				if !ok || n != float64(int64(n)) {
+					return nil, fmt.Errorf("credentials[%d].%s must be a number", i, key)
+				}
+				if key == "app-id" {
+					c.AppID = int64(n)
+				} else {
+					c.AppInstallationID = int64(n)
+				}
+			default:
+				return nil, fmt.Errorf("credentials[%d]: unknown key %q (known: app-id, app-installation-id, app-private-key-file, token)", i, key)
+			}
+		}
+		creds = append(creds, c)
+	}
+	return creds, nil
+}
+
+// validateCredentials checks every additional credential.
+func validateCredentials(creds []CredentialConfig) error {
+	for i, c := range creds {
+		if err := c.validate(); err != nil {
+			return fmt.Errorf("credentials: %s: %w", c.name(i+2), err)
+		}
+	}
+	return nil
+}
+
+// poolAuth spreads the requests of a provider's REST and GraphQL clients over the configured
+// authentication and the additional credentials. The clients share it, so the pool tracks the
+// rate limits of each credential across both.
+type poolAuth struct {
+	once sync.Once
+	pool *api.CredentialPool // nil without additional credentials
+	err  error
+}
+
+// client returns an HTTP client sending requests through the pool of the configured authentication,
+// authenticated by primary, and the additional credentials. Without additional credentials it
+// returns primary.
+func (a *poolAuth) client(primary *http.Client, primaryName string, creds []CredentialConfig, e endpoints) (*http.Client, error) {
+	if len(creds) == 0 {
+		return primary, nil
+	}
+	a.once.Do(func() {
+		credentials := []api.Credential{{Name: primaryName, Transport: primary.Transport}}
+		for i, c := range creds {
+			// The configured authentication is the first credential
+			cred := api.Credential{Name: c.name(i + 2)}
+			if c.Token != "" {
+				cred.Transport = &oauth2.Transport{
+					Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: c.Token}),
+					Base:   http.DefaultTransport,
+				}
+			} else {
+				transport, _, err := newAppTransport(c.AppID, c.AppPrivateKeyFile, c.AppInstallationID, e)
+				if err != nil {
+					a.err = fmt.Errorf("credential %s: %w", cred.Name, err)
+					return
+				}
+				cred.Transport = transport
+			}
+			credentials = append(credentials, cred)
+		}
+		a.pool, a.err = api.NewCredentialPool(credentials)
+	})
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &http.Client{Transport: a.pool}, nil
+}
@@ -0,0 +1,172 @@
+// Package config provides configuration interfaces and implementations for the GitHub Enterprise Reports tool.
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+)
+
+// DormancyConfig holds the dormancy settings used by the users report.
+// Zero values fall back to utils.DefaultDormancyPolicy.
+type DormancyConfig struct {
+	WindowDays int                    // Look-back window in days
+	Threshold  float64                // Minimum activity score for a user to count as active
+	Signals    []DormancySignalConfig // Overrides for built-in signals and additional audit-log signals
+}
+
+// DormancySignalConfig overrides the weight of a built-in signal or defines an audit-log signal.
+type DormancySignalConfig struct {
+	Name      string
+	Weight    float64
+	Actions   []string // Audit-log actions; required for signals that are not built in
+	TokenOnly bool     // Only count audit-log entries performed with an access token
+}
+
+// Policy merges the configured settings onto the default dormancy policy and validates the result.
+func (d DormancyConfig) Policy() (utils.DormancyPolicy, error) {
+	policy := utils.DefaultDormancyPolicy()
+	if d.WindowDays > 0 {
+		policy.Window = time.Duration(d.WindowDays) * 24 * time.Hour
+	}
+	if d.Threshold != 0 {
+		policy.Threshold = d.Threshold
+	}
+
+	for _, sc := range d.Signals {
+		i := signalIndex(policy.Signals, sc.Name)
+		if i < 0 {
+			policy.Signals = append(policy.Signals, utils.ActivitySignal{
+				Name:      sc.Name,
+				Weight:    sc.Weight,
+				Source:    utils.SourceAuditLog,
+				Actions:   sc.Actions,
+				TokenOnly: sc.TokenOnly,
+			})
+			continue
+		}
+
+		signal := &policy.Signals[i]
+		signal.Weight = sc.Weight
+		if len(sc.Actions) > 0 {
+			if signal.Source != utils.SourceAuditLog {
+				return utils.DormancyPolicy{}, fmt.Errorf("dormancy signal %q does not read the audit log and cannot set actions", sc.Name)
+			}
+			signal.Actions = sc.Actions
+		}
+	}
+
+	if err := policy.Validate(); err != nil {
+		return utils.DormancyPolicy{}, err
+	}
+	return policy, nil
+}
+
+// signalIndex returns the index of the named signal, or -1 if it is not present.
+func signalIndex(signals []utils.ActivitySignal, name string) int {
+	for i, s := range signals {
+		if s.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseDormancy converts the raw "dormancy" configuration section into a DormancyConfig.
+// Each signal maps either to a weight or to a map with "weight", "actions" and "token-only" keys,
+// for example:
+//
+//	dormancy:
+//	  window-days: 60
+//	  threshold: 1
+//	  signals:
+//	    copilot: 0
+//	    deployments:
+//	      weight: 0.5
+//	      actions: [workflows.completed_workflow_run]
+func parseDormancy(raw any) (DormancyConfig, error) {
+	var d DormancyConfig
+	if raw == nil {
+		return d, nil
+	}
+
+	section, ok := raw.(map[string]any)
+	if !ok {
+		return d, fmt.Errorf("dormancy must be a map")
+	}
+
+	if v, ok := section["window-days"]; ok {
+		days, ok := toFloat(v)
+		if !ok || days != float64(int(days)) {
+			return d, fmt.Errorf("dormancy.window-days must be a whole number of days")
+		}
+		d.WindowDays = int(days)
+	}
+	if v, ok := section["threshold"]; ok {
+		threshold, ok := toFloat(v)
+		if !ok {
+			return d, fmt.Errorf("dormancy.threshold must be a number")
+		}
+		d.Threshold = threshold
+	}
+
+	if v, ok := section["signals"]; ok && v != nil {
+		signals, ok := v.(map[string]any)
+		if !ok {
+			return d, fmt.Errorf("dormancy.signals must be a map of signal name to weight")
+		}
+		// Sort names so custom signals are added in a stable order
+		names := make([]string, 0, len(signals))
+		for name := range signals {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			entry := signals[name]
+			sc := DormancySignalConfig{Name: name}
+			if weight, ok := toFloat(entry); ok {
+				sc.Weight = weight
+				d.Signals = append(d.Signals, sc)
+				continue
+			}
+
+			m, ok := entry.(map[string]any)
+			if !ok {
+				return d, fmt.Errorf("dormancy.signals.%s must be a weight or a map with weight and actions", name)
+			}
+			if sc.Weight, ok = toFloat(m["weight"]); !ok {
+				return d, fmt.Errorf("dormancy.signals.%s.weight must be a number", name)
+			}
+			if actions, ok := m["actions"].([]any); ok {
+				for _, a := range actions {
+					action, ok := a.(string)
+					if !ok {
+						return d, fmt.Errorf("dormancy.signals.%s.actions must be a list of audit-log actions", name)
+					}
+					sc.Actions = append(sc.Actions, action)
+				}
+			}
+			sc.TokenOnly, _ = m["token-only"].(bool)
+			d.Signals = append(d.Signals, sc)
+		}
+	}
+
+	return d, nil
+}
+
+// toFloat converts the numeric types produced by config decoding into a float64.
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
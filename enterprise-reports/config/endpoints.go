@@ -0,0 +1,110 @@
+// Package config provides configuration interfaces and implementations for the GitHub Enterprise Reports tool.
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/shurcooL/githubv4"
+)
+
+// endpoints are the API URLs of a GitHub deployment. Empty URLs use GitHub.com.
+type endpoints struct {
+	rest    string // REST API base URL, with a trailing slash
+	upload  string // Upload API base URL, with a trailing slash
+	graphQL string // GraphQL API URL
+}
+
+// resolveEndpoints derives the API URLs from the configured hostname or base URL, which are exclusive.
+//
+// A GitHub Enterprise Server hostname such as "github.example.com" serves the REST API at /api/v3/,
+// uploads at /api/uploads/ and GraphQL at /api/graphql. A GHE.com hostname such as "octocorp.ghe.com"
+// serves its APIs from the "api." subdomain, like GitHub.com. A base URL is used as the REST API
+// as given; GraphQL is served at /api/graphql next to an /api/v3 base URL, and at /graphql otherwise.
+func resolveEndpoints(hostname, baseURL string) (endpoints, error) {
+	switch {
+	case hostname != "" && baseURL != "":
+		return endpoints{}, fmt.Errorf("hostname and base-url cannot both be set; use hostname for GitHub Enterprise Server")
+	case hostname != "":
+		return hostnameEndpoints(hostname)
+	case baseURL != "":
+		return baseURLEndpoints(baseURL)
+	}
+	return endpoints{}, nil
+}
+
+// hostnameEndpoints derives the API URLs of the deployment at hostname, which may include a scheme and port.
+func hostnameEndpoints(hostname string) (endpoints, error) {
+	raw := hostname
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return endpoints{}, fmt.Errorf("invalid hostname %q: expected a host such as github.example.com", hostname)
+	}
+	if strings.Trim(u.Path, "/") != "" {
+		return endpoints{}, fmt.Errorf("invalid hostname %q: the API paths are derived from the host and must not be included", hostname)
+	}
+	root := u.Scheme + "://" + u.Host
+
+	switch host := strings.ToLower(u.Hostname()); {
+	case host == "github.com" || host == "api.github.com":
+		return endpoints{}, nil
+	case strings.HasSuffix(host, ".ghe.com"):
+		api := u.Scheme + "://api." + strings.TrimPrefix(u.Host, "api.")
+		return endpoints{rest: api + "/", upload: api + "/", graphQL: api + "/graphql"}, nil
+	}
+	return endpoints{
+		rest:    root + "/api/v3/",
+		upload:  root + "/api/uploads/",
+		graphQL: root + "/api/graphql",
+	}, nil
+}
+
+// baseURLEndpoints derives the upload and GraphQL URLs that go with a REST API base URL.
+func baseURLEndpoints(baseURL string) (endpoints, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return endpoints{}, fmt.Errorf("invalid base URL %q", baseURL)
+	}
+	rest := strings.TrimSuffix(u.String(), "/") + "/"
+	root := u.Scheme + "://" + u.Host
+
+	if strings.HasSuffix(strings.TrimSuffix(u.Path, "/"), "/api/v3") {
+		return endpoints{rest: rest, upload: root + "/api/uploads/", graphQL: root + "/api/graphql"}, nil
+	}
+	return endpoints{rest: rest, upload: rest, graphQL: root + "/graphql"}, nil
+}
+
+// restClient creates a REST client for the endpoints.
+func (e endpoints) restClient(httpClient *http.Client) (*github.Client, error) {
+	client := github.NewClient(httpClient)
+	if e.rest == "" {
+		return client, nil
+	}
+
+	// Set the URLs directly, as WithEnterpriseURLs would append /api/v3/ to a base URL pointing at a proxy
+	restURL, err := url.Parse(e.rest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	uploadURL, err := url.Parse(e.upload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upload URL: %w", err)
+	}
+	client.BaseURL = restURL
+	client.UploadURL = uploadURL
+	return client, nil
+}
+
+// graphQLClient creates a GraphQL client for the endpoints.
+func (e endpoints) graphQLClient(httpClient *http.Client) *githubv4.Client {
+	if e.graphQL == "" {
+		return githubv4.NewClient(httpClient)
+	}
+	return githubv4.NewEnterpriseClient(e.graphQL, httpClient)
+}
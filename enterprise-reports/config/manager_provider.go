@@ -3,20 +3,22 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/policy"
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
 	"github.com/shurcooL/githubv4"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
 )
@@ -41,9 +43,16 @@ type ManagerProvider struct {
 	// viper instance used for configuration management
 	v *viper.Viper
 
+	// flags are the command line flags bound to the viper instance
+	flags *pflag.FlagSet
+
 	// profile is the selected configuration profile
 	profile string
 
+	// batch is set for a provider loaded for one profile of a batch run, which writes its
+	// reports to a subdirectory named after the profile
+	batch bool
+
 	// configPaths are the paths where config files are searched
 	configPaths []string
 
@@ -54,6 +63,7 @@ type ManagerProvider struct {
 	outputDir      string
 	logLevel       string
 	baseURL        string
+	hostname       string
 
 	// Report selection flags
 	runOrganizations      bool
@@ -62,13 +72,60 @@ type ManagerProvider struct {
 	runCollaborators      bool
 	runUsers              bool
 	runActiveRepositories bool
+	runStaleRepositories  bool
+	runLicenses           bool
+	runCopilot            bool
+	runAuditLog           bool
+	runAdmins             bool
+	runOrgSettings        bool
+	runIdentities         bool
+	runPolicy             bool
+
+	// Report layout settings
+	columns map[string][]ColumnConfig
+
+	// Dormancy settings for the users report
+	dormancy DormancyConfig
+
+	// Days without activity after which a Copilot seat is inactive
+	copilotInactiveDays int
+
+	// Activity windows of the active-repositories and stale-repositories reports
+	activeRepositoryDays int
+	staleRepositoryDays  int
+
+	// Audit log export settings
+	auditLogPhrase     string
+	auditLogInclude    string
+	auditLogCheckpoint string
+
+	// Organization settings baseline for the org-settings report
+	orgBaseline utils.OrgBaseline
+
+	// Rules evaluated by the policy report
+	policyRules []policy.Rule
 
 	// Auth settings
 	authMethod      string
 	token           string
+	tokenFile       string
+	tokenCommand    string
 	appID           int64
 	appKeyFile      string
 	appInstallation int64
+
+	// Additional credentials sharing the requests
+	credentials []CredentialConfig
+
+	// Cassette directories the API traffic is recorded to or replayed from
+	recordDir string
+	replayDir string
+
+	// Token, GitHub App authentication, credential pool and cassette shared by the clients
+	tokenAuth tokenAuth
+	app       appAuth
+	pool      poolAuth
+	cassette  cassetteAuth
 }
 
 // NewManagerProvider creates a new ManagerProvider with default settings.
@@ -101,6 +158,7 @@ func NewManagerProvider() *ManagerProvider {
 func (m *ManagerProvider) InitializeFlags(rootCmd *cobra.Command) {
 	// Add profile flag first, as it affects how the config is loaded
 	rootCmd.PersistentFlags().StringP("profile", "p", DefaultProfile, "Configuration profile to use")
+	rootCmd.PersistentFlags().String("profiles", "", "Comma-separated configuration profiles to run one after another, e.g. prod,emu,ghes")
 	rootCmd.PersistentFlags().StringP("config-file", "c", "", "Path to config file (default is ./config.yml, ~/.gh-enterprise-reports/config.yml)")
 
 	// Report selection flags
@@ -110,17 +168,31 @@ func (m *ManagerProvider) InitializeFlags(rootCmd *cobra.Command) {
 	rootCmd.PersistentFlags().Bool("collaborators", false, "Generate the collaborators report")
 	rootCmd.PersistentFlags().Bool("users", false, "Generate the users report")
 	rootCmd.PersistentFlags().Bool("active-repositories", false, "Generate the active repositories report")
+	rootCmd.PersistentFlags().Bool("stale-repositories", false, "Generate the stale repositories report scoring repositories for archival")
+	rootCmd.PersistentFlags().Bool("licenses", false, "Generate the licenses report")
+	rootCmd.PersistentFlags().Bool("copilot", false, "Generate the copilot seats report")
+	rootCmd.PersistentFlags().Bool("audit-log", false, "Generate the audit log export report")
+	rootCmd.PersistentFlags().Bool("admins", false, "Generate the enterprise and organization admins report")
+	rootCmd.PersistentFlags().Bool("org-settings", false, "Generate the organization security settings report")
+	rootCmd.PersistentFlags().Bool("identities", false, "Generate the identity provider reconciliation report")
+	rootCmd.PersistentFlags().Bool("policy", false, "Evaluate the policy rules over the selected reports and write the violations report")
 
 	// Authentication flags
-	rootCmd.PersistentFlags().String("auth-method", "token", "Authentication method (token or app)")
-	rootCmd.PersistentFlags().String("token", "", "GitHub personal access token (required if auth-method is token)")
+	rootCmd.PersistentFlags().String("auth-method", "token", "Authentication method (token, app, or gh to reuse the gh CLI's login)")
+	rootCmd.PersistentFlags().String("token", "", "GitHub personal access token (one of token, token-file or token-command is required if auth-method is token)")
+	rootCmd.PersistentFlags().String("token-file", "", "File holding the GitHub personal access token")
+	rootCmd.PersistentFlags().String("token-command", "", "Command printing the GitHub personal access token, e.g. a password manager or vault CLI")
 	rootCmd.PersistentFlags().Int64("app-id", 0, "GitHub App ID (required if auth-method is app)")
 	rootCmd.PersistentFlags().String("app-private-key-file", "", "GitHub App private key file path (required if auth-method is app)")
-	rootCmd.PersistentFlags().Int64("app-installation-id", 0, "GitHub App installation ID (required if auth-method is app)")
+	rootCmd.PersistentFlags().String("tokens", "", "Comma-separated additional tokens that share the API requests, each with its own rate limits")
+	rootCmd.PersistentFlags().Int64("app-installation-id", 0, "GitHub App installation ID; when unset, all installations of the app are discovered and each organization is read through its own")
 
 	// Enterprise and API settings
 	rootCmd.PersistentFlags().String("enterprise", "", "Enterprise slug (required)")
 	rootCmd.PersistentFlags().String("base-url", "", "Base URL for GitHub API (defaults to https://api.github.com)")
+	rootCmd.PersistentFlags().String("hostname", "", "GitHub Enterprise Server or GHE.com hostname, e.g. github.example.com; the API URLs are derived from it")
+	rootCmd.PersistentFlags().String("record", "", "Directory to record every API request and response to, with credentials scrubbed")
+	rootCmd.PersistentFlags().String("replay", "", "Directory of recorded API traffic to generate the reports from offline, without authentication")
 
 	// Format and output options
 	rootCmd.PersistentFlags().String("output-format", "csv", "Output format for reports (csv, json, or xlsx)")
@@ -128,14 +200,87 @@ func (m *ManagerProvider) InitializeFlags(rootCmd *cobra.Command) {
 
 	// Other settings
 	rootCmd.PersistentFlags().Int("workers", 5, "Number of concurrent workers for fetching data")
+	rootCmd.PersistentFlags().Int("dormancy-window-days", 0, "Days of inactivity after which users are considered dormant (default 90)")
+	rootCmd.PersistentFlags().Int("copilot-inactive-days", 0, "Days without activity after which Copilot seats are considered inactive (default 30)")
+	rootCmd.PersistentFlags().Int("active-repository-days", 0, "Days within which a push makes a repository active (default 90)")
+	rootCmd.PersistentFlags().Int("stale-repository-days", 0, "Days without activity after which a repository is considered stale (default 365)")
+
+	// Audit log export settings
+	rootCmd.PersistentFlags().String("audit-log-phrase", "", "Audit log search phrase selecting the entries to export, e.g. \"action:repo.destroy org:my-org\"")
+	rootCmd.PersistentFlags().String("audit-log-include", "all", "Audit log event types to export (web, git, or all)")
+	rootCmd.PersistentFlags().String("audit-log-checkpoint", "", "File recording the last exported audit log entry (defaults to <enterprise>_audit-log_checkpoint.json in the output directory)")
 	rootCmd.PersistentFlags().String("log-level", "info", "Log level (debug, info, warn, error, fatal)")
 
 	// Bind flags to Viper
-	if err := m.v.BindPFlags(rootCmd.PersistentFlags()); err != nil {
+	m.flags = rootCmd.PersistentFlags()
+	if err := bindFlags(m.v, m.flags); err != nil {
 		slog.Error("Failed to bind flags to viper", "error", err)
 	}
 }
 
+// bindFlags binds the flags to the viper instance. The profiles flag is left out, as a bound flag
+// would shadow the profiles section of the config file.
+func bindFlags(v *viper.Viper, flags *pflag.FlagSet) error {
+	var errs []error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Name != "profiles" {
+			errs = append(errs, v.BindPFlag(f.Name, f))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// GetProfiles returns the profiles selected with --profiles for a batch run, or nil for a single run.
+func (m *ManagerProvider) GetProfiles() []string {
+	if m.flags == nil {
+		return nil
+	}
+	raw, _ := m.flags.GetString("profiles")
+	var profiles []string
+	for _, p := range strings.Split(raw, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles
+}
+
+// LoadProfiles loads each profile selected with --profiles into its own provider.
+// LoadConfig merges a profile into the viper instance, so every profile gets a fresh one bound to the
+// same flags and reading the same config file, and does not see the settings of the others.
+// Each profile writes its reports to a subdirectory named after it in its output directory.
+// All profiles are loaded before any report runs, and their errors are returned together.
+func (m *ManagerProvider) LoadProfiles() ([]*ManagerProvider, error) {
+	var providers []*ManagerProvider
+	var errs []error
+	seen := make(map[string]bool)
+	for _, name := range m.GetProfiles() {
+		if seen[name] {
+			errs = append(errs, fmt.Errorf("profile %q is selected more than once", name))
+			continue
+		}
+		seen[name] = true
+
+		p := NewManagerProvider()
+		p.configPaths = m.configPaths
+		p.batch = true
+		p.flags = m.flags
+		if err := bindFlags(p.v, p.flags); err != nil {
+			return nil, utils.NewAppError(utils.ErrorTypeConfig, "Failed to bind flags", err)
+		}
+		p.v.Set("profile", name)
+		if err := p.LoadConfig(); err != nil {
+			errs = append(errs, fmt.Errorf("profile %q: %w", name, err))
+			continue
+		}
+		providers = append(providers, p)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return providers, nil
+}
+
 // LoadConfig loads the configuration from command line flags, environment variables, and config file.
 func (m *ManagerProvider) LoadConfig() error {
 	// First, get the profile and config file from flags/env vars
@@ -196,8 +341,12 @@ func (m *ManagerProvider) LoadConfig() error {
 	m.workers = m.v.GetInt("workers")
 	m.outputFormat = m.v.GetString("output-format")
 	m.outputDir = m.v.GetString("output-dir")
+	if m.batch {
+		m.outputDir = filepath.Join(m.outputDir, m.profile)
+	}
 	m.logLevel = m.v.GetString("log-level")
 	m.baseURL = m.v.GetString("base-url")
+	m.hostname = m.v.GetString("hostname")
 
 	m.runOrganizations = m.v.GetBool("organizations")
 	m.runRepositories = m.v.GetBool("repositories")
@@ -205,13 +354,75 @@ func (m *ManagerProvider) LoadConfig() error {
 	m.runCollaborators = m.v.GetBool("collaborators")
 	m.runUsers = m.v.GetBool("users")
 	m.runActiveRepositories = m.v.GetBool("active-repositories")
+	m.runStaleRepositories = m.v.GetBool("stale-repositories")
+	m.runLicenses = m.v.GetBool("licenses")
+	m.runCopilot = m.v.GetBool("copilot")
+	m.runAuditLog = m.v.GetBool("audit-log")
+	m.runAdmins = m.v.GetBool("admins")
+	m.runOrgSettings = m.v.GetBool("org-settings")
+	m.runIdentities = m.v.GetBool("identities")
+	m.runPolicy = m.v.GetBool("policy")
+
+	columns, err := parseColumns(m.v.Get("columns"))
+	if err != nil {
+		return utils.NewAppError(utils.ErrorTypeConfig, "Error reading column configuration", err)
+	}
+	m.columns = columns
+
+	dormancy, err := parseDormancy(m.v.Get("dormancy"))
+	if err != nil {
+		return utils.NewAppError(utils.ErrorTypeConfig, "Error reading dormancy configuration", err)
+	}
+	// The flag takes precedence over the window set in the dormancy section
+	if days := m.v.GetInt("dormancy-window-days"); days > 0 {
+		dormancy.WindowDays = days
+	}
+	m.dormancy = dormancy
+	m.copilotInactiveDays = m.v.GetInt("copilot-inactive-days")
+	m.activeRepositoryDays = m.v.GetInt("active-repository-days")
+	m.staleRepositoryDays = m.v.GetInt("stale-repository-days")
+	m.auditLogPhrase = m.v.GetString("audit-log-phrase")
+	m.auditLogInclude = m.v.GetString("audit-log-include")
+	m.auditLogCheckpoint = m.v.GetString("audit-log-checkpoint")
+
+	orgBaseline, err := parseOrgBaseline(m.v.Get("org-baseline"))
+	if err != nil {
+		return utils.NewAppError(utils.ErrorTypeConfig, "Error reading org-baseline configuration", err)
+	}
+	m.orgBaseline = orgBaseline
+
+	policyRules, err := parsePolicyRules(m.v.Get("policy-rules"))
+	if err != nil {
+		return utils.NewAppError(utils.ErrorTypeConfig, "Error reading policy-rules configuration", err)
+	}
+	m.policyRules = policyRules
 
 	m.authMethod = m.v.GetString("auth-method")
 	m.token = m.v.GetString("token")
+	m.tokenFile = m.v.GetString("token-file")
+	m.tokenCommand = m.v.GetString("token-command")
 	m.appID = m.v.GetInt64("app-id")
 	m.appKeyFile = m.v.GetString("app-private-key-file")
 	m.appInstallation = m.v.GetInt64("app-installation-id")
 
+	credentials, err := parseCredentials(m.v.Get("credentials"), m.v.Get("tokens"))
+	if err != nil {
+		return utils.NewAppError(utils.ErrorTypeConfig, "Error reading credentials configuration", err)
+	}
+	m.credentials = credentials
+
+	// Each profile of a batch run records its own cassette
+	m.recordDir = m.v.GetString("record")
+	m.replayDir = m.v.GetString("replay")
+	if m.batch {
+		if m.recordDir != "" {
+			m.recordDir = filepath.Join(m.recordDir, m.profile)
+		}
+		if m.replayDir != "" {
+			m.replayDir = filepath.Join(m.replayDir, m.profile)
+		}
+	}
+
 	return m.Validate()
 }
 
@@ -250,6 +461,11 @@ func (m *ManagerProvider) GetBaseURL() string {
 	return m.baseURL
 }
 
+// GetHostname returns the GitHub Enterprise Server hostname the API URLs are derived from.
+func (m *ManagerProvider) GetHostname() string {
+	return m.hostname
+}
+
 // ShouldRunOrganizationsReport returns whether to run the organizations report.
 func (m *ManagerProvider) ShouldRunOrganizationsReport() bool {
 	return m.runOrganizations
@@ -280,6 +496,124 @@ func (m *ManagerProvider) ShouldRunActiveRepositoriesReport() bool {
 	return m.runActiveRepositories
 }
 
+// ShouldRunStaleRepositoriesReport returns whether to run the stale repositories report.
+func (m *ManagerProvider) ShouldRunStaleRepositoriesReport() bool {
+	return m.runStaleRepositories
+}
+
+// ShouldRunLicensesReport returns whether to run the licenses report.
+func (m *ManagerProvider) ShouldRunLicensesReport() bool {
+	return m.runLicenses
+}
+
+// ShouldRunCopilotReport returns whether to run the copilot report.
+func (m *ManagerProvider) ShouldRunCopilotReport() bool {
+	return m.runCopilot
+}
+
+// ShouldRunAuditLogReport returns whether to run the audit log export report.
+func (m *ManagerProvider) ShouldRunAuditLogReport() bool {
+	return m.runAuditLog
+}
+
+// ShouldRunAdminsReport returns whether to run the admins report.
+func (m *ManagerProvider) ShouldRunAdminsReport() bool {
+	return m.runAdmins
+}
+
+// ShouldRunOrgSettingsReport returns whether to run the organization settings report.
+func (m *ManagerProvider) ShouldRunOrgSettingsReport() bool {
+	return m.runOrgSettings
+}
+
+// ShouldRunIdentitiesReport returns whether to run the identities report.
+func (m *ManagerProvider) ShouldRunIdentitiesReport() bool {
+	return m.runIdentities
+}
+
+// ShouldRunPolicyReport returns whether to evaluate the policy rules and write the policy report.
+func (m *ManagerProvider) ShouldRunPolicyReport() bool {
+	return m.runPolicy
+}
+
+// selectedReports returns the names of the data reports selected to run.
+func (m *ManagerProvider) selectedReports() map[string]bool {
+	return map[string]bool{
+		"organizations":       m.runOrganizations,
+		"repositories":        m.runRepositories,
+		"teams":               m.runTeams,
+		"collaborators":       m.runCollaborators,
+		"users":               m.runUsers,
+		"active-repositories": m.runActiveRepositories,
+		"stale-repositories":  m.runStaleRepositories,
+		"licenses":            m.runLicenses,
+		"copilot":             m.runCopilot,
+		"audit-log":           m.runAuditLog,
+		"admins":              m.runAdmins,
+		"org-settings":        m.runOrgSettings,
+		"identities":          m.runIdentities,
+	}
+}
+
+// GetReportColumns returns the configured columns for the given report.
+func (m *ManagerProvider) GetReportColumns(report string) []ColumnConfig {
+	return m.columns[report]
+}
+
+// GetDormancyPolicy returns the dormancy policy for the users report.
+// An invalid configuration, which Validate reports, yields the default policy.
+func (m *ManagerProvider) GetDormancyPolicy() utils.DormancyPolicy {
+	policy, err := m.dormancy.Policy()
+	if err != nil {
+		return utils.DefaultDormancyPolicy()
+	}
+	return policy
+}
+
+// GetCopilotInactiveDays returns the days without activity after which a Copilot seat is inactive.
+// Zero means the report default.
+func (m *ManagerProvider) GetCopilotInactiveDays() int {
+	return m.copilotInactiveDays
+}
+
+// GetActiveRepositoryDays returns the days within which a push makes a repository active.
+// Zero means the report default.
+func (m *ManagerProvider) GetActiveRepositoryDays() int {
+	return m.activeRepositoryDays
+}
+
+// GetStaleRepositoryDays returns the days without activity after which a repository is stale.
+// Zero means the report default.
+func (m *ManagerProvider) GetStaleRepositoryDays() int {
+	return m.staleRepositoryDays
+}
+
+// GetAuditLogPhrase returns the search phrase selecting the audit log entries to export.
+func (m *ManagerProvider) GetAuditLogPhrase() string {
+	return m.auditLogPhrase
+}
+
+// GetAuditLogInclude returns the audit log event types to export.
+func (m *ManagerProvider) GetAuditLogInclude() string {
+	return m.auditLogInclude
+}
+
+// GetAuditLogCheckpoint returns the path of the audit log export checkpoint file.
+// An empty path places it in the output directory.
+func (m *ManagerProvider) GetAuditLogCheckpoint() string {
+	return m.auditLogCheckpoint
+}
+
+// GetOrgBaseline returns the organization settings baseline for the org-settings report.
+func (m *ManagerProvider) GetOrgBaseline() utils.OrgBaseline {
+	return m.orgBaseline
+}
+
+// GetPolicyRules returns the rules evaluated by the policy report.
+func (m *ManagerProvider) GetPolicyRules() []policy.Rule {
+	return m.policyRules
+}
+
 // GetAuthMethod returns the authentication method.
 func (m *ManagerProvider) GetAuthMethod() string {
 	return m.authMethod
@@ -290,6 +624,28 @@ func (m *ManagerProvider) GetToken() string {
 	return m.token
 }
 
+// GetTokenFile returns the file holding the token.
+func (m *ManagerProvider) GetTokenFile() string {
+	return m.tokenFile
+}
+
+// GetTokenCommand returns the command printing the token.
+func (m *ManagerProvider) GetTokenCommand() string {
+	return m.tokenCommand
+}
+
+// tokenSource returns where the token of token or gh authentication comes from.
+func (m *ManagerProvider) tokenSource() tokenSource {
+	return tokenSource{
+		method:   m.authMethod,
+		token:    m.token,
+		file:     m.tokenFile,
+		command:  m.tokenCommand,
+		hostname: m.hostname,
+		baseURL:  m.baseURL,
+	}
+}
+
 // GetAppID returns the GitHub App ID.
 func (m *ManagerProvider) GetAppID() int64 {
 	return m.appID
@@ -305,6 +661,34 @@ func (m *ManagerProvider) GetAppInstallationID() int64 {
 	return m.appInstallation
 }
 
+// GetCredentials returns the additional credentials that share the API requests.
+func (m *ManagerProvider) GetCredentials() []CredentialConfig {
+	return m.credentials
+}
+
+// GetRecordDir returns the directory the API traffic is recorded to, or "" when it is not recorded.
+func (m *ManagerProvider) GetRecordDir() string {
+	return m.recordDir
+}
+
+// GetReplayDir returns the directory the API traffic is replayed from, or "" when the API is used.
+func (m *ManagerProvider) GetReplayDir() string {
+	return m.replayDir
+}
+
+// CredentialPool returns the pool spreading requests over the credentials, or nil without
+// additional credentials or before a client is created.
+func (m *ManagerProvider) CredentialPool() *api.CredentialPool {
+	return m.pool.pool
+}
+
+// GetAppInstallationOrgs returns the organizations the GitHub App is installed on when no installation
+// ID is configured and each organization is read through its own installation. It is nil for other
+// authentication, and until a client is created.
+func (m *ManagerProvider) GetAppInstallationOrgs() []string {
+	return m.app.organizations()
+}
+
 // CreateFilePath creates a file path for a report.
 func (m *ManagerProvider) CreateFilePath(reportType string) string {
 	timestamp := time.Now().Format("2006-01-02_15-04")
@@ -321,13 +705,14 @@ func (m *ManagerProvider) Validate() error {
 		errs = append(errs, fmt.Errorf("enterprise flag is required"))
 	}
 
-	// Authentication validation
-	switch m.authMethod {
-	case "token":
-		if m.token == "" {
-			errs = append(errs, fmt.Errorf("token is required when auth-method is token"))
+	// Authentication validation; replayed traffic needs none
+	switch {
+	case m.replayDir != "":
+	case m.authMethod == "token" || m.authMethod == "gh":
+		if err := m.tokenSource().validate(); err != nil {
+			errs = append(errs, err)
 		}
-	case "app":
+	case m.authMethod == "app":
 		if m.appID == 0 {
 			errs = append(errs, fmt.Errorf("app-id is required when auth-method is app"))
 		}
@@ -336,17 +721,59 @@ func (m *ManagerProvider) Validate() error {
 		} else if _, err := os.Stat(m.appKeyFile); os.IsNotExist(err) {
 			errs = append(errs, fmt.Errorf("app-private-key-file %q does not exist", m.appKeyFile))
 		}
-		if m.appInstallation == 0 {
-			errs = append(errs, fmt.Errorf("app-installation-id is required when auth-method is app"))
-		}
 	default:
-		errs = append(errs, fmt.Errorf("unknown auth-method %q: please use 'token' or 'app'", m.authMethod))
+		errs = append(errs, fmt.Errorf("unknown auth-method %q: please use 'token', 'app' or 'gh'", m.authMethod))
 	}
 
 	// at least one report
 	if !m.runOrganizations && !m.runRepositories && !m.runTeams &&
-		!m.runCollaborators && !m.runUsers && !m.runActiveRepositories {
-		errs = append(errs, fmt.Errorf("no report selected: please specify at least one of: organizations, repositories, teams, collaborators, users, active-repositories"))
+		!m.runCollaborators && !m.runUsers && !m.runActiveRepositories && !m.runStaleRepositories && !m.runLicenses && !m.runCopilot && !m.runAuditLog && !m.runAdmins && !m.runOrgSettings && !m.runIdentities {
+		errs = append(errs, fmt.Errorf("no report selected: please specify at least one of: organizations, repositories, teams, collaborators, users, active-repositories, stale-repositories, licenses, copilot, audit-log, admins, org-settings, identities"))
+	}
+
+	if err := validateCredentials(m.credentials); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := validateCassette(m.recordDir, m.replayDir); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := validateColumns(m.columns); err != nil {
+		errs = append(errs, err)
+	}
+
+	if policy, err := m.dormancy.Policy(); err != nil {
+		errs = append(errs, err)
+	} else if limited := policy.RetentionLimited(); len(limited) > 0 {
+		slog.Warn("the audit log only keeps git events for 7 days, so these dormancy signals only see the last 7 days of the window",
+			"signals", limited, "window_days", int(policy.Window/(24*time.Hour)))
+	}
+
+	if m.copilotInactiveDays < 0 {
+		errs = append(errs, fmt.Errorf("copilot-inactive-days must not be negative; got %d", m.copilotInactiveDays))
+	}
+
+	if m.activeRepositoryDays < 0 {
+		errs = append(errs, fmt.Errorf("active-repository-days must not be negative; got %d", m.activeRepositoryDays))
+	}
+
+	if m.staleRepositoryDays < 0 {
+		errs = append(errs, fmt.Errorf("stale-repository-days must not be negative; got %d", m.staleRepositoryDays))
+	}
+
+	if _, err := resolveEndpoints(m.hostname, m.baseURL); err != nil {
+		errs = append(errs, err)
+	}
+
+	if !validAuditLogIncludes[m.auditLogInclude] {
+		errs = append(errs, fmt.Errorf("audit-log-include must be one of: web, git, all; got %q", m.auditLogInclude))
+	}
+
+	if m.runPolicy {
+		if err := validatePolicy(m.policyRules, m.selectedReports()); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	// Output format validation
@@ -394,54 +821,58 @@ func (m *ManagerProvider) Validate() error {
 // CreateRESTClient creates a GitHub REST client.
 func (m *ManagerProvider) CreateRESTClient() (*github.Client, error) {
 	ctx := context.Background()
-	var client *github.Client
+	endpoints, err := resolveEndpoints(m.GetHostname(), m.GetBaseURL())
+	if err != nil {
+		return nil, err
+	}
+
+	// Replayed traffic is answered from the cassette, without authentication
+	if m.replayDir != "" {
+		httpClient, err := m.cassette.replay(m.replayDir)
+		if err != nil {
+			return nil, err
+		}
+		return endpoints.restClient(httpClient)
+	}
+
+	var httpClient *http.Client
 
 	switch m.GetAuthMethod() {
-	case "token":
-		if m.GetToken() == "" {
-			return nil, fmt.Errorf("token required for token authentication")
+	case "token", "gh":
+		token, err := m.tokenAuth.resolve(m.tokenSource())
+		if err != nil {
+			return nil, err
 		}
 		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: m.GetToken()},
+			&oauth2.Token{AccessToken: token},
 		)
-		tc := oauth2.NewClient(ctx, ts)
-		client = github.NewClient(tc)
+		httpClient = oauth2.NewClient(ctx, ts)
 	case "app":
-		// GitHub App authentication
-		if m.GetAppID() == 0 {
-			return nil, fmt.Errorf("app-id is required for GitHub App authentication")
-		}
-		if m.GetAppPrivateKeyFile() == "" {
-			return nil, fmt.Errorf("app-private-key-file is required for GitHub App authentication")
-		}
-		if m.GetAppInstallationID() == 0 {
-			return nil, fmt.Errorf("app-installation-id is required for GitHub App authentication")
-		}
-
-		itr, err := ghinstallation.NewKeyFromFile(
-			http.DefaultTransport,
-			m.GetAppID(),
-			m.GetAppInstallationID(),
-			m.GetAppPrivateKeyFile(),
-		)
+		// GitHub App authentication, shared with the other client
+		httpClient, err = m.app.client(m.GetAppID(), m.GetAppPrivateKeyFile(), m.GetAppInstallationID(), endpoints)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create GitHub App installation transport: %w", err)
+			return nil, err
 		}
-		client = github.NewClient(&http.Client{Transport: itr})
 	default:
 		return nil, fmt.Errorf("unsupported authentication method: %s", m.GetAuthMethod())
 	}
 
-	// Set custom base URL if provided
-	if m.GetBaseURL() != "" {
-		baseURL, err := url.Parse(m.GetBaseURL())
+	// Additional credentials share the requests with the configured authentication
+	httpClient, err = m.pool.client(httpClient, m.GetAuthMethod(), m.GetCredentials(), endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	// Recording sees the requests before authentication adds the credentials
+	if m.recordDir != "" {
+		httpClient, err = m.cassette.record(httpClient, m.recordDir)
 		if err != nil {
-			return nil, fmt.Errorf("invalid base URL: %w", err)
+			return nil, err
 		}
-		client.BaseURL = baseURL
 	}
 
-	return client, nil
+	// Point the client at the configured GitHub Enterprise Server or base URL
+	return endpoints.restClient(httpClient)
 }
 
 // CreateGraphQLClient creates a GitHub GraphQL client.
@@ -449,70 +880,55 @@ func (m *ManagerProvider) CreateGraphQLClient() (*githubv4.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	var client *githubv4.Client
-
-	switch m.GetAuthMethod() {
-	case "token":
-		if m.GetToken() == "" {
-			return nil, fmt.Errorf("token required for token authentication")
-		}
-		src := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: m.GetToken()})
-		httpClient := oauth2.NewClient(ctx, src)
-
-		// If a custom base URL is specified, use it with the GraphQL client
-		if m.GetBaseURL() != "" {
-			// Extract the hostname for GraphQL endpoint
-			baseURL, err := url.Parse(m.GetBaseURL())
-			if err != nil {
-				return nil, fmt.Errorf("invalid base URL: %w", err)
-			}
+	endpoints, err := resolveEndpoints(m.GetHostname(), m.GetBaseURL())
+	if err != nil {
+		return nil, err
+	}
 
-			// Construct GraphQL API endpoint (usually the REST API base URL with /graphql appended)
-			graphqlURL := fmt.Sprintf("%s://%s/graphql", baseURL.Scheme, baseURL.Host)
-			client = githubv4.NewEnterpriseClient(graphqlURL, httpClient)
-		} else {
-			// Use the standard GitHub GraphQL endpoint
-			client = githubv4.NewClient(httpClient)
+	// Replayed traffic is answered from the cassette, without authentication
+	if m.replayDir != "" {
+		httpClient, err := m.cassette.replay(m.replayDir)
+		if err != nil {
+			return nil, err
 		}
+		return endpoints.graphQLClient(httpClient), nil
+	}
 
-	case "app":
-		// GitHub App authentication
-		if m.GetAppID() == 0 {
-			return nil, fmt.Errorf("app-id is required for GitHub App authentication")
-		}
-		if m.GetAppPrivateKeyFile() == "" {
-			return nil, fmt.Errorf("app-private-key-file is required for GitHub App authentication")
-		}
-		if m.GetAppInstallationID() == 0 {
-			return nil, fmt.Errorf("app-installation-id is required for GitHub App authentication")
-		}
+	var httpClient *http.Client
 
-		itr, err := ghinstallation.NewKeyFromFile(
-			http.DefaultTransport,
-			m.GetAppID(),
-			m.GetAppInstallationID(),
-			m.GetAppPrivateKeyFile(),
-		)
+	switch m.GetAuthMethod() {
+	case "token", "gh":
+		token, err := m.tokenAuth.resolve(m.tokenSource())
 		if err != nil {
-			return nil, fmt.Errorf("failed to create GitHub App installation transport: %w", err)
+			return nil, err
 		}
-		httpClient := &http.Client{Transport: itr}
+		src := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+		httpClient = oauth2.NewClient(ctx, src)
 
-		// Handle custom base URL
-		if m.GetBaseURL() != "" {
-			baseURL, err := url.Parse(m.GetBaseURL())
-			if err != nil {
-				return nil, fmt.Errorf("invalid base URL: %w", err)
-			}
-			graphqlURL := fmt.Sprintf("%s://%s/graphql", baseURL.Scheme, baseURL.Host)
-			client = githubv4.NewEnterpriseClient(graphqlURL, httpClient)
-		} else {
-			client = githubv4.NewClient(httpClient)
+	case "app":
+		// GitHub App authentication, shared with the other client
+		httpClient, err = m.app.client(m.GetAppID(), m.GetAppPrivateKeyFile(), m.GetAppInstallationID(), endpoints)
+		if err != nil {
+			return nil, err
 		}
 
 	default:
 		return nil, fmt.Errorf("unsupported authentication method: %s", m.GetAuthMethod())
 	}
 
-	return client, nil
+	// Additional credentials share the requests with the configured authentication
+	httpClient, err = m.pool.client(httpClient, m.GetAuthMethod(), m.GetCredentials(), endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	// Recording sees the requests before authentication adds the credentials
+	if m.recordDir != "" {
+		httpClient, err = m.cassette.record(httpClient, m.recordDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return endpoints.graphQLClient(httpClient), nil
 }
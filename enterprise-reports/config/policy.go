@@ -0,0 +1,102 @@
+// Package config provides configuration interfaces and implementations for the GitHub Enterprise Reports tool.
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/policy"
+)
+
+// parsePolicyRules converts the raw "policy-rules" configuration section into the rules evaluated
+// by the policy report. The section is a list of rules, for example:
+//
+//	policy-rules:
+//	  - id: repo-without-team
+//	    report: repositories
+//	    severity: medium
+//	    description: Repositories must be owned by a team
+//	    condition: size(row.Teams) == 0
+//	    resource: row.Repository.FullName
+//
+// Unknown keys are rejected so a misspelt field cannot silently change a rule.
+func parsePolicyRules(raw any) ([]policy.Rule, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("policy-rules must be a list")
+	}
+
+	rules := make([]policy.Rule, 0, len(list))
+	for i, entry := range list {
+		section, ok := entry.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("policy-rules[%d] must be a map", i)
+		}
+
+		var r policy.Rule
+		strs := map[string]*string{
+			"id":          &r.ID,
+			"report":      &r.Report,
+			"severity":    &r.Severity,
+			"description": &r.Description,
+			"for-each":    &r.ForEach,
+			"condition":   &r.Condition,
+			"resource":    &r.Resource,
+			"message":     &r.Message,
+		}
+		for key, v := range section {
+			if field, ok := strs[key]; ok {
+				value, ok := v.(string)
+				if !ok {
+					return nil, fmt.Errorf("policy-rules[%d].%s must be a string", i, key)
+				}
+				*field = value
+				continue
+			}
+			if key == "max-per-resource" {
+				value, ok := v.(int)
+				if !ok {
+					return nil, fmt.Errorf("policy-rules[%d].max-per-resource must be a whole number", i)
+				}
+				r.MaxPerResource = value
+				continue
+			}
+
+			known := []string{"max-per-resource"}
+			for k := range strs {
+				known = append(known, k)
+			}
+			slices.Sort(known)
+			return nil, fmt.Errorf("unknown policy-rules[%d] field %q: known fields are %s", i, key, strings.Join(known, ", "))
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// validatePolicy checks that the policy rules compile and only read reports that are selected,
+// since rules over a report that does not run would silently pass.
+func validatePolicy(rules []policy.Rule, selected map[string]bool) error {
+	if len(rules) == 0 {
+		return fmt.Errorf("the policy report requires at least one rule in policy-rules")
+	}
+
+	engine, err := policy.NewEngine(rules)
+	if err != nil {
+		return err
+	}
+	for _, name := range engine.Reports() {
+		if name == "policy" || !slices.Contains(ReportNames, name) {
+			return fmt.Errorf("policy rules read unknown report %q", name)
+		}
+		if !selected[name] {
+			return fmt.Errorf("policy rules read the %s report, which is not selected", name)
+		}
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package config
 
 import (
 	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/policy"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
 	"github.com/shurcooL/githubv4"
 )
 
@@ -17,6 +19,7 @@ type Provider interface {
 	GetOutputDir() string
 	GetLogLevel() string
 	GetBaseURL() string
+	GetHostname() string
 
 	// Report selection methods
 	ShouldRunOrganizationsReport() bool
@@ -25,13 +28,39 @@ type Provider interface {
 	ShouldRunCollaboratorsReport() bool
 	ShouldRunUsersReport() bool
 	ShouldRunActiveRepositoriesReport() bool
+	ShouldRunStaleRepositoriesReport() bool
+	ShouldRunLicensesReport() bool
+	ShouldRunCopilotReport() bool
+	ShouldRunAuditLogReport() bool
+	ShouldRunAdminsReport() bool
+	ShouldRunOrgSettingsReport() bool
+	ShouldRunIdentitiesReport() bool
+	ShouldRunPolicyReport() bool
+
+	// Report layout methods
+	GetReportColumns(report string) []ColumnConfig
+	GetDormancyPolicy() utils.DormancyPolicy
+	GetCopilotInactiveDays() int
+	GetActiveRepositoryDays() int
+	GetStaleRepositoryDays() int
+	GetAuditLogPhrase() string
+	GetAuditLogInclude() string
+	GetAuditLogCheckpoint() string
+	GetOrgBaseline() utils.OrgBaseline
+	GetPolicyRules() []policy.Rule
 
 	// Authentication methods
 	GetAuthMethod() string
 	GetToken() string
+	GetTokenFile() string
+	GetTokenCommand() string
 	GetAppID() int64
 	GetAppPrivateKeyFile() string
 	GetAppInstallationID() int64
+	GetAppInstallationOrgs() []string
+	GetCredentials() []CredentialConfig
+	GetRecordDir() string
+	GetReplayDir() string
 
 	// Utility methods
 	CreateFilePath(reportType string) string
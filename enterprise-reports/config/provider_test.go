@@ -2,7 +2,10 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -68,6 +71,50 @@ func runStandardProviderTests(t *testing.T, provider *StandardProvider) {
 	assert.True(t, filepath.IsAbs(filePath))
 }
 
+// TestStandardProvider_Cassette tests that the clients record the API traffic without the token,
+// and that a provider without a token replays it once the API is gone.
+func TestStandardProvider_Cassette(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		_, err := fmt.Fprint(w, `{"login":"acme","id":1}`)
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+
+	recording := NewStandardProviderWithInternalConfig(&Config{
+		EnterpriseSlug: "test-enterprise",
+		AuthMethod:     "token",
+		Token:          "test-token",
+		BaseURL:        srv.URL + "/",
+		RecordDir:      dir,
+	})
+	client, err := recording.CreateRESTClient()
+	require.NoError(t, err)
+	_, _, err = client.Organizations.Get(context.Background(), "acme")
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "test-token")
+
+	srv.Close()
+	replaying := NewStandardProviderWithInternalConfig(&Config{
+		EnterpriseSlug: "test-enterprise",
+		AuthMethod:     "token",
+		BaseURL:        srv.URL + "/",
+		ReplayDir:      dir,
+	})
+	client, err = replaying.CreateRESTClient()
+	require.NoError(t, err)
+	org, _, err := client.Organizations.Get(context.Background(), "acme")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), org.GetID())
+}
+
 // TestManagerProvider tests the ManagerProvider implementation.
 func TestManagerProvider(t *testing.T) {
 	// Create a temporary config file and output directory for testing
@@ -105,6 +152,14 @@ profiles:
     repositories: true
     teams: false
     output-format: "json"
+
+  columns:
+    users: true
+    columns:
+      users:
+        - Login
+        - name: Email
+          header: Work Email
 `, reportsDir)
 
 	err = os.WriteFile(configPath, []byte(configContent), 0644)
@@ -158,6 +213,28 @@ profiles:
 		assert.True(t, provider.ShouldRunRepositoriesReport())
 	})
 
+	// Test loading a profile with column configuration
+	t.Run("LoadColumnsProfile", func(t *testing.T) {
+		mockCmd := &cobra.Command{
+			Use: "test-columns",
+		}
+
+		provider := NewManagerProvider()
+		provider.InitializeFlags(mockCmd)
+
+		t.Setenv("GH_REPORT_CONFIG_FILE", configPath)
+		t.Setenv("GH_REPORT_PROFILE", "columns")
+
+		err := provider.LoadConfig()
+		require.NoError(t, err)
+
+		assert.Equal(t, []ColumnConfig{
+			{Name: "Login"},
+			{Name: "Email", Header: "Work Email"},
+		}, provider.GetReportColumns("users"))
+		assert.Empty(t, provider.GetReportColumns("repositories"))
+	})
+
 	// Test validation errors
 	t.Run("ValidationErrors", func(t *testing.T) {
 		provider := NewManagerProvider()
@@ -171,6 +248,7 @@ profiles:
 		provider.runCollaborators = false
 		provider.runUsers = false
 		provider.outputFormat = "invalid"
+		provider.columns = map[string][]ColumnConfig{"widgets": {{Name: "Login"}}}
 
 		err := provider.Validate()
 		assert.Error(t, err)
@@ -178,6 +256,80 @@ profiles:
 		assert.Contains(t, err.Error(), "unknown auth-method")
 		assert.Contains(t, err.Error(), "no report selected")
 		assert.Contains(t, err.Error(), "output-format must be one of")
+		assert.Contains(t, err.Error(), "unknown report(s) widgets")
+	})
+}
+
+// TestLoadProfiles tests that each profile of a batch run is loaded on its own.
+func TestLoadProfiles(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.yml")
+	reportsDir := filepath.Join(tempDir, "reports")
+
+	configContent := fmt.Sprintf(`
+token: "test-token"
+output-dir: %q
+
+profiles:
+  prod:
+    enterprise: "prod-enterprise"
+    users: true
+    workers: 2
+  emu:
+    enterprise: "emu-enterprise"
+    organizations: true
+`, reportsDir)
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+	t.Setenv("GH_REPORT_CONFIG_FILE", configPath)
+
+	newProvider := func(t *testing.T, profiles string) *ManagerProvider {
+		cmd := &cobra.Command{Use: "test-profiles"}
+		provider := NewManagerProvider()
+		provider.InitializeFlags(cmd)
+		require.NoError(t, cmd.PersistentFlags().Set("profiles", profiles))
+		require.NoError(t, cmd.PersistentFlags().Set("output-format", "json"))
+		return provider
+	}
+
+	t.Run("Profiles are isolated", func(t *testing.T) {
+		provider := newProvider(t, "prod, emu")
+		assert.Equal(t, []string{"prod", "emu"}, provider.GetProfiles())
+
+		providers, err := provider.LoadProfiles()
+		require.NoError(t, err)
+		require.Len(t, providers, 2)
+
+		prod, emu := providers[0], providers[1]
+		assert.Equal(t, "prod", prod.GetProfile())
+		assert.Equal(t, "prod-enterprise", prod.GetEnterpriseSlug())
+		assert.Equal(t, 2, prod.GetWorkers())
+		assert.True(t, prod.ShouldRunUsersReport())
+		assert.False(t, prod.ShouldRunOrganizationsReport())
+		assert.Equal(t, filepath.Join(reportsDir, "prod"), prod.GetOutputDir())
+
+		assert.Equal(t, "emu", emu.GetProfile())
+		assert.Equal(t, "emu-enterprise", emu.GetEnterpriseSlug())
+		assert.Equal(t, 5, emu.GetWorkers())
+		assert.False(t, emu.ShouldRunUsersReport())
+		assert.True(t, emu.ShouldRunOrganizationsReport())
+		assert.Equal(t, filepath.Join(reportsDir, "emu"), emu.GetOutputDir())
+
+		// Flags apply to every profile, and the output subdirectories are created
+		for _, p := range providers {
+			assert.Equal(t, "json", p.GetOutputFormat())
+			assert.DirExists(t, p.GetOutputDir())
+		}
+	})
+
+	t.Run("Errors are reported per profile", func(t *testing.T) {
+		_, err := newProvider(t, "prod,missing,prod").LoadProfiles()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `profile "missing"`)
+		assert.Contains(t, err.Error(), `profile "prod" is selected more than once`)
+	})
+
+	t.Run("No profiles for a single run", func(t *testing.T) {
+		assert.Empty(t, newProvider(t, "").GetProfiles())
 	})
 }
 
@@ -5,12 +5,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"net/url"
 	"path/filepath"
 	"time"
 
-	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/policy"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
 	"github.com/shurcooL/githubv4"
 	"golang.org/x/oauth2"
 )
@@ -18,7 +19,11 @@ import (
 // StandardProvider implements the Provider interface using an internal configuration structure.
 // It no longer depends directly on the legacy Config struct.
 type StandardProvider struct {
-	config *Config
+	config    *Config
+	tokenAuth tokenAuth    // Token shared by the clients
+	app       appAuth      // GitHub App authentication shared by the clients
+	pool      poolAuth     // Credential pool shared by the clients
+	cassette  cassetteAuth // Recorded or replayed API traffic shared by the clients
 }
 
 // NewStandardProviderWithInternalConfig creates a new StandardProvider using an internal config.
@@ -66,6 +71,11 @@ func (p *StandardProvider) GetBaseURL() string {
 	return p.config.BaseURL
 }
 
+// GetHostname returns the GitHub Enterprise Server hostname the API URLs are derived from.
+func (p *StandardProvider) GetHostname() string {
+	return p.config.Hostname
+}
+
 // ShouldRunOrganizationsReport returns whether to run the organizations report.
 func (p *StandardProvider) ShouldRunOrganizationsReport() bool {
 	return p.config.Organizations
@@ -96,6 +106,105 @@ func (p *StandardProvider) ShouldRunActiveRepositoriesReport() bool {
 	return p.config.ActiveRepositories
 }
 
+// ShouldRunStaleRepositoriesReport returns whether to run the stale repositories report.
+func (p *StandardProvider) ShouldRunStaleRepositoriesReport() bool {
+	return p.config.StaleRepositories
+}
+
+// ShouldRunLicensesReport returns whether to run the licenses report.
+func (p *StandardProvider) ShouldRunLicensesReport() bool {
+	return p.config.Licenses
+}
+
+// ShouldRunCopilotReport returns whether to run the copilot report.
+func (p *StandardProvider) ShouldRunCopilotReport() bool {
+	return p.config.Copilot
+}
+
+// ShouldRunAuditLogReport returns whether to run the audit log export report.
+func (p *StandardProvider) ShouldRunAuditLogReport() bool {
+	return p.config.AuditLog
+}
+
+// ShouldRunAdminsReport returns whether to run the admins report.
+func (p *StandardProvider) ShouldRunAdminsReport() bool {
+	return p.config.Admins
+}
+
+// ShouldRunOrgSettingsReport returns whether to run the organization settings report.
+func (p *StandardProvider) ShouldRunOrgSettingsReport() bool {
+	return p.config.OrgSettings
+}
+
+// ShouldRunIdentitiesReport returns whether to run the identities report.
+func (p *StandardProvider) ShouldRunIdentitiesReport() bool {
+	return p.config.Identities
+}
+
+// ShouldRunPolicyReport returns whether to evaluate the policy rules and write the policy report.
+func (p *StandardProvider) ShouldRunPolicyReport() bool {
+	return p.config.Policy
+}
+
+// GetReportColumns returns the configured columns for the given report.
+func (p *StandardProvider) GetReportColumns(report string) []ColumnConfig {
+	return p.config.Columns[report]
+}
+
+// GetDormancyPolicy returns the dormancy policy for the users report.
+// An invalid configuration, which Validate reports, yields the default policy.
+func (p *StandardProvider) GetDormancyPolicy() utils.DormancyPolicy {
+	policy, err := p.config.Dormancy.Policy()
+	if err != nil {
+		return utils.DefaultDormancyPolicy()
+	}
+	return policy
+}
+
+// GetCopilotInactiveDays returns the days without activity after which a Copilot seat is inactive.
+// Zero means the report default.
+func (p *StandardProvider) GetCopilotInactiveDays() int {
+	return p.config.CopilotInactiveDays
+}
+
+// GetActiveRepositoryDays returns the days within which a push makes a repository active.
+// Zero means the report default.
+func (p *StandardProvider) GetActiveRepositoryDays() int {
+	return p.config.ActiveRepositoryDays
+}
+
+// GetStaleRepositoryDays returns the days without activity after which a repository is stale.
+// Zero means the report default.
+func (p *StandardProvider) GetStaleRepositoryDays() int {
+	return p.config.StaleRepositoryDays
+}
+
+// GetAuditLogPhrase returns the search phrase selecting the audit log entries to export.
+func (p *StandardProvider) GetAuditLogPhrase() string {
+	return p.config.AuditLogPhrase
+}
+
+// GetAuditLogInclude returns the audit log event types to export.
+func (p *StandardProvider) GetAuditLogInclude() string {
+	return p.config.AuditLogInclude
+}
+
+// GetAuditLogCheckpoint returns the path of the audit log export checkpoint file.
+// An empty path places it in the output directory.
+func (p *StandardProvider) GetAuditLogCheckpoint() string {
+	return p.config.AuditLogCheckpoint
+}
+
+// GetOrgBaseline returns the organization settings baseline for the org-settings report.
+func (p *StandardProvider) GetOrgBaseline() utils.OrgBaseline {
+	return p.config.OrgBaseline
+}
+
+// GetPolicyRules returns the rules evaluated by the policy report.
+func (p *StandardProvider) GetPolicyRules() []policy.Rule {
+	return p.config.PolicyRules
+}
+
 // GetAuthMethod returns the authentication method.
 func (p *StandardProvider) GetAuthMethod() string {
 	return p.config.AuthMethod
@@ -106,6 +215,16 @@ func (p *StandardProvider) GetToken() string {
 	return p.config.Token
 }
 
+// GetTokenFile returns the file holding the token.
+func (p *StandardProvider) GetTokenFile() string {
+	return p.config.TokenFile
+}
+
+// GetTokenCommand returns the command printing the token.
+func (p *StandardProvider) GetTokenCommand() string {
+	return p.config.TokenCommand
+}
+
 // GetAppID returns the GitHub App ID.
 func (p *StandardProvider) GetAppID() int64 {
 	return p.config.GithubAppID
@@ -121,6 +240,34 @@ func (p *StandardProvider) GetAppInstallationID() int64 {
 	return p.config.GithubAppInstallationID
 }
 
+// GetCredentials returns the additional credentials that share the API requests.
+func (p *StandardProvider) GetCredentials() []CredentialConfig {
+	return p.config.Credentials
+}
+
+// GetRecordDir returns the directory the API traffic is recorded to, or "" when it is not recorded.
+func (p *StandardProvider) GetRecordDir() string {
+	return p.config.RecordDir
+}
+
+// GetReplayDir returns the directory the API traffic is replayed from, or "" when the API is used.
+func (p *StandardProvider) GetReplayDir() string {
+	return p.config.ReplayDir
+}
+
+// CredentialPool returns the pool spreading requests over the credentials, or nil without
+// additional credentials or before a client is created.
+func (p *StandardProvider) CredentialPool() *api.CredentialPool {
+	return p.pool.pool
+}
+
+// GetAppInstallationOrgs returns the organizations the GitHub App is installed on when no installation
+// ID is configured and each organization is read through its own installation. It is nil for other
+// authentication, and until a client is created.
+func (p *StandardProvider) GetAppInstallationOrgs() []string {
+	return p.app.organizations()
+}
+
 // CreateFilePath creates a file path for a report.
 func (p *StandardProvider) CreateFilePath(reportType string) string {
 	timestamp := time.Now().Format("2006-01-02_15-04")
@@ -136,54 +283,58 @@ func (p *StandardProvider) Validate() error {
 // CreateRESTClient creates a GitHub REST client.
 func (p *StandardProvider) CreateRESTClient() (*github.Client, error) {
 	ctx := context.Background()
-	var client *github.Client
+	endpoints, err := resolveEndpoints(p.GetHostname(), p.GetBaseURL())
+	if err != nil {
+		return nil, err
+	}
+
+	// Replayed traffic is answered from the cassette, without authentication
+	if p.GetReplayDir() != "" {
+		httpClient, err := p.cassette.replay(p.GetReplayDir())
+		if err != nil {
+			return nil, err
+		}
+		return endpoints.restClient(httpClient)
+	}
+
+	var httpClient *http.Client
 
 	switch p.GetAuthMethod() {
-	case "token":
-		if p.GetToken() == "" {
-			return nil, fmt.Errorf("token required for token authentication")
+	case "token", "gh":
+		token, err := p.tokenAuth.resolve(p.config.tokenSource())
+		if err != nil {
+			return nil, err
 		}
 		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: p.GetToken()},
+			&oauth2.Token{AccessToken: token},
 		)
-		tc := oauth2.NewClient(ctx, ts)
-		client = github.NewClient(tc)
+		httpClient = oauth2.NewClient(ctx, ts)
 	case "app":
-		// GitHub App authentication
-		if p.GetAppID() == 0 {
-			return nil, fmt.Errorf("app-id is required for GitHub App authentication")
-		}
-		if p.GetAppPrivateKeyFile() == "" {
-			return nil, fmt.Errorf("app-private-key-file is required for GitHub App authentication")
-		}
-		if p.GetAppInstallationID() == 0 {
-			return nil, fmt.Errorf("app-installation-id is required for GitHub App authentication")
-		}
-
-		itr, err := ghinstallation.NewKeyFromFile(
-			http.DefaultTransport,
-			p.GetAppID(),
-			p.GetAppInstallationID(),
-			p.GetAppPrivateKeyFile(),
-		)
+		// GitHub App authentication, shared with the other client
+		httpClient, err = p.app.client(p.GetAppID(), p.GetAppPrivateKeyFile(), p.GetAppInstallationID(), endpoints)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create GitHub App installation transport: %w", err)
+			return nil, err
 		}
-		client = github.NewClient(&http.Client{Transport: itr})
 	default:
 		return nil, fmt.Errorf("unsupported authentication method: %s", p.GetAuthMethod())
 	}
 
-	// Set custom base URL if provided
-	if p.GetBaseURL() != "" {
-		baseURL, err := url.Parse(p.GetBaseURL())
+	// Additional credentials share the requests with the configured authentication
+	httpClient, err = p.pool.client(httpClient, p.GetAuthMethod(), p.GetCredentials(), endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	// Recording sees the requests before authentication adds the credentials
+	if p.GetRecordDir() != "" {
+		httpClient, err = p.cassette.record(httpClient, p.GetRecordDir())
 		if err != nil {
-			return nil, fmt.Errorf("invalid base URL: %w", err)
+			return nil, err
 		}
-		client.BaseURL = baseURL
 	}
 
-	return client, nil
+	// Point the client at the configured GitHub Enterprise Server or base URL
+	return endpoints.restClient(httpClient)
 }
 
 // CreateGraphQLClient creates a GitHub GraphQL client.
@@ -191,69 +342,55 @@ func (p *StandardProvider) CreateGraphQLClient() (*githubv4.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	var client *githubv4.Client
+	endpoints, err := resolveEndpoints(p.GetHostname(), p.GetBaseURL())
+	if err != nil {
+		return nil, err
+	}
 
-	switch p.GetAuthMethod() {
-	case "token":
-		if p.GetToken() == "" {
-			return nil, fmt.Errorf("token required for token authentication")
-		}
-		src := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: p.GetToken()})
-		httpClient := oauth2.NewClient(ctx, src)
-
-		// If a custom base URL is specified, use it with the GraphQL client
-		if p.GetBaseURL() != "" {
-			baseURL, err := url.Parse(p.GetBaseURL())
-			if err != nil {
-				return nil, fmt.Errorf("invalid base URL: %w", err)
-			}
-
-			// Construct GraphQL API endpoint (usually the REST API base URL with /graphql appended)
-			graphqlURL := fmt.Sprintf("%s://%s/graphql", baseURL.Scheme, baseURL.Host)
-			client = githubv4.NewEnterpriseClient(graphqlURL, httpClient)
-		} else {
-			// Use the standard GitHub GraphQL endpoint
-			client = githubv4.NewClient(httpClient)
+	// Replayed traffic is answered from the cassette, without authentication
+	if p.GetReplayDir() != "" {
+		httpClient, err := p.cassette.replay(p.GetReplayDir())
+		if err != nil {
+			return nil, err
 		}
+		return endpoints.graphQLClient(httpClient), nil
+	}
 
-	case "app":
-		// GitHub App authentication
-		if p.GetAppID() == 0 {
-			return nil, fmt.Errorf("app-id is required for GitHub App authentication")
-		}
-		if p.GetAppPrivateKeyFile() == "" {
-			return nil, fmt.Errorf("app-private-key-file is required for GitHub App authentication")
-		}
-		if p.GetAppInstallationID() == 0 {
-			return nil, fmt.Errorf("app-installation-id is required for GitHub App authentication")
-		}
+	var httpClient *http.Client
 
-		itr, err := ghinstallation.NewKeyFromFile(
-			http.DefaultTransport,
-			p.GetAppID(),
-			p.GetAppInstallationID(),
-			p.GetAppPrivateKeyFile(),
-		)
+	switch p.GetAuthMethod() {
+	case "token", "gh":
+		token, err := p.tokenAuth.resolve(p.config.tokenSource())
 		if err != nil {
-			return nil, fmt.Errorf("failed to create GitHub App installation transport: %w", err)
+			return nil, err
 		}
-		httpClient := &http.Client{Transport: itr}
-
-		// Handle custom base URL
-		if p.GetBaseURL() != "" {
-			baseURL, err := url.Parse(p.GetBaseURL())
-			if err != nil {
-				return nil, fmt.Errorf("invalid base URL: %w", err)
-			}
-			graphqlURL := fmt.Sprintf("%s://%s/graphql", baseURL.Scheme, baseURL.Host)
-			client = githubv4.NewEnterpriseClient(graphqlURL, httpClient)
-		} else {
-			client = githubv4.NewClient(httpClient)
+		src := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+		httpClient = oauth2.NewClient(ctx, src)
+
+	case "app":
+		// GitHub App authentication, shared with the other client
+		httpClient, err = p.app.client(p.GetAppID(), p.GetAppPrivateKeyFile(), p.GetAppInstallationID(), endpoints)
+		if err != nil {
+			return nil, err
 		}
 
 	default:
 		return nil, fmt.Errorf("unsupported authentication method: %s", p.GetAuthMethod())
 	}
 
-	return client, nil
+	// Additional credentials share the requests with the configured authentication
+	httpClient, err = p.pool.client(httpClient, p.GetAuthMethod(), p.GetCredentials(), endpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	// Recording sees the requests before authentication adds the credentials
+	if p.GetRecordDir() != "" {
+		httpClient, err = p.cassette.record(httpClient, p.GetRecordDir())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return endpoints.graphQLClient(httpClient), nil
 }
@@ -0,0 +1,172 @@
+// Package config provides configuration interfaces and implementations for the GitHub Enterprise Reports tool.
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenCommandTimeout bounds how long a token command or the gh CLI may take to print a token.
+const tokenCommandTimeout = 30 * time.Second
+
+// tokenSource describes where the token of token or gh authentication comes from.
+type tokenSource struct {
+	method   string // token or gh
+	token    string
+	file     string // File holding the token
+	command  string // Command printing the token, e.g. a vault CLI
+	hostname string // Host the gh CLI token is read for
+	baseURL  string
+}
+
+// validate checks that token authentication has exactly one source, and gh authentication none.
+func (s tokenSource) validate() error {
+	var set []string
+	for _, source := range [][2]string{{"token", s.token}, {"token-file", s.file}, {"token-command", s.command}} {
+		if source[1] != "" {
+			set = append(set, source[0])
+		}
+	}
+	switch {
+	case s.method == "gh" && len(set) > 0:
+		return fmt.Errorf("%s cannot be set when auth-method is gh, which reads the gh CLI's token", strings.Join(set, " and "))
+	case s.method == "gh":
+		return nil
+	case len(set) == 0:
+		return fmt.Errorf("token is required when auth-method is token: set token, token-file or token-command")
+	case len(set) > 1:
+		return fmt.Errorf("only one of token, token-file and token-command can be set")
+	}
+	if s.file != "" {
+		if _, err := os.Stat(s.file); err != nil {
+			return fmt.Errorf("token-file %q: %w", s.file, err)
+		}
+	}
+	return nil
+}
+
+// resolve returns the token from the source.
+func (s tokenSource) resolve() (string, error) {
+	var token string
+	switch {
+	case s.method == "gh":
+		host := ghHost(s.hostname, s.baseURL)
+		t, err := ghToken(host)
+		if err != nil {
+			return "", err
+		}
+		token = t
+	case s.file != "":
+		data, err := os.ReadFile(s.file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read token-file: %w", err)
+		}
+		token = string(data)
+	case s.command != "":
+		out, err := runTokenCommand(s.command)
+		if err != nil {
+			return "", fmt.Errorf("token-command failed: %w", err)
+		}
+		token = out
+	default:
+		token = s.token
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", fmt.Errorf("no token found for auth-method %s", s.method)
+	}
+	return token, nil
+}
+
+// tokenAuth resolves the token of a provider once for both of its clients, so a token command
+// runs a single time.
+type tokenAuth struct {
+	once  sync.Once
+	token string
+	err   error
+}
+
+// resolve returns the token from the source, resolving it on first use.
+func (a *tokenAuth) resolve(s tokenSource) (string, error) {
+	a.once.Do(func() {
+		a.token, a.err = s.resolve()
+	})
+	return a.token, a.err
+}
+
+// ghHost returns the host the gh CLI knows the configured GitHub deployment by, e.g. "github.com"
+// or "github.example.com".
+func ghHost(hostname, baseURL string) string {
+	raw := hostname
+	if raw == "" {
+		raw = baseURL
+	}
+	if raw == "" {
+		return "github.com"
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "github.com"
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Host), "api.")
+}
+
+// ghToken returns the token the gh CLI uses for the host: the token in its environment variables
+// if set, and otherwise the one it stored with gh auth login. Like gh, it reads GH_TOKEN and
+// GITHUB_TOKEN for GitHub.com and GHE.com, and GH_ENTERPRISE_TOKEN and GITHUB_ENTERPRISE_TOKEN
+// for GitHub Enterprise Server.
+func ghToken(host string) (string, error) {
+	vars := []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	if host == "github.com" || strings.HasSuffix(host, ".ghe.com") {
+		vars = []string{"GH_TOKEN", "GITHUB_TOKEN"}
+	}
+	for _, name := range vars {
+		if token := os.Getenv(name); token != "" {
+			return token, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gh", "auth", "token", "--hostname", host)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the gh CLI token for %s (run gh auth login --hostname %s, or set %s): %w: %s",
+			host, host, vars[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// runTokenCommand runs the command with the system shell and returns what it printed.
+func runTokenCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,374 @@
+// Package fakegithub runs an in-process HTTP server emulating the GitHub REST and GraphQL
+// endpoints the api package uses, seeded from a fixture describing a synthetic enterprise.
+package fakegithub
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Fixture describes the synthetic enterprise a Server serves.
+type Fixture struct {
+	Enterprise    string `json:"enterprise"`     // Slug of the enterprise
+	ServerVersion string `json:"server_version"` // Version the meta endpoint reports for GitHub Enterprise Server; empty for GitHub.com
+	// ReferenceTime, when set, is the time the fixture was written at. Every time in the fixture is
+	// moved forward by the whole days since, so its activity stays as recent as when it was written.
+	ReferenceTime time.Time      `json:"reference_time"`
+	Users         []User         `json:"users"` // Members of the enterprise
+	Organizations []Organization `json:"organizations"`
+	AuditLog      []AuditEvent   `json:"audit_log"`
+}
+
+// User is a member of the enterprise.
+type User struct {
+	Login     string    `json:"login"`
+	ID        int64     `json:"id"` // Defaults to a sequential ID
+	Name      string    `json:"name"`
+	Email     string    `json:"email"` // Email of the user's SAML identity; users without one have no identity
+	CreatedAt time.Time `json:"created_at"`
+	// Role is the user's enterprise role: OWNER or BILLING_MANAGER, or empty for members.
+	Role      string `json:"role"`
+	TwoFactor bool   `json:"two_factor"`
+	// Contributions lists the days the user contributed on, e.g. "2024-05-01".
+	Contributions []string `json:"contributions"`
+}
+
+// Organization is an organization of the enterprise.
+type Organization struct {
+	Login                 string         `json:"login"`
+	ID                    int64          `json:"id"` // Defaults to a sequential ID
+	Name                  string         `json:"name"`
+	DefaultRepoPermission string         `json:"default_repository_permission"` // Defaults to read
+	TwoFactorRequired     bool           `json:"two_factor_requirement_enabled"`
+	Members               []Member       `json:"members"`
+	Invitations           []Invitation   `json:"invitations"`
+	Repositories          []Repository   `json:"repositories"`
+	Teams                 []Team         `json:"teams"`
+	IPAllowList           bool           `json:"ip_allow_list"`
+	CustomRoles           []OrgRole      `json:"custom_roles"`
+	Settings              map[string]any `json:"settings"` // Additional fields of the organization, as the REST API names them
+}
+
+// Member is a user's membership of an organization.
+type Member struct {
+	Login string `json:"login"`
+	Role  string `json:"role"` // admin or member; defaults to member
+}
+
+// Invitation is a pending invitation to join an organization, by login or by email.
+type Invitation struct {
+	Login string `json:"login"`
+	Email string `json:"email"`
+}
+
+// OrgRole is an organization role assigned to users.
+type OrgRole struct {
+	ID    int64    `json:"id"` // Defaults to a sequential ID
+	Name  string   `json:"name"`
+	Users []string `json:"users"`
+}
+
+// Repository is a repository of an organization.
+type Repository struct {
+	Name             string            `json:"name"`
+	ID               int64             `json:"id"`         // Defaults to a sequential ID
+	Visibility       string            `json:"visibility"` // public, private or internal; defaults to private
+	Archived         bool              `json:"archived"`
+	Empty            bool              `json:"empty"`
+	Parent           string            `json:"parent"`         // Full name of the repository a fork was created from
+	DefaultBranch    string            `json:"default_branch"` // Defaults to main
+	Language         string            `json:"language"`
+	Topics           []string          `json:"topics"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	PushedAt         time.Time         `json:"pushed_at"`
+	Collaborators    []Collaborator    `json:"collaborators"`
+	CustomProperties map[string]string `json:"custom_properties"`
+}
+
+// Collaborator is a user with access to a repository.
+type Collaborator struct {
+	Login      string `json:"login"`
+	Permission string `json:"permission"` // admin, maintain, write, triage or read; defaults to read
+}
+
+// Team is a team of an organization.
+type Team struct {
+	Name         string           `json:"name"`
+	ID           int64            `json:"id"`      // Defaults to a sequential ID
+	Slug         string           `json:"slug"`    // Defaults to the name, lowercased with dashes
+	Privacy      string           `json:"privacy"` // closed or secret; defaults to closed
+	Description  string           `json:"description"`
+	Members      []TeamMember     `json:"members"`
+	Repositories []TeamRepository `json:"repositories"`
+}
+
+// TeamMember is a user's membership of a team.
+type TeamMember struct {
+	Login string `json:"login"`
+	Role  string `json:"role"` // member or maintainer; defaults to member
+}
+
+// TeamRepository is a repository of the team's organization the team has access to.
+type TeamRepository struct {
+	Name       string `json:"name"`
+	Permission string `json:"permission"` // admin, maintain, push, triage or pull; defaults to pull
+}
+
+// AuditEvent is an entry of the enterprise audit log.
+type AuditEvent struct {
+	Action    string    `json:"action"` // e.g. user.login or git.push; git.* actions are git events
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+	Org       string    `json:"org"`
+	Repo      string    `json:"repo"` // Full name of the repository
+	User      string    `json:"user"` // User the action was performed on
+	TokenID   int64     `json:"token_id"`
+}
+
+//go:embed demo.json
+var demoFixture []byte
+
+// DemoFixture returns the fixture of the demo enterprise.
+func DemoFixture() (*Fixture, error) {
+	return ParseFixture(demoFixture)
+}
+
+// LoadFixture reads a JSON fixture from a file.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	return ParseFixture(data)
+}
+
+// ParseFixture decodes a JSON fixture and checks it.
+func ParseFixture(data []byte) (*Fixture, error) {
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture: %w", err)
+	}
+	if err := f.normalize(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// normalize fills in the defaults of the fixture and checks that every login and repository it
+// refers to exists.
+func (f *Fixture) normalize() error {
+	if f.Enterprise == "" {
+		return fmt.Errorf("fixture: enterprise is required")
+	}
+
+	var nextID int64 = 1000
+	id := func(set int64) int64 {
+		if set != 0 {
+			return set
+		}
+		nextID++
+		return nextID
+	}
+
+	users := make(map[string]bool, len(f.Users))
+	for i := range f.Users {
+		u := &f.Users[i]
+		if u.Login == "" {
+			return fmt.Errorf("fixture: users[%d]: login is required", i)
+		}
+		if users[u.Login] {
+			return fmt.Errorf("fixture: duplicate user %q", u.Login)
+		}
+		users[u.Login] = true
+		u.ID = id(u.ID)
+		for _, day := range u.Contributions {
+			if _, err := time.Parse("2006-01-02", day); err != nil {
+				return fmt.Errorf("fixture: user %q: invalid contribution day %q", u.Login, day)
+			}
+		}
+	}
+	user := func(context, login string) error {
+		if !users[login] {
+			return fmt.Errorf("fixture: %s: %q is not a user of the enterprise", context, login)
+		}
+		return nil
+	}
+
+	orgs := make(map[string]bool, len(f.Organizations))
+	for i := range f.Organizations {
+		o := &f.Organizations[i]
+		if o.Login == "" {
+			return fmt.Errorf("fixture: organizations[%d]: login is required", i)
+		}
+		if orgs[o.Login] {
+			return fmt.Errorf("fixture: duplicate organization %q", o.Login)
+		}
+		orgs[o.Login] = true
+		o.ID = id(o.ID)
+		if o.DefaultRepoPermission == "" {
+			o.DefaultRepoPermission = "read"
+		}
+
+		for j := range o.Members {
+			m := &o.Members[j]
+			if err := user("organization "+o.Login, m.Login); err != nil {
+				return err
+			}
+			if m.Role == "" {
+				m.Role = "member"
+			}
+		}
+
+		repos := make(map[string]bool, len(o.Repositories))
+		for j := range o.Repositories {
+			r := &o.Repositories[j]
+			if r.Name == "" {
+				return fmt.Errorf("fixture: organization %q: repositories[%d]: name is required", o.Login, j)
+			}
+			repos[r.Name] = true
+			r.ID = id(r.ID)
+			if r.Visibility == "" {
+				r.Visibility = "private"
+			}
+			if r.DefaultBranch == "" {
+				r.DefaultBranch = "main"
+			}
+			for k := range r.Collaborators {
+				c := &r.Collaborators[k]
+				if err := user("repository "+o.Login+"/"+r.Name, c.Login); err != nil {
+					return err
+				}
+				if c.Permission == "" {
+					c.Permission = "read"
+				}
+			}
+		}
+
+		for j := range o.Teams {
+			t := &o.Teams[j]
+			if t.Name == "" {
+				return fmt.Errorf("fixture: organization %q: teams[%d]: name is required", o.Login, j)
+			}
+			t.ID = id(t.ID)
+			if t.Slug == "" {
+				t.Slug = strings.ToLower(strings.ReplaceAll(t.Name, " ", "-"))
+			}
+			if t.Privacy == "" {
+				t.Privacy = "closed"
+			}
+			for k := range t.Members {
+				m := &t.Members[k]
+				if err := user("team "+o.Login+"/"+t.Slug, m.Login); err != nil {
+					return err
+				}
+				if m.Role == "" {
+					m.Role = "member"
+				}
+			}
+			for k := range t.Repositories {
+				r := &t.Repositories[k]
+				if !repos[r.Name] {
+					return fmt.Errorf("fixture: team %s/%s: %q is not a repository of the organization", o.Login, t.Slug, r.Name)
+				}
+				if r.Permission == "" {
+					r.Permission = "pull"
+				}
+			}
+		}
+
+		for j := range o.CustomRoles {
+			role := &o.CustomRoles[j]
+			role.ID = id(role.ID)
+			for _, login := range role.Users {
+				if err := user("organization role "+role.Name, login); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for i, e := range f.AuditLog {
+		if e.Action == "" || e.CreatedAt.IsZero() {
+			return fmt.Errorf("fixture: audit_log[%d]: action and created_at are required", i)
+		}
+	}
+
+	if !f.ReferenceTime.IsZero() {
+		f.shift(time.Since(f.ReferenceTime).Truncate(24 * time.Hour))
+	}
+	return nil
+}
+
+// shift moves every time in the fixture forward by d, a whole number of days.
+func (f *Fixture) shift(d time.Duration) {
+	move := func(t *time.Time) {
+		if !t.IsZero() {
+			*t = t.Add(d)
+		}
+	}
+	for i := range f.Users {
+		u := &f.Users[i]
+		move(&u.CreatedAt)
+		for j, day := range u.Contributions {
+			date, _ := time.Parse("2006-01-02", day)
+			u.Contributions[j] = date.Add(d).Format("2006-01-02")
+		}
+	}
+	for i := range f.Organizations {
+		for j := range f.Organizations[i].Repositories {
+			r := &f.Organizations[i].Repositories[j]
+			move(&r.CreatedAt)
+			move(&r.UpdatedAt)
+			move(&r.PushedAt)
+		}
+	}
+	for i := range f.AuditLog {
+		move(&f.AuditLog[i].CreatedAt)
+	}
+	f.ReferenceTime = f.ReferenceTime.Add(d)
+}
+
+// user returns the user with the login, or nil.
+func (f *Fixture) user(login string) *User {
+	for i := range f.Users {
+		if strings.EqualFold(f.Users[i].Login, login) {
+			return &f.Users[i]
+		}
+	}
+	return nil
+}
+
+// organization returns the organization with the login, or nil.
+func (f *Fixture) organization(login string) *Organization {
+	for i := range f.Organizations {
+		if strings.EqualFold(f.Organizations[i].Login, login) {
+			return &f.Organizations[i]
+		}
+	}
+	return nil
+}
+
+// repository returns the repository with the full name, or nil.
+func (f *Fixture) repository(fullName string) (*Organization, *Repository) {
+	owner, name, _ := strings.Cut(fullName, "/")
+	o := f.organization(owner)
+	if o == nil {
+		return nil, nil
+	}
+	return o, o.repository(name)
+}
+
+// repository returns the organization's repository with the name, or nil.
+func (o *Organization) repository(name string) *Repository {
+	for i := range o.Repositories {
+		if strings.EqualFold(o.Repositories[i].Name, name) {
+			return &o.Repositories[i]
+		}
+	}
+	return nil
+}
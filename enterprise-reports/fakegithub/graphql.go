@@ -0,0 +1,382 @@
+// Package fakegithub runs an in-process HTTP server emulating the GitHub REST and GraphQL
+// endpoints the api package uses, seeded from a fixture describing a synthetic enterprise.
+package fakegithub
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// object is a GraphQL object. Its fields hold a scalar, an object, a list of objects, or a resolver
+// computing the value from the field's arguments. The "__typename" field decides which inline
+// fragments apply to the object.
+type object map[string]any
+
+// resolver computes the value of a field with arguments, such as a connection.
+type resolver func(args map[string]any) (any, error)
+
+// notFoundError is a field that does not resolve, such as a user that does not exist. The field
+// is null in the response and the error is reported next to the data, as GitHub does.
+type notFoundError struct {
+	typeName string
+	key      string
+	value    string
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("Could not resolve to a %s with the %s of '%s'.", e.typeName, e.key, e.value)
+}
+
+// selection is a field or inline fragment of a parsed query.
+type selection struct {
+	alias    string
+	name     string
+	args     map[string]any // Values, with variables as variable
+	fragment string         // Type condition of an inline fragment; empty for fields
+	children []selection
+}
+
+// variable is a reference to a query variable in an argument.
+type variable string
+
+// graphQLError is an error of the response's errors list.
+type graphQLError struct {
+	Message string   `json:"message"`
+	Type    string   `json:"type,omitempty"`
+	Path    []string `json:"path,omitempty"`
+}
+
+// execute resolves the selections on the object with the request's variables. Fields that do not
+// resolve are null and reported as errors.
+func execute(selections []selection, obj object, vars map[string]any, path []string) (map[string]any, []graphQLError) {
+	result := make(map[string]any, len(selections))
+	var errs []graphQLError
+	for _, sel := range selections {
+		if sel.fragment != "" {
+			if typeName, ok := obj["__typename"].(string); ok && typeName != sel.fragment {
+				continue
+			}
+			data, fragmentErrs := execute(sel.children, obj, vars, path)
+			for k, v := range data {
+				result[k] = v
+			}
+			errs = append(errs, fragmentErrs...)
+			continue
+		}
+
+		key := sel.alias
+		if key == "" {
+			key = sel.name
+		}
+		fieldPath := append(append([]string(nil), path...), key)
+
+		value, ok := obj[sel.name]
+		if !ok {
+			errs = append(errs, graphQLError{
+				Message: fmt.Sprintf("Field '%s' doesn't exist on type '%v'", sel.name, obj["__typename"]),
+				Path:    fieldPath,
+			})
+			result[key] = nil
+			continue
+		}
+		if resolve, ok := value.(resolver); ok {
+			args, err := bindArguments(sel.args, vars)
+			if err == nil {
+				value, err = resolve(args)
+			}
+			if err != nil {
+				e := graphQLError{Message: err.Error(), Path: fieldPath}
+				if _, ok := err.(*notFoundError); ok {
+					e.Type = "NOT_FOUND"
+				}
+				errs = append(errs, e)
+				result[key] = nil
+				continue
+			}
+		}
+
+		data, valueErrs := complete(sel, value, vars, fieldPath)
+		result[key] = data
+		errs = append(errs, valueErrs...)
+	}
+	return result, errs
+}
+
+// complete resolves the sub-selections of a field's value.
+func complete(sel selection, value any, vars map[string]any, path []string) (any, []graphQLError) {
+	switch v := value.(type) {
+	case object:
+		if v == nil {
+			return nil, nil
+		}
+		return execute(sel.children, v, vars, path)
+	case []object:
+		list := make([]any, 0, len(v))
+		var errs []graphQLError
+		for i, item := range v {
+			data, itemErrs := execute(sel.children, item, vars, append(path, strconv.Itoa(i)))
+			list = append(list, data)
+			errs = append(errs, itemErrs...)
+		}
+		return list, errs
+	}
+	return value, nil
+}
+
+// bindArguments replaces the variables in the arguments by their values.
+func bindArguments(args map[string]any, vars map[string]any) (map[string]any, error) {
+	bound := make(map[string]any, len(args))
+	for name, value := range args {
+		v, err := bindValue(value, vars)
+		if err != nil {
+			return nil, err
+		}
+		bound[name] = v
+	}
+	return bound, nil
+}
+
+func bindValue(value any, vars map[string]any) (any, error) {
+	switch v := value.(type) {
+	case variable:
+		bound, ok := vars[string(v)]
+		if !ok {
+			return nil, fmt.Errorf("Variable $%s is used but not provided", v)
+		}
+		return bound, nil
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			b, err := bindValue(item, vars)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = b
+		}
+		return list, nil
+	case map[string]any:
+		return bindArguments(v, vars)
+	}
+	return value, nil
+}
+
+// parseQuery parses the subset of GraphQL that githubv4 sends: a single query operation with
+// variable definitions, aliased fields with arguments, and inline fragments.
+func parseQuery(query string) ([]selection, error) {
+	p := &parser{tokens: tokenize(query)}
+	if p.peek() == "query" {
+		p.next()
+		if p.peek() != "(" && p.peek() != "{" {
+			p.next() // Operation name
+		}
+		if p.peek() == "(" {
+			// Variable types are not checked, so their definitions are skipped
+			for depth := 0; ; {
+				tok := p.next()
+				if tok == "" {
+					return nil, fmt.Errorf("unterminated variable definitions")
+				}
+				if tok == "(" {
+					depth++
+				} else if tok == ")" {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+		}
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.next(); tok != "" {
+		return nil, fmt.Errorf("unexpected %q after the query", tok)
+	}
+	return selections, nil
+}
+
+// parser reads a query's tokens.
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *parser) expect(want string) error {
+	if tok := p.next(); tok != want {
+		return fmt.Errorf("expected %q, got %q", want, tok)
+	}
+	return nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []selection
+	for p.peek() != "}" {
+		if p.peek() == "" {
+			return nil, fmt.Errorf("unterminated selection set")
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	p.next()
+	return selections, nil
+}
+
+func (p *parser) selection() (selection, error) {
+	if p.peek() == "..." {
+		p.next()
+		if err := p.expect("on"); err != nil {
+			return selection{}, err
+		}
+		sel := selection{fragment: p.next()}
+		children, err := p.selectionSet()
+		sel.children = children
+		return sel, err
+	}
+
+	sel := selection{name: p.next()}
+	if !isName(sel.name) {
+		return selection{}, fmt.Errorf("expected a field name, got %q", sel.name)
+	}
+	if p.peek() == ":" {
+		p.next()
+		sel.alias, sel.name = sel.name, p.next()
+	}
+	if p.peek() == "(" {
+		p.next()
+		sel.args = make(map[string]any)
+		for p.peek() != ")" {
+			name := p.next()
+			if err := p.expect(":"); err != nil {
+				return selection{}, err
+			}
+			value, err := p.value()
+			if err != nil {
+				return selection{}, err
+			}
+			sel.args[name] = value
+		}
+		p.next()
+	}
+	if p.peek() == "{" {
+		children, err := p.selectionSet()
+		if err != nil {
+			return selection{}, err
+		}
+		sel.children = children
+	}
+	return sel, nil
+}
+
+func (p *parser) value() (any, error) {
+	tok := p.next()
+	switch {
+	case tok == "$":
+		return variable(p.next()), nil
+	case tok == "[":
+		var list []any
+		for p.peek() != "]" {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		p.next()
+		return list, nil
+	case tok == "{":
+		fields := make(map[string]any)
+		for p.peek() != "}" {
+			name := p.next()
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			fields[name] = v
+		}
+		p.next()
+		return fields, nil
+	case strings.HasPrefix(tok, `"`):
+		return strconv.Unquote(tok)
+	case tok == "true" || tok == "false":
+		return tok == "true", nil
+	case tok == "null":
+		return nil, nil
+	case tok != "" && (tok[0] == '-' || unicode.IsDigit(rune(tok[0]))):
+		// Numbers are decoded like JSON variables
+		return strconv.ParseFloat(tok, 64)
+	case isName(tok):
+		return tok, nil // Enum value
+	}
+	return nil, fmt.Errorf("unexpected %q in arguments", tok)
+}
+
+// tokenize splits a query into names, numbers, strings and punctuators. Commas are insignificant
+// in GraphQL and dropped.
+func tokenize(query string) []string {
+	var tokens []string
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ',' || unicode.IsSpace(rune(c)):
+			i++
+		case strings.HasPrefix(query[i:], "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case c == '"':
+			j := i + 1
+			for j < len(query) && query[j] != '"' {
+				if query[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(query))
+			tokens = append(tokens, query[i:j])
+			i = j
+		case c == '_' || c == '-' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)):
+			j := i + 1
+			for j < len(query) && (query[j] == '_' || query[j] == '.' || unicode.IsLetter(rune(query[j])) || unicode.IsDigit(rune(query[j]))) {
+				j++
+			}
+			tokens = append(tokens, query[i:j])
+			i = j
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens
+}
+
+// isName reports whether the token is a GraphQL name.
+func isName(tok string) bool {
+	if tok == "" || !(tok[0] == '_' || unicode.IsLetter(rune(tok[0]))) {
+		return false
+	}
+	return !strings.ContainsAny(tok, ".-")
+}
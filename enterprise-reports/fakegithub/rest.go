@@ -0,0 +1,516 @@
+// Package fakegithub runs an in-process HTTP server emulating the GitHub REST and GraphQL
+// endpoints the api package uses, seeded from a fixture describing a synthetic enterprise.
+package fakegithub
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+)
+
+// routes registers the REST endpoints and the GraphQL endpoint.
+func (s *Server) routes() {
+	s.mux.HandleFunc("POST /graphql", s.handleGraphQL)
+	s.mux.HandleFunc("GET /meta", s.handleMeta)
+	s.mux.HandleFunc("GET /rate_limit", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.rateLimits())
+	})
+
+	s.mux.HandleFunc("GET /enterprises/{enterprise}/audit-log", s.handleAuditLog)
+
+	s.mux.HandleFunc("GET /orgs/{org}", s.orgHandler(func(w http.ResponseWriter, r *http.Request, o *Organization) {
+		writeJSON(w, http.StatusOK, s.restOrganization(o))
+	}))
+	s.mux.HandleFunc("GET /orgs/{org}/members", s.orgHandler(func(w http.ResponseWriter, r *http.Request, o *Organization) {
+		members := make([]*github.User, 0, len(o.Members))
+		for _, m := range o.Members {
+			members = append(members, s.restUser(m.Login))
+		}
+		writePage(w, r, s.pageSize(r), members)
+	}))
+	s.mux.HandleFunc("GET /orgs/{org}/invitations", s.orgHandler(func(w http.ResponseWriter, r *http.Request, o *Organization) {
+		invitations := make([]*github.Invitation, 0, len(o.Invitations))
+		for i, inv := range o.Invitations {
+			invitation := &github.Invitation{ID: github.Ptr(int64(i + 1)), Role: github.Ptr("direct_member")}
+			if inv.Login != "" {
+				invitation.Login = github.Ptr(inv.Login)
+			}
+			if inv.Email != "" {
+				invitation.Email = github.Ptr(inv.Email)
+			}
+			invitations = append(invitations, invitation)
+		}
+		writePage(w, r, s.pageSize(r), invitations)
+	}))
+	s.mux.HandleFunc("GET /orgs/{org}/repos", s.orgHandler(func(w http.ResponseWriter, r *http.Request, o *Organization) {
+		repos := make([]*github.Repository, 0, len(o.Repositories))
+		for i := range o.Repositories {
+			repos = append(repos, s.restRepository(o, &o.Repositories[i], ""))
+		}
+		writePage(w, r, s.pageSize(r), repos)
+	}))
+	s.mux.HandleFunc("GET /orgs/{org}/teams", s.orgHandler(func(w http.ResponseWriter, r *http.Request, o *Organization) {
+		teams := make([]*github.Team, 0, len(o.Teams))
+		for i := range o.Teams {
+			teams = append(teams, restTeam(o, &o.Teams[i], ""))
+		}
+		writePage(w, r, s.pageSize(r), teams)
+	}))
+	s.mux.HandleFunc("GET /orgs/{org}/teams/{team}/members", s.teamHandler(func(w http.ResponseWriter, r *http.Request, o *Organization, t *Team) {
+		role := r.URL.Query().Get("role")
+		var members []*github.User
+		for _, m := range t.Members {
+			if role == "" || role == "all" || role == m.Role {
+				members = append(members, s.restUser(m.Login))
+			}
+		}
+		writePage(w, r, s.pageSize(r), members)
+	}))
+	s.mux.HandleFunc("GET /orgs/{org}/teams/{team}/repos", s.teamHandler(func(w http.ResponseWriter, r *http.Request, o *Organization, t *Team) {
+		repos := make([]*github.Repository, 0, len(t.Repositories))
+		for _, tr := range t.Repositories {
+			repos = append(repos, s.restRepository(o, o.repository(tr.Name), tr.Permission))
+		}
+		writePage(w, r, s.pageSize(r), repos)
+	}))
+	s.mux.HandleFunc("GET /orgs/{org}/teams/{team}/external-groups", s.teamHandler(func(w http.ResponseWriter, r *http.Request, o *Organization, t *Team) {
+		writeJSON(w, http.StatusOK, &github.ExternalGroupList{Groups: []*github.ExternalGroup{}})
+	}))
+	s.mux.HandleFunc("GET /orgs/{org}/teams/{team}/team-sync/group-mappings", s.teamHandler(func(w http.ResponseWriter, r *http.Request, o *Organization, t *Team) {
+		writeJSON(w, http.StatusOK, &github.IDPGroupList{Groups: []*github.IDPGroup{}})
+	}))
+	s.mux.HandleFunc("GET /orgs/{org}/organization-roles", s.orgHandler(func(w http.ResponseWriter, r *http.Request, o *Organization) {
+		roles := make([]*github.CustomOrgRoles, 0, len(o.CustomRoles))
+		for _, role := range o.CustomRoles {
+			roles = append(roles, &github.CustomOrgRoles{ID: github.Ptr(role.ID), Name: github.Ptr(role.Name)})
+		}
+		writeJSON(w, http.StatusOK, &github.OrganizationCustomRoles{TotalCount: github.Ptr(len(roles)), CustomRepoRoles: roles})
+	}))
+	s.mux.HandleFunc("GET /orgs/{org}/organization-roles/{role}/users", s.orgHandler(func(w http.ResponseWriter, r *http.Request, o *Organization) {
+		for _, role := range o.CustomRoles {
+			if strconv.FormatInt(role.ID, 10) == r.PathValue("role") {
+				users := make([]*github.User, 0, len(role.Users))
+				for _, login := range role.Users {
+					users = append(users, s.restUser(login))
+				}
+				writePage(w, r, s.pageSize(r), users)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "Not Found")
+	}))
+
+	s.mux.HandleFunc("GET /repos/{owner}/{repo}/teams", s.repoHandler(func(w http.ResponseWriter, r *http.Request, o *Organization, repo *Repository) {
+		var teams []*github.Team
+		for i := range o.Teams {
+			t := &o.Teams[i]
+			for _, tr := range t.Repositories {
+				if tr.Name == repo.Name {
+					teams = append(teams, restTeam(o, t, tr.Permission))
+				}
+			}
+		}
+		writePage(w, r, s.pageSize(r), teams)
+	}))
+	s.mux.HandleFunc("GET /repos/{owner}/{repo}/collaborators", s.repoHandler(func(w http.ResponseWriter, r *http.Request, o *Organization, repo *Repository) {
+		collaborators := make([]*github.User, 0, len(repo.Collaborators))
+		for _, c := range repo.Collaborators {
+			u := s.restUser(c.Login)
+			u.Permissions = permissions(c.Permission)
+			u.RoleName = github.Ptr(c.Permission)
+			collaborators = append(collaborators, u)
+		}
+		writePage(w, r, s.pageSize(r), collaborators)
+	}))
+	s.mux.HandleFunc("GET /repos/{owner}/{repo}/properties/values", s.repoHandler(func(w http.ResponseWriter, r *http.Request, o *Organization, repo *Repository) {
+		names := make([]string, 0, len(repo.CustomProperties))
+		for name := range repo.CustomProperties {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		values := make([]*github.CustomPropertyValue, 0, len(names))
+		for _, name := range names {
+			values = append(values, &github.CustomPropertyValue{PropertyName: name, Value: repo.CustomProperties[name]})
+		}
+		writeJSON(w, http.StatusOK, values)
+	}))
+	s.mux.HandleFunc("GET /repos/{owner}/{repo}/commits", s.repoHandler(func(w http.ResponseWriter, r *http.Request, o *Organization, repo *Repository) {
+		writePage(w, r, s.pageSize(r), []*github.RepositoryCommit{})
+	}))
+	s.mux.HandleFunc("GET /repos/{owner}/{repo}/actions/runs", s.repoHandler(func(w http.ResponseWriter, r *http.Request, o *Organization, repo *Repository) {
+		writeJSON(w, http.StatusOK, &github.WorkflowRuns{TotalCount: github.Ptr(0), WorkflowRuns: []*github.WorkflowRun{}})
+	}))
+
+	s.mux.HandleFunc("GET /users/{user}/events", func(w http.ResponseWriter, r *http.Request) {
+		if s.fixture.user(r.PathValue("user")) == nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		writePage(w, r, s.pageSize(r), []*github.Event{})
+	})
+
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "Not Found")
+	})
+}
+
+// handleMeta reports the GitHub Enterprise Server version of the fixture, if any.
+func (s *Server) handleMeta(w http.ResponseWriter, r *http.Request) {
+	meta := map[string]any{"verifiable_password_authentication": false}
+	if s.fixture.ServerVersion != "" {
+		meta["installed_version"] = s.fixture.ServerVersion
+	}
+	writeJSON(w, http.StatusOK, meta)
+}
+
+// handleAuditLog serves the audit log entries matching the search phrase and event types, in pages
+// linked with cursors. The phrase qualifiers action, actor, org, repo, user and created are supported.
+func (s *Server) handleAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.PathValue("enterprise") != s.fixture.Enterprise {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	query := r.URL.Query()
+	match, err := parsePhrase(query.Get("phrase"))
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	include := query.Get("include")
+	if include == "" {
+		include = "web"
+	}
+
+	var entries []*github.AuditEntry
+	for i, e := range s.fixture.AuditLog {
+		git := strings.HasPrefix(e.Action, "git.")
+		if (include == "web" && git) || (include == "git" && !git) || !match(e) {
+			continue
+		}
+		entry := &github.AuditEntry{
+			Action:     github.Ptr(e.Action),
+			Actor:      github.Ptr(e.Actor),
+			CreatedAt:  &github.Timestamp{Time: e.CreatedAt},
+			Timestamp:  &github.Timestamp{Time: e.CreatedAt},
+			DocumentID: github.Ptr(fmt.Sprintf("doc-%d", i+1)), // Stable across searches, like the API's
+		}
+		if e.Org != "" {
+			entry.Org = github.Ptr(e.Org)
+		}
+		if e.User != "" {
+			entry.User = github.Ptr(e.User)
+		}
+		if e.TokenID != 0 {
+			entry.TokenID = github.Ptr(e.TokenID)
+		}
+		if e.Repo != "" {
+			entry.AdditionalFields = map[string]any{"repo": e.Repo}
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortStableFunc(entries, func(a, b *github.AuditEntry) int {
+		if query.Get("order") == "asc" {
+			return a.CreatedAt.Compare(b.CreatedAt.Time)
+		}
+		return b.CreatedAt.Compare(a.CreatedAt.Time)
+	})
+
+	// Pages are linked with the cursor of the next page's first entry, as the API does
+	start, err := decodeCursor(query.Get("after"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	start = min(start, len(entries))
+	end := min(start+s.pageSize(r), len(entries))
+	if end < len(entries) {
+		next := *r.URL
+		q := next.Query()
+		q.Set("after", encodeCursor(end))
+		next.RawQuery = q.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, absoluteURL(r, &next)))
+	}
+	writeJSON(w, http.StatusOK, entries[start:end])
+}
+
+// parsePhrase returns a function matching the audit log events selected by the search phrase.
+func parsePhrase(phrase string) (func(AuditEvent) bool, error) {
+	var checks []func(AuditEvent) bool
+	for _, term := range strings.Fields(phrase) {
+		key, value, ok := strings.Cut(term, ":")
+		if !ok {
+			return nil, fmt.Errorf("unsupported search term %q", term)
+		}
+		switch key {
+		case "action", "actor", "org", "repo", "user":
+			checks = append(checks, func(e AuditEvent) bool {
+				field := map[string]string{"action": e.Action, "actor": e.Actor, "org": e.Org, "repo": e.Repo, "user": e.User}[key]
+				return field == value || (key == "action" && !strings.Contains(value, ".") && strings.HasPrefix(field, value+"."))
+			})
+		case "created":
+			op := strings.TrimRight(value[:min(2, len(value))], "0123456789")
+			t, err := time.Parse(time.RFC3339, strings.TrimPrefix(value, op))
+			if err != nil {
+				if t, err = time.Parse("2006-01-02", strings.TrimPrefix(value, op)); err != nil {
+					return nil, fmt.Errorf("invalid created qualifier %q", value)
+				}
+			}
+			checks = append(checks, func(e AuditEvent) bool {
+				switch op {
+				case ">=":
+					return !e.CreatedAt.Before(t)
+				case ">":
+					return e.CreatedAt.After(t)
+				case "<=":
+					return !e.CreatedAt.After(t)
+				case "<":
+					return e.CreatedAt.Before(t)
+				}
+				return e.CreatedAt.Format("2006-01-02") == t.Format("2006-01-02")
+			})
+		default:
+			return nil, fmt.Errorf("unsupported search qualifier %q", key)
+		}
+	}
+	return func(e AuditEvent) bool {
+		for _, check := range checks {
+			if !check(e) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// orgHandler serves a request for an organization of the fixture, and 404 for others.
+func (s *Server) orgHandler(serve func(http.ResponseWriter, *http.Request, *Organization)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		o := s.fixture.organization(r.PathValue("org"))
+		if o == nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		serve(w, r, o)
+	}
+}
+
+// teamHandler serves a request for a team of the fixture, and 404 for others.
+func (s *Server) teamHandler(serve func(http.ResponseWriter, *http.Request, *Organization, *Team)) http.HandlerFunc {
+	return s.orgHandler(func(w http.ResponseWriter, r *http.Request, o *Organization) {
+		for i := range o.Teams {
+			if o.Teams[i].Slug == r.PathValue("team") {
+				serve(w, r, o, &o.Teams[i])
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "Not Found")
+	})
+}
+
+// repoHandler serves a request for a repository of the fixture, and 404 for others.
+func (s *Server) repoHandler(serve func(http.ResponseWriter, *http.Request, *Organization, *Repository)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		o := s.fixture.organization(r.PathValue("owner"))
+		if o == nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		repo := o.repository(r.PathValue("repo"))
+		if repo == nil {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		serve(w, r, o, repo)
+	}
+}
+
+// pageSize returns the items per page the request asks for, capped at the server's page size.
+func (s *Server) pageSize(r *http.Request) int {
+	size, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || size <= 0 {
+		size = 30
+	}
+	return min(size, s.opts.PageSize)
+}
+
+// writePage writes the page of the items the request's page parameter selects, with Link headers
+// to the next and last pages as the API sends them.
+func writePage[T any](w http.ResponseWriter, r *http.Request, size int, items []T) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pages := max((len(items)+size-1)/size, 1)
+
+	var links []string
+	link := func(page int, rel string) {
+		u := *r.URL
+		q := u.Query()
+		q.Set("page", strconv.Itoa(page))
+		q.Set("per_page", strconv.Itoa(size))
+		u.RawQuery = q.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, absoluteURL(r, &u), rel))
+	}
+	if page < pages {
+		link(page+1, "next")
+		link(pages, "last")
+	}
+	if page > 1 {
+		link(1, "first")
+		link(page-1, "prev")
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	start := min((page-1)*size, len(items))
+	end := min(start+size, len(items))
+	writeJSON(w, http.StatusOK, items[start:end])
+}
+
+// absoluteURL returns the URL as the client addressed the server.
+func absoluteURL(r *http.Request, u *url.URL) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	abs := *u
+	abs.Scheme, abs.Host = scheme, r.Host
+	return abs.String()
+}
+
+// encodeCursor returns the opaque cursor of the item at the offset.
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte("cursor:" + strconv.Itoa(offset)))
+}
+
+// decodeCursor returns the offset of a cursor, or 0 for an empty cursor.
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(data), "cursor:"))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return offset, nil
+}
+
+// permissions returns the repository permissions map of a permission level, which includes the
+// lower levels. The levels write and read are the push and pull permissions.
+func permissions(level string) map[string]bool {
+	levels := []string{"pull", "triage", "push", "maintain", "admin"}
+	switch level {
+	case "write":
+		level = "push"
+	case "read":
+		level = "pull"
+	}
+	granted := make(map[string]bool, len(levels))
+	for i, l := range levels {
+		granted[l] = i <= slices.Index(levels, level)
+	}
+	return granted
+}
+
+// restUser returns the fixture user with the login as the REST API lists users.
+func (s *Server) restUser(login string) *github.User {
+	u := &github.User{Login: github.Ptr(login), Type: github.Ptr("User")}
+	if user := s.fixture.user(login); user != nil {
+		u.ID = github.Ptr(user.ID)
+		u.NodeID = github.Ptr(nodeID("U", user.ID))
+		u.SiteAdmin = github.Ptr(false)
+	}
+	return u
+}
+
+// restOrganization returns the organization as the REST API gets it.
+func (s *Server) restOrganization(o *Organization) map[string]any {
+	// A map rather than github.Organization, so fixtures can set any field the API returns
+	org := map[string]any{
+		"login":                          o.Login,
+		"id":                             o.ID,
+		"node_id":                        nodeID("O", o.ID),
+		"name":                           o.Name,
+		"type":                           "Organization",
+		"default_repository_permission":  o.DefaultRepoPermission,
+		"two_factor_requirement_enabled": o.TwoFactorRequired,
+	}
+	for k, v := range o.Settings {
+		org[k] = v
+	}
+	return org
+}
+
+// restRepository returns the repository as the REST API lists it. A team's repositories carry the
+// team's permission.
+func (s *Server) restRepository(o *Organization, r *Repository, permission string) *github.Repository {
+	repo := &github.Repository{
+		ID:            github.Ptr(r.ID),
+		NodeID:        github.Ptr(nodeID("R", r.ID)),
+		Name:          github.Ptr(r.Name),
+		FullName:      github.Ptr(o.Login + "/" + r.Name),
+		Owner:         &github.User{Login: github.Ptr(o.Login), ID: github.Ptr(o.ID), Type: github.Ptr("Organization")},
+		Private:       github.Ptr(r.Visibility != "public"),
+		Visibility:    github.Ptr(r.Visibility),
+		Archived:      github.Ptr(r.Archived),
+		Fork:          github.Ptr(r.Parent != ""),
+		DefaultBranch: github.Ptr(r.DefaultBranch),
+		Topics:        r.Topics,
+	}
+	if r.Language != "" {
+		repo.Language = github.Ptr(r.Language)
+	}
+	if r.Empty {
+		repo.Size = github.Ptr(0)
+	}
+	for field, t := range map[**github.Timestamp]time.Time{&repo.CreatedAt: r.CreatedAt, &repo.UpdatedAt: r.UpdatedAt, &repo.PushedAt: r.PushedAt} {
+		if !t.IsZero() {
+			*field = &github.Timestamp{Time: t}
+		}
+	}
+	if permission != "" {
+		repo.Permissions = permissions(permission)
+		repo.RoleName = github.Ptr(permission)
+	}
+	return repo
+}
+
+// restTeam returns the team as the REST API lists it. A repository's teams carry their permission
+// on the repository.
+func restTeam(o *Organization, t *Team, permission string) *github.Team {
+	team := &github.Team{
+		ID:           github.Ptr(t.ID),
+		NodeID:       github.Ptr(nodeID("T", t.ID)),
+		Name:         github.Ptr(t.Name),
+		Slug:         github.Ptr(t.Slug),
+		Privacy:      github.Ptr(t.Privacy),
+		Organization: &github.Organization{Login: github.Ptr(o.Login), ID: github.Ptr(o.ID)},
+		MembersCount: github.Ptr(len(t.Members)),
+		ReposCount:   github.Ptr(len(t.Repositories)),
+	}
+	if t.Description != "" {
+		team.Description = github.Ptr(t.Description)
+	}
+	if permission != "" {
+		team.Permission = github.Ptr(permission)
+		team.Permissions = permissions(permission)
+	}
+	return team
+}
+
+// nodeID returns a global node ID for the object of the kind, e.g. "U" for users.
+func nodeID(kind string, id int64) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s_%d", kind, id)))
+}
@@ -0,0 +1,399 @@
+// Package fakegithub runs an in-process HTTP server emulating the GitHub REST and GraphQL
+// endpoints the api package uses, seeded from a fixture describing a synthetic enterprise.
+package fakegithub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// handleGraphQL executes a GraphQL query against the fixture.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Problems parsing JSON")
+		return
+	}
+	selections, err := parseQuery(req.Query)
+	if err != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"errors": []graphQLError{{Message: "Parse error: " + err.Error()}}})
+		return
+	}
+
+	data, errs := execute(selections, s.query(), req.Variables, nil)
+	resp := map[string]any{"data": data}
+	if len(errs) > 0 {
+		resp["errors"] = errs
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// query returns the root of the GraphQL schema.
+func (s *Server) query() object {
+	return object{
+		"__typename": "Query",
+		"enterprise": resolver(func(args map[string]any) (any, error) {
+			slug, _ := args["slug"].(string)
+			if slug != s.fixture.Enterprise {
+				return nil, &notFoundError{"Enterprise", "slug", slug}
+			}
+			return s.enterprise(), nil
+		}),
+		"organization": resolver(func(args map[string]any) (any, error) {
+			login, _ := args["login"].(string)
+			o := s.fixture.organization(login)
+			if o == nil {
+				return nil, &notFoundError{"Organization", "login", login}
+			}
+			return s.organization(o), nil
+		}),
+		"user": resolver(func(args map[string]any) (any, error) {
+			login, _ := args["login"].(string)
+			u := s.fixture.user(login)
+			if u == nil {
+				return nil, &notFoundError{"User", "login", login}
+			}
+			return s.user(u), nil
+		}),
+		"repository": resolver(func(args map[string]any) (any, error) {
+			owner, _ := args["owner"].(string)
+			name, _ := args["name"].(string)
+			o, r := s.fixture.repository(owner + "/" + name)
+			if r == nil {
+				return nil, &notFoundError{"Repository", "name", owner + "/" + name}
+			}
+			return s.repository(o, r), nil
+		}),
+		"rateLimit": s.graphQLRateLimit(),
+	}
+}
+
+// graphQLRateLimit returns the GraphQL rate limit, which the query being served has already drawn from.
+func (s *Server) graphQLRateLimit() object {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.budgets["graphql"]
+	return object{
+		"cost":      1,
+		"limit":     b.limit,
+		"remaining": b.limit - b.used,
+		"used":      b.used,
+		"resetAt":   b.reset.UTC().Format(time.RFC3339),
+		"nodeCount": 0,
+	}
+}
+
+// enterprise returns the fixture's enterprise.
+func (s *Server) enterprise() object {
+	orgs := make([]object, 0, len(s.fixture.Organizations))
+	for i := range s.fixture.Organizations {
+		orgs = append(orgs, object{"node": s.organization(&s.fixture.Organizations[i])})
+	}
+
+	var members, admins []object
+	for i := range s.fixture.Users {
+		u := &s.fixture.Users[i]
+		members = append(members, object{"node": object{
+			"__typename": "EnterpriseUserAccount",
+			"id":         nodeID("EUA", u.ID),
+			"login":      u.Login,
+			"name":       u.Name,
+			"createdAt":  formatTime(u.CreatedAt),
+			"user":       s.user(u),
+		}})
+		if u.Role != "" {
+			admins = append(admins, object{"role": u.Role, "node": s.user(u)})
+		}
+	}
+
+	return object{
+		"__typename":    "Enterprise",
+		"id":            nodeID("E", 1),
+		"slug":          s.fixture.Enterprise,
+		"name":          s.fixture.Enterprise,
+		"organizations": s.connection(orgs),
+		"members": resolver(func(args map[string]any) (any, error) {
+			// Every fixture user is a member of the Server deployment when the fixture describes
+			// GitHub Enterprise Server, and of the Cloud deployment otherwise
+			deployment := "CLOUD"
+			if s.fixture.ServerVersion != "" {
+				deployment = "SERVER"
+			}
+			if d, ok := args["deployment"].(string); ok && d != deployment {
+				return s.page(nil, args)
+			}
+			return s.page(members, args)
+		}),
+		"ownerInfo": object{
+			"__typename":                "EnterpriseOwnerInfo",
+			"admins":                    s.connection(admins),
+			"ipAllowListEnabledSetting": "DISABLED",
+			"samlIdentityProvider":      s.identityProvider(),
+			"oidcProvider":              object(nil),
+		},
+	}
+}
+
+// identityProvider returns the enterprise's SAML identity provider, which has an identity for every
+// user with an email, or nil when no user has one.
+func (s *Server) identityProvider() object {
+	var identities []object
+	for i := range s.fixture.Users {
+		u := &s.fixture.Users[i]
+		if u.Email == "" {
+			continue
+		}
+		identity := object{
+			"__typename":   "ExternalIdentity",
+			"emails":       []object{{"value": u.Email, "primary": true}},
+			"nameId":       u.Email,
+			"username":     u.Login,
+			"scimIdentity": object(nil),
+		}
+		identities = append(identities, object{"node": object{
+			"__typename":   "ExternalIdentity",
+			"guid":         nodeID("EI", u.ID),
+			"user":         s.user(u),
+			"samlIdentity": identity,
+			"scimIdentity": object(nil),
+		}})
+	}
+	if len(identities) == 0 {
+		return nil
+	}
+	return object{
+		"__typename": "EnterpriseIdentityProvider",
+		"id":         nodeID("EIP", 1),
+		"externalIdentities": resolver(func(args map[string]any) (any, error) {
+			login, ok := args["login"].(string)
+			if !ok {
+				return s.page(identities, args)
+			}
+			var matching []object
+			for _, identity := range identities {
+				if user := identity["node"].(object)["user"].(object); strings.EqualFold(user["login"].(string), login) {
+					matching = append(matching, identity)
+				}
+			}
+			return s.page(matching, args)
+		}),
+	}
+}
+
+// organization returns an organization of the fixture.
+func (s *Server) organization(o *Organization) object {
+	members := make([]object, 0, len(o.Members))
+	for _, m := range o.Members {
+		u := s.fixture.user(m.Login)
+		members = append(members, object{
+			"role":                strings.ToUpper(m.Role),
+			"hasTwoFactorEnabled": u.TwoFactor,
+			"node":                s.user(u),
+		})
+	}
+	repos := make([]object, 0, len(o.Repositories))
+	for i := range o.Repositories {
+		repos = append(repos, object{"node": s.repository(o, &o.Repositories[i])})
+	}
+	teams := make([]object, 0, len(o.Teams))
+	for _, t := range o.Teams {
+		teams = append(teams, object{"node": object{
+			"__typename": "Team",
+			"id":         nodeID("T", t.ID),
+			"databaseId": t.ID,
+			"name":       t.Name,
+			"slug":       t.Slug,
+		}})
+	}
+	ipAllowList := "DISABLED"
+	if o.IPAllowList {
+		ipAllowList = "ENABLED"
+	}
+	return object{
+		"__typename":                "Organization",
+		"id":                        nodeID("O", o.ID),
+		"databaseId":                o.ID,
+		"login":                     o.Login,
+		"name":                      o.Name,
+		"membersWithRole":           s.connection(members),
+		"repositories":              s.connection(repos),
+		"teams":                     s.connection(teams),
+		"ipAllowListEnabledSetting": ipAllowList,
+		"samlIdentityProvider":      object(nil),
+	}
+}
+
+// user returns a user of the fixture.
+func (s *Server) user(u *User) object {
+	return object{
+		"__typename": "User",
+		"id":         nodeID("U", u.ID),
+		"databaseId": u.ID,
+		"login":      u.Login,
+		"name":       u.Name,
+		"email":      u.Email,
+		"createdAt":  formatTime(u.CreatedAt),
+		"contributionsCollection": resolver(func(args map[string]any) (any, error) {
+			return contributions(u, args)
+		}),
+	}
+}
+
+// contributions returns the user's contributions collection between the from and to arguments,
+// which default to the year before now.
+func contributions(u *User, args map[string]any) (object, error) {
+	to := time.Now()
+	if v, ok := args["to"].(string); ok {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to: %v", v)
+		}
+		to = t
+	}
+	from := to.AddDate(-1, 0, 0)
+	if v, ok := args["from"].(string); ok {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from: %v", v)
+		}
+		from = t
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		return nil, fmt.Errorf("The total time spanned by 'from' and 'to' must not exceed 1 year")
+	}
+
+	// Days are reported as a single week, which is all the consumers read
+	var days []object
+	for _, day := range slices.Sorted(slices.Values(u.Contributions)) {
+		date, _ := time.Parse("2006-01-02", day)
+		if date.Before(from.Truncate(24*time.Hour)) || date.After(to) {
+			continue
+		}
+		days = append(days, object{"date": day, "contributionCount": 1, "weekday": int(date.Weekday())})
+	}
+	total := len(days)
+	return object{
+		"__typename":                          "ContributionsCollection",
+		"totalCommitContributions":            total,
+		"totalIssueContributions":             0,
+		"totalPullRequestContributions":       0,
+		"totalPullRequestReviewContributions": 0,
+		"hasAnyContributions":                 total > 0,
+		"hasAnyRestrictedContributions":       false,
+		"latestRestrictedContributionDate":    nil,
+		"contributionCalendar": object{
+			"totalContributions": total,
+			"weeks":              []object{{"contributionDays": days}},
+		},
+	}, nil
+}
+
+// repository returns a repository of the fixture. Its default branch is its only branch, last
+// committed to when the repository was pushed to.
+func (s *Server) repository(o *Organization, r *Repository) object {
+	repo := object{
+		"__typename":       "Repository",
+		"id":               nodeID("R", r.ID),
+		"databaseId":       r.ID,
+		"name":             r.Name,
+		"nameWithOwner":    o.Login + "/" + r.Name,
+		"isEmpty":          r.Empty,
+		"isArchived":       r.Archived,
+		"isFork":           r.Parent != "",
+		"defaultBranchRef": object{"name": r.DefaultBranch},
+		"latestRelease":    object(nil),
+		"parent":           object(nil),
+		"pullRequests":     resolver(func(map[string]any) (any, error) { return object{"totalCount": 0, "nodes": []object{}}, nil }),
+		"issues":           resolver(func(map[string]any) (any, error) { return object{"totalCount": 0, "nodes": []object{}}, nil }),
+	}
+	var branches []object
+	if !r.Empty {
+		branches = append(branches, object{"node": object{
+			"__typename": "Ref",
+			"name":       r.DefaultBranch,
+			"target": object{
+				"__typename":    "Commit",
+				"committedDate": formatTime(r.PushedAt),
+			},
+		}})
+	}
+	repo["refs"] = s.connection(branches)
+	if r.Parent != "" {
+		if po, parent := s.fixture.repository(r.Parent); parent != nil {
+			repo["parent"] = object{
+				"nameWithOwner":    po.Login + "/" + parent.Name,
+				"defaultBranchRef": object{"name": parent.DefaultBranch},
+			}
+		}
+	}
+	return repo
+}
+
+// connection returns a resolver paginating the edges with the first and after arguments. Each edge
+// holds its node and the fields of the edge itself, such as a member's role.
+func (s *Server) connection(edges []object) resolver {
+	return func(args map[string]any) (any, error) {
+		return s.page(edges, args)
+	}
+}
+
+// page returns the page of the edges the first and after arguments select, capped at the server's
+// page size.
+func (s *Server) page(edges []object, args map[string]any) (any, error) {
+	size := s.opts.PageSize
+	if first, ok := args["first"].(float64); ok {
+		if first < 1 || first > maxPageSize {
+			return nil, fmt.Errorf("Requesting %v records on the connection exceeds the `first` limit of %d records.", first, maxPageSize)
+		}
+		size = min(size, int(first))
+	}
+	start := 0
+	if after, ok := args["after"].(string); ok {
+		offset, err := decodeCursor(after)
+		if err != nil {
+			return nil, fmt.Errorf("`%s` does not appear to be a valid cursor.", after)
+		}
+		start = min(offset+1, len(edges))
+	}
+	end := min(start+size, len(edges))
+
+	nodes := make([]object, 0, end-start)
+	page := make([]object, 0, end-start)
+	for i, edge := range edges[start:end] {
+		nodes = append(nodes, edge["node"].(object))
+		e := object{"cursor": encodeCursor(start + i)}
+		for k, v := range edge {
+			e[k] = v
+		}
+		page = append(page, e)
+	}
+	endCursor := any(nil)
+	if end > start {
+		endCursor = encodeCursor(end - 1)
+	}
+	return object{
+		"totalCount": len(edges),
+		"nodes":      nodes,
+		"edges":      page,
+		"pageInfo": object{
+			"hasNextPage":     end < len(edges),
+			"hasPreviousPage": start > 0,
+			"endCursor":       endCursor,
+		},
+	}, nil
+}
+
+// formatTime formats a time as a GraphQL DateTime, or null for the zero time.
+func formatTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,303 @@
+// Package fakegithub runs an in-process HTTP server emulating the GitHub REST and GraphQL
+// endpoints the api package uses, seeded from a fixture describing a synthetic enterprise.
+package fakegithub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/shurcooL/githubv4"
+)
+
+const (
+	// DefaultRateLimit is the hourly budget of each rate limit resource.
+	DefaultRateLimit = 5000
+
+	// maxPageSize is the largest page the API returns.
+	maxPageSize = 100
+)
+
+// Options configure a Server.
+type Options struct {
+	// PageSize caps the items per page of REST listings and GraphQL connections, so small fixtures
+	// still span several pages. Defaults to the API's maximum of 100.
+	PageSize int
+
+	// RateLimit is the budget of each rate limit resource, which resets an hour after the server
+	// starts. Requests beyond it fail as rate limited. Defaults to DefaultRateLimit.
+	RateLimit int
+
+	// Latency delays every response.
+	Latency time.Duration
+}
+
+// Fault makes matching requests fail or respond slowly.
+type Fault struct {
+	Method string // HTTP method of the requests; empty matches any
+	// Path of the requests, e.g. "/orgs/acme/repos". A trailing * matches the paths with the prefix.
+	// GraphQL requests have the path /graphql.
+	Path string
+	// Query is a text the GraphQL query must contain, e.g. "membersWithRole"; empty matches any.
+	Query string
+
+	// Status is the status to respond with. A GraphQL request with status 200 is answered with
+	// a GraphQL error. Zero only adds the delay.
+	Status  int
+	Message string        // Message of the error; defaults to the status text
+	Delay   time.Duration // Delay before responding
+	Times   int           // Number of requests to affect; zero affects every matching request
+}
+
+// fault is an injected fault with the requests it has affected.
+type fault struct {
+	Fault
+	hits int
+}
+
+// matches reports whether the fault applies to the request, whose GraphQL query is given.
+func (f *fault) matches(r *http.Request, query string) bool {
+	if f.Times > 0 && f.hits >= f.Times {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(f.Path, "*"); ok {
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			return false
+		}
+	} else if f.Path != "" && r.URL.Path != f.Path {
+		return false
+	}
+	return f.Query == "" || strings.Contains(query, f.Query)
+}
+
+// budget is the rate limit of a resource.
+type budget struct {
+	limit int
+	used  int
+	reset time.Time
+}
+
+// Server emulates the GitHub API of the fixture's enterprise. It serves the REST API from the root
+// and GraphQL at /graphql, as well as under the /api/v3 and /api/graphql paths of GitHub Enterprise
+// Server, so clients can reach it with either a base URL or a hostname. Endpoints the fixture has no
+// data for answer 404 Not Found, like an API the token cannot see.
+type Server struct {
+	fixture *Fixture
+	opts    Options
+	mux     *http.ServeMux
+	ts      *httptest.Server
+
+	mu       sync.Mutex
+	faults   []*fault
+	budgets  map[string]*budget // Rate limit by resource
+	requests []string           // Method and URL of every request served
+}
+
+// NewServer creates a server for the fixture. Serve it with Start, or as an http.Handler.
+func NewServer(fixture *Fixture, opts Options) *Server {
+	if opts.PageSize <= 0 || opts.PageSize > maxPageSize {
+		opts.PageSize = maxPageSize
+	}
+	if opts.RateLimit <= 0 {
+		opts.RateLimit = DefaultRateLimit
+	}
+	s := &Server{
+		fixture: fixture,
+		opts:    opts,
+		mux:     http.NewServeMux(),
+		budgets: make(map[string]*budget),
+	}
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	for _, resource := range []string{"core", "graphql", "search", "audit_log"} {
+		s.budgets[resource] = &budget{limit: opts.RateLimit, reset: reset}
+	}
+	s.routes()
+	return s
+}
+
+// Start serves the server on a local port and returns its URL.
+func (s *Server) Start() string {
+	s.ts = httptest.NewServer(s)
+	slog.Debug("fake GitHub API started", "url", s.ts.URL, "enterprise", s.fixture.Enterprise)
+	return s.ts.URL
+}
+
+// Close stops a started server.
+func (s *Server) Close() {
+	if s.ts != nil {
+		s.ts.Close()
+	}
+}
+
+// URL returns the URL of a started server.
+func (s *Server) URL() string {
+	if s.ts == nil {
+		return ""
+	}
+	return s.ts.URL
+}
+
+// RESTClient returns a REST client for the started server, which it reaches through the /api/v3
+// paths of GitHub Enterprise Server.
+func (s *Server) RESTClient() *github.Client {
+	client, err := github.NewClient(s.ts.Client()).WithEnterpriseURLs(s.ts.URL, s.ts.URL)
+	if err != nil {
+		// The URL of a started test server always parses
+		panic(err)
+	}
+	return client
+}
+
+// GraphQLClient returns a GraphQL client for the started server.
+func (s *Server) GraphQLClient() *githubv4.Client {
+	return githubv4.NewEnterpriseClient(s.ts.URL+"/graphql", s.ts.Client())
+}
+
+// Inject adds a fault affecting the requests it matches, in addition to the faults already injected.
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault{Fault: f})
+}
+
+// Requests returns the method and URL of every request served so far, e.g. "GET /orgs/acme/repos?page=2".
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// ServeHTTP serves a request, applying the latency, faults and rate limits.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Serve the paths of GitHub Enterprise Server like those of GitHub.com
+	switch {
+	case r.URL.Path == "/api/graphql":
+		r.URL.Path = "/graphql"
+	case strings.HasPrefix(r.URL.Path, "/api/v3/"):
+		r.URL.Path = strings.TrimPrefix(r.URL.Path, "/api/v3")
+	}
+
+	// The GraphQL query is read for the faults, so the body is buffered for the handler
+	var query string
+	if r.URL.Path == "/graphql" && r.Body != nil {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "failed to read body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		query = string(body)
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+	var matched *Fault
+	for _, f := range s.faults {
+		if f.matches(r, query) {
+			f.hits++
+			matched = &f.Fault
+			break
+		}
+	}
+	resource := requestResource(r)
+	b := s.budgets[resource]
+	exhausted := b.used >= b.limit
+	if !exhausted {
+		b.used++
+	}
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(b.limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(b.limit-b.used))
+	w.Header().Set("X-RateLimit-Used", strconv.Itoa(b.used))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(b.reset.Unix(), 10))
+	w.Header().Set("X-RateLimit-Resource", resource)
+	s.mu.Unlock()
+
+	delay := s.opts.Latency
+	if matched != nil {
+		delay += matched.Delay
+	}
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case exhausted:
+		writeError(w, http.StatusForbidden, fmt.Sprintf("API rate limit exceeded for %s.", resource))
+	case matched != nil && matched.Status == http.StatusOK && r.URL.Path == "/graphql":
+		writeJSON(w, http.StatusOK, map[string]any{"errors": []graphQLError{{Message: faultMessage(matched)}}})
+	case matched != nil && matched.Status != 0:
+		writeError(w, matched.Status, faultMessage(matched))
+	default:
+		s.mux.ServeHTTP(w, r)
+	}
+}
+
+// faultMessage returns the message of an injected error.
+func faultMessage(f *Fault) string {
+	if f.Message != "" {
+		return f.Message
+	}
+	return http.StatusText(f.Status)
+}
+
+// requestResource returns the rate limit resource a request draws from.
+func requestResource(r *http.Request) string {
+	switch path := r.URL.Path; {
+	case path == "/graphql":
+		return "graphql"
+	case strings.HasSuffix(path, "/audit-log"):
+		return "audit_log"
+	case strings.HasPrefix(path, "/search/"):
+		return "search"
+	}
+	return "core"
+}
+
+// rateLimits returns the rate limits as the rate_limit endpoint reports them.
+func (s *Server) rateLimits() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resources := make(map[string]any, len(s.budgets))
+	for name, b := range s.budgets {
+		resources[name] = map[string]any{
+			"limit":     b.limit,
+			"used":      b.used,
+			"remaining": b.limit - b.used,
+			"reset":     b.reset.Unix(),
+		}
+	}
+	return map[string]any{"resources": resources, "rate": resources["core"]}
+}
+
+// writeJSON writes the value as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Debug("failed to write fake response", "error", err)
+	}
+}
+
+// writeError writes an error response like the REST API's.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{
+		"message":           message,
+		"documentation_url": "https://docs.github.com/rest",
+	})
+}
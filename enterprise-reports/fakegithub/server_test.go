@@ -0,0 +1,219 @@
+// Package fakegithub runs an in-process HTTP server emulating the GitHub REST and GraphQL
+// endpoints the api package uses, seeded from a fixture describing a synthetic enterprise.
+package fakegithub
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFixture is a small enterprise whose listings span several pages of two items.
+const testFixture = `{
+	"enterprise": "acme",
+	"users": [
+		{"login": "alice", "email": "alice@acme.example", "role": "OWNER", "two_factor": true},
+		{"login": "bob", "email": "bob@acme.example"},
+		{"login": "carol"}
+	],
+	"organizations": [
+		{"login": "acme-a", "members": [{"login": "alice", "role": "admin"}, {"login": "bob"}, {"login": "carol"}],
+		 "repositories": [{"name": "one"}, {"name": "two"}, {"name": "three", "visibility": "internal"}]},
+		{"login": "acme-b", "members": [{"login": "alice", "role": "admin"}]},
+		{"login": "acme-c"}
+	],
+	"audit_log": [
+		{"action": "user.login", "actor": "alice", "created_at": "2024-05-01T10:00:00Z"},
+		{"action": "user.login", "actor": "bob", "created_at": "2024-05-02T10:00:00Z"},
+		{"action": "git.push", "actor": "bob", "created_at": "2024-05-03T10:00:00Z", "org": "acme-a", "repo": "acme-a/one"},
+		{"action": "repo.create", "actor": "alice", "created_at": "2024-05-04T10:00:00Z", "org": "acme-a", "repo": "acme-a/two"}
+	]
+}`
+
+// newTestServer starts a server for testFixture with pages of two items.
+func newTestServer(t *testing.T, opts Options) *Server {
+	t.Helper()
+	fixture, err := ParseFixture([]byte(testFixture))
+	require.NoError(t, err)
+	if opts.PageSize == 0 {
+		opts.PageSize = 2
+	}
+	srv := NewServer(fixture, opts)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestServer_RESTPagination tests that REST listings are paged with Link headers the client follows.
+func TestServer_RESTPagination(t *testing.T) {
+	srv := newTestServer(t, Options{})
+	ctx := context.Background()
+
+	repos, err := api.FetchOrganizationRepositories(ctx, srv.RESTClient(), "acme-a")
+	require.NoError(t, err)
+	var names []string
+	for _, r := range repos {
+		names = append(names, r.GetName())
+	}
+	assert.Equal(t, []string{"one", "two", "three"}, names)
+	assert.Equal(t, "internal", repos[2].GetVisibility())
+
+	var pages int
+	for _, req := range srv.Requests() {
+		if strings.HasPrefix(req, "GET /orgs/acme-a/repos") {
+			pages++
+		}
+	}
+	assert.Equal(t, 2, pages)
+}
+
+// TestServer_GraphQL tests that GraphQL connections are paged and batched lookups resolve, with
+// unknown users reported as not found.
+func TestServer_GraphQL(t *testing.T) {
+	srv := newTestServer(t, Options{})
+	ctx := context.Background()
+	client := srv.GraphQLClient()
+
+	orgs, err := api.FetchEnterpriseOrgs(ctx, client, "acme")
+	require.NoError(t, err)
+	require.Len(t, orgs, 3)
+	assert.Equal(t, "acme-c", orgs[2].GetLogin())
+
+	members, err := api.FetchOrganizationMembershipsWithRole(ctx, client, "acme-a")
+	require.NoError(t, err)
+	require.Len(t, members, 3)
+	assert.Equal(t, "admin", members[0].GetRoleName())
+
+	emails, err := api.FetchUserEmails(ctx, client, "acme", []string{"alice", "carol", "nobody"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice@acme.example", emails["alice"])
+	assert.Equal(t, "N/A", emails["carol"])
+
+	_, err = api.FetchEnterpriseOrgs(ctx, client, "other")
+	assert.ErrorContains(t, err, "Could not resolve to a Enterprise")
+}
+
+// TestServer_AuditLog tests that the audit log is filtered by phrase and include, sorted, and paged by cursor.
+func TestServer_AuditLog(t *testing.T) {
+	srv := newTestServer(t, Options{})
+	ctx := context.Background()
+
+	var actors []string
+	err := api.StreamAuditLog(ctx, srv.RESTClient(), "acme", api.AuditLogQuery{Phrase: "action:user.login", Include: "web"},
+		func(entries []*github.AuditEntry, next string) error {
+			for _, e := range entries {
+				actors = append(actors, e.GetActor())
+			}
+			return nil
+		})
+	require.NoError(t, err)
+	require.Len(t, actors, 2)
+	assert.Equal(t, "bob", actors[0]) // Newest first
+
+	var actions []string
+	err = api.StreamAuditLog(ctx, srv.RESTClient(), "acme", api.AuditLogQuery{Phrase: "created:>=2024-05-02", Include: "all", Order: "asc"},
+		func(entries []*github.AuditEntry, next string) error {
+			for _, e := range entries {
+				actions = append(actions, e.GetAction())
+			}
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user.login", "git.push", "repo.create"}, actions)
+}
+
+// TestServer_RateLimit tests that every response reports the budget of its resource and that
+// requests beyond it fail as rate limited.
+func TestServer_RateLimit(t *testing.T) {
+	srv := newTestServer(t, Options{RateLimit: 2})
+	client := srv.RESTClient()
+	ctx := context.Background()
+
+	_, resp, err := client.Organizations.Get(ctx, "acme-a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.Rate.Limit)
+	assert.Equal(t, 1, resp.Rate.Remaining)
+
+	_, _, err = client.Organizations.Get(ctx, "acme-b")
+	require.NoError(t, err)
+
+	_, _, err = client.Organizations.Get(ctx, "acme-c")
+	var rateErr *github.RateLimitError
+	assert.ErrorAs(t, err, &rateErr)
+
+	// GraphQL draws from its own budget
+	graphQL := srv.rateLimits()["resources"].(map[string]any)["graphql"].(map[string]any)
+	assert.Equal(t, 2, graphQL["remaining"])
+}
+
+// TestServer_Faults tests that injected faults fail the requests they match, up to their count.
+func TestServer_Faults(t *testing.T) {
+	srv := newTestServer(t, Options{})
+	ctx := context.Background()
+
+	srv.Inject(Fault{Path: "/orgs/acme-a", Status: http.StatusBadGateway, Times: 1})
+	_, err := api.FetchOrganization(ctx, srv.RESTClient(), "acme-a")
+	assert.ErrorContains(t, err, "502")
+	org, err := api.FetchOrganization(ctx, srv.RESTClient(), "acme-a")
+	require.NoError(t, err)
+	assert.Equal(t, "read", org.GetDefaultRepoPermission())
+
+	srv.Inject(Fault{Path: "/graphql", Query: "membersWithRole", Status: http.StatusOK, Message: "Something went wrong"})
+	_, err = api.FetchOrganizationMembershipsWithRole(ctx, srv.GraphQLClient(), "acme-a")
+	assert.ErrorContains(t, err, "Something went wrong")
+	_, err = api.FetchEnterpriseOrgs(ctx, srv.GraphQLClient(), "acme")
+	assert.NoError(t, err)
+
+	srv.Inject(Fault{Path: "/orgs/acme-b/*", Delay: 50 * time.Millisecond})
+	start := time.Now()
+	_, err = api.FetchOrganizationMemberLogins(ctx, srv.RESTClient(), "acme-b")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+// TestServer_EnterpriseServerPaths tests that the server answers the paths of GitHub Enterprise
+// Server and reports its version when the fixture has one.
+func TestServer_EnterpriseServerPaths(t *testing.T) {
+	fixture, err := ParseFixture([]byte(`{"enterprise": "acme", "server_version": "3.14.2"}`))
+	require.NoError(t, err)
+	srv := NewServer(fixture, Options{})
+	srv.Start()
+	defer srv.Close()
+
+	server, err := api.DetectServer(context.Background(), srv.RESTClient())
+	require.NoError(t, err)
+	assert.Equal(t, "3.14.2", server.Version)
+}
+
+// TestParseFixture tests that fixtures referring to unknown users or repositories are rejected
+// and that the demo fixture loads with its activity moved to the recent past.
+func TestParseFixture(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		wantErr string
+	}{
+		{"missing enterprise", `{}`, "enterprise is required"},
+		{"unknown member", `{"enterprise": "e", "organizations": [{"login": "o", "members": [{"login": "ghost"}]}]}`, `"ghost" is not a user`},
+		{"unknown team repository", `{"enterprise": "e", "organizations": [{"login": "o", "teams": [{"name": "t", "repositories": [{"name": "r"}]}]}]}`, `"r" is not a repository`},
+		{"invalid JSON", `{`, "failed to parse fixture"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFixture([]byte(tt.fixture))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	demo, err := DemoFixture()
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), demo.ReferenceTime, 24*time.Hour)
+	assert.NotEmpty(t, demo.Organizations)
+}
@@ -3,6 +3,7 @@ package logging
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"os"
 
@@ -68,8 +69,14 @@ func (m *multiHandler) WithGroup(name string) slog.Handler {
 //
 // Returns a slog.Handler that writes to both outputs simultaneously.
 func NewMultiHandler(file *os.File, level slog.Level) slog.Handler {
+	return NewMultiHandlerWithConsole(file, os.Stderr, level)
+}
+
+// NewMultiHandlerWithConsole creates a handler like NewMultiHandler that writes the colored
+// text logs to console instead of stderr, e.g. a progress display keeping its lines below them.
+func NewMultiHandlerWithConsole(file *os.File, console io.Writer, level slog.Level) slog.Handler {
 	fileH := slog.NewJSONHandler(file, &slog.HandlerOptions{Level: level})
-	consoleH := tint.NewHandler(os.Stderr, &tint.Options{Level: level})
+	consoleH := tint.NewHandler(console, &tint.Options{Level: level})
 	return &multiHandler{handlers: []slog.Handler{consoleH, fileH}}
 }
 
@@ -85,3 +92,9 @@ func SetupLogging(file *os.File, level slog.Level) {
 	logger := slog.New(h)
 	slog.SetDefault(logger)
 }
+
+// SetupLoggingWithConsole configures the global slog logger like SetupLogging, writing the
+// terminal logs to console instead of stderr.
+func SetupLoggingWithConsole(file *os.File, console io.Writer, level slog.Level) {
+	slog.SetDefault(slog.New(NewMultiHandlerWithConsole(file, console, level)))
+}
@@ -0,0 +1,305 @@
+// Package policy evaluates user-defined compliance rules over the rows of the enterprise reports.
+package policy
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/google/cel-go/ext"
+)
+
+// program is a rule with its expressions compiled.
+type program struct {
+	rule      Rule
+	forEach   cel.Program // Nil when the rule reads whole rows
+	condition cel.Program
+	resource  cel.Program
+	message   cel.Program // Nil when the rule uses its description
+}
+
+// ruleErrors records the rows a rule failed to evaluate.
+type ruleErrors struct {
+	count int
+	first error
+}
+
+// Engine evaluates rules over report rows as the reports record them.
+// It is safe for concurrent use by the workers of several reports.
+type Engine struct {
+	programs []*program
+
+	mu         sync.Mutex
+	violations []*Violation
+	matches    map[string]map[string]int // Rule ID to resource to matches, for MaxPerResource rules
+	errors     map[string]*ruleErrors    // Rule ID to evaluation errors
+}
+
+// NewEngine compiles rules into an engine. Rule IDs must be unique.
+func NewEngine(rules []Rule) (*Engine, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("row", cel.DynType),
+		cel.Variable("item", cel.DynType),
+		ext.Strings(),
+		ext.Lists(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating policy environment: %w", err)
+	}
+
+	e := &Engine{
+		matches: make(map[string]map[string]int),
+		errors:  make(map[string]*ruleErrors),
+	}
+	seen := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[rule.ID] {
+			return nil, fmt.Errorf("policy rule %s is declared more than once", rule.ID)
+		}
+		seen[rule.ID] = true
+		if rule.Severity == "" {
+			rule.Severity = DefaultSeverity
+		}
+
+		p := &program{rule: rule}
+		for _, expr := range []struct {
+			name   string
+			source string
+			want   *cel.Type
+			prg    *cel.Program
+		}{
+			{"for-each", rule.ForEach, cel.ListType(cel.DynType), &p.forEach},
+			{"condition", rule.Condition, cel.BoolType, &p.condition},
+			{"resource", rule.Resource, cel.StringType, &p.resource},
+			{"message", rule.Message, cel.StringType, &p.message},
+		} {
+			if expr.source == "" {
+				continue
+			}
+			*expr.prg, err = compile(env, expr.source, expr.want)
+			if err != nil {
+				return nil, fmt.Errorf("policy rule %s %s: %w", rule.ID, expr.name, err)
+			}
+		}
+		e.programs = append(e.programs, p)
+	}
+	return e, nil
+}
+
+// compile parses and checks an expression. Rows are dynamically typed, so an expression is only
+// rejected when its result type is known not to be want.
+func compile(env *cel.Env, source string, want *cel.Type) (cel.Program, error) {
+	ast, issues := env.Compile(source)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if out := ast.OutputType(); out.Kind() != types.DynKind && !want.IsAssignableType(out) {
+		return nil, fmt.Errorf("expression returns %s, want %s", out, want)
+	}
+	return env.Program(ast)
+}
+
+// Reports returns the names of the reports the rules read, in rule order.
+func (e *Engine) Reports() []string {
+	var names []string
+	for _, p := range e.programs {
+		if !slices.Contains(names, p.rule.Report) {
+			names = append(names, p.rule.Report)
+		}
+	}
+	return names
+}
+
+// Uses reports whether any rule reads the named report.
+func (e *Engine) Uses(report string) bool {
+	return slices.Contains(e.Reports(), report)
+}
+
+// Recorder returns the recorder through which the named report passes its rows to the engine.
+func (e *Engine) Recorder(report string) *Recorder {
+	return &Recorder{engine: e, report: report}
+}
+
+// Recorder evaluates the rules of one report over each row it records.
+type Recorder struct {
+	engine *Engine
+	report string
+}
+
+// Record evaluates every rule that reads the recorder's report over row.
+func (r *Recorder) Record(row any) {
+	var value any
+	for _, p := range r.engine.programs {
+		if p.rule.Report != r.report {
+			continue
+		}
+		// Rows are only converted for reports that rules read
+		if value == nil {
+			value = toValue(reflect.ValueOf(row), 0)
+		}
+		if err := r.engine.evaluate(p, value); err != nil {
+			r.engine.fail(p.rule, err)
+		}
+	}
+}
+
+// evaluate applies a rule to one row.
+func (e *Engine) evaluate(p *program, row any) error {
+	vars := map[string]any{"row": row}
+	if p.forEach == nil {
+		return e.match(p, vars)
+	}
+
+	out, _, err := p.forEach.Eval(vars)
+	if err != nil {
+		return fmt.Errorf("for-each: %w", err)
+	}
+	items, ok := out.(traits.Lister)
+	if !ok {
+		return fmt.Errorf("for-each returned %s, want a list", out.Type())
+	}
+	it := items.Iterator()
+	for it.HasNext() == types.True {
+		if err := e.match(p, map[string]any{"row": row, "item": it.Next()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match records a violation, or a match of a MaxPerResource rule, when the condition holds.
+func (e *Engine) match(p *program, vars map[string]any) error {
+	out, _, err := p.condition.Eval(vars)
+	if err != nil {
+		return fmt.Errorf("condition: %w", err)
+	}
+	violates, ok := out.Value().(bool)
+	if !ok {
+		return fmt.Errorf("condition returned %s, want bool", out.Type())
+	}
+	if !violates {
+		return nil
+	}
+
+	resource, err := evalString(p.resource, vars)
+	if err != nil {
+		return fmt.Errorf("resource: %w", err)
+	}
+
+	if p.rule.MaxPerResource > 0 {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if e.matches[p.rule.ID] == nil {
+			e.matches[p.rule.ID] = make(map[string]int)
+		}
+		e.matches[p.rule.ID][resource]++
+		return nil
+	}
+
+	message := cmp.Or(p.rule.Description, p.rule.ID)
+	if p.message != nil {
+		if message, err = evalString(p.message, vars); err != nil {
+			return fmt.Errorf("message: %w", err)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.violations = append(e.violations, newViolation(p.rule, resource, message))
+	return nil
+}
+
+// evalString evaluates an expression that must return a string.
+func evalString(prg cel.Program, vars map[string]any) (string, error) {
+	out, _, err := prg.Eval(vars)
+	if err != nil {
+		return "", err
+	}
+	s, ok := out.Value().(string)
+	if !ok {
+		return "", fmt.Errorf("returned %s, want string", out.Type())
+	}
+	return s, nil
+}
+
+// fail records that a rule could not be evaluated over a row.
+func (e *Engine) fail(rule Rule, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	errs := e.errors[rule.ID]
+	if errs == nil {
+		errs = &ruleErrors{first: err}
+		e.errors[rule.ID] = errs
+		slog.Warn("policy rule could not be evaluated", "rule", rule.ID, "report", rule.Report, "error", err)
+	}
+	errs.count++
+}
+
+// newViolation creates a violation of rule by resource.
+func newViolation(rule Rule, resource, message string) *Violation {
+	return &Violation{
+		RuleID:      rule.ID,
+		Severity:    rule.Severity,
+		Report:      rule.Report,
+		Resource:    resource,
+		Message:     message,
+		Description: rule.Description,
+	}
+}
+
+// Violations returns the violations found so far, most severe first, then by rule and resource.
+func (e *Engine) Violations() []*Violation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	violations := slices.Clone(e.violations)
+	for _, p := range e.programs {
+		for resource, count := range e.matches[p.rule.ID] {
+			if count <= p.rule.MaxPerResource {
+				continue
+			}
+			message := fmt.Sprintf("%d matches, more than the allowed %d", count, p.rule.MaxPerResource)
+			if p.rule.Description != "" {
+				message = p.rule.Description + ": " + message
+			}
+			violations = append(violations, newViolation(p.rule, resource, message))
+		}
+	}
+
+	slices.SortStableFunc(violations, func(a, b *Violation) int {
+		return cmp.Or(
+			cmp.Compare(slices.Index(Severities, b.Severity), slices.Index(Severities, a.Severity)),
+			cmp.Compare(a.RuleID, b.RuleID),
+			cmp.Compare(a.Resource, b.Resource),
+		)
+	})
+	return violations
+}
+
+// Err reports the rules that could not be evaluated over every row, since their violations
+// may be incomplete.
+func (e *Engine) Err() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.errors) == 0 {
+		return nil
+	}
+	var msgs []string
+	for _, p := range e.programs {
+		if errs := e.errors[p.rule.ID]; errs != nil {
+			msgs = append(msgs, fmt.Sprintf("rule %s failed on %d rows: %v", p.rule.ID, errs.count, errs.first))
+		}
+	}
+	return fmt.Errorf("policy rules could not be evaluated: %s", strings.Join(msgs, "; "))
+}
@@ -0,0 +1,160 @@
+// Package policy evaluates user-defined compliance rules over the rows of the enterprise reports.
+// This file contains tests for the policy rule engine.
+package policy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collaboratorsRow mirrors the shape of a collaborators report row.
+type collaboratorsRow struct {
+	*github.Repository
+	Collaborators []collaborator
+}
+
+type collaborator struct {
+	Login      string
+	Permission string
+}
+
+// TestEngine_MaxPerResource tests that a for-each rule with max-per-resource reports each resource
+// matched more often than allowed across all rows, such as users with admin on too many repositories.
+func TestEngine_MaxPerResource(t *testing.T) {
+	engine, err := NewEngine([]Rule{{
+		ID:             "admin-sprawl",
+		Report:         "collaborators",
+		Severity:       "critical",
+		Description:    "Users must not administer too many repositories",
+		ForEach:        "row.Collaborators",
+		Condition:      `item.Permission == "admin"`,
+		Resource:       "item.Login",
+		MaxPerResource: 1,
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"collaborators"}, engine.Reports())
+	assert.False(t, engine.Uses("repositories"))
+
+	recorder := engine.Recorder("collaborators")
+	recorder.Record(&collaboratorsRow{Repository: &github.Repository{FullName: github.Ptr("org/a")},
+		Collaborators: []collaborator{{"alice", "admin"}, {"bob", "admin"}}})
+	recorder.Record(&collaboratorsRow{Repository: &github.Repository{FullName: github.Ptr("org/b")},
+		Collaborators: []collaborator{{"alice", "admin"}, {"bob", "push"}}})
+	// Rows of other reports are ignored
+	engine.Recorder("repositories").Record(&collaboratorsRow{Collaborators: []collaborator{{"bob", "admin"}}})
+
+	require.NoError(t, engine.Err())
+	assert.Equal(t, []*Violation{{
+		RuleID:      "admin-sprawl",
+		Severity:    "critical",
+		Report:      "collaborators",
+		Resource:    "alice",
+		Message:     "Users must not administer too many repositories: 2 matches, more than the allowed 1",
+		Description: "Users must not administer too many repositories",
+	}}, engine.Violations())
+}
+
+// TestEngine_Order tests that violations are ordered by severity, then rule and resource,
+// and that rules without a severity use the default.
+func TestEngine_Order(t *testing.T) {
+	engine, err := NewEngine([]Rule{
+		{ID: "b-rule", Report: "teams", Condition: "true", Resource: "row.Name"},
+		{ID: "a-rule", Report: "teams", Severity: "low", Condition: "true", Resource: "row.Name"},
+		{ID: "c-rule", Report: "teams", Severity: "high", Condition: "true", Resource: "row.Name"},
+	})
+	require.NoError(t, err)
+
+	engine.Recorder("teams").Record(struct{ Name string }{"t2"})
+	engine.Recorder("teams").Record(struct{ Name string }{"t1"})
+
+	var got []string
+	for _, v := range engine.Violations() {
+		got = append(got, v.Severity+" "+v.RuleID+" "+v.Resource)
+	}
+	assert.Equal(t, []string{
+		"high c-rule t1", "high c-rule t2",
+		"medium b-rule t1", "medium b-rule t2",
+		"low a-rule t1", "low a-rule t2",
+	}, got)
+}
+
+// TestEngine_EvaluationErrors tests that rows a rule cannot be evaluated over are reported as an error
+// rather than silently passing.
+func TestEngine_EvaluationErrors(t *testing.T) {
+	engine, err := NewEngine([]Rule{{
+		ID:        "owned-by-octocat",
+		Report:    "repositories",
+		Condition: `row.Repository.Owner.Login != "octocat"`,
+		Resource:  "row.Repository.FullName",
+	}})
+	require.NoError(t, err)
+
+	recorder := engine.Recorder("repositories")
+	recorder.Record(&collaboratorsRow{Repository: &github.Repository{FullName: github.Ptr("org/a")}})
+	recorder.Record(&collaboratorsRow{Repository: &github.Repository{FullName: github.Ptr("org/b")}})
+
+	assert.Empty(t, engine.Violations())
+	assert.ErrorContains(t, engine.Err(), "rule owned-by-octocat failed on 2 rows")
+}
+
+// TestNewEngine_Invalid tests that rules that cannot be compiled are rejected.
+func TestNewEngine_Invalid(t *testing.T) {
+	valid := Rule{ID: "r", Report: "teams", Condition: "true", Resource: "row.Name"}
+	for name, rule := range map[string]Rule{
+		"missing id":          {Report: "teams", Condition: "true", Resource: "row.Name"},
+		"unknown severity":    {ID: "r", Report: "teams", Severity: "urgent", Condition: "true", Resource: "row.Name"},
+		"syntax error":        {ID: "r", Report: "teams", Condition: "row.Name ==", Resource: "row.Name"},
+		"non-bool condition":  {ID: "r", Report: "teams", Condition: `"yes"`, Resource: "row.Name"},
+		"non-string resource": {ID: "r", Report: "teams", Condition: "true", Resource: "1"},
+		"message with max":    {ID: "r", Report: "teams", Condition: "true", Resource: "row.Name", Message: `"m"`, MaxPerResource: 2},
+	} {
+		_, err := NewEngine([]Rule{rule})
+		assert.Error(t, err, name)
+	}
+
+	_, err := NewEngine([]Rule{valid, valid})
+	assert.ErrorContains(t, err, "declared more than once")
+}
+
+// TestToValue tests that rows are converted into the values CEL reads.
+func TestToValue(t *testing.T) {
+	type team struct {
+		Slug   *string
+		Parent *string
+	}
+	type row struct {
+		*team
+		ID        int64
+		Private   bool
+		CreatedAt *github.Timestamp
+		Topics    []string
+		Members   []string
+		Labels    map[string]int
+		secret    string
+	}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	got := toValue(reflect.ValueOf(&row{
+		team:      &team{Slug: github.Ptr("eng")},
+		ID:        7,
+		Private:   true,
+		CreatedAt: &github.Timestamp{Time: created},
+		Topics:    []string{"go"},
+		Labels:    map[string]int{"a": 1},
+		secret:    "hidden",
+	}), 0)
+
+	assert.Equal(t, map[string]any{
+		"ID":        int64(7),
+		"Private":   true,
+		"CreatedAt": created,
+		"Topics":    []any{"go"},
+		"Members":   []any{},
+		"Labels":    map[string]any{"a": int64(1)},
+	}, got)
+}
@@ -0,0 +1,96 @@
+// Package policy evaluates user-defined compliance rules over the rows of the enterprise reports.
+//
+// Rules are written in the Common Expression Language (CEL). Each rule reads the rows of one report,
+// bound to the variable row, and flags the rows for which its condition is true, for example:
+//
+//	id: public-repo-topic
+//	report: repositories
+//	severity: high
+//	description: Public repositories must have the open-source topic
+//	condition: row.Repository.Visibility == "public" && !("open-source" in row.Repository.Topics)
+//	resource: row.Repository.FullName
+//
+// A row is the report's processed data rather than its output columns: structs become maps keyed by
+// Go field name, embedded structs are nested under their type name, slices become lists and
+// timestamps become CEL timestamps. Unset fields are left out, so they can be tested with has().
+package policy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Severities a rule can be assigned, from least to most severe.
+var Severities = []string{"low", "medium", "high", "critical"}
+
+// DefaultSeverity is the severity of rules that do not set one.
+const DefaultSeverity = "medium"
+
+// Rule declares a compliance check over the rows of one report.
+type Rule struct {
+	ID          string // Unique identifier, reported with every violation
+	Report      string // Report whose rows the rule reads, e.g. repositories
+	Severity    string // One of Severities; DefaultSeverity when empty
+	Description string // What the rule requires; the default violation message
+
+	// ForEach optionally expands each row into a list, e.g. row.Collaborators.
+	// The condition, resource and message are then evaluated once per element, bound to item.
+	ForEach string
+	// Condition is true when a row, or item, violates the rule.
+	Condition string
+	// Resource names what violates the rule, e.g. row.Repository.FullName.
+	Resource string
+	// Message optionally describes a violation; it must evaluate to a string.
+	Message string
+
+	// MaxPerResource, when set, counts the matches of each resource across all rows and reports
+	// a single violation for each resource matched more than MaxPerResource times,
+	// e.g. users with admin access to more than 50 repositories.
+	MaxPerResource int
+}
+
+// Validate checks that the rule has what it needs to be compiled.
+func (r Rule) Validate() error {
+	var errs []string
+	if r.ID == "" {
+		errs = append(errs, "id is required")
+	}
+	if r.Report == "" {
+		errs = append(errs, "report is required")
+	}
+	if r.Condition == "" {
+		errs = append(errs, "condition is required")
+	}
+	if r.Resource == "" {
+		errs = append(errs, "resource is required")
+	}
+	if r.Severity != "" && !slices.Contains(Severities, r.Severity) {
+		errs = append(errs, fmt.Sprintf("severity must be one of %s, got %q", strings.Join(Severities, ", "), r.Severity))
+	}
+	if r.MaxPerResource < 0 {
+		errs = append(errs, "max-per-resource must not be negative")
+	}
+	if r.MaxPerResource > 0 && r.Message != "" {
+		errs = append(errs, "message cannot be used with max-per-resource, which reports the number of matches")
+	}
+
+	if len(errs) > 0 {
+		id := r.ID
+		if id == "" {
+			id = "(unnamed)"
+		}
+		return fmt.Errorf("invalid policy rule %s: %s", id, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Violation is a resource that does not meet a rule.
+type Violation struct {
+	RuleID      string
+	Severity    string
+	Report      string // Report the rule read
+	Resource    string
+	Message     string
+	Description string // Description of the rule
+}
@@ -0,0 +1,81 @@
+// Package policy evaluates user-defined compliance rules over the rows of the enterprise reports.
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+)
+
+// maxDepth bounds how deeply a row is converted, guarding against reference cycles.
+const maxDepth = 32
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	timestampType = reflect.TypeOf(github.Timestamp{})
+)
+
+// toValue converts a report row into the maps, lists and scalars that CEL expressions read.
+// It returns nil for nil pointers and for values CEL cannot represent, such as functions.
+func toValue(v reflect.Value, depth int) any {
+	if !v.IsValid() || depth > maxDepth {
+		return nil
+	}
+
+	switch v.Type() {
+	case timeType:
+		return v.Interface().(time.Time)
+	case timestampType:
+		return v.Interface().(github.Timestamp).Time
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return toValue(v.Elem(), depth+1)
+	case reflect.Struct:
+		fields := make(map[string]any, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			// Embedded fields are kept under their type name, as Go code selects them
+			if value := toValue(v.Field(i), depth+1); value != nil {
+				fields[field.Name] = value
+			}
+		}
+		return fields
+	case reflect.Slice, reflect.Array:
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = toValue(v.Index(i), depth+1)
+		}
+		return list
+	case reflect.Map:
+		entries := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			if value := toValue(iter.Value(), depth+1); value != nil {
+				entries[fmt.Sprint(iter.Key().Interface())] = value
+			}
+		}
+		return entries
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,245 @@
+// Package progress tracks the progress of the reports of a run and displays it in the terminal,
+// or logs it periodically when the output is not a terminal.
+package progress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// terminalInterval is how often the progress is redrawn in a terminal.
+	terminalInterval = time.Second
+	// logInterval is how often the progress is logged when the output is not a terminal.
+	logInterval = 30 * time.Second
+)
+
+// Display shows the progress of a tracker. In a terminal, it redraws a block of lines below the
+// log output, which is written through the Display so the lines stay below it. Otherwise, it
+// logs the progress of the running reports periodically.
+type Display struct {
+	tracker  *Tracker
+	out      io.Writer
+	terminal bool
+	interval time.Duration
+
+	mu      sync.Mutex
+	lines   int  // Lines of the drawn block
+	stopped bool // Once stopped, log output is written through without the block
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewDisplay creates a display of the tracker writing to out, redrawing in place when out is a terminal.
+func NewDisplay(tracker *Tracker, out io.Writer, terminal bool) *Display {
+	interval := logInterval
+	if terminal {
+		interval = terminalInterval
+	}
+	return &Display{tracker: tracker, out: out, terminal: terminal, interval: interval}
+}
+
+// IsTerminal reports whether the file is a terminal that supports redrawing lines.
+func IsTerminal(f *os.File) bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Terminal reports whether the display redraws in place, so log output should be written through it.
+func (d *Display) Terminal() bool {
+	return d.terminal
+}
+
+// Start starts refreshing the display until Stop is called.
+func (d *Display) Start() {
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+				d.refresh()
+			}
+		}
+	}()
+}
+
+// Stop stops refreshing the display. In a terminal, the final progress is left on screen.
+func (d *Display) Stop() {
+	if d.stop == nil {
+		return
+	}
+	close(d.stop)
+	<-d.done
+	d.stop = nil
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.terminal {
+		d.clear()
+		d.draw()
+		d.lines = 0
+	}
+	d.stopped = true
+}
+
+// Write writes log output above the block of a terminal display.
+func (d *Display) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.terminal || d.stopped {
+		return d.out.Write(p)
+	}
+	d.clear()
+	n, err := d.out.Write(p)
+	d.draw()
+	return n, err
+}
+
+// refresh redraws the block of a terminal display, or logs the progress of the running reports.
+func (d *Display) refresh() {
+	if !d.terminal {
+		d.log()
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clear()
+	d.draw()
+}
+
+// clear erases the drawn block, leaving the cursor where it started.
+func (d *Display) clear() {
+	if d.lines > 0 {
+		// Move to the first line of the block and erase to the end of the screen
+		if _, err := fmt.Fprintf(d.out, "\r\033[%dA\033[J", d.lines); err != nil {
+			return
+		}
+		d.lines = 0
+	}
+}
+
+// draw writes the block below the cursor.
+func (d *Display) draw() {
+	block := Render(d.tracker)
+	if _, err := io.WriteString(d.out, block); err != nil {
+		return
+	}
+	d.lines = strings.Count(block, "\n")
+}
+
+// log logs the progress of each running report with the API budgets left.
+func (d *Display) log() {
+	budgets := budgetAttrs(d.tracker)
+	for _, s := range d.tracker.Statuses() {
+		if s.State != StateRunning && s.State != StateFetching {
+			continue
+		}
+		attrs := []any{
+			"report", s.Name,
+			"state", s.State,
+			"processed", s.Processed,
+			"total", s.Total,
+			"errors", s.Errors,
+			"per_second", fmt.Sprintf("%.1f", s.Throughput()),
+		}
+		if eta, ok := s.ETA(); ok {
+			attrs = append(attrs, "eta", formatDuration(eta))
+		}
+		slog.Info("report progress", append(attrs, budgets...)...)
+	}
+}
+
+// budgetAttrs returns the REST and GraphQL budgets left as log attributes.
+func budgetAttrs(t *Tracker) []any {
+	var attrs []any
+	for _, resource := range []string{"rest", "graphql"} {
+		if b, ok := t.Budget(resource); ok {
+			attrs = append(attrs, resource, fmt.Sprintf("%d/%d", b.Remaining, b.Limit))
+		}
+	}
+	return attrs
+}
+
+// Render returns the progress of the tracker's reports as a block of lines, one per report,
+// followed by the API budgets left.
+func Render(t *Tracker) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Progress (%s elapsed)\n", formatDuration(t.Elapsed()))
+
+	statuses := t.Statuses()
+	width := 0
+	for _, s := range statuses {
+		width = max(width, len(s.Name))
+	}
+	for _, s := range statuses {
+		line := fmt.Sprintf("  %-*s  %-8s  %s", width, s.Name, s.State, statusDetail(s))
+		fmt.Fprintln(&buf, strings.TrimRight(line, " "))
+	}
+
+	var budgets []string
+	for _, resource := range []struct{ key, name string }{{"rest", "REST"}, {"graphql", "GraphQL"}} {
+		if b, ok := t.Budget(resource.key); ok {
+			budget := fmt.Sprintf("%s %d/%d", resource.name, b.Remaining, b.Limit)
+			if until := time.Until(b.Reset); until > 0 {
+				budget += fmt.Sprintf(" (resets in %s)", formatDuration(until))
+			}
+			budgets = append(budgets, budget)
+		}
+	}
+	if len(budgets) > 0 {
+		fmt.Fprintf(&buf, "  %s\n", strings.Join(budgets, "   "))
+	}
+	return buf.String()
+}
+
+// statusDetail describes the counters of a report.
+func statusDetail(s Status) string {
+	switch s.State {
+	case StatePending, StateSkipped:
+		return ""
+	case StateFetching:
+		return formatDuration(s.Elapsed)
+	}
+	if s.Total == 0 && s.Processed+s.Errors == 0 && s.State != StateRunning {
+		return "in " + formatDuration(s.Elapsed)
+	}
+
+	var parts []string
+	if s.Total > 0 {
+		done := s.Processed + s.Errors
+		parts = append(parts, fmt.Sprintf("%d/%d (%d%%)", done, s.Total, done*100/s.Total))
+	} else {
+		parts = append(parts, fmt.Sprintf("%d", s.Processed+s.Errors))
+	}
+	parts = append(parts, fmt.Sprintf("%d errors", s.Errors), fmt.Sprintf("%.1f/s", s.Throughput()))
+	if eta, ok := s.ETA(); ok {
+		parts = append(parts, "ETA "+formatDuration(eta))
+	} else if s.State == StateDone || s.State == StateFailed {
+		parts = append(parts, "in "+formatDuration(s.Elapsed))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// formatDuration rounds a duration to seconds, or to minutes from an hour on.
+func formatDuration(d time.Duration) string {
+	if d >= time.Hour {
+		return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+	}
+	return d.Round(time.Second).String()
+}
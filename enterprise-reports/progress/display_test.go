@@ -0,0 +1,91 @@
+package progress
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTracker returns a tracker with a done, a running and a pending report.
+func testTracker() *Tracker {
+	tracker := NewTracker()
+	orgs := tracker.Report("organizations")
+	orgs.Begin()
+	orgs.AddTotal(2)
+	orgs.Processed()
+	orgs.Processed()
+	orgs.Finish(nil)
+
+	repos := tracker.Report("repositories")
+	repos.Begin()
+	repos.AddTotal(10)
+	repos.Processed()
+	repos.Failed()
+
+	tracker.Report("teams")
+	tracker.SetBudget("rest", 4200, 5000, time.Now().Add(30*time.Minute))
+	return tracker
+}
+
+// TestRender tests that each report gets a line with its counters, followed by the budgets.
+func TestRender(t *testing.T) {
+	lines := strings.Split(strings.TrimSuffix(Render(testTracker()), "\n"), "\n")
+	require.Len(t, lines, 5)
+	assert.True(t, strings.HasPrefix(lines[0], "Progress ("))
+	assert.Regexp(t, `^  organizations  done +2/2 \(100%\)  0 errors  .*/s  in `, lines[1])
+	assert.Regexp(t, `^  repositories   running +2/10 \(20%\)  1 errors  .*/s  ETA `, lines[2])
+	assert.Equal(t, "  teams          pending", lines[3])
+	assert.Regexp(t, `^  REST 4200/5000 \(resets in (29|30)m`, lines[4])
+}
+
+// TestDisplay_Terminal tests that log output is written above the block, which is redrawn below it.
+func TestDisplay_Terminal(t *testing.T) {
+	var out bytes.Buffer
+	d := NewDisplay(testTracker(), &out, true)
+	d.Start()
+
+	_, err := d.Write([]byte("first log line\n"))
+	require.NoError(t, err)
+	_, err = d.Write([]byte("second log line\n"))
+	require.NoError(t, err)
+	d.Stop()
+
+	// The block drawn after the first line is erased before the second line is written
+	written := out.String()
+	first := strings.Index(written, "first log line")
+	erase := strings.Index(written, "\r\033[5A\033[J")
+	second := strings.Index(written, "second log line")
+	assert.True(t, first < erase && erase < second, written)
+	assert.Contains(t, written[second:], "Progress (") // The final block is left on screen
+
+	// Once stopped, log output is written through
+	out.Reset()
+	_, err = d.Write([]byte("after\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "after\n", out.String())
+}
+
+// TestDisplay_Log tests that without a terminal the running reports are logged periodically.
+func TestDisplay_Log(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	var out bytes.Buffer
+	d := NewDisplay(testTracker(), &out, false)
+	d.interval = 10 * time.Millisecond
+	d.Start()
+	time.Sleep(50 * time.Millisecond)
+	d.Stop()
+
+	assert.Empty(t, out.String())
+	assert.Contains(t, logs.String(), `msg="report progress" report=repositories state=running processed=1 total=10 errors=1`)
+	assert.Contains(t, logs.String(), "rest=4200/5000")
+	assert.NotContains(t, logs.String(), "report=organizations")
+}
@@ -0,0 +1,268 @@
+// Package progress tracks the progress of the reports of a run and displays it in the terminal,
+// or logs it periodically when the output is not a terminal.
+package progress
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// State is the stage a report is in.
+type State string
+
+const (
+	StatePending  State = "pending"  // Not started yet
+	StateFetching State = "fetching" // Started, fetching what it processes
+	StateRunning  State = "running"  // Processing its items
+	StateDone     State = "done"     // Completed
+	StateFailed   State = "failed"   // Failed
+	StateSkipped  State = "skipped"  // Not supported by the server
+)
+
+// Tracker collects the progress of the reports of a run and the API budgets left. Its methods
+// are safe for concurrent use, and do nothing on a nil Tracker.
+type Tracker struct {
+	mu      sync.Mutex
+	started time.Time
+	reports []*Report
+	budgets map[string]Budget
+}
+
+// Budget is the rate limit budget of an API resource.
+type Budget struct {
+	Remaining int
+	Limit     int
+	Reset     time.Time
+}
+
+// NewTracker creates a tracker for a run starting now.
+func NewTracker() *Tracker {
+	return &Tracker{started: time.Now(), budgets: make(map[string]Budget)}
+}
+
+// Report returns the progress of the named report, adding it as pending the first time.
+func (t *Tracker) Report(name string) *Report {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, r := range t.reports {
+		if r.name == name {
+			return r
+		}
+	}
+	r := &Report{name: name, state: StatePending}
+	t.reports = append(t.reports, r)
+	return r
+}
+
+// SetBudget records the budget left of an API resource, e.g. "rest" or "graphql".
+func (t *Tracker) SetBudget(resource string, remaining, limit int, reset time.Time) {
+	if t == nil || limit <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.budgets[resource] = Budget{Remaining: remaining, Limit: limit, Reset: reset}
+}
+
+// Budget returns the last budget recorded for an API resource.
+func (t *Tracker) Budget(resource string) (Budget, bool) {
+	if t == nil {
+		return Budget{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b, ok := t.budgets[resource]
+	return b, ok
+}
+
+// Elapsed returns the time since the run started.
+func (t *Tracker) Elapsed() time.Duration {
+	if t == nil {
+		return 0
+	}
+	return time.Since(t.started)
+}
+
+// Statuses returns the progress of the reports, in the order they were added.
+func (t *Tracker) Statuses() []Status {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	reports := append([]*Report(nil), t.reports...)
+	t.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]Status, 0, len(reports))
+	for _, r := range reports {
+		statuses = append(statuses, r.status(now))
+	}
+	return statuses
+}
+
+// Report tracks the progress of one report. Its methods are safe for concurrent use, and do
+// nothing on a nil Report, so reports run without a tracker are not affected.
+type Report struct {
+	name      string
+	total     atomic.Int64
+	processed atomic.Int64
+	errors    atomic.Int64
+
+	mu           sync.Mutex
+	state        State
+	started      time.Time // When the report started
+	itemsStarted time.Time // When the report started processing its items
+	finished     time.Time
+}
+
+// Begin marks the report started, fetching what it processes.
+func (r *Report) Begin() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = StateFetching
+	r.started = time.Now()
+}
+
+// AddTotal adds items the report is about to process to its total, marking it running.
+func (r *Report) AddTotal(n int) {
+	if r == nil {
+		return
+	}
+	r.total.Add(int64(n))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.itemsStarted.IsZero() {
+		r.itemsStarted = time.Now()
+	}
+	r.state = StateRunning
+}
+
+// Processed counts an item the report processed.
+func (r *Report) Processed() {
+	if r == nil {
+		return
+	}
+	r.processed.Add(1)
+}
+
+// Failed counts an item the report failed to process.
+func (r *Report) Failed() {
+	if r == nil {
+		return
+	}
+	r.errors.Add(1)
+}
+
+// Finish marks the report done, or failed when err is not nil.
+func (r *Report) Finish(err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = StateDone
+	if err != nil {
+		r.state = StateFailed
+	}
+	r.finished = time.Now()
+}
+
+// Skip marks the report skipped.
+func (r *Report) Skip() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = StateSkipped
+}
+
+// status returns the progress of the report at the given time.
+func (r *Report) status(now time.Time) Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := Status{
+		Name:      r.name,
+		State:     r.state,
+		Total:     int(r.total.Load()),
+		Processed: int(r.processed.Load()),
+		Errors:    int(r.errors.Load()),
+	}
+	end := now
+	if !r.finished.IsZero() {
+		end = r.finished
+	}
+	if !r.started.IsZero() {
+		s.Elapsed = end.Sub(r.started)
+	}
+	if !r.itemsStarted.IsZero() {
+		s.ItemsElapsed = end.Sub(r.itemsStarted)
+	}
+	return s
+}
+
+// Status is the progress of a report at a point in time.
+type Status struct {
+	Name         string
+	State        State
+	Total        int           // Items to process; 0 when not known up front, as for the audit log
+	Processed    int           // Items processed
+	Errors       int           // Items that failed
+	Elapsed      time.Duration // Time since the report started
+	ItemsElapsed time.Duration // Time since the report started processing its items
+}
+
+// Throughput returns the items handled per second since the report started processing them.
+func (s Status) Throughput() float64 {
+	if s.ItemsElapsed <= 0 {
+		return 0
+	}
+	return float64(s.Processed+s.Errors) / s.ItemsElapsed.Seconds()
+}
+
+// ETA returns the estimated time left to process the remaining items at the current throughput,
+// and false when it cannot be estimated yet.
+func (s Status) ETA() (time.Duration, bool) {
+	throughput := s.Throughput()
+	if s.State != StateRunning || s.Total == 0 || throughput == 0 {
+		return 0, false
+	}
+	left := max(s.Total-s.Processed-s.Errors, 0)
+	return time.Duration(float64(left) / throughput * float64(time.Second)), true
+}
+
+// trackerKey is the context key of a Tracker.
+type trackerKey struct{}
+
+// reportKey is the context key of a Report.
+type reportKey struct{}
+
+// WithTracker returns a context carrying the tracker, which the reports and API calls made with it update.
+func WithTracker(ctx context.Context, t *Tracker) context.Context {
+	return context.WithValue(ctx, trackerKey{}, t)
+}
+
+// TrackerFrom returns the tracker carried by the context, or nil.
+func TrackerFrom(ctx context.Context) *Tracker {
+	t, _ := ctx.Value(trackerKey{}).(*Tracker)
+	return t
+}
+
+// WithReport returns a context carrying the progress of the report run with it.
+func WithReport(ctx context.Context, r *Report) context.Context {
+	return context.WithValue(ctx, reportKey{}, r)
+}
+
+// ReportFrom returns the report progress carried by the context, or nil.
+func ReportFrom(ctx context.Context) *Report {
+	r, _ := ctx.Value(reportKey{}).(*Report)
+	return r
+}
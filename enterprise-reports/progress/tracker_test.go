@@ -0,0 +1,80 @@
+package progress
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTracker tests that the counters of the reports and the budgets are collected in the order
+// the reports were added.
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+	repos := tracker.Report("repositories")
+	tracker.Report("teams")
+	assert.Same(t, repos, tracker.Report("repositories"))
+
+	repos.Begin()
+	repos.AddTotal(4)
+	repos.Processed()
+	repos.Processed()
+	repos.Failed()
+	tracker.SetBudget("rest", 4000, 5000, time.Now().Add(time.Hour))
+	tracker.SetBudget("graphql", 0, 0, time.Time{}) // Unknown limits are ignored
+
+	statuses := tracker.Statuses()
+	require.Len(t, statuses, 2)
+	assert.Equal(t, StateRunning, statuses[0].State)
+	assert.Equal(t, []int{4, 2, 1}, []int{statuses[0].Total, statuses[0].Processed, statuses[0].Errors})
+	assert.Equal(t, StatePending, statuses[1].State)
+
+	budget, ok := tracker.Budget("rest")
+	require.True(t, ok)
+	assert.Equal(t, 4000, budget.Remaining)
+	_, ok = tracker.Budget("graphql")
+	assert.False(t, ok)
+
+	repos.Finish(errors.New("boom"))
+	assert.Equal(t, StateFailed, tracker.Statuses()[0].State)
+}
+
+// TestStatus_ETA tests that the time left is estimated from the throughput of the items handled so far.
+func TestStatus_ETA(t *testing.T) {
+	s := Status{State: StateRunning, Total: 100, Processed: 15, Errors: 5, ItemsElapsed: 10 * time.Second}
+	assert.InDelta(t, 2.0, s.Throughput(), 0.001)
+	eta, ok := s.ETA()
+	require.True(t, ok)
+	assert.Equal(t, 40*time.Second, eta)
+
+	// Without a total or any item handled, there is no estimate
+	_, ok = Status{State: StateRunning, Processed: 15, ItemsElapsed: time.Second}.ETA()
+	assert.False(t, ok)
+	_, ok = Status{State: StateRunning, Total: 100, ItemsElapsed: time.Second}.ETA()
+	assert.False(t, ok)
+}
+
+// TestContext tests that a nil tracker or report, as without a display, is safe to use.
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, TrackerFrom(ctx))
+	assert.Nil(t, ReportFrom(ctx))
+	assert.NotPanics(t, func() {
+		r := TrackerFrom(ctx).Report("users")
+		r.Begin()
+		r.AddTotal(1)
+		r.Processed()
+		r.Failed()
+		r.Finish(nil)
+		TrackerFrom(ctx).SetBudget("rest", 1, 2, time.Now())
+	})
+
+	tracker := NewTracker()
+	report := tracker.Report("users")
+	ctx = WithReport(WithTracker(ctx, tracker), report)
+	assert.Same(t, tracker, TrackerFrom(ctx))
+	assert.Same(t, report, ReportFrom(ctx))
+}
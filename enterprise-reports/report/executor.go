@@ -5,10 +5,15 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/config"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/policy"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/progress"
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/reports"
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
 	"github.com/shurcooL/githubv4"
@@ -27,12 +32,14 @@ type ReportRunner interface {
 // OrganizationsReportRunner implements the ReportRunner interface for organizations report
 type OrganizationsReportRunner struct {
 	enterpriseSlug string
+	opts           reports.Options
 }
 
 // NewOrganizationsReportRunner is a constructor function for creating organizations report runners
-var NewOrganizationsReportRunner = func(enterpriseSlug string) ReportRunner {
+var NewOrganizationsReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
 	return &OrganizationsReportRunner{
 		enterpriseSlug: enterpriseSlug,
+		opts:           opts,
 	}
 }
 
@@ -40,7 +47,7 @@ var NewOrganizationsReportRunner = func(enterpriseSlug string) ReportRunner {
 func (r *OrganizationsReportRunner) Run(ctx context.Context, restClient *github.Client,
 	graphQLClient *githubv4.Client, outputFilename string, workers int, cache *utils.SharedCache) error {
 
-	return reports.OrganizationsReport(ctx, graphQLClient, restClient, r.enterpriseSlug, outputFilename, workers, cache)
+	return reports.OrganizationsReport(ctx, graphQLClient, restClient, r.enterpriseSlug, outputFilename, workers, cache, r.opts)
 }
 
 // Name returns the report name
@@ -51,12 +58,14 @@ func (r *OrganizationsReportRunner) Name() string {
 // RepositoriesReportRunner implements the ReportRunner interface for repositories report
 type RepositoriesReportRunner struct {
 	enterpriseSlug string
+	opts           reports.Options
 }
 
 // NewRepositoriesReportRunner is a constructor function for creating repositories report runners
-var NewRepositoriesReportRunner = func(enterpriseSlug string) ReportRunner {
+var NewRepositoriesReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
 	return &RepositoriesReportRunner{
 		enterpriseSlug: enterpriseSlug,
+		opts:           opts,
 	}
 }
 
@@ -64,7 +73,7 @@ var NewRepositoriesReportRunner = func(enterpriseSlug string) ReportRunner {
 func (r *RepositoriesReportRunner) Run(ctx context.Context, restClient *github.Client,
 	graphQLClient *githubv4.Client, outputFilename string, workers int, cache *utils.SharedCache) error {
 
-	return reports.RepositoryReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache)
+	return reports.RepositoryReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache, r.opts)
 }
 
 // Name returns the report name
@@ -75,12 +84,14 @@ func (r *RepositoriesReportRunner) Name() string {
 // TeamsReportRunner implements the ReportRunner interface for teams report
 type TeamsReportRunner struct {
 	enterpriseSlug string
+	opts           reports.Options
 }
 
 // NewTeamsReportRunner is a constructor function for creating teams report runners
-var NewTeamsReportRunner = func(enterpriseSlug string) ReportRunner {
+var NewTeamsReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
 	return &TeamsReportRunner{
 		enterpriseSlug: enterpriseSlug,
+		opts:           opts,
 	}
 }
 
@@ -88,7 +99,7 @@ var NewTeamsReportRunner = func(enterpriseSlug string) ReportRunner {
 func (r *TeamsReportRunner) Run(ctx context.Context, restClient *github.Client,
 	graphQLClient *githubv4.Client, outputFilename string, workers int, cache *utils.SharedCache) error {
 
-	return reports.TeamsReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache)
+	return reports.TeamsReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache, r.opts)
 }
 
 // Name returns the report name
@@ -99,12 +110,14 @@ func (r *TeamsReportRunner) Name() string {
 // CollaboratorsReportRunner implements the ReportRunner interface for collaborators report
 type CollaboratorsReportRunner struct {
 	enterpriseSlug string
+	opts           reports.Options
 }
 
 // NewCollaboratorsReportRunner is a constructor function for creating collaborators report runners
-var NewCollaboratorsReportRunner = func(enterpriseSlug string) ReportRunner {
+var NewCollaboratorsReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
 	return &CollaboratorsReportRunner{
 		enterpriseSlug: enterpriseSlug,
+		opts:           opts,
 	}
 }
 
@@ -112,7 +125,7 @@ var NewCollaboratorsReportRunner = func(enterpriseSlug string) ReportRunner {
 func (r *CollaboratorsReportRunner) Run(ctx context.Context, restClient *github.Client,
 	graphQLClient *githubv4.Client, outputFilename string, workers int, cache *utils.SharedCache) error {
 
-	return reports.CollaboratorsReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache)
+	return reports.CollaboratorsReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache, r.opts)
 }
 
 // Name returns the report name
@@ -123,12 +136,14 @@ func (r *CollaboratorsReportRunner) Name() string {
 // UsersReportRunner implements the ReportRunner interface for users report
 type UsersReportRunner struct {
 	enterpriseSlug string
+	opts           reports.Options
 }
 
 // NewUsersReportRunner is a constructor function for creating users report runners
-var NewUsersReportRunner = func(enterpriseSlug string) ReportRunner {
+var NewUsersReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
 	return &UsersReportRunner{
 		enterpriseSlug: enterpriseSlug,
+		opts:           opts,
 	}
 }
 
@@ -136,7 +151,7 @@ var NewUsersReportRunner = func(enterpriseSlug string) ReportRunner {
 func (r *UsersReportRunner) Run(ctx context.Context, restClient *github.Client,
 	graphQLClient *githubv4.Client, outputFilename string, workers int, cache *utils.SharedCache) error {
 
-	return reports.UsersReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache)
+	return reports.UsersReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache, r.opts)
 }
 
 // Name returns the report name
@@ -147,12 +162,14 @@ func (r *UsersReportRunner) Name() string {
 // ActiveRepositoriesReportRunner implements the ReportRunner interface for active repositories report
 type ActiveRepositoriesReportRunner struct {
 	enterpriseSlug string
+	opts           reports.Options
 }
 
 // NewActiveRepositoriesReportRunner is a constructor function for creating active repositories report runners
-var NewActiveRepositoriesReportRunner = func(enterpriseSlug string) ReportRunner {
+var NewActiveRepositoriesReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
 	return &ActiveRepositoriesReportRunner{
 		enterpriseSlug: enterpriseSlug,
+		opts:           opts,
 	}
 }
 
@@ -160,7 +177,7 @@ var NewActiveRepositoriesReportRunner = func(enterpriseSlug string) ReportRunner
 func (r *ActiveRepositoriesReportRunner) Run(ctx context.Context, restClient *github.Client,
 	graphQLClient *githubv4.Client, outputFilename string, workers int, cache *utils.SharedCache) error {
 
-	return reports.ActiveRepositoriesReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache)
+	return reports.ActiveRepositoriesReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache, r.opts)
 }
 
 // Name returns the report name
@@ -168,10 +185,195 @@ func (r *ActiveRepositoriesReportRunner) Name() string {
 	return "active-repositories"
 }
 
+// StaleRepositoriesReportRunner implements the ReportRunner interface for stale repositories report
+type StaleRepositoriesReportRunner struct {
+	enterpriseSlug string
+	opts           reports.Options
+}
+
+// NewStaleRepositoriesReportRunner is a constructor function for creating stale repositories report runners
+var NewStaleRepositoriesReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
+	return &StaleRepositoriesReportRunner{
+		enterpriseSlug: enterpriseSlug,
+		opts:           opts,
+	}
+}
+
+// Run executes the stale repositories report
+func (r *StaleRepositoriesReportRunner) Run(ctx context.Context, restClient *github.Client,
+	graphQLClient *githubv4.Client, outputFilename string, workers int, cache *utils.SharedCache) error {
+
+	return reports.StaleRepositoriesReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache, r.opts)
+}
+
+// Name returns the report name
+func (r *StaleRepositoriesReportRunner) Name() string {
+	return "stale-repositories"
+}
+
+// LicensesReportRunner implements the ReportRunner interface for licenses report
+type LicensesReportRunner struct {
+	enterpriseSlug string
+	opts           reports.Options
+}
+
+// NewLicensesReportRunner is a constructor function for creating licenses report runners
+var NewLicensesReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
+	return &LicensesReportRunner{
+		enterpriseSlug: enterpriseSlug,
+		opts:           opts,
+	}
+}
+
+// Run executes the licenses report
+func (r *LicensesReportRunner) Run(ctx context.Context, restClient *github.Client,
+	graphQLClient *githubv4.Client, outputFilename string, workers int, cache *utils.SharedCache) error {
+
+	return reports.LicensesReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache, r.opts)
+}
+
+// Name returns the report name
+func (r *LicensesReportRunner) Name() string {
+	return "licenses"
+}
+
+// CopilotReportRunner implements the ReportRunner interface for copilot report
+type CopilotReportRunner struct {
+	enterpriseSlug string
+	opts           reports.Options
+}
+
+// NewCopilotReportRunner is a constructor function for creating copilot report runners
+var NewCopilotReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
+	return &CopilotReportRunner{
+		enterpriseSlug: enterpriseSlug,
+		opts:           opts,
+	}
+}
+
+// Run executes the copilot report
+func (r *CopilotReportRunner) Run(ctx context.Context, restClient *github.Client,
+	graphQLClient *githubv4.Client, outputFilename string, workers int, cache *utils.SharedCache) error {
+
+	return reports.CopilotReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache, r.opts)
+}
+
+// Name returns the report name
+func (r *CopilotReportRunner) Name() string {
+	return "copilot"
+}
+
+// AuditLogReportRunner implements the ReportRunner interface for audit log report
+type AuditLogReportRunner struct {
+	enterpriseSlug string
+	opts           reports.Options
+}
+
+// NewAuditLogReportRunner is a constructor function for creating audit log report runners
+var NewAuditLogReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
+	return &AuditLogReportRunner{
+		enterpriseSlug: enterpriseSlug,
+		opts:           opts,
+	}
+}
+
+// Run executes the audit log report
+func (r *AuditLogReportRunner) Run(ctx context.Context, restClient *github.Client,
+	graphQLClient *githubv4.Client, outputFilename string, workers int, cache *utils.SharedCache) error {
+
+	return reports.AuditLogReport(ctx, restClient, r.enterpriseSlug, outputFilename, r.opts)
+}
+
+// Name returns the report name
+func (r *AuditLogReportRunner) Name() string {
+	return "audit-log"
+}
+
+// AdminsReportRunner implements the ReportRunner interface for admins report
+type AdminsReportRunner struct {
+	enterpriseSlug string
+	opts           reports.Options
+}
+
+// NewAdminsReportRunner is a constructor function for creating admins report runners
+var NewAdminsReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
+	return &AdminsReportRunner{
+		enterpriseSlug: enterpriseSlug,
+		opts:           opts,
+	}
+}
+
+// Run executes the admins report
+func (r *AdminsReportRunner) Run(ctx context.Context, restClient *github.Client,
+	graphQLClient *githubv4.Client, outputFilename string, workers int, cache *utils.SharedCache) error {
+
+	return reports.AdminsReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache, r.opts)
+}
+
+// Name returns the report name
+func (r *AdminsReportRunner) Name() string {
+	return "admins"
+}
+
+// OrgSettingsReportRunner implements the ReportRunner interface for organization settings report
+type OrgSettingsReportRunner struct {
+	enterpriseSlug string
+	opts           reports.Options
+}
+
+// NewOrgSettingsReportRunner is a constructor function for creating organization settings report runners
+var NewOrgSettingsReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
+	return &OrgSettingsReportRunner{
+		enterpriseSlug: enterpriseSlug,
+		opts:           opts,
+	}
+}
+
+// Run executes the organization settings report
+func (r *OrgSettingsReportRunner) Run(ctx context.Context, restClient *github.Client,
+	graphQLClient *githubv4.Client, outputFilename string, workers int, cache *utils.SharedCache) error {
+
+	return reports.OrganizationSettingsReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache, r.opts)
+}
+
+// Name returns the report name
+func (r *OrgSettingsReportRunner) Name() string {
+	return "org-settings"
+}
+
+// IdentitiesReportRunner implements the ReportRunner interface for identities report
+type IdentitiesReportRunner struct {
+	enterpriseSlug string
+	opts           reports.Options
+}
+
+// NewIdentitiesReportRunner is a constructor function for creating identities report runners
+var NewIdentitiesReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
+	return &IdentitiesReportRunner{
+		enterpriseSlug: enterpriseSlug,
+		opts:           opts,
+	}
+}
+
+// Run executes the identities report
+func (r *IdentitiesReportRunner) Run(ctx context.Context, restClient *github.Client,
+	graphQLClient *githubv4.Client, outputFilename string, workers int, cache *utils.SharedCache) error {
+
+	return reports.IdentitiesReport(ctx, restClient, graphQLClient, r.enterpriseSlug, outputFilename, workers, cache, r.opts)
+}
+
+// Name returns the report name
+func (r *IdentitiesReportRunner) Name() string {
+	return "identities"
+}
+
 // ReportExecutor coordinates the execution of multiple reports
 type ReportExecutor struct {
 	config config.Provider
 	cache  *utils.SharedCache
+	policy *policy.Engine  // Evaluates the policy rules over the reports' rows; nil when not selected
+	server *api.ServerInfo // Deployment the clients are connected to; nil for GitHub Enterprise Cloud
+	failed []string        // Reports that failed or were skipped in the last Execute
 }
 
 // NewReportExecutor creates a new report executor
@@ -182,14 +384,39 @@ func NewReportExecutor(config config.Provider) *ReportExecutor {
 	}
 }
 
-// Execute runs all the selected reports based on configuration
-func (re *ReportExecutor) Execute(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client) {
+// Execute runs all the selected reports based on configuration.
+// A failed report is logged and does not stop the others. Execute only returns an error when the
+// policy report is selected and finds violations, or cannot evaluate its rules over complete data.
+func (re *ReportExecutor) Execute(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client) error {
 	startTime := time.Now()
 	workers := re.config.GetWorkers()
 	if workers < 1 {
 		workers = 5 // Default to 5 workers if not specified
 	}
 
+	// The policy rules are evaluated over the rows of the other reports as they are written
+	if re.config.ShouldRunPolicyReport() {
+		engine, err := policy.NewEngine(re.config.GetPolicyRules())
+		if err != nil {
+			return err
+		}
+		re.policy = engine
+	}
+
+	// Reports that GitHub Enterprise Server does not support are skipped, so the version is detected first
+	re.detectServer(ctx, restClient)
+
+	// A GitHub App read through its organization installations cannot list the enterprise's
+	// organizations, so the reports read the organizations it is installed on
+	if logins := re.config.GetAppInstallationOrgs(); len(logins) > 0 {
+		orgs := make([]*github.Organization, 0, len(logins))
+		for _, login := range logins {
+			orgs = append(orgs, &github.Organization{Login: github.Ptr(login)})
+		}
+		re.cache.SetEnterpriseOrgs(orgs)
+		slog.Info("reading the organizations the GitHub App is installed on", "organizations", len(orgs))
+	}
+
 	// Log the start of report generation
 	slog.Info("starting report generation",
 		"workers", workers,
@@ -197,53 +424,257 @@ func (re *ReportExecutor) Execute(ctx context.Context, restClient *github.Client
 		"outputDir", re.config.GetOutputDir())
 
 	// Create report runners if they're enabled in config
+	runners := re.runners()
+
+	// Execute each selected report. Reports the server does not support count as failed, so policy
+	// rules over them are not reported as passing.
+	// The selected reports are tracked as pending until they run.
+	tracker := progress.TrackerFrom(ctx)
+	for _, runner := range runners {
+		tracker.Report(runner.Name())
+	}
+
+	var failed []string
+	for _, runner := range runners {
+		if reason := unsupportedReason(re.server, runner.Name()); reason != "" {
+			slog.Warn("skipping report", "report", runner.Name(), "reason", reason)
+			tracker.Report(runner.Name()).Skip()
+			failed = append(failed, runner.Name())
+			continue
+		}
+		if err := re.executeReport(ctx, runner, restClient, graphQLClient, workers); err != nil {
+			failed = append(failed, runner.Name())
+		}
+	}
+
+	re.failed = failed
+
+	// Report completion
+	duration := time.Since(startTime).Round(time.Second)
+	slog.Info("reports completed", "duration", duration)
+
+	if re.policy != nil {
+		return re.executePolicy(failed)
+	}
+	return nil
+}
+
+// detectServer detects the deployment the clients are connected to when a hostname or base URL is
+// configured. Without one, GitHub Enterprise Cloud is assumed. When detection fails, GitHub
+// Enterprise Server of an unknown version is assumed, so version-gated reports are skipped.
+func (re *ReportExecutor) detectServer(ctx context.Context, restClient *github.Client) {
+	if re.config.GetHostname() == "" && re.config.GetBaseURL() == "" {
+		return
+	}
+	server, err := api.DetectServer(ctx, restClient)
+	if err != nil {
+		slog.Warn("failed to detect the server version, assuming GitHub Enterprise Server of an unknown version", "error", err)
+		re.server = &api.ServerInfo{Version: api.UnknownVersion}
+		return
+	}
+	re.server = server
+	slog.Info("detected GitHub deployment", "server", server.String())
+}
+
+// runners returns the runners of the selected reports, in the order they run.
+func (re *ReportExecutor) runners() []ReportRunner {
 	var runners []ReportRunner
 
 	if re.config.ShouldRunOrganizationsReport() {
-		runners = append(runners, NewOrganizationsReportRunner(re.config.GetEnterpriseSlug()))
+		runners = append(runners, NewOrganizationsReportRunner(re.config.GetEnterpriseSlug(), re.reportOptions("organizations")))
 	}
 
 	if re.config.ShouldRunRepositoriesReport() {
-		runners = append(runners, NewRepositoriesReportRunner(re.config.GetEnterpriseSlug()))
+		runners = append(runners, NewRepositoriesReportRunner(re.config.GetEnterpriseSlug(), re.reportOptions("repositories")))
 	}
 
 	if re.config.ShouldRunTeamsReport() {
-		runners = append(runners, NewTeamsReportRunner(re.config.GetEnterpriseSlug()))
+		runners = append(runners, NewTeamsReportRunner(re.config.GetEnterpriseSlug(), re.reportOptions("teams")))
 	}
 
 	if re.config.ShouldRunCollaboratorsReport() {
-		runners = append(runners, NewCollaboratorsReportRunner(re.config.GetEnterpriseSlug()))
+		runners = append(runners, NewCollaboratorsReportRunner(re.config.GetEnterpriseSlug(), re.reportOptions("collaborators")))
 	}
 
 	if re.config.ShouldRunUsersReport() {
-		runners = append(runners, NewUsersReportRunner(re.config.GetEnterpriseSlug()))
+		runners = append(runners, NewUsersReportRunner(re.config.GetEnterpriseSlug(), re.reportOptions("users")))
 	}
 
 	if re.config.ShouldRunActiveRepositoriesReport() {
-		runners = append(runners, NewActiveRepositoriesReportRunner(re.config.GetEnterpriseSlug()))
+		runners = append(runners, NewActiveRepositoriesReportRunner(re.config.GetEnterpriseSlug(), re.reportOptions("active-repositories")))
 	}
 
-	// Execute each selected report
-	for _, runner := range runners {
-		re.executeReport(ctx, runner, restClient, graphQLClient, workers)
+	if re.config.ShouldRunStaleRepositoriesReport() {
+		runners = append(runners, NewStaleRepositoriesReportRunner(re.config.GetEnterpriseSlug(), re.reportOptions("stale-repositories")))
 	}
 
-	// Report completion
-	duration := time.Since(startTime).Round(time.Second)
-	slog.Info("reports completed", "duration", duration)
+	// Runs after the users report so dormancy results can be reused from the cache
+	if re.config.ShouldRunLicensesReport() {
+		runners = append(runners, NewLicensesReportRunner(re.config.GetEnterpriseSlug(), re.reportOptions("licenses")))
+	}
+
+	if re.config.ShouldRunCopilotReport() {
+		runners = append(runners, NewCopilotReportRunner(re.config.GetEnterpriseSlug(), re.reportOptions("copilot")))
+	}
+
+	if re.config.ShouldRunAuditLogReport() {
+		runners = append(runners, NewAuditLogReportRunner(re.config.GetEnterpriseSlug(), re.reportOptions("audit-log")))
+	}
+
+	// Runs after the organizations report so organization owners can be read from the cache
+	if re.config.ShouldRunAdminsReport() {
+		runners = append(runners, NewAdminsReportRunner(re.config.GetEnterpriseSlug(), re.reportOptions("admins")))
+	}
+
+	if re.config.ShouldRunOrgSettingsReport() {
+		runners = append(runners, NewOrgSettingsReportRunner(re.config.GetEnterpriseSlug(), re.reportOptions("org-settings")))
+	}
+
+	// Runs after the users report so enterprise users can be read from the cache
+	if re.config.ShouldRunIdentitiesReport() {
+		runners = append(runners, NewIdentitiesReportRunner(re.config.GetEnterpriseSlug(), re.reportOptions("identities")))
+	}
+
+	return runners
+}
+
+// Failed returns the reports that failed, or that the server does not support, in the last Execute.
+func (re *ReportExecutor) Failed() []string {
+	return re.failed
+}
+
+// Estimate predicts the API points and wall-clock time of the selected reports without running
+// them. It fetches the number of organizations, repositories, teams and users of the enterprise,
+// and the current rate limits, which cost a few API points. Reports the server does not support
+// are left out.
+func (re *ReportExecutor) Estimate(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client) (*reports.Estimate, error) {
+	re.detectServer(ctx, restClient)
+
+	var names []string
+	for _, runner := range re.runners() {
+		if reason := unsupportedReason(re.server, runner.Name()); reason != "" {
+			slog.Warn("skipping report", "report", runner.Name(), "reason", reason)
+			continue
+		}
+		names = append(names, runner.Name())
+	}
+
+	counts, err := api.FetchEnterpriseCounts(ctx, graphQLClient, re.config.GetEnterpriseSlug(), re.server.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	// Without the current rate limits, full budgets are assumed
+	rateLimits, _, err := restClient.RateLimit.Get(ctx)
+	if err != nil {
+		slog.Warn("failed to fetch rate limits, assuming full budgets", "error", err)
+	}
+
+	workers := re.config.GetWorkers()
+	if workers < 1 {
+		workers = 5 // Default to 5 workers if not specified
+	}
+	return reports.EstimateReports(names, reports.EstimateInput{
+		Counts:      counts,
+		Workers:     workers,
+		Credentials: 1 + len(re.config.GetCredentials()),
+		RateLimits:  rateLimits,
+		Dormancy:    serverDormancyPolicy(re.server, re.config.GetDormancyPolicy()),
+	}), nil
+}
+
+// executePolicy writes the policy rule violations found while the reports ran. It returns an error
+// when there are violations, or when rules could not be evaluated over every row of their report,
+// so the run exits with a non-zero status.
+func (re *ReportExecutor) executePolicy(failed []string) error {
+	filename := re.config.CreateFilePath("policy")
+	violations := re.policy.Violations()
+	if err := reports.PolicyReport(violations, filename, re.reportOptions("policy")); err != nil {
+		return fmt.Errorf("policy report failed: %w", err)
+	}
+
+	var incomplete []string
+	for _, name := range re.policy.Reports() {
+		if slices.Contains(failed, name) {
+			incomplete = append(incomplete, name)
+		}
+	}
+	if len(incomplete) > 0 {
+		return fmt.Errorf("policy rules were not evaluated over complete data, these reports failed: %s", strings.Join(incomplete, ", "))
+	}
+	if err := re.policy.Err(); err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		bySeverity := make(map[string]int)
+		for _, v := range violations {
+			bySeverity[v.Severity]++
+		}
+		slog.Warn("policy violations found", "violations", len(violations), "by_severity", bySeverity, "report", filename)
+		return fmt.Errorf("policy check failed: %d violations", len(violations))
+	}
+	slog.Info("policy check passed", "rules", len(re.config.GetPolicyRules()), "report", filename)
+	return nil
+}
+
+// reportOptions builds the report options for the named report from configuration.
+func (re *ReportExecutor) reportOptions(reportName string) reports.Options {
+	opts := reports.Options{Server: re.server}
+	for _, c := range re.config.GetReportColumns(reportName) {
+		opts.Columns = append(opts.Columns, reports.ColumnSpec{Name: c.Name, Header: c.Header})
+	}
+	if reportName == "users" || reportName == "licenses" {
+		opts.Dormancy = serverDormancyPolicy(re.server, re.config.GetDormancyPolicy())
+	}
+	if reportName == "copilot" {
+		opts.InactiveDays = re.config.GetCopilotInactiveDays()
+	}
+	if reportName == "active-repositories" {
+		opts.ActiveDays = re.config.GetActiveRepositoryDays()
+	}
+	if reportName == "stale-repositories" {
+		opts.StaleDays = re.config.GetStaleRepositoryDays()
+	}
+	if reportName == "audit-log" {
+		opts.AuditLog = reports.AuditLogOptions{
+			Phrase:     re.config.GetAuditLogPhrase(),
+			Include:    re.config.GetAuditLogInclude(),
+			Checkpoint: re.config.GetAuditLogCheckpoint(),
+		}
+	}
+	if reportName == "org-settings" {
+		opts.OrgBaseline = re.config.GetOrgBaseline()
+	}
+	if re.policy != nil && re.policy.Uses(reportName) {
+		opts.Rows = re.policy.Recorder(reportName)
+	}
+	return opts
 }
 
 // executeReport runs a single report and logs its execution
 func (re *ReportExecutor) executeReport(ctx context.Context, runner ReportRunner,
-	restClient *github.Client, graphQLClient *githubv4.Client, workers int) {
+	restClient *github.Client, graphQLClient *githubv4.Client, workers int) error {
 
 	reportName := runner.Name()
 	startTime := time.Now()
 
 	slog.Info("generating report", "report", reportName)
 
+	progressReport := progress.TrackerFrom(ctx).Report(reportName)
+	progressReport.Begin()
+
 	filename := re.config.CreateFilePath(reportName)
-	err := runner.Run(ctx, restClient, graphQLClient, filename, workers, re.cache)
+	errorLog := reports.NewErrorLog(reportName, filename)
+	runCtx := reports.WithErrorLog(progress.WithReport(ctx, progressReport), errorLog)
+	err := runner.Run(runCtx, restClient, graphQLClient, filename, workers, re.cache)
+	progressReport.Finish(err)
+
+	if closeErr := errorLog.Close(); closeErr != nil {
+		slog.Error("failed to write item errors", "report", reportName, "file", errorLog.Path(), "error", closeErr)
+	} else if errorLog.Count() > 0 {
+		slog.Warn("some items could not be fetched", "report", reportName, "errors", errorLog.Count(), "file", errorLog.Path())
+	}
 
 	if err != nil {
 		slog.Error("report failed", "report", reportName, "error", err)
@@ -259,4 +690,5 @@ func (re *ReportExecutor) executeReport(ctx context.Context, runner ReportRunner
 		)
 		slog.Info("========================================")
 	}
+	return err
 }
@@ -2,14 +2,26 @@ package report
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/config"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/policy"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/progress"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/reports"
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
 	"github.com/shurcooL/githubv4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockProvider is a mock implementation of config.Provider for testing.
@@ -47,6 +59,11 @@ func (m *MockProvider) GetBaseURL() string {
 	return args.String(0)
 }
 
+func (m *MockProvider) GetHostname() string {
+	args := m.Called()
+	return args.String(0)
+}
+
 func (m *MockProvider) ShouldRunOrganizationsReport() bool {
 	args := m.Called()
 	return args.Bool(0)
@@ -77,6 +94,100 @@ func (m *MockProvider) ShouldRunActiveRepositoriesReport() bool {
 	return args.Bool(0)
 }
 
+func (m *MockProvider) ShouldRunStaleRepositoriesReport() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *MockProvider) ShouldRunLicensesReport() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *MockProvider) ShouldRunCopilotReport() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *MockProvider) ShouldRunAuditLogReport() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *MockProvider) ShouldRunAdminsReport() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *MockProvider) ShouldRunOrgSettingsReport() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *MockProvider) ShouldRunIdentitiesReport() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *MockProvider) ShouldRunPolicyReport() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *MockProvider) GetReportColumns(report string) []config.ColumnConfig {
+	args := m.Called(report)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]config.ColumnConfig)
+}
+
+func (m *MockProvider) GetDormancyPolicy() utils.DormancyPolicy {
+	args := m.Called()
+	return args.Get(0).(utils.DormancyPolicy)
+}
+
+func (m *MockProvider) GetCopilotInactiveDays() int {
+	args := m.Called()
+	return args.Int(0)
+}
+
+func (m *MockProvider) GetActiveRepositoryDays() int {
+	args := m.Called()
+	return args.Int(0)
+}
+
+func (m *MockProvider) GetStaleRepositoryDays() int {
+	args := m.Called()
+	return args.Int(0)
+}
+
+func (m *MockProvider) GetAuditLogPhrase() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockProvider) GetAuditLogInclude() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockProvider) GetAuditLogCheckpoint() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockProvider) GetOrgBaseline() utils.OrgBaseline {
+	args := m.Called()
+	return args.Get(0).(utils.OrgBaseline)
+}
+
+func (m *MockProvider) GetPolicyRules() []policy.Rule {
+	args := m.Called()
+	rules, _ := args.Get(0).([]policy.Rule)
+	return rules
+}
+
 func (m *MockProvider) GetAuthMethod() string {
 	args := m.Called()
 	return args.String(0)
@@ -87,6 +198,26 @@ func (m *MockProvider) GetToken() string {
 	return args.String(0)
 }
 
+func (m *MockProvider) GetTokenFile() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockProvider) GetTokenCommand() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockProvider) GetRecordDir() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockProvider) GetReplayDir() string {
+	args := m.Called()
+	return args.String(0)
+}
+
 func (m *MockProvider) GetAppID() int64 {
 	args := m.Called()
 	return args.Get(0).(int64)
@@ -102,6 +233,22 @@ func (m *MockProvider) GetAppInstallationID() int64 {
 	return args.Get(0).(int64)
 }
 
+func (m *MockProvider) GetCredentials() []config.CredentialConfig {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]config.CredentialConfig)
+}
+
+func (m *MockProvider) GetAppInstallationOrgs() []string {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).([]string)
+}
+
 func (m *MockProvider) CreateFilePath(reportType string) string {
 	args := m.Called(reportType)
 	return args.String(0)
@@ -169,6 +316,11 @@ func TestReportExecutor_Execute(t *testing.T) {
 				mp.On("GetOutputFormat").Return("csv")
 				mp.On("GetOutputDir").Return(tmpDir)
 				mp.On("GetEnterpriseSlug").Return("test-enterprise")
+				mp.On("GetHostname").Return("")
+				mp.On("GetAppInstallationOrgs").Return(nil)
+				mp.On("GetBaseURL").Return("")
+				mp.On("GetReportColumns", mock.Anything).Return(nil)
+				mp.On("GetDormancyPolicy").Return(utils.DefaultDormancyPolicy()).Maybe()
 
 				mp.On("ShouldRunOrganizationsReport").Return(true)
 				mp.On("ShouldRunRepositoriesReport").Return(true)
@@ -176,6 +328,14 @@ func TestReportExecutor_Execute(t *testing.T) {
 				mp.On("ShouldRunCollaboratorsReport").Return(true)
 				mp.On("ShouldRunUsersReport").Return(true)
 				mp.On("ShouldRunActiveRepositoriesReport").Return(false)
+				mp.On("ShouldRunStaleRepositoriesReport").Return(false)
+				mp.On("ShouldRunLicensesReport").Return(false)
+				mp.On("ShouldRunCopilotReport").Return(false)
+				mp.On("ShouldRunAuditLogReport").Return(false)
+				mp.On("ShouldRunAdminsReport").Return(false)
+				mp.On("ShouldRunOrgSettingsReport").Return(false)
+				mp.On("ShouldRunIdentitiesReport").Return(false)
+				mp.On("ShouldRunPolicyReport").Return(false)
 
 				mp.On("CreateFilePath", "organizations").Return(filepath.Join(tmpDir, "test-enterprise_organizations.csv"))
 				mp.On("CreateFilePath", "repositories").Return(filepath.Join(tmpDir, "test-enterprise_repositories.csv"))
@@ -193,6 +353,11 @@ func TestReportExecutor_Execute(t *testing.T) {
 				mp.On("GetOutputFormat").Return("csv")
 				mp.On("GetOutputDir").Return(tmpDir)
 				mp.On("GetEnterpriseSlug").Return("test-enterprise")
+				mp.On("GetHostname").Return("")
+				mp.On("GetAppInstallationOrgs").Return(nil)
+				mp.On("GetBaseURL").Return("")
+				mp.On("GetReportColumns", mock.Anything).Return(nil)
+				mp.On("GetDormancyPolicy").Return(utils.DefaultDormancyPolicy()).Maybe()
 
 				mp.On("ShouldRunOrganizationsReport").Return(true)
 				mp.On("ShouldRunRepositoriesReport").Return(false)
@@ -200,6 +365,14 @@ func TestReportExecutor_Execute(t *testing.T) {
 				mp.On("ShouldRunCollaboratorsReport").Return(false)
 				mp.On("ShouldRunUsersReport").Return(false)
 				mp.On("ShouldRunActiveRepositoriesReport").Return(false)
+				mp.On("ShouldRunStaleRepositoriesReport").Return(false)
+				mp.On("ShouldRunLicensesReport").Return(false)
+				mp.On("ShouldRunCopilotReport").Return(false)
+				mp.On("ShouldRunAuditLogReport").Return(false)
+				mp.On("ShouldRunAdminsReport").Return(false)
+				mp.On("ShouldRunOrgSettingsReport").Return(false)
+				mp.On("ShouldRunIdentitiesReport").Return(false)
+				mp.On("ShouldRunPolicyReport").Return(false)
 
 				mp.On("CreateFilePath", "organizations").Return(filepath.Join(tmpDir, "test-enterprise_organizations.csv"))
 			},
@@ -213,6 +386,11 @@ func TestReportExecutor_Execute(t *testing.T) {
 				mp.On("GetOutputFormat").Return("csv")
 				mp.On("GetOutputDir").Return(tmpDir)
 				mp.On("GetEnterpriseSlug").Return("test-enterprise")
+				mp.On("GetHostname").Return("")
+				mp.On("GetAppInstallationOrgs").Return(nil)
+				mp.On("GetBaseURL").Return("")
+				mp.On("GetReportColumns", mock.Anything).Return(nil)
+				mp.On("GetDormancyPolicy").Return(utils.DefaultDormancyPolicy()).Maybe()
 
 				mp.On("ShouldRunOrganizationsReport").Return(false)
 				mp.On("ShouldRunRepositoriesReport").Return(true)
@@ -220,6 +398,14 @@ func TestReportExecutor_Execute(t *testing.T) {
 				mp.On("ShouldRunCollaboratorsReport").Return(false)
 				mp.On("ShouldRunUsersReport").Return(false)
 				mp.On("ShouldRunActiveRepositoriesReport").Return(false)
+				mp.On("ShouldRunStaleRepositoriesReport").Return(false)
+				mp.On("ShouldRunLicensesReport").Return(false)
+				mp.On("ShouldRunCopilotReport").Return(false)
+				mp.On("ShouldRunAuditLogReport").Return(false)
+				mp.On("ShouldRunAdminsReport").Return(false)
+				mp.On("ShouldRunOrgSettingsReport").Return(false)
+				mp.On("ShouldRunIdentitiesReport").Return(false)
+				mp.On("ShouldRunPolicyReport").Return(false)
 
 				mp.On("CreateFilePath", "repositories").Return(filepath.Join(tmpDir, "test-enterprise_repositories.csv"))
 			},
@@ -258,8 +444,9 @@ func TestReportExecutor_Execute(t *testing.T) {
 					err = utils.NewAppError(utils.ErrorTypeAPI, "test error", nil)
 				}
 
+				// Reports run with a context carrying their progress
 				mockRunner.On("Run",
-					ctx,
+					mock.AnythingOfType("*context.valueCtx"),
 					restClient,
 					graphQLClient,
 					outputPath,
@@ -273,31 +460,31 @@ func TestReportExecutor_Execute(t *testing.T) {
 				switch reportName {
 				case "organizations":
 					originalOrgRunner := NewOrganizationsReportRunner
-					NewOrganizationsReportRunner = func(enterpriseSlug string) ReportRunner {
+					NewOrganizationsReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
 						return mockRunner
 					}
 					defer func() { NewOrganizationsReportRunner = originalOrgRunner }()
 				case "repositories":
 					originalRepoRunner := NewRepositoriesReportRunner
-					NewRepositoriesReportRunner = func(enterpriseSlug string) ReportRunner {
+					NewRepositoriesReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
 						return mockRunner
 					}
 					defer func() { NewRepositoriesReportRunner = originalRepoRunner }()
 				case "teams":
 					originalTeamsRunner := NewTeamsReportRunner
-					NewTeamsReportRunner = func(enterpriseSlug string) ReportRunner {
+					NewTeamsReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
 						return mockRunner
 					}
 					defer func() { NewTeamsReportRunner = originalTeamsRunner }()
 				case "collaborators":
 					originalCollabRunner := NewCollaboratorsReportRunner
-					NewCollaboratorsReportRunner = func(enterpriseSlug string) ReportRunner {
+					NewCollaboratorsReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
 						return mockRunner
 					}
 					defer func() { NewCollaboratorsReportRunner = originalCollabRunner }()
 				case "users":
 					originalUsersRunner := NewUsersReportRunner
-					NewUsersReportRunner = func(enterpriseSlug string) ReportRunner {
+					NewUsersReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
 						return mockRunner
 					}
 					defer func() { NewUsersReportRunner = originalUsersRunner }()
@@ -305,7 +492,7 @@ func TestReportExecutor_Execute(t *testing.T) {
 			}
 
 			// Execute reports
-			executor.Execute(ctx, restClient, graphQLClient)
+			assert.NoError(t, executor.Execute(ctx, restClient, graphQLClient))
 
 			// Verify all expectations
 			for _, mockRunner := range reportRunners {
@@ -315,3 +502,248 @@ func TestReportExecutor_Execute(t *testing.T) {
 		})
 	}
 }
+
+// rowsRunner is a report runner that records fixed rows through its options, as the reports do.
+type rowsRunner struct {
+	opts reports.Options
+	rows []any
+	err  error
+}
+
+func (r *rowsRunner) Run(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client,
+	outputFilename string, workers int, cache *utils.SharedCache) error {
+	for _, row := range r.rows {
+		r.opts.Rows.Record(row)
+	}
+	return r.err
+}
+
+func (r *rowsRunner) Name() string {
+	return "repositories"
+}
+
+func TestReportExecutor_Policy(t *testing.T) {
+	rules := []policy.Rule{
+		{
+			ID:          "public-repo-topic",
+			Report:      "repositories",
+			Severity:    "high",
+			Description: "Public repositories must have the open-source topic",
+			Condition:   `row.Repository.Visibility == "public" && !("open-source" in row.Repository.Topics)`,
+			Resource:    "row.Repository.FullName",
+		},
+		{
+			ID:        "repo-without-team",
+			Report:    "repositories",
+			Severity:  "low",
+			Condition: "size(row.Teams) == 0",
+			Resource:  "row.Repository.FullName",
+			Message:   `"no team has access to " + row.Repository.Name`,
+		},
+	}
+	rows := []any{
+		&reports.RepoReport{Repository: &github.Repository{Name: github.Ptr("a"), FullName: github.Ptr("org/a"),
+			Visibility: github.Ptr("public"), Topics: []string{"open-source"}}},
+		&reports.RepoReport{Repository: &github.Repository{Name: github.Ptr("b"), FullName: github.Ptr("org/b"),
+			Visibility: github.Ptr("public")}},
+	}
+
+	testCases := []struct {
+		name      string
+		rows      []any
+		runErr    error
+		wantErr   string
+		wantLines []string
+	}{
+		{
+			name:    "Violations fail the run",
+			rows:    rows,
+			wantErr: "policy check failed: 3 violations",
+			wantLines: []string{
+				"Rule ID,Severity,Resource,Message",
+				"public-repo-topic,high,org/b,Public repositories must have the open-source topic",
+				"repo-without-team,low,org/a,no team has access to a",
+				"repo-without-team,low,org/b,no team has access to b",
+			},
+		},
+		{
+			name:      "No rows pass",
+			wantLines: []string{"Rule ID,Severity,Resource,Message"},
+		},
+		{
+			name:      "Failed report fails the run",
+			runErr:    utils.NewAppError(utils.ErrorTypeAPI, "test error", nil),
+			wantErr:   "these reports failed: repositories",
+			wantLines: []string{"Rule ID,Severity,Resource,Message"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			mp := new(MockProvider)
+			mp.On("GetWorkers").Return(2)
+			mp.On("GetOutputFormat").Return("csv")
+			mp.On("GetOutputDir").Return(tmpDir)
+			mp.On("GetEnterpriseSlug").Return("test-enterprise")
+			mp.On("GetHostname").Return("")
+			mp.On("GetAppInstallationOrgs").Return(nil)
+			mp.On("GetBaseURL").Return("")
+			mp.On("GetReportColumns", mock.Anything).Return(nil)
+			mp.On("GetPolicyRules").Return(rules)
+
+			mp.On("ShouldRunOrganizationsReport").Return(false)
+			mp.On("ShouldRunRepositoriesReport").Return(true)
+			mp.On("ShouldRunTeamsReport").Return(false)
+			mp.On("ShouldRunCollaboratorsReport").Return(false)
+			mp.On("ShouldRunUsersReport").Return(false)
+			mp.On("ShouldRunActiveRepositoriesReport").Return(false)
+			mp.On("ShouldRunStaleRepositoriesReport").Return(false)
+			mp.On("ShouldRunLicensesReport").Return(false)
+			mp.On("ShouldRunCopilotReport").Return(false)
+			mp.On("ShouldRunAuditLogReport").Return(false)
+			mp.On("ShouldRunAdminsReport").Return(false)
+			mp.On("ShouldRunOrgSettingsReport").Return(false)
+			mp.On("ShouldRunIdentitiesReport").Return(false)
+			mp.On("ShouldRunPolicyReport").Return(true)
+
+			policyFile := filepath.Join(tmpDir, "test-enterprise_policy.csv")
+			mp.On("CreateFilePath", "repositories").Return(filepath.Join(tmpDir, "test-enterprise_repositories.csv"))
+			mp.On("CreateFilePath", "policy").Return(policyFile)
+
+			originalRepoRunner := NewRepositoriesReportRunner
+			NewRepositoriesReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
+				return &rowsRunner{opts: opts, rows: tc.rows, err: tc.runErr}
+			}
+			defer func() { NewRepositoriesReportRunner = originalRepoRunner }()
+
+			err := NewReportExecutor(mp).Execute(context.Background(), &github.Client{}, &githubv4.Client{})
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.wantErr)
+			}
+
+			data, readErr := os.ReadFile(policyFile)
+			assert.NoError(t, readErr)
+			assert.Equal(t, tc.wantLines, strings.Split(strings.TrimSpace(string(data)), "\n"))
+			mp.AssertExpectations(t)
+		})
+	}
+}
+
+// optsRunner is a report runner that records the options it was created with.
+type optsRunner struct {
+	name string
+	opts reports.Options
+	ran  bool
+}
+
+func (r *optsRunner) Run(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client,
+	outputFilename string, workers int, cache *utils.SharedCache) error {
+	r.ran = true
+	return nil
+}
+
+func (r *optsRunner) Name() string {
+	return r.name
+}
+
+func TestReportExecutor_Server(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		meta   string
+		want   string
+	}{
+		{name: "detected", status: http.StatusOK, meta: `{"verifiable_password_authentication":true,"installed_version":"3.2.5"}`, want: "3.2.5"},
+		// A configured hostname is not GitHub Enterprise Cloud, even when its version cannot be read
+		{name: "detection fails", status: http.StatusNotFound, meta: `{"message":"Not Found"}`, want: api.UnknownVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/v3/meta", r.URL.Path)
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.meta)
+			}))
+			t.Cleanup(srv.Close)
+			restClient := github.NewClient(srv.Client())
+			baseURL, _ := url.Parse(srv.URL + "/api/v3/")
+			restClient.BaseURL = baseURL
+
+			tmpDir := t.TempDir()
+			mp := new(MockProvider)
+			mp.On("GetWorkers").Return(2)
+			mp.On("GetOutputFormat").Return("csv")
+			mp.On("GetOutputDir").Return(tmpDir)
+			mp.On("GetEnterpriseSlug").Return("test-enterprise")
+			mp.On("GetHostname").Return("github.example.com")
+			mp.On("GetAppInstallationOrgs").Return(nil)
+			mp.On("GetReportColumns", mock.Anything).Return(nil)
+			mp.On("GetDormancyPolicy").Return(utils.DefaultDormancyPolicy())
+			mp.On("GetAuditLogPhrase").Return("")
+			mp.On("GetAuditLogInclude").Return("")
+			mp.On("GetAuditLogCheckpoint").Return("")
+
+			mp.On("ShouldRunOrganizationsReport").Return(false)
+			mp.On("ShouldRunRepositoriesReport").Return(false)
+			mp.On("ShouldRunTeamsReport").Return(false)
+			mp.On("ShouldRunCollaboratorsReport").Return(false)
+			mp.On("ShouldRunUsersReport").Return(true)
+			mp.On("ShouldRunActiveRepositoriesReport").Return(false)
+			mp.On("ShouldRunStaleRepositoriesReport").Return(false)
+			mp.On("ShouldRunLicensesReport").Return(true)
+			mp.On("ShouldRunCopilotReport").Return(false)
+			mp.On("ShouldRunAuditLogReport").Return(true)
+			mp.On("ShouldRunAdminsReport").Return(false)
+			mp.On("ShouldRunOrgSettingsReport").Return(false)
+			mp.On("ShouldRunIdentitiesReport").Return(false)
+			mp.On("ShouldRunPolicyReport").Return(false)
+			mp.On("CreateFilePath", "users").Return(filepath.Join(tmpDir, "test-enterprise_users.csv"))
+
+			users := &optsRunner{name: "users"}
+			licenses := &optsRunner{name: "licenses"}
+			auditLog := &optsRunner{name: "audit-log"}
+			originalUsers, originalLicenses, originalAuditLog := NewUsersReportRunner, NewLicensesReportRunner, NewAuditLogReportRunner
+			NewUsersReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner {
+				users.opts = opts
+				return users
+			}
+			NewLicensesReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner { return licenses }
+			NewAuditLogReportRunner = func(enterpriseSlug string, opts reports.Options) ReportRunner { return auditLog }
+			defer func() {
+				NewUsersReportRunner, NewLicensesReportRunner, NewAuditLogReportRunner = originalUsers, originalLicenses, originalAuditLog
+			}()
+
+			tracker := progress.NewTracker()
+			ctx := progress.WithTracker(context.Background(), tracker)
+			assert.NoError(t, NewReportExecutor(mp).Execute(ctx, restClient, &githubv4.Client{}))
+
+			// Licenses are Cloud-only and the audit log API needs Server 3.3
+			assert.True(t, users.ran)
+			assert.False(t, licenses.ran)
+			assert.False(t, auditLog.ran)
+
+			// The skipped reports are shown as such in the progress
+			var states []progress.State
+			for _, s := range tracker.Statuses() {
+				states = append(states, s.State)
+			}
+			assert.Equal(t, []progress.State{progress.StateDone, progress.StateSkipped, progress.StateSkipped}, states)
+
+			// The reports list the members of the Server deployment
+			require.NotNil(t, users.opts.Server)
+			assert.Equal(t, tt.want, users.opts.Server.Version)
+			assert.Equal(t, githubv4.EnterpriseUserDeploymentServer, users.opts.Server.Deployment())
+
+			// Copilot seat activity is not a dormancy signal on Server
+			for _, s := range users.opts.Dormancy.Signals {
+				assert.NotEqual(t, utils.SourceCopilot, s.Source)
+			}
+			assert.NotEmpty(t, users.opts.Dormancy.Signals)
+			mp.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// Package report provides functionality for generating GitHub Enterprise reports.
+package report
+
+import (
+	"fmt"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+)
+
+// serverRequirement describes which GitHub Enterprise Server versions support a report.
+type serverRequirement struct {
+	minVersion string // Oldest Server version supporting the report; empty when no version does
+	reason     string // Why older or all Server versions are not supported
+}
+
+// serverRequirements lists the reports that GitHub Enterprise Server does not support, or only
+// supports from a version on. Reports not listed run on every version.
+var serverRequirements = map[string]serverRequirement{
+	"licenses":   {reason: "the consumed licenses API is only available on GitHub Enterprise Cloud"},
+	"copilot":    {reason: "Copilot seat management is only available on GitHub Enterprise Cloud"},
+	"identities": {reason: "enterprise SAML and SCIM identities are only available on GitHub Enterprise Cloud"},
+	"audit-log":  {minVersion: "3.3", reason: "the enterprise audit log API was added in GitHub Enterprise Server 3.3"},
+}
+
+// unsupportedReason returns why the server cannot run the named report, or "" when it can.
+func unsupportedReason(server *api.ServerInfo, report string) string {
+	req, ok := serverRequirements[report]
+	if !ok || !server.IsServer() {
+		return ""
+	}
+	if req.minVersion != "" && server.AtLeast(req.minVersion) {
+		return ""
+	}
+	return fmt.Sprintf("%s is not supported on %s: %s", report, server, req.reason)
+}
+
+// serverDormancyPolicy drops the dormancy signals whose source the server does not provide.
+// Copilot seat activity is only available on GitHub Enterprise Cloud.
+func serverDormancyPolicy(server *api.ServerInfo, policy utils.DormancyPolicy) utils.DormancyPolicy {
+	if !server.IsServer() {
+		return policy
+	}
+	if policy.IsZero() {
+		policy = utils.DefaultDormancyPolicy()
+	}
+	signals := make([]utils.ActivitySignal, 0, len(policy.Signals))
+	for _, s := range policy.Signals {
+		if s.Source != utils.SourceCopilot {
+			signals = append(signals, s)
+		}
+	}
+	policy.Signals = signals
+	return policy
+}
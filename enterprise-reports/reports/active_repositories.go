@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"log/slog"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v70/github"
@@ -16,16 +18,54 @@ import (
 	"golang.org/x/time/rate"
 )
 
+// DefaultActiveRepositoryDays is the window in days within which a push makes a repository active.
+const DefaultActiveRepositoryDays = 90
+
+// noreplyDomain is the domain of the private commit emails GitHub assigns to users,
+// in the form ID+login@users.noreply.github.com, or login@users.noreply.github.com for older accounts.
+const noreplyDomain = "@users.noreply.github.com"
+
+// Contributor is an author of commits made to a repository within the activity window.
+type Contributor struct {
+	Login   string // GitHub login; empty when the author could not be resolved to a user
+	ID      int64  // GitHub user ID; zero when unknown
+	Name    string // Git author name, as recorded on the first commit listed
+	Email   string // Git author email, as recorded on the first commit listed
+	Commits int    // Number of commits authored within the window
+	Bot     bool   // The author is a GitHub App or another bot account
+}
+
+// String returns the login of the contributor, or its git name and email when it could not be resolved.
+func (c *Contributor) String() string {
+	if c.Login != "" {
+		return c.Login
+	}
+	if c.Email == "" {
+		return c.Name
+	}
+	return fmt.Sprintf("%s <%s>", c.Name, c.Email)
+}
+
 // ActiveRepoReport contains repository information with recent commit activity
-// and the list of contributors who have committed within the specified time range.
+// and the contributors who have committed within the specified time range.
 type ActiveRepoReport struct {
 	*github.Repository
-	RecentContributors []string // List of unique contributor names who committed in the last 90 days
+	RecentContributors []string       // Logins of the human contributors who committed within the window, or name and email when unresolved
+	Contributors       []*Contributor // Every commit author within the window, including bots, by descending commit count
+	Branches           int            // Branches with commits within the window whose commits were read
+	Commits            int            // Unique commits within the window across all branches
+	ItemStatus                        // Parts of the repository that could not be fetched
 }
 
 // ActiveRepositoriesReport generates a CSV report for repositories with recent commit activity.
-// It identifies repositories that have been committed to within the last 90 days and lists
-// all contributors who have made commits during that period.
+// It identifies repositories that have been pushed to within the window of opts.ActiveDays
+// (DefaultActiveRepositoryDays by default) and lists all contributors who have made commits during that period
+// on any branch.
+//
+// Contributors are commit authors, resolved to GitHub users from the commit's linked author, then
+// from noreply commit emails, then by matching the commit email with the identity provider emails of
+// enterprise members. Authors that resolve to no user are listed by git name and email. Bots, such as
+// GitHub Apps, are detected from their account type and login and listed separately.
 //
 // Parameters:
 //   - ctx: Context for cancellation and timeout
@@ -35,12 +75,19 @@ type ActiveRepoReport struct {
 //   - filename: Output CSV file path
 //   - workerCount: Number of concurrent workers for processing repositories
 //   - cache: Shared cache for storing and retrieving GitHub data
+//   - opts: Report options, such as the columns to write
 //
 // The report includes repository owner, name, last pushed date, and a list of
-// recent contributors who committed within the last 90 days.
-func ActiveRepositoriesReport(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache) error {
+// recent contributors who committed within the window.
+func ActiveRepositoriesReport(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache, opts Options) error {
 	slog.Info("starting active repositories report", slog.String("enterprise", enterpriseSlug), slog.String("filename", filename), slog.Int("workers", workerCount))
 
+	header, formatter, err := selectColumns(activeRepoColumns, defaultActiveRepoColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("active repositories report columns: %w", err)
+	}
+	formatter = recordRows(opts.Rows, formatter)
+
 	// Create appropriate report writer based on file extension
 	reportWriter, reportErr := NewReportWriter(filename)
 	if reportErr != nil {
@@ -52,13 +99,6 @@ func ActiveRepositoriesReport(ctx context.Context, restClient *github.Client, gr
 		}
 	}()
 
-	header := []string{
-		"Owner",
-		"Repository",
-		"Pushed_At",
-		"Recent_Contributors",
-	}
-
 	// Write header to report
 	if headerErr := reportWriter.WriteHeader(header); headerErr != nil {
 		return fmt.Errorf("failed to write header: %w", headerErr)
@@ -66,7 +106,6 @@ func ActiveRepositoriesReport(ctx context.Context, restClient *github.Client, gr
 
 	// Check cache for organizations or fetch from API
 	var orgs []*github.Organization
-	var err error
 
 	if cachedOrgs, found := cache.GetEnterpriseOrgs(); found {
 		slog.Info("using cached enterprise organizations")
@@ -103,9 +142,12 @@ func ActiveRepositoriesReport(ctx context.Context, restClient *github.Client, gr
 		reposList = append(reposList, repos...)
 	}
 
-	// Filter repositories that have been pushed to in the last activeWindowDays
-	const activeWindowDays = 90
-	cutoffDate := time.Now().AddDate(0, 0, -activeWindowDays)
+	// Filter repositories that have been pushed to within the window
+	activeDays := opts.ActiveDays
+	if activeDays <= 0 {
+		activeDays = DefaultActiveRepositoryDays
+	}
+	cutoffDate := time.Now().AddDate(0, 0, -activeDays)
 	var activeRepos []*github.Repository
 
 	for _, repo := range reposList {
@@ -114,67 +156,264 @@ func ActiveRepositoriesReport(ctx context.Context, restClient *github.Client, gr
 		}
 	}
 
-	slog.Info("filtered active repositories", "total_repos", len(reposList), "active_repos", len(activeRepos))
+	slog.Info("filtered active repositories", "total_repos", len(reposList), "active_repos", len(activeRepos), "active_days", activeDays)
+
+	resolver := &contributorResolver{graphQLClient: graphQLClient, enterpriseSlug: enterpriseSlug, deployment: opts.Server.Deployment(), cache: cache}
 
-	// Processor: fetch recent commits and extract contributors
+	// Processor: fetch recent commits on every branch and summarize their authors
 	processor := func(ctx context.Context, repo *github.Repository) (*ActiveRepoReport, error) {
-		slog.Info("processing active repository", "repo", repo.GetFullName())
+		slog.Debug("processing active repository", "repo", repo.GetFullName())
+		owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+		report := &ActiveRepoReport{Repository: repo}
 
-		// Fetch commits from the last 90 days
-		commits, err := api.FetchRepositoryCommits(ctx, restClient, repo.GetOwner().GetLogin(), repo.GetName(), cutoffDate)
+		// Only branches committed to within the window can hold recent commits
+		defaultBranch := repo.GetDefaultBranch()
+		branches, err := api.FetchActiveBranches(ctx, graphQLClient, owner, name, cutoffDate)
 		if err != nil {
-			slog.Warn("failed to fetch commits", "repo", repo.GetFullName(), "err", err)
-			// Return repo with empty contributors list rather than failing
-			return &ActiveRepoReport{Repository: repo, RecentContributors: []string{}}, nil
-		}
-
-		// Extract unique contributors from commits
-		contributorMap := make(map[string]bool)
-		for _, commit := range commits {
-			if commit.GetCommit() != nil && commit.GetCommit().GetCommitter() != nil {
-				committerName := commit.GetCommit().GetCommitter().GetName()
-				if committerName != "" && committerName != "GitHub" && committerName != "github-merge-queue" {
-					contributorMap[committerName] = true
-				}
+			slog.Warn("failed to fetch branches, using the default branch", "repo", repo.GetFullName(), "err", err)
+			report.fail(ctx, repo.GetFullName(), "branches", err)
+			branches = []string{defaultBranch}
+		}
+
+		// The default branch is listed in full, and every other branch only adds the commits it holds
+		// beyond the default branch. Commits reachable from several branches are counted once.
+		seen := make(map[string]bool)
+		var commits []*github.RepositoryCommit
+		for _, branch := range branches {
+			var branchCommits []*github.RepositoryCommit
+			if defaultBranch == "" || branch == defaultBranch {
+				branchCommits, err = api.FetchBranchCommits(ctx, restClient, owner, name, branch, cutoffDate)
+			} else {
+				branchCommits, err = api.FetchBranchUniqueCommits(ctx, restClient, owner, name, defaultBranch, branch, cutoffDate)
+			}
+			if err != nil {
+				slog.Warn("failed to fetch commits", "repo", repo.GetFullName(), "branch", branch, "err", err)
+				report.fail(ctx, repo.GetFullName(), "commits", err)
+				continue
 			}
-			// Also check commit author in case it's different from committer
-			if commit.GetCommit() != nil && commit.GetCommit().GetAuthor() != nil {
-				authorName := commit.GetCommit().GetAuthor().GetName()
-				if authorName != "" && authorName != "GitHub" && authorName != "github-merge-queue" {
-					contributorMap[authorName] = true
+			report.Branches++
+			for _, commit := range branchCommits {
+				if !seen[commit.GetSHA()] {
+					seen[commit.GetSHA()] = true
+					commits = append(commits, commit)
 				}
 			}
 		}
 
-		// Convert map to sorted slice
-		contributors := make([]string, 0, len(contributorMap))
-		for name := range contributorMap {
-			contributors = append(contributors, name)
+		contributors := summarizeContributors(commits, resolver.emailLookup(ctx, commits))
+		recent := make([]string, 0, len(contributors))
+		for _, c := range contributors {
+			if !c.Bot {
+				recent = append(recent, c.String())
+			}
 		}
-		sort.Strings(contributors)
+		sort.Strings(recent)
 
-		return &ActiveRepoReport{Repository: repo, RecentContributors: contributors}, nil
+		report.RecentContributors = recent
+		report.Contributors = contributors
+		report.Commits = len(commits)
+		return report, nil
 	}
 
-	// Formatter: format ActiveRepoReport into CSV row
-	formatter := func(r *ActiveRepoReport) []string {
-		contributorsStr := strings.Join(r.RecentContributors, "; ")
-		if contributorsStr == "" {
-			contributorsStr = "N/A"
+	// Create a limiter for rate limiting - more conservative due to commit fetching
+	// Commit fetching can be expensive, so we limit to 1 request per second
+	limiter := rate.NewLimiter(rate.Limit(activeRepositoriesRate), workerCount)
+
+	// Run the report using the new report writer interface
+	return RunReportWithWriter(ctx, activeRepos, processor, formatter, limiter, workerCount, reportWriter)
+}
+
+// contributorResolver maps commit emails to enterprise members through the emails their identity
+// provider reports. The emails are only fetched once a commit cannot be resolved otherwise, and
+// the lookup is shared by every repository of the report.
+type contributorResolver struct {
+	graphQLClient  *githubv4.Client
+	enterpriseSlug string
+	deployment     githubv4.EnterpriseUserDeployment
+	cache          *utils.SharedCache
+
+	once    sync.Once
+	byEmail map[string]*github.User
+}
+
+// emailLookup returns a function resolving commit emails to enterprise members. When every commit
+// is linked to a GitHub user, it returns nil without fetching anything.
+func (r *contributorResolver) emailLookup(ctx context.Context, commits []*github.RepositoryCommit) func(email string) *github.User {
+	unresolved := false
+	for _, commit := range commits {
+		if commit.GetAuthor().GetLogin() == "" && noreplyLogin(commit.GetCommit().GetAuthor().GetEmail()) == "" {
+			unresolved = true
+			break
 		}
+	}
+	if !unresolved {
+		return nil
+	}
 
-		return []string{
-			r.GetOwner().GetLogin(),
-			r.GetName(),
-			r.GetPushedAt().Format(time.RFC3339),
-			contributorsStr,
+	r.once.Do(func() { r.byEmail = r.fetchEmails(ctx) })
+	return func(email string) *github.User {
+		return r.byEmail[strings.ToLower(email)]
+	}
+}
+
+// fetchEmails maps the identity provider emails of enterprise members to the members.
+// Failures are logged and leave commits resolved by the other means only.
+func (r *contributorResolver) fetchEmails(ctx context.Context) map[string]*github.User {
+	users, found := r.cache.GetEnterpriseUsers()
+	if !found {
+		var err error
+		users, err = api.FetchEnterpriseUsers(ctx, r.graphQLClient, r.enterpriseSlug, r.deployment)
+		if err != nil {
+			slog.Warn("failed to fetch enterprise users, commit emails will not be resolved", "enterprise", r.enterpriseSlug, "err", err)
+			return nil
 		}
+		r.cache.SetEnterpriseUsers(users)
 	}
 
-	// Create a limiter for rate limiting - more conservative due to commit fetching
-	// Commit fetching can be expensive, so we limit to 1 request per second
-	limiter := rate.NewLimiter(rate.Limit(1), workerCount)
+	logins := make([]string, 0, len(users))
+	byLogin := make(map[string]*github.User, len(users))
+	for _, u := range users {
+		logins = append(logins, u.GetLogin())
+		byLogin[u.GetLogin()] = u
+	}
+	emails, err := api.FetchUserEmails(ctx, r.graphQLClient, r.enterpriseSlug, logins)
+	if err != nil {
+		slog.Warn("failed to fetch enterprise user emails, commit emails will not be resolved", "enterprise", r.enterpriseSlug, "err", err)
+		return nil
+	}
 
-	// Run the report using the new report writer interface
-	return RunReportWithWriter(ctx, activeRepos, processor, formatter, limiter, workerCount, reportWriter)
+	byEmail := make(map[string]*github.User, len(emails))
+	for login, email := range emails {
+		if email != "N/A" {
+			byEmail[strings.ToLower(email)] = byLogin[login]
+		}
+	}
+	slog.Info("fetched enterprise user emails for commit resolution", "users", len(users), "emails", len(byEmail))
+	return byEmail
 }
+
+// summarizeContributors groups commits by author, resolving each author to a GitHub user from the
+// commit's linked author, its noreply email, or lookup, which may be nil. Contributors are returned
+// by descending commit count, then by name.
+func summarizeContributors(commits []*github.RepositoryCommit, lookup func(email string) *github.User) []*Contributor {
+	byKey := make(map[string]*Contributor)
+	var contributors []*Contributor
+	for _, commit := range commits {
+		gitAuthor := commit.GetCommit().GetAuthor()
+		c := &Contributor{Name: gitAuthor.GetName(), Email: gitAuthor.GetEmail()}
+
+		switch author := commit.GetAuthor(); {
+		case author.GetLogin() != "":
+			c.Login, c.ID = author.GetLogin(), author.GetID()
+			c.Bot = author.GetType() == "Bot"
+		case noreplyLogin(c.Email) != "":
+			c.Login, c.ID = noreplyLogin(c.Email), noreplyID(c.Email)
+		case lookup != nil && c.Email != "":
+			if u := lookup(c.Email); u != nil {
+				c.Login, c.ID = u.GetLogin(), u.GetID()
+			}
+		}
+		c.Bot = c.Bot || strings.HasSuffix(c.Login, "[bot]")
+
+		// Unresolved authors are told apart by email, or by name when the commit has no email
+		key := "login:" + strings.ToLower(c.Login)
+		if c.Login == "" {
+			key = "email:" + strings.ToLower(c.Email)
+			if c.Email == "" {
+				key = "name:" + c.Name
+			}
+		}
+		existing, ok := byKey[key]
+		if !ok {
+			byKey[key] = c
+			contributors = append(contributors, c)
+			existing = c
+		} else if existing.ID == 0 {
+			existing.ID = c.ID
+		}
+		existing.Commits++
+	}
+
+	sort.SliceStable(contributors, func(i, j int) bool {
+		if contributors[i].Commits != contributors[j].Commits {
+			return contributors[i].Commits > contributors[j].Commits
+		}
+		return contributors[i].String() < contributors[j].String()
+	})
+	return contributors
+}
+
+// noreplyLogin returns the login of a GitHub noreply commit email, or "" for other emails.
+func noreplyLogin(email string) string {
+	if !strings.HasSuffix(strings.ToLower(email), noreplyDomain) {
+		return ""
+	}
+	local := email[:len(email)-len(noreplyDomain)]
+	if _, login, found := strings.Cut(local, "+"); found {
+		return login
+	}
+	return local
+}
+
+// noreplyID returns the user ID of a GitHub noreply commit email, or zero when it does not include one.
+func noreplyID(email string) int64 {
+	local, _, found := strings.Cut(email, "+")
+	if !found {
+		return 0
+	}
+	id, err := strconv.ParseInt(local, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// formatContributors renders the contributors selected by include, or "N/A" when there are none.
+func formatContributors(contributors []*Contributor, include func(*Contributor) bool, format func(*Contributor) string) string {
+	var parts []string
+	for _, c := range contributors {
+		if include(c) {
+			parts = append(parts, format(c))
+		}
+	}
+	if len(parts) == 0 {
+		return "N/A"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// activeRepoColumns lists every column the active repositories report can output.
+var activeRepoColumns = []Column[*ActiveRepoReport]{
+	{Name: "Owner", Value: func(r *ActiveRepoReport) string { return r.GetOwner().GetLogin() }},
+	{Name: "Repository", Value: func(r *ActiveRepoReport) string { return r.GetName() }},
+	{Name: "Visibility", Value: func(r *ActiveRepoReport) string { return r.GetVisibility() }},
+	{Name: "Pushed_At", Value: func(r *ActiveRepoReport) string { return r.GetPushedAt().Format(time.RFC3339) }},
+	{Name: "Recent_Contributors", Value: func(r *ActiveRepoReport) string {
+		if len(r.RecentContributors) == 0 {
+			return "N/A"
+		}
+		return strings.Join(r.RecentContributors, "; ")
+	}},
+	{Name: "Contributor_Count", Value: func(r *ActiveRepoReport) string { return fmt.Sprintf("%d", len(r.RecentContributors)) }},
+	{Name: "Contributor_IDs", Value: func(r *ActiveRepoReport) string {
+		return formatContributors(r.Contributors, func(c *Contributor) bool { return !c.Bot && c.ID != 0 },
+			func(c *Contributor) string { return strconv.FormatInt(c.ID, 10) })
+	}},
+	{Name: "Contributor_Commits", Value: func(r *ActiveRepoReport) string {
+		return formatContributors(r.Contributors, func(c *Contributor) bool { return !c.Bot },
+			func(c *Contributor) string { return fmt.Sprintf("%s (%d)", c, c.Commits) })
+	}},
+	{Name: "Unresolved_Contributors", Value: func(r *ActiveRepoReport) string {
+		return formatContributors(r.Contributors, func(c *Contributor) bool { return c.Login == "" }, (*Contributor).String)
+	}},
+	{Name: "Bots", Value: func(r *ActiveRepoReport) string {
+		return formatContributors(r.Contributors, func(c *Contributor) bool { return c.Bot },
+			func(c *Contributor) string { return fmt.Sprintf("%s (%d)", c, c.Commits) })
+	}},
+	{Name: "Commit_Count", Value: func(r *ActiveRepoReport) string { return strconv.Itoa(r.Commits) }},
+	{Name: "Branch_Count", Value: func(r *ActiveRepoReport) string { return strconv.Itoa(r.Branches) }},
+	statusColumn[*ActiveRepoReport](),
+}
+
+// defaultActiveRepoColumns is the column layout written when no columns are configured.
+var defaultActiveRepoColumns = []string{"Owner", "Repository", "Pushed_At", "Recent_Contributors", "Status"}
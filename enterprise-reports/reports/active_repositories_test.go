@@ -2,14 +2,23 @@
 package reports
 
 import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-github/v70/github"
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockActiveRepositoriesAPIClient is a mock implementation for testing active repositories report
@@ -62,12 +71,6 @@ func TestActiveRepositoriesReport(t *testing.T) {
 	// Test repositories (includes both active and inactive)
 	repos := []*github.Repository{activeRepo1, activeRepo2, inactiveRepo}
 
-	// Test commits for active repos
-	commits1 := []*github.RepositoryCommit{
-		createTestCommit("user1", "user1", time.Now().AddDate(0, 0, -5)),
-		createTestCommit("user2", "user2", time.Now().AddDate(0, 0, -10)),
-	}
-
 	// Set up cache with test data
 	cache.SetEnterpriseOrgs(orgs)
 	cache.SetOrgRepositories("org1", repos)
@@ -90,65 +93,6 @@ func TestActiveRepositoriesReport(t *testing.T) {
 		assert.Equal(t, "active-repo-1", activeRepos[0].GetName())
 		assert.Equal(t, "active-repo-2", activeRepos[1].GetName())
 	})
-
-	t.Run("should extract unique contributors from commits", func(t *testing.T) {
-		// Test the contributor extraction logic
-		contributorMap := make(map[string]bool)
-
-		for _, commit := range commits1 {
-			if commit.GetCommit() != nil && commit.GetCommit().GetCommitter() != nil {
-				committerName := commit.GetCommit().GetCommitter().GetName()
-				if committerName != "" && committerName != "GitHub" && committerName != "github-merge-queue" {
-					contributorMap[committerName] = true
-				}
-			}
-			if commit.GetCommit() != nil && commit.GetCommit().GetAuthor() != nil {
-				authorName := commit.GetCommit().GetAuthor().GetName()
-				if authorName != "" && authorName != "GitHub" && authorName != "github-merge-queue" {
-					contributorMap[authorName] = true
-				}
-			}
-		}
-
-		// Convert map to slice
-		contributors := make([]string, 0, len(contributorMap))
-		for name := range contributorMap {
-			contributors = append(contributors, name)
-		}
-
-		assert.Len(t, contributors, 2, "Should have 2 unique contributors")
-		assert.Contains(t, contributors, "user1")
-		assert.Contains(t, contributors, "user2")
-	})
-
-	t.Run("should filter out GitHub system users", func(t *testing.T) {
-		// Test commits with system users
-		systemCommits := []*github.RepositoryCommit{
-			createTestCommit("user1", "user1", time.Now()),
-			createTestCommit("GitHub", "GitHub", time.Now()),
-			createTestCommit("github-merge-queue", "github-merge-queue", time.Now()),
-		}
-
-		contributorMap := make(map[string]bool)
-		for _, commit := range systemCommits {
-			if commit.GetCommit() != nil && commit.GetCommit().GetCommitter() != nil {
-				committerName := commit.GetCommit().GetCommitter().GetName()
-				if committerName != "" && committerName != "GitHub" && committerName != "github-merge-queue" {
-					contributorMap[committerName] = true
-				}
-			}
-		}
-
-		contributors := make([]string, 0, len(contributorMap))
-		for name := range contributorMap {
-			contributors = append(contributors, name)
-		}
-
-		assert.Len(t, contributors, 1, "Should filter out GitHub system users")
-		assert.Contains(t, contributors, "user1")
-		assert.NotContains(t, contributors, "GitHub")
-		assert.NotContains(t, contributors, "github-merge-queue")
-	})
 }
 
 func TestActiveRepoReportFormatting(t *testing.T) {
@@ -196,3 +140,111 @@ func TestActiveRepoReportFormattingEmptyContributors(t *testing.T) {
 	// Test formatting
 	assert.Equal(t, "N/A", expectedRow[3])
 }
+
+// authoredCommit creates a commit with a git author email, linked to the GitHub user login when it is not empty.
+func authoredCommit(sha, name, email, login, userType string, id int64) *github.RepositoryCommit {
+	commit := createTestCommit(name, "GitHub", time.Now())
+	commit.SHA = github.Ptr(sha)
+	commit.Commit.Author.Email = github.Ptr(email)
+	if login != "" {
+		commit.Author = &github.User{Login: github.Ptr(login), ID: github.Ptr(id), Type: github.Ptr(userType)}
+	}
+	return commit
+}
+
+// TestSummarizeContributors tests that commit authors are resolved to logins from the linked author,
+// noreply emails and the email lookup, and that bots and unresolved authors are told apart.
+func TestSummarizeContributors(t *testing.T) {
+	commits := []*github.RepositoryCommit{
+		authoredCommit("1", "Alice A", "alice@corp.com", "alice", "User", 1),
+		authoredCommit("2", "alice", "7+Alice@users.noreply.github.com", "", "", 0),
+		authoredCommit("3", "Bob", "bob@corp.com", "", "", 0),
+		authoredCommit("4", "dependabot[bot]", "49699333+dependabot[bot]@users.noreply.github.com", "", "", 0),
+		authoredCommit("5", "Renovate", "bot@renovateapp.com", "renovate", "Bot", 2),
+		authoredCommit("6", "Carol", "carol@laptop.local", "", "", 0),
+		authoredCommit("7", "Carol", "carol@laptop.local", "", "", 0),
+		createTestCommit("Dave", "GitHub", time.Now()),
+	}
+	lookup := func(email string) *github.User {
+		if email == "bob@corp.com" {
+			return &github.User{Login: github.Ptr("bob"), ID: github.Ptr(int64(3))}
+		}
+		return nil
+	}
+
+	var got []string
+	for _, c := range summarizeContributors(commits, lookup) {
+		got = append(got, fmt.Sprintf("%s id=%d commits=%d bot=%t", c, c.ID, c.Commits, c.Bot))
+	}
+	assert.Equal(t, []string{
+		"Carol <carol@laptop.local> id=0 commits=2 bot=false",
+		"alice id=1 commits=2 bot=false",
+		"Dave id=0 commits=1 bot=false",
+		"bob id=3 commits=1 bot=false",
+		"dependabot[bot] id=49699333 commits=1 bot=true",
+		"renovate id=2 commits=1 bot=true",
+	}, got)
+
+	// Without a lookup, authors that are not linked to a user stay unresolved
+	assert.Equal(t, "Bob <bob@corp.com>", summarizeContributors(commits[2:3], nil)[0].String())
+}
+
+// TestActiveRepositoriesReport_AllBranches tests that commits are read from every branch committed to
+// within the window and that commits on several branches are counted once.
+func TestActiveRepositoriesReport_AllBranches(t *testing.T) {
+	recent := time.Now().UTC().AddDate(0, 0, -1).Format(time.RFC3339)
+	old := time.Now().UTC().AddDate(-1, 0, 0).Format(time.RFC3339)
+	gSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := fmt.Fprintf(w, `{"data":{"repository":{"refs":{"nodes":[
+			{"name":"main","target":{"committedDate":%q}},
+			{"name":"feature","target":{"committedDate":%q}},
+			{"name":"broken","target":{"committedDate":%q}},
+			{"name":"abandoned","target":{"committedDate":%q}}],
+			"pageInfo":{"hasNextPage":false,"endCursor":null}}}}}`, recent, recent, recent, old)
+		require.NoError(t, err)
+	}))
+	t.Cleanup(gSrv.Close)
+
+	commit := func(sha, login, date string) string {
+		return fmt.Sprintf(`{"sha":%q,"author":{"login":%q,"id":1,"type":"User"},"commit":{"author":{"name":%q,"email":"x@corp.com"},"committer":{"date":%q}}}`, sha, login, login, date)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/app/commits", func(w http.ResponseWriter, r *http.Request) {
+		// Only the default branch is listed
+		assert.Equal(t, "main", r.URL.Query().Get("sha"))
+		fmt.Fprintf(w, "[%s,%s]", commit("a", "alice", recent), commit("b", "alice", recent))
+	})
+	mux.HandleFunc("/repos/org/app/compare/main...feature", func(w http.ResponseWriter, r *http.Request) {
+		// The commits the feature branch holds beyond main, including one from before the window
+		fmt.Fprintf(w, `{"commits":[%s,%s]}`, commit("z", "carol", old), commit("c", "bob", recent))
+	})
+	mux.HandleFunc("/repos/org/app/compare/main...broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Not Found"}`)
+	})
+	rSrv := httptest.NewServer(mux)
+	t.Cleanup(rSrv.Close)
+
+	restClient := github.NewClient(rSrv.Client())
+	baseURL, _ := url.Parse(rSrv.URL + "/")
+	restClient.BaseURL = baseURL
+	graphClient := githubv4.NewEnterpriseClient(gSrv.URL, gSrv.Client())
+
+	repo := createTestRepoForActive("org", "app", time.Now().AddDate(0, 0, -1))
+	repo.DefaultBranch = github.Ptr("main")
+	cache := utils.NewSharedCache()
+	cache.SetEnterpriseOrgs([]*github.Organization{{Login: github.Ptr("org")}})
+	cache.SetOrgRepositories("org", []*github.Repository{repo})
+
+	out := filepath.Join(t.TempDir(), "active.csv")
+	opts := Options{ActiveDays: 30, Columns: []ColumnSpec{{Name: "Repository"}, {Name: "Recent_Contributors"}, {Name: "Contributor_Commits"}, {Name: "Commit_Count"}, {Name: "Branch_Count"}, {Name: "Status"}}}
+	require.NoError(t, ActiveRepositoriesReport(context.Background(), restClient, graphClient, "ent", out, 1, cache, opts))
+
+	// The branch that could not be read is not counted
+	records, err := csv.NewReader(strings.NewReader(readFile(t, out))).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Repository", "Recent_Contributors", "Contributor_Commits", "Commit_Count", "Branch_Count", "Status"},
+		{"app", "alice; bob", "alice (2); bob (1)", "3", "2", "failed: commits"},
+	}, records)
+}
@@ -0,0 +1,258 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"log/slog"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/time/rate"
+)
+
+// Scopes of the privileged roles listed by the admins report.
+const (
+	AdminScopeEnterprise   = "Enterprise"
+	AdminScopeOrganization = "Organization"
+)
+
+// AdminReport represents a privileged role held by a user in the enterprise or one of its organizations.
+type AdminReport struct {
+	Scope           string       // AdminScopeEnterprise or AdminScopeOrganization
+	Organization    string       // Organization the role applies to; empty for enterprise roles
+	User            *github.User // User holding the role
+	Role            string       // Role name, e.g. owner, billing_manager, security_manager or a custom role
+	RoleSource      string       // Where the role is defined: Enterprise, Organization or Predefined
+	RoleDescription string       // Description of an organization role
+	ItemStatus                   // Roles of the organization that could not be fetched
+}
+
+// AdminsReport creates a report of privileged access across the enterprise, one row per role held
+// by a user, for access reviews. It lists:
+//   - the enterprise owners and billing managers,
+//   - the owners of each organization,
+//   - the users assigned an organization role in each organization, such as security managers
+//     and custom organization roles, including how the role was assigned.
+//
+// Listing the enterprise owners requires a token belonging to an enterprise owner, and listing
+// organization role assignments requires organization owner access. Organizations whose owners or
+// roles cannot be listed, and roles whose holders cannot be listed, are reported with a row whose
+// Status column shows the failure, so a review knows they are incomplete.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout
+//   - restClient: GitHub REST API client
+//   - graphQLClient: GitHub GraphQL API client
+//   - enterpriseSlug: Enterprise identifier
+//   - filename: Output CSV file path
+//   - workerCount: Number of concurrent workers for writing roles
+//   - cache: Shared cache for storing and retrieving GitHub data
+//   - opts: Report options, such as the columns to write
+func AdminsReport(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache, opts Options) error {
+	slog.Info("starting admins report", "enterprise", enterpriseSlug, "filename", filename, "workers", workerCount)
+
+	header, formatter, err := selectColumns(adminColumns, defaultAdminColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("admins report columns: %w", err)
+	}
+	formatter = recordRows(opts.Rows, formatter)
+
+	// Create appropriate report writer based on file extension
+	reportWriter, reportErr := NewReportWriter(filename)
+	if reportErr != nil {
+		return reportErr
+	}
+	defer func() {
+		if err := reportWriter.Close(); err != nil {
+			slog.Error("Failed to close report writer", "error", err)
+		}
+	}()
+
+	// Write header to report
+	if headerErr := reportWriter.WriteHeader(header); headerErr != nil {
+		return fmt.Errorf("failed to write header: %w", headerErr)
+	}
+
+	// An incomplete list of enterprise admins would mislead a review, so fail rather than skip them
+	slog.Info("fetching enterprise admins", "enterprise", enterpriseSlug)
+	enterpriseAdmins, err := api.FetchEnterpriseAdmins(ctx, graphQLClient, enterpriseSlug)
+	if err != nil {
+		return fmt.Errorf("failed to fetch enterprise admins: %w", err)
+	}
+	var admins []*AdminReport
+	for _, u := range enterpriseAdmins {
+		admins = append(admins, &AdminReport{
+			Scope:      AdminScopeEnterprise,
+			User:       u,
+			Role:       strings.ToLower(u.GetRoleName()),
+			RoleSource: AdminScopeEnterprise,
+		})
+	}
+
+	// Check cache for organizations or fetch from API
+	var orgs []*github.Organization
+
+	if cachedOrgs, found := cache.GetEnterpriseOrgs(); found {
+		slog.Info("using cached enterprise organizations")
+		orgs = cachedOrgs
+	} else {
+		slog.Info("fetching enterprise organizations", "enterprise", enterpriseSlug)
+		orgs, err = api.FetchEnterpriseOrgs(ctx, graphQLClient, enterpriseSlug)
+		if err != nil {
+			return fmt.Errorf("failed to fetch organizations: %w", err)
+		}
+		// Store in cache
+		cache.SetEnterpriseOrgs(orgs)
+	}
+
+	// Roles are listed per organization, so collect them before writing one row per role
+	for _, org := range orgs {
+		owners, err := orgOwners(ctx, graphQLClient, cache, org.GetLogin())
+		if err != nil {
+			slog.Warn("failed to fetch organization owners", "org", org.GetLogin(), "error", err)
+			failed := &AdminReport{
+				Scope:        AdminScopeOrganization,
+				Organization: org.GetLogin(),
+				User:         &github.User{},
+				Role:         "owner",
+				RoleSource:   AdminScopeOrganization,
+			}
+			failed.fail(ctx, org.GetLogin(), "owners", err)
+			admins = append(admins, failed)
+		}
+		for _, u := range owners {
+			admins = append(admins, &AdminReport{
+				Scope:        AdminScopeOrganization,
+				Organization: org.GetLogin(),
+				User:         u,
+				Role:         "owner",
+				RoleSource:   AdminScopeOrganization,
+			})
+		}
+
+		// A role that cannot be read gets a failed row, so the roster shows the gap
+		roles, err := api.FetchOrganizationRoles(ctx, restClient, org.GetLogin())
+		if err != nil {
+			// Organization roles require owner access to the organization
+			slog.Warn("failed to fetch organization roles, skipping role assignments", "org", org.GetLogin(), "error", err)
+			failed := &AdminReport{
+				Scope:        AdminScopeOrganization,
+				Organization: org.GetLogin(),
+				User:         &github.User{},
+			}
+			failed.fail(ctx, org.GetLogin(), "roles", err)
+			admins = append(admins, failed)
+			continue
+		}
+		for _, role := range roles {
+			users, err := api.FetchOrganizationRoleUsers(ctx, restClient, org.GetLogin(), role.GetID())
+			if err != nil {
+				slog.Warn("failed to fetch organization role assignments", "org", org.GetLogin(), "role", role.GetName(), "error", err)
+				failed := &AdminReport{
+					Scope:           AdminScopeOrganization,
+					Organization:    org.GetLogin(),
+					User:            &github.User{},
+					Role:            role.GetName(),
+					RoleSource:      role.GetSource(),
+					RoleDescription: role.GetDescription(),
+				}
+				failed.fail(ctx, org.GetLogin(), "roles", err)
+				admins = append(admins, failed)
+				continue
+			}
+			for _, u := range users {
+				admins = append(admins, &AdminReport{
+					Scope:           AdminScopeOrganization,
+					Organization:    org.GetLogin(),
+					User:            u,
+					Role:            role.GetName(),
+					RoleSource:      role.GetSource(),
+					RoleDescription: role.GetDescription(),
+				})
+			}
+		}
+		slog.Info("collected organization admins", "org", org.GetLogin(), "owners", len(owners), "roles", len(roles))
+	}
+
+	// Processor: roles are fetched up front, so there is nothing left to enrich
+	processor := func(ctx context.Context, admin *AdminReport) (*AdminReport, error) {
+		return admin, nil
+	}
+
+	// Roles are fetched up front, so processing makes no API calls and is not rate limited
+	limiter := rate.NewLimiter(rate.Inf, workerCount)
+
+	return RunReportWithWriter(ctx, admins, processor, formatter, limiter, workerCount, reportWriter)
+}
+
+// orgOwners returns the owners of an organization, read from the cache when another report
+// already fetched the organization's members with their roles.
+func orgOwners(ctx context.Context, graphQLClient *githubv4.Client, cache *utils.SharedCache, org string) ([]*github.User, error) {
+	members, found := cache.GetOrgMembers(org)
+	if found {
+		slog.Info("using cached organization members", "org", org)
+	} else {
+		var err error
+		members, err = api.FetchOrganizationMembershipsWithRole(ctx, graphQLClient, org)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch members of organization %q: %w", org, err)
+		}
+		// Store in cache
+		cache.SetOrgMembers(org, members)
+	}
+
+	var owners []*github.User
+	for _, m := range members {
+		if m.GetRoleName() == "admin" {
+			owners = append(owners, m)
+		}
+	}
+	return owners, nil
+}
+
+// adminAssignment describes how an organization role was granted to a user: directly,
+// through the listed teams, or both. Enterprise roles and organization owners are always direct.
+// The failed row of a role that could not be read has no user, and no assignment.
+func adminAssignment(r *AdminReport) string {
+	if r.User.GetLogin() == "" {
+		return "N/A"
+	}
+	if r.User.Assignment == nil {
+		return "direct"
+	}
+	return r.User.GetAssignment()
+}
+
+// adminColumns lists every column the admins report can output.
+var adminColumns = []Column[*AdminReport]{
+	{Name: "Scope", Value: func(r *AdminReport) string { return r.Scope }},
+	{Name: "Organization", Value: func(r *AdminReport) string { return naIfEmpty(r.Organization) }},
+	{Name: "Login", Value: func(r *AdminReport) string { return naIfEmpty(r.User.GetLogin()) }},
+	{Name: "Name", Value: func(r *AdminReport) string { return naIfEmpty(r.User.GetName()) }},
+	{Name: "Role", Value: func(r *AdminReport) string { return naIfEmpty(r.Role) }},
+	{Name: "Role Source", Value: func(r *AdminReport) string { return naIfEmpty(r.RoleSource) }},
+	{Name: "Assignment", Value: adminAssignment},
+	{Name: "Inherited From", Value: func(r *AdminReport) string {
+		teams := make([]string, 0, len(r.User.InheritedFrom))
+		for _, t := range r.User.InheritedFrom {
+			teams = append(teams, t.GetSlug())
+		}
+		return joinOrNA(teams)
+	}},
+	{Name: "User ID", Value: func(r *AdminReport) string {
+		if r.User.ID == nil {
+			return "N/A"
+		}
+		return fmt.Sprintf("%d", r.User.GetID())
+	}},
+	{Name: "Role Description", Value: func(r *AdminReport) string { return naIfEmpty(r.RoleDescription) }},
+	statusColumn[*AdminReport](),
+}
+
+// defaultAdminColumns is the column layout written when no columns are configured.
+var defaultAdminColumns = []string{"Scope", "Organization", "Login", "Name", "Role", "Role Source", "Assignment", "Inherited From", "Status"}
@@ -0,0 +1,164 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+// This file contains tests for the admins report functionality.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/fakegithub"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdminsReport tests that the admins report lists enterprise admins, organization owners
+// from GraphQL or the cache, and organization role assignments.
+func TestAdminsReport(t *testing.T) {
+	muxG := http.NewServeMux()
+	muxG.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var resp string
+		switch {
+		case strings.Contains(string(body), "ownerInfo"):
+			resp = `{"data":{"enterprise":{"ownerInfo":{"admins":{"edges":[
+				{"role":"OWNER","node":{"login":"ent-owner","name":"Enterprise Owner","databaseId":1}},
+				{"role":"BILLING_MANAGER","node":{"login":"billing","name":"","databaseId":2}}],
+				"pageInfo":{"hasNextPage":false,"endCursor":""}}}}}}`
+		case strings.Contains(string(body), "membersWithRole"):
+			resp = `{"data":{"organization":{"membersWithRole":{"edges":[
+				{"role":"ADMIN","node":{"login":"org1-owner","name":"Org Owner","databaseId":3}},
+				{"role":"MEMBER","node":{"login":"dev","name":"Dev","databaseId":4}}],
+				"pageInfo":{"hasNextPage":false,"endCursor":""}}}}}`
+		default:
+			t.Fatalf("unexpected query: %s", body)
+		}
+		_, err = fmt.Fprint(w, resp)
+		require.NoError(t, err)
+	})
+	gSrv := httptest.NewServer(muxG)
+	defer gSrv.Close()
+
+	muxR := http.NewServeMux()
+	muxR.HandleFunc("/orgs/org1/organization-roles", func(w http.ResponseWriter, r *http.Request) {
+		_, err := fmt.Fprint(w, `{"total_count":2,"roles":[
+			{"id":10,"name":"security_manager","source":"Predefined","description":"Manage security"},
+			{"id":11,"name":"auditor","source":"Organization","description":"Read audit data"}]}`)
+		require.NoError(t, err)
+	})
+	muxR.HandleFunc("/orgs/org1/organization-roles/10/users", func(w http.ResponseWriter, r *http.Request) {
+		_, err := fmt.Fprint(w, `[{"login":"sec","id":5,"assignment":"indirect","inherited_from":[{"slug":"security"}]}]`)
+		require.NoError(t, err)
+	})
+	muxR.HandleFunc("/orgs/org1/organization-roles/11/users", func(w http.ResponseWriter, r *http.Request) {
+		_, err := fmt.Fprint(w, `[]`)
+		require.NoError(t, err)
+	})
+	muxR.HandleFunc("/orgs/org2/organization-roles", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Must be an organization owner"}`, http.StatusForbidden)
+	})
+	rSrv := httptest.NewServer(muxR)
+	defer rSrv.Close()
+
+	restClient := github.NewClient(rSrv.Client())
+	baseURL, _ := url.Parse(rSrv.URL + "/")
+	restClient.BaseURL = baseURL
+	graphClient := githubv4.NewEnterpriseClient(gSrv.URL+"/graphql", gSrv.Client())
+
+	// org2's members were fetched by the organizations report with REST role names
+	cache := utils.NewSharedCache()
+	cache.SetEnterpriseOrgs([]*github.Organization{{Login: github.Ptr("org1")}, {Login: github.Ptr("org2")}})
+	cache.SetOrgMembers("org2", []*github.User{
+		{Login: github.Ptr("org2-owner"), RoleName: github.Ptr("admin")},
+		{Login: github.Ptr("member"), RoleName: github.Ptr("member")},
+	})
+
+	out := filepath.Join(t.TempDir(), "admins.csv")
+	err := AdminsReport(context.Background(), restClient, graphClient, "ent", out, 2, cache, Options{})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(readFile(t, out)), "\n")
+	require.Len(t, lines, 7)
+	assert.Equal(t, "Scope,Organization,Login,Name,Role,Role Source,Assignment,Inherited From,Status", lines[0])
+	assert.ElementsMatch(t, []string{
+		"Enterprise,N/A,ent-owner,Enterprise Owner,owner,Enterprise,direct,N/A,ok",
+		"Enterprise,N/A,billing,N/A,billing_manager,Enterprise,direct,N/A,ok",
+		"Organization,org1,org1-owner,Org Owner,owner,Organization,direct,N/A,ok",
+		"Organization,org1,sec,N/A,security_manager,Predefined,indirect,security,ok",
+		"Organization,org2,org2-owner,N/A,owner,Organization,direct,N/A,ok",
+		"Organization,org2,N/A,N/A,N/A,N/A,N/A,N/A,failed: roles",
+	}, lines[1:])
+}
+
+// TestAdminsReport_OwnersFailure tests that an organization whose owners cannot be listed is reported
+// as failed, and recorded, while the rest of the report is written.
+func TestAdminsReport_OwnersFailure(t *testing.T) {
+	srv := startDemoServer(t)
+	srv.Inject(fakegithub.Fault{Path: "/graphql", Query: "membersWithRole", Status: http.StatusBadGateway})
+	out := filepath.Join(t.TempDir(), "admins.csv")
+	errorLog := NewErrorLog("admins", out)
+	ctx := WithErrorLog(context.Background(), errorLog)
+
+	require.NoError(t, AdminsReport(ctx, srv.RESTClient(), srv.GraphQLClient(), "octodemo", out, 2, utils.NewSharedCache(), Options{
+		Columns: []ColumnSpec{{Name: "Scope"}, {Name: "Organization"}, {Name: "Login"}, {Name: "Role"}, {Name: "Status"}},
+	}))
+	require.NoError(t, errorLog.Close())
+
+	lines := reportLines(t, out)
+	assert.Contains(t, lines, "Organization,octodemo-apps,N/A,owner,failed: owners")
+	assert.Contains(t, lines, "Organization,octodemo-platform,N/A,owner,failed: owners")
+	entries := readItemErrors(t, ErrorsPath(out))
+	require.Len(t, entries, 2)
+	assert.Equal(t, "owners", entries[0].Field)
+}
+
+// TestAdminsReport_RoleUsersFailure tests that a role whose holders cannot be listed gets a failed
+// row, and is recorded, so the roster shows the gap.
+func TestAdminsReport_RoleUsersFailure(t *testing.T) {
+	srv := startDemoServer(t)
+	srv.Inject(fakegithub.Fault{Path: "/orgs/octodemo-platform/organization-roles/*", Status: http.StatusBadGateway})
+	out := filepath.Join(t.TempDir(), "admins.csv")
+	errorLog := NewErrorLog("admins", out)
+	ctx := WithErrorLog(context.Background(), errorLog)
+
+	require.NoError(t, AdminsReport(ctx, srv.RESTClient(), srv.GraphQLClient(), "octodemo", out, 2, utils.NewSharedCache(), Options{
+		Columns: []ColumnSpec{{Name: "Organization"}, {Name: "Login"}, {Name: "Role"}, {Name: "Status"}},
+	}))
+	require.NoError(t, errorLog.Close())
+
+	lines := reportLines(t, out)
+	assert.Contains(t, lines, "octodemo-platform,N/A,security_manager,failed: roles")
+	assert.NotContains(t, lines, "octodemo-platform,ada,security_manager,ok")
+	entries := readItemErrors(t, ErrorsPath(out))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "octodemo-platform", entries[0].Item)
+	assert.Equal(t, "roles", entries[0].Field)
+}
+
+// TestAdminsReport_OwnerInfoHidden tests that the admins report fails when the token cannot see
+// the enterprise admins, rather than writing an incomplete roster.
+func TestAdminsReport_OwnerInfoHidden(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		_, err := fmt.Fprint(w, `{"data":{"enterprise":{"ownerInfo":null}}}`)
+		require.NoError(t, err)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	graphClient := githubv4.NewEnterpriseClient(srv.URL+"/graphql", srv.Client())
+	out := filepath.Join(t.TempDir(), "admins.csv")
+	err := AdminsReport(context.Background(), github.NewClient(nil), graphClient, "ent", out, 1, utils.NewSharedCache(), Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "enterprise owner")
+}
@@ -0,0 +1,320 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/progress"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+)
+
+// DefaultAuditLogInclude is the event types exported when AuditLogOptions.Include is empty.
+const DefaultAuditLogInclude = "all"
+
+// AuditLogOptions selects the entries the audit-log report exports.
+type AuditLogOptions struct {
+	// Phrase is an audit log search phrase filtering by action, actor, org, repo or date,
+	// e.g. "action:repo.destroy org:my-org". Empty exports every entry.
+	Phrase string
+	// Include selects the event types to export ("web", "git" or "all"). Defaults to DefaultAuditLogInclude.
+	Include string
+	// Checkpoint is the file recording how far previous exports got. Defaults to
+	// "<enterprise>_audit-log_checkpoint.json" next to the report file.
+	Checkpoint string
+}
+
+// AuditLogCheckpoint records the newest entries exported by an audit-log report run,
+// so the next run with the same phrase only exports entries created after them.
+type AuditLogCheckpoint struct {
+	Phrase      string    `json:"phrase"`
+	Include     string    `json:"include"`
+	LastCreated time.Time `json:"last_created"`
+	// DocumentIDs lists the entries exported within the second of LastCreated. The search only
+	// filters whole seconds, so these entries are fetched again and must be skipped.
+	DocumentIDs []string `json:"document_ids"`
+}
+
+// LoadAuditLogCheckpoint reads a checkpoint file. A missing file yields a nil checkpoint and no error.
+func LoadAuditLogCheckpoint(path string) (*AuditLogCheckpoint, error) {
+	// #nosec G304  // path is chosen by the user running the export
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log checkpoint %s: %w", path, err)
+	}
+
+	var checkpoint AuditLogCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse audit log checkpoint %s: %w", path, err)
+	}
+	return &checkpoint, nil
+}
+
+// Save writes the checkpoint to path, replacing the previous file only once the new one is complete.
+func (c *AuditLogCheckpoint) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode audit log checkpoint: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write audit log checkpoint %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace audit log checkpoint %s: %w", path, err)
+	}
+	return nil
+}
+
+// observe advances the checkpoint past an exported entry.
+func (c *AuditLogCheckpoint) observe(entry *github.AuditEntry) {
+	if entry.CreatedAt == nil {
+		return
+	}
+	created := entry.CreatedAt.UTC()
+	switch second := created.Truncate(time.Second); {
+	case second.After(c.LastCreated.Truncate(time.Second)):
+		c.LastCreated = created
+		c.DocumentIDs = []string{entry.GetDocumentID()}
+	case second.Equal(c.LastCreated.Truncate(time.Second)):
+		if created.After(c.LastCreated) {
+			c.LastCreated = created
+		}
+		c.DocumentIDs = append(c.DocumentIDs, entry.GetDocumentID())
+	}
+}
+
+// AuditLogReport exports the enterprise audit log entries matching opts.AuditLog.Phrase, oldest first.
+// Entries are written page by page as they are fetched rather than being collected first.
+//
+// After a successful export the newest entry is recorded in a checkpoint file. The next export with
+// the same phrase and include setting only fetches entries created since then, which makes the report
+// suitable for feeding incremental exports to a SIEM. Changing the phrase starts a full export.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout
+//   - restClient: GitHub REST API client
+//   - enterpriseSlug: Enterprise identifier
+//   - filename: Output file path
+//   - opts: Report options, such as the columns to write and the entries to export
+func AuditLogReport(ctx context.Context, restClient *github.Client, enterpriseSlug, filename string, opts Options) error {
+	slog.Info("starting audit log report", "enterprise", enterpriseSlug, "filename", filename, "phrase", opts.AuditLog.Phrase)
+
+	header, formatter, err := selectColumns(auditLogColumns, defaultAuditLogColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("audit-log report columns: %w", err)
+	}
+	formatter = recordRows(opts.Rows, formatter)
+
+	include := opts.AuditLog.Include
+	if include == "" {
+		include = DefaultAuditLogInclude
+	}
+	checkpointPath := opts.AuditLog.Checkpoint
+	if checkpointPath == "" {
+		checkpointPath = filepath.Join(filepath.Dir(filename), enterpriseSlug+"_audit-log_checkpoint.json")
+	}
+
+	previous, err := LoadAuditLogCheckpoint(checkpointPath)
+	if err != nil {
+		return err
+	}
+	if previous != nil && (previous.Phrase != opts.AuditLog.Phrase || previous.Include != include) {
+		slog.Warn("audit log checkpoint was written for a different query, exporting all matching entries",
+			"checkpoint", checkpointPath, "checkpoint_phrase", previous.Phrase, "checkpoint_include", previous.Include)
+		previous = nil
+	}
+	if previous != nil && previous.LastCreated.IsZero() {
+		// The previous export found no entries
+		previous = nil
+	}
+
+	query := api.AuditLogQuery{Phrase: opts.AuditLog.Phrase, Include: include, Order: "asc"}
+	checkpoint := &AuditLogCheckpoint{Phrase: opts.AuditLog.Phrase, Include: include}
+	if previous != nil {
+		slog.Info("resuming audit log export from checkpoint", "checkpoint", checkpointPath, "last_created", previous.LastCreated)
+		query.Phrase, err = resumeAuditLogPhrase(query.Phrase, previous.LastCreated)
+		if err != nil {
+			return fmt.Errorf("resuming audit log export from checkpoint %s: %w", checkpointPath, err)
+		}
+		*checkpoint = *previous
+		checkpoint.DocumentIDs = slices.Clone(previous.DocumentIDs)
+	}
+
+	// Create appropriate report writer based on file extension
+	reportWriter, err := NewReportWriter(filename)
+	if err != nil {
+		return err
+	}
+	closed := false
+	defer func() {
+		if closed {
+			return
+		}
+		if err := reportWriter.Close(); err != nil {
+			slog.Error("Failed to close report writer", "error", err)
+		}
+	}()
+
+	// Write header to report
+	if err := reportWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	// The number of entries is not known up front, so progress only counts the exported ones
+	progress.ReportFrom(ctx).AddTotal(0)
+
+	var exported, skipped int
+	err = api.StreamAuditLog(ctx, restClient, enterpriseSlug, query, func(entries []*github.AuditEntry, _ string) error {
+		for _, entry := range entries {
+			// Entries in the checkpoint's last second were exported by the previous run
+			if previous != nil && slices.Contains(previous.DocumentIDs, entry.GetDocumentID()) {
+				skipped++
+				continue
+			}
+			if err := reportWriter.WriteRow(formatter(entry)); err != nil {
+				return utils.NewAppError(utils.ErrorTypeIO, "failed to write row", err).WithRetry(false)
+			}
+			checkpoint.observe(entry)
+			exported++
+			progress.ReportFrom(ctx).Processed()
+		}
+		slog.Info("exported audit log page", "entries", exported)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("exporting audit log for %q: %w", enterpriseSlug, err)
+	}
+
+	// Only advance the checkpoint once every exported entry is safely written
+	closed = true
+	if err := reportWriter.Close(); err != nil {
+		return fmt.Errorf("failed to close report writer: %w", err)
+	}
+	if err := checkpoint.Save(checkpointPath); err != nil {
+		return err
+	}
+
+	slog.Info("audit log report completed", "exported", exported, "skipped", skipped, "checkpoint", checkpointPath, "last_created", checkpoint.LastCreated)
+	return nil
+}
+
+// resumeAuditLogPhrase restricts a search phrase to entries created at or after the second of since.
+// The search applies a single created qualifier, so a lower bound in the phrase, as in created:>=date,
+// created:>date or created:date..date, is merged with since by keeping the later of the two.
+// Other created qualifiers, such as an upper bound alone, cannot be merged and are rejected.
+func resumeAuditLogPhrase(phrase string, since time.Time) (string, error) {
+	since = since.UTC().Truncate(time.Second)
+	resume := "created:>=" + since.Format(time.RFC3339)
+
+	fields := strings.Fields(phrase)
+	index := -1
+	for i, field := range fields {
+		if !strings.HasPrefix(field, "created:") {
+			continue
+		}
+		if index >= 0 {
+			return "", fmt.Errorf("phrase %q filters by created more than once", phrase)
+		}
+		index = i
+	}
+	if index < 0 {
+		return strings.Join(append(fields, resume), " "), nil
+	}
+
+	value := strings.TrimPrefix(fields[index], "created:")
+	switch {
+	case strings.HasPrefix(value, ">"):
+		bound, err := parseAuditLogDate(strings.TrimLeft(value, ">="))
+		if err != nil {
+			return "", err
+		}
+		if !bound.After(since) {
+			fields[index] = resume
+		}
+	case strings.Contains(value, ".."):
+		lower, upper, _ := strings.Cut(value, "..")
+		if lower != "*" {
+			bound, err := parseAuditLogDate(lower)
+			if err != nil {
+				return "", err
+			}
+			if bound.After(since) {
+				break
+			}
+		}
+		fields[index] = "created:" + since.Format(time.RFC3339) + ".." + upper
+	default:
+		return "", fmt.Errorf("the created:%s qualifier of phrase %q cannot be combined with the checkpoint: use a lower bound or a range, or delete the checkpoint", value, phrase)
+	}
+	return strings.Join(fields, " "), nil
+}
+
+// parseAuditLogDate parses a date of a created qualifier, either a day or a time, as UTC when it has no offset.
+func parseAuditLogDate(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid created date %q", value)
+}
+
+// auditLogField returns a field of an entry that go-github does not map, such as "repo", or "" if absent.
+func auditLogField(e *github.AuditEntry, name string) string {
+	value, ok := e.AdditionalFields[name]
+	if !ok || value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+// auditLogColumns lists every column the audit-log report can output.
+var auditLogColumns = []Column[*github.AuditEntry]{
+	{Name: "Created At", Value: func(e *github.AuditEntry) string { return formatTimestamp(e.CreatedAt) }},
+	{Name: "Action", Value: func(e *github.AuditEntry) string { return e.GetAction() }},
+	{Name: "Actor", Value: func(e *github.AuditEntry) string { return e.GetActor() }},
+	{Name: "User", Value: func(e *github.AuditEntry) string { return e.GetUser() }},
+	{Name: "Organization", Value: func(e *github.AuditEntry) string { return e.GetOrg() }},
+	{Name: "Repository", Value: func(e *github.AuditEntry) string { return auditLogField(e, "repo") }},
+	{Name: "Country", Value: func(e *github.AuditEntry) string { return e.GetActorLocation().GetCountryCode() }},
+	{Name: "Document ID", Value: func(e *github.AuditEntry) string { return e.GetDocumentID() }},
+	{Name: "Actor ID", Value: func(e *github.AuditEntry) string {
+		if e.ActorID == nil {
+			return ""
+		}
+		return fmt.Sprintf("%d", e.GetActorID())
+	}},
+	{Name: "Hashed Token", Value: func(e *github.AuditEntry) string { return e.GetHashedToken() }},
+	{Name: "Token Scopes", Value: func(e *github.AuditEntry) string { return e.GetTokenScopes() }},
+	{Name: "External Identity", Value: func(e *github.AuditEntry) string { return e.GetExternalIdentityNameID() }},
+	{Name: "Operation Type", Value: func(e *github.AuditEntry) string { return auditLogField(e, "operation_type") }},
+	{Name: "Raw", Value: func(e *github.AuditEntry) string {
+		// The full entry as returned by the API, for SIEM ingestion
+		data, err := json.Marshal(e)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}},
+}
+
+// defaultAuditLogColumns is the column layout written when no columns are configured.
+var defaultAuditLogColumns = []string{"Created At", "Action", "Actor", "User", "Organization", "Repository", "Country", "Document ID"}
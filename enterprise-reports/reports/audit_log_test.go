@@ -0,0 +1,189 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+// This file contains tests for the audit log report functionality.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuditLogReport_Checkpoint tests that the audit log report streams every page of entries,
+// records a checkpoint, and only exports new entries on the next run.
+func TestAuditLogReport_Checkpoint(t *testing.T) {
+	entry := func(id, action, created string) string {
+		return fmt.Sprintf(`{"_document_id":%q,"action":%q,"actor":"alice","org":"org1","repo":"org1/repo1","created_at":%q}`, id, action, created)
+	}
+
+	var phrases []string
+	run := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/enterprises/ent/audit-log", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		assert.Equal(t, "asc", q.Get("order"))
+		assert.Equal(t, "all", q.Get("include"))
+		phrases = append(phrases, q.Get("phrase"))
+
+		var body string
+		switch {
+		case run == 0 && q.Get("after") == "":
+			// First page links to a second page by cursor
+			w.Header().Set("Link", `<https://api.github.com/enterprises/ent/audit-log?after=cursor1>; rel="next"`)
+			body = "[" + entry("a", "repo.create", "2024-05-01T10:00:00Z") + "," + entry("b", "repo.destroy", "2024-05-01T10:00:05Z") + "]"
+		case run == 0:
+			assert.Equal(t, "cursor1", q.Get("after"))
+			body = "[" + entry("c", "repo.create", "2024-05-01T10:00:05Z") + "]"
+		default:
+			// The search includes the checkpoint's second, so "b" and "c" are returned again
+			body = "[" + entry("b", "repo.destroy", "2024-05-01T10:00:05Z") + "," + entry("c", "repo.create", "2024-05-01T10:00:05Z") + "," +
+				entry("d", "repo.archived", "2024-05-02T08:00:00Z") + "]"
+		}
+		_, err := fmt.Fprint(w, body)
+		require.NoError(t, err)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	restClient := github.NewClient(srv.Client())
+	baseURL, _ := url.Parse(srv.URL + "/")
+	restClient.BaseURL = baseURL
+
+	dir := t.TempDir()
+	opts := Options{
+		Columns:  []ColumnSpec{{Name: "Document ID"}, {Name: "Action"}, {Name: "Repository"}},
+		AuditLog: AuditLogOptions{Phrase: "org:org1"},
+	}
+
+	first := filepath.Join(dir, "first.csv")
+	require.NoError(t, AuditLogReport(context.Background(), restClient, "ent", first, opts))
+	assert.Equal(t, "Document ID,Action,Repository\na,repo.create,org1/repo1\nb,repo.destroy,org1/repo1\nc,repo.create,org1/repo1\n", readFile(t, first))
+
+	checkpoint, err := LoadAuditLogCheckpoint(filepath.Join(dir, "ent_audit-log_checkpoint.json"))
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 5, 0, time.UTC), checkpoint.LastCreated)
+	assert.Equal(t, []string{"b", "c"}, checkpoint.DocumentIDs)
+
+	run++
+	second := filepath.Join(dir, "second.csv")
+	require.NoError(t, AuditLogReport(context.Background(), restClient, "ent", second, opts))
+	assert.Equal(t, "Document ID,Action,Repository\nd,repo.archived,org1/repo1\n", readFile(t, second))
+	assert.Equal(t, "org:org1 created:>=2024-05-01T10:00:05Z", phrases[len(phrases)-1])
+
+	checkpoint, err = LoadAuditLogCheckpoint(filepath.Join(dir, "ent_audit-log_checkpoint.json"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d"}, checkpoint.DocumentIDs)
+}
+
+// TestAuditLogReport_PhraseChanged tests that a checkpoint written for another phrase is ignored.
+func TestAuditLogReport_PhraseChanged(t *testing.T) {
+	var phrase string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/enterprises/ent/audit-log", func(w http.ResponseWriter, r *http.Request) {
+		phrase = r.URL.Query().Get("phrase")
+		_, err := fmt.Fprint(w, `[]`)
+		require.NoError(t, err)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	restClient := github.NewClient(srv.Client())
+	baseURL, _ := url.Parse(srv.URL + "/")
+	restClient.BaseURL = baseURL
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "checkpoint.json")
+	old := &AuditLogCheckpoint{Phrase: "action:user.login", Include: "all", LastCreated: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	require.NoError(t, old.Save(path))
+
+	opts := Options{AuditLog: AuditLogOptions{Phrase: "action:repo.destroy", Checkpoint: path}}
+	require.NoError(t, AuditLogReport(context.Background(), restClient, "ent", filepath.Join(dir, "out.csv"), opts))
+	assert.Equal(t, "action:repo.destroy", phrase)
+}
+
+// TestResumeAuditLogPhrase tests that resuming merges a created qualifier of the phrase with the
+// checkpoint by keeping the later lower bound, and rejects qualifiers that cannot be merged.
+func TestResumeAuditLogPhrase(t *testing.T) {
+	since := time.Date(2024, 5, 1, 10, 0, 5, 500, time.UTC)
+	tests := []struct {
+		name    string
+		phrase  string
+		want    string
+		wantErr string
+	}{
+		{name: "no phrase", phrase: "", want: "created:>=2024-05-01T10:00:05Z"},
+		{name: "no created qualifier", phrase: "org:org1", want: "org:org1 created:>=2024-05-01T10:00:05Z"},
+		{name: "earlier lower bound", phrase: "created:>=2024-01-01 org:org1", want: "created:>=2024-05-01T10:00:05Z org:org1"},
+		{name: "later lower bound", phrase: "created:>2024-06-01 org:org1", want: "created:>2024-06-01 org:org1"},
+		{name: "range from earlier", phrase: "created:2024-01-01..2024-12-31", want: "created:2024-05-01T10:00:05Z..2024-12-31"},
+		{name: "range from later", phrase: "created:2024-06-01..2024-12-31", want: "created:2024-06-01..2024-12-31"},
+		{name: "open range", phrase: "created:*..2024-12-31", want: "created:2024-05-01T10:00:05Z..2024-12-31"},
+		{name: "upper bound", phrase: "created:<2024-12-31", wantErr: "cannot be combined with the checkpoint"},
+		{name: "single day", phrase: "created:2024-05-01", wantErr: "cannot be combined with the checkpoint"},
+		{name: "invalid date", phrase: "created:>=yesterday", wantErr: "invalid created date"},
+		{name: "repeated qualifier", phrase: "created:>=2024-01-01 created:<2024-12-31", wantErr: "more than once"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resumeAuditLogPhrase(tt.phrase, since)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestAuditLogReport_CreatedPhrase tests that resuming an export whose phrase filters by created
+// searches from the later of the phrase's lower bound and the checkpoint.
+func TestAuditLogReport_CreatedPhrase(t *testing.T) {
+	var phrase string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/enterprises/ent/audit-log", func(w http.ResponseWriter, r *http.Request) {
+		phrase = r.URL.Query().Get("phrase")
+		_, err := fmt.Fprint(w, `[]`)
+		require.NoError(t, err)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	restClient := github.NewClient(srv.Client())
+	baseURL, _ := url.Parse(srv.URL + "/")
+	restClient.BaseURL = baseURL
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "checkpoint.json")
+	previous := &AuditLogCheckpoint{Phrase: "created:>=2024-01-01", Include: "all", LastCreated: time.Date(2024, 5, 1, 10, 0, 5, 0, time.UTC)}
+	require.NoError(t, previous.Save(path))
+
+	opts := Options{AuditLog: AuditLogOptions{Phrase: "created:>=2024-01-01", Checkpoint: path}}
+	require.NoError(t, AuditLogReport(context.Background(), restClient, "ent", filepath.Join(dir, "out.csv"), opts))
+	assert.Equal(t, "created:>=2024-05-01T10:00:05Z", phrase)
+
+	previous.Phrase = "created:<2024-12-31"
+	require.NoError(t, previous.Save(path))
+	opts.AuditLog.Phrase = previous.Phrase
+	err := AuditLogReport(context.Background(), restClient, "ent", filepath.Join(dir, "out.csv"), opts)
+	assert.ErrorContains(t, err, "cannot be combined with the checkpoint")
+}
+
+// readFile returns the contents of a file written by a test.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.ReplaceAll(string(data), "\r\n", "\n")
+}
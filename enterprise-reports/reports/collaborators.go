@@ -22,6 +22,7 @@ import (
 type CollaboratorReport struct {
 	Repository    *github.Repository // The repository being analyzed
 	Collaborators []CollaboratorInfo // List of collaborators with their permissions
+	ItemStatus                       // Whether the collaborators could be fetched
 }
 
 // CollaboratorInfo contains simplified collaborator information for CSV output.
@@ -43,12 +44,19 @@ type CollaboratorInfo struct {
 //   - enterpriseSlug: Enterprise identifier
 //   - filename: Output CSV file path
 //   - workerCount: Number of concurrent workers for processing repositories
+//   - opts: Report options, such as the columns to write
 //
 // The report includes repository full name and JSON-encoded collaborator details
 // with login, ID, and permission level for each collaborator.
-func CollaboratorsReport(ctx context.Context, restClient *github.Client, graphClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache) error {
+func CollaboratorsReport(ctx context.Context, restClient *github.Client, graphClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache, opts Options) error {
 	slog.Info("starting collaborators report", "enterprise", enterpriseSlug, "filename", filename, "workers", workerCount)
 
+	header, formatter, err := selectColumns(collaboratorColumns, defaultCollaboratorColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("collaborators report columns: %w", err)
+	}
+	formatter = recordRows(opts.Rows, formatter)
+
 	// Create appropriate report writer based on file extension
 	reportWriter, reportErr := NewReportWriter(filename)
 	if reportErr != nil {
@@ -60,8 +68,6 @@ func CollaboratorsReport(ctx context.Context, restClient *github.Client, graphCl
 		}
 	}()
 
-	header := []string{"Repository", "Collaborators"}
-
 	// Write header to report
 	if headerErr := reportWriter.WriteHeader(header); headerErr != nil {
 		return fmt.Errorf("failed to write header: %w", headerErr)
@@ -69,7 +75,6 @@ func CollaboratorsReport(ctx context.Context, restClient *github.Client, graphCl
 
 	// Check cache for organizations or fetch from API
 	var orgs []*github.Organization
-	var err error
 
 	if cachedOrgs, found := cache.GetEnterpriseOrgs(); found {
 		slog.Info("using cached enterprise organizations")
@@ -108,7 +113,7 @@ func CollaboratorsReport(ctx context.Context, restClient *github.Client, graphCl
 
 	// Processor: fetch collaborators for a repository
 	processor := func(ctx context.Context, repo *github.Repository) (*CollaboratorReport, error) {
-		slog.Info("processing collaborators", "repo", repo.GetFullName())
+		slog.Debug("processing collaborators", "repo", repo.GetFullName())
 
 		// Check cache for repository collaborators
 		var cols []*github.User
@@ -120,9 +125,11 @@ func CollaboratorsReport(ctx context.Context, restClient *github.Client, graphCl
 		} else {
 			cols, err = api.FetchRepoCollaborators(ctx, restClient, repo)
 			if err != nil {
-				// Log the error but return a report with empty collaborators instead of skipping.
+				// Log the error but return a report with empty collaborators, marked failed, instead of skipping.
 				slog.Warn("failed to fetch collaborators, reporting repo with empty collaborators", slog.String("repo", repo.GetFullName()), "error", err)
-				return &CollaboratorReport{Repository: repo, Collaborators: []CollaboratorInfo{}}, nil // Return non-nil report, nil error
+				report := &CollaboratorReport{Repository: repo, Collaborators: []CollaboratorInfo{}}
+				report.fail(ctx, repo.GetFullName(), "collaborators", err)
+				return report, nil // Return non-nil report, nil error
 			}
 			// Store in cache
 			cache.SetRepoCollaborators(repo.GetFullName(), cols)
@@ -135,28 +142,38 @@ func CollaboratorsReport(ctx context.Context, restClient *github.Client, graphCl
 		return &CollaboratorReport{Repository: repo, Collaborators: infos}, nil
 	}
 
-	// Formatter: format collaborators into CSV row
-	formatter := func(r *CollaboratorReport) []string {
-		row := []string{r.Repository.GetFullName()}
-		if len(r.Collaborators) == 0 {
-			row = append(row, "N/A") // Add "N/A" if no collaborators
-		} else {
-			for _, ci := range r.Collaborators {
-				data, err := json.Marshal(ci)
-				if err != nil {
-					slog.Error("failed to marshal collaborator info", slog.String("repo", r.Repository.GetFullName()), slog.Any("ci", ci), "error", err)
-					continue // Skip this collaborator on error
-				}
-				row = append(row, string(data))
-			}
-		}
-		return row
-	}
-
 	// Create a limiter for rate limiting - aiming for ~10 requests/sec (below 15/sec limit)
 	// with a burst matching the number of workers.
-	limiter := rate.NewLimiter(rate.Limit(10), workerCount) // e.g., 10 requests/sec, burst of workerCount
+	limiter := rate.NewLimiter(rate.Limit(collaboratorsRate), workerCount) // e.g., 10 requests/sec, burst of workerCount
 
 	// Run the report using the new report writer interface
 	return RunReportWithWriter(ctx, repos, processor, formatter, limiter, workerCount, reportWriter)
 }
+
+// collaboratorColumns lists every column the collaborators report can output.
+var collaboratorColumns = []Column[*CollaboratorReport]{
+	{Name: "Repository", Value: func(r *CollaboratorReport) string { return r.Repository.GetFullName() }},
+	{Name: "Visibility", Value: func(r *CollaboratorReport) string { return r.Repository.GetVisibility() }},
+	{Name: "Collaborator Count", Value: func(r *CollaboratorReport) string { return fmt.Sprintf("%d", len(r.Collaborators)) }},
+	statusColumn[*CollaboratorReport](),
+	// Collaborators expands into one cell per collaborator.
+	{Name: "Collaborators", Values: func(r *CollaboratorReport) []string {
+		if len(r.Collaborators) == 0 {
+			return []string{"N/A"} // Add "N/A" if no collaborators
+		}
+		var cells []string
+		for _, ci := range r.Collaborators {
+			data, err := json.Marshal(ci)
+			if err != nil {
+				slog.Error("failed to marshal collaborator info", slog.String("repo", r.Repository.GetFullName()), slog.Any("ci", ci), "error", err)
+				continue // Skip this collaborator on error
+			}
+			cells = append(cells, string(data))
+		}
+		return cells
+	}},
+}
+
+// defaultCollaboratorColumns is the column layout written when no columns are configured.
+// Status precedes Collaborators, which expands into a variable number of cells.
+var defaultCollaboratorColumns = []string{"Repository", "Status", "Collaborators"}
@@ -29,7 +29,7 @@ func TestCollaboratorsReport_FileCreationError(t *testing.T) {
 	// invalid directory to force createCSVFileWithHeader error
 	invalidPath := "/this/path/does/not/exist/report.csv"
 	cache := utils.NewSharedCache()
-	err := CollaboratorsReport(ctx, nil, nil, "ent", invalidPath, 1, cache)
+	err := CollaboratorsReport(ctx, nil, nil, "ent", invalidPath, 1, cache, Options{})
 	require.Error(t, err)
 }
 
@@ -48,7 +48,7 @@ func TestCollaboratorsReport_GraphQLFetchError(t *testing.T) {
 	tmp := t.TempDir()
 	filePath := filepath.Join(tmp, "out.csv")
 	cache := utils.NewSharedCache()
-	err := CollaboratorsReport(context.Background(), nil, graphClient, "ent", filePath, 1, cache)
+	err := CollaboratorsReport(context.Background(), nil, graphClient, "ent", filePath, 1, cache, Options{})
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "failed to fetch enterprise orgs")
 }
@@ -71,7 +71,7 @@ func TestCollaboratorsReport_NoOrgs(t *testing.T) {
 	tmp := t.TempDir()
 	filePath := filepath.Join(tmp, "out.csv")
 	cache := utils.NewSharedCache()
-	err := CollaboratorsReport(context.Background(), restClient, graphClient, "ent", filePath, 1, cache)
+	err := CollaboratorsReport(context.Background(), restClient, graphClient, "ent", filePath, 1, cache, Options{})
 	require.NoError(t, err)
 
 	data, readErr := os.ReadFile(filePath)
@@ -79,7 +79,7 @@ func TestCollaboratorsReport_NoOrgs(t *testing.T) {
 
 	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
 	require.Len(t, lines, 1)
-	assert.Equal(t, "Repository,Collaborators", lines[0])
+	assert.Equal(t, "Repository,Status,Collaborators", lines[0])
 }
 
 // TestCollaboratorsReport_SingleRepoSingleCollaborator tests that the CollaboratorsReport function
@@ -127,7 +127,7 @@ func TestCollaboratorsReport_SingleRepoSingleCollaborator(t *testing.T) {
 	tmp := t.TempDir()
 	filePath := filepath.Join(tmp, "out.csv")
 	cache := utils.NewSharedCache()
-	err := CollaboratorsReport(context.Background(), restClient, graphClient, "ent", filePath, 1, cache)
+	err := CollaboratorsReport(context.Background(), restClient, graphClient, "ent", filePath, 1, cache, Options{})
 	require.NoError(t, err)
 
 	data, readErr := os.ReadFile(filePath)
@@ -135,16 +135,17 @@ func TestCollaboratorsReport_SingleRepoSingleCollaborator(t *testing.T) {
 
 	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
 	require.Len(t, lines, 2)
-	assert.Equal(t, "Repository,Collaborators", lines[0])
+	assert.Equal(t, "Repository,Status,Collaborators", lines[0])
 
 	// parse the record line
 	reader := csv.NewReader(strings.NewReader(lines[1]))
 	record, parseErr := reader.Read()
 	require.NoError(t, parseErr)
 	assert.Equal(t, "org1/repo1", record[0])
+	assert.Equal(t, "ok", record[1])
 
 	var info CollaboratorInfo
-	jsonErr := json.Unmarshal([]byte(record[1]), &info)
+	jsonErr := json.Unmarshal([]byte(record[2]), &info)
 	require.NoError(t, jsonErr)
 	assert.Equal(t, CollaboratorInfo{
 		Login:      "user1",
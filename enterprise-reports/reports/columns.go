@@ -0,0 +1,131 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+package reports
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+)
+
+// Column describes a single output column of a report.
+// Name is the canonical column name, used both as the default header and as the key
+// users reference when selecting columns in their configuration.
+type Column[R any] struct {
+	Name string
+	// Value renders the column for a processed item.
+	Value func(R) string
+	// Values renders a column that expands into a variable number of cells.
+	// It is used instead of Value when set, e.g. one cell per collaborator, and must be
+	// selected last so the cells of the other columns stay under their headers.
+	Values func(R) []string
+}
+
+// ColumnSpec selects a column for output and optionally renames its header.
+type ColumnSpec struct {
+	Name   string // Canonical column name
+	Header string // Header to write; defaults to Name when empty
+}
+
+// Options carries per-report settings that tailor how a report is generated.
+// The zero value produces each report with its default layout.
+type Options struct {
+	// Columns selects, orders and renames the output columns.
+	// When empty, the report's default columns are written.
+	Columns []ColumnSpec
+
+	// Dormancy decides which users the users report flags as dormant.
+	// When zero, utils.DefaultDormancyPolicy is used.
+	Dormancy utils.DormancyPolicy
+
+	// InactiveDays is how many days without activity make a Copilot seat inactive in the copilot report.
+	// When zero, DefaultCopilotInactiveDays is used.
+	InactiveDays int
+
+	// ActiveDays is the window in days within which a push makes a repository active in the
+	// active-repositories report. When zero, DefaultActiveRepositoryDays is used.
+	ActiveDays int
+
+	// StaleDays is the window in days without activity after which the stale-repositories report
+	// scores a repository as stale. When zero, DefaultStaleRepositoryDays is used.
+	StaleDays int
+
+	// AuditLog selects the entries the audit-log report exports and where it keeps its checkpoint.
+	AuditLog AuditLogOptions
+
+	// OrgBaseline declares the organization settings the org-settings report checks organizations against.
+	// When zero, settings are reported without violations.
+	OrgBaseline utils.OrgBaseline
+
+	// Rows, when set, receives every row the report writes, e.g. to evaluate policy rules over them.
+	Rows RowRecorder
+
+	// Server is the deployment the clients are connected to. When nil, GitHub Enterprise Cloud is assumed.
+	Server *api.ServerInfo
+}
+
+// selectColumns resolves the requested column specs against the columns a report makes available.
+// When no specs are given, the columns named in defaults are used in that order.
+// It returns the header to write and a formatter that renders an item into a row with the same layout.
+func selectColumns[R any](available []Column[R], defaults []string, specs []ColumnSpec) ([]string, func(R) []string, error) {
+	if len(specs) == 0 {
+		specs = make([]ColumnSpec, len(defaults))
+		for i, name := range defaults {
+			specs[i] = ColumnSpec{Name: name}
+		}
+	}
+
+	byName := make(map[string]Column[R], len(available))
+	for _, c := range available {
+		byName[strings.ToLower(c.Name)] = c
+	}
+
+	header := make([]string, 0, len(specs))
+	selected := make([]Column[R], 0, len(specs))
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		key := strings.ToLower(strings.TrimSpace(spec.Name))
+		col, ok := byName[key]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown column %q: available columns are %s", spec.Name, strings.Join(columnNames(available), ", "))
+		}
+		if seen[key] {
+			return nil, nil, fmt.Errorf("column %q selected more than once", spec.Name)
+		}
+		seen[key] = true
+		if len(selected) > 0 && selected[len(selected)-1].Values != nil {
+			return nil, nil, fmt.Errorf("column %q must be the last column: it expands into a cell per value", selected[len(selected)-1].Name)
+		}
+
+		name := spec.Header
+		if name == "" {
+			name = col.Name
+		}
+		header = append(header, name)
+		selected = append(selected, col)
+	}
+
+	formatter := func(r R) []string {
+		row := make([]string, 0, len(selected))
+		for _, col := range selected {
+			if col.Values != nil {
+				row = append(row, col.Values(r)...)
+				continue
+			}
+			row = append(row, col.Value(r))
+		}
+		return row
+	}
+
+	return header, formatter, nil
+}
+
+// columnNames returns the canonical names of the given columns in order.
+func columnNames[R any](columns []Column[R]) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return names
+}
@@ -0,0 +1,93 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+// This file contains tests for report column selection.
+package reports
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSelectColumns_Defaults tests that the default layout is used when no columns are configured.
+func TestSelectColumns_Defaults(t *testing.T) {
+	header, formatter, err := selectColumns(userColumns, defaultUserColumns, nil)
+	require.NoError(t, err)
+	assert.Equal(t, defaultUserColumns, header)
+
+	row := formatter(&UserReport{User: &github.User{ID: github.Ptr(int64(7)), Login: github.Ptr("octocat")}})
+	require.Len(t, row, len(defaultUserColumns))
+	assert.Equal(t, "7", row[0])
+	assert.Equal(t, "octocat", row[1])
+	assert.Equal(t, "N/A", row[3])
+}
+
+// TestSelectColumns_ReorderAndRename tests that columns can be dropped, reordered and renamed,
+// and that column names are matched case-insensitively.
+func TestSelectColumns_ReorderAndRename(t *testing.T) {
+	specs := []ColumnSpec{
+		{Name: "login"},
+		{Name: "Name", Header: "Full Name"},
+	}
+	header, formatter, err := selectColumns(userColumns, defaultUserColumns, specs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Login", "Full Name"}, header)
+
+	row := formatter(&UserReport{User: &github.User{Login: github.Ptr("octocat"), Name: github.Ptr("Mona")}})
+	assert.Equal(t, []string{"octocat", "Mona"}, row)
+}
+
+// TestSelectColumns_ExtraRepositoryColumns tests that repository fields outside the default
+// layout can be selected.
+func TestSelectColumns_ExtraRepositoryColumns(t *testing.T) {
+	specs := []ColumnSpec{{Name: "Repository"}, {Name: "Language"}, {Name: "Fork"}, {Name: "License"}, {Name: "Size"}}
+	header, formatter, err := selectColumns(repoColumns, defaultRepoColumns, specs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Repository", "Language", "Fork", "License", "Size"}, header)
+
+	repo := &github.Repository{
+		Name:     github.Ptr("repo1"),
+		Language: github.Ptr("Go"),
+		Fork:     github.Ptr(true),
+		License:  &github.License{SPDXID: github.Ptr("MIT")},
+		Size:     github.Ptr(42),
+	}
+	assert.Equal(t, []string{"repo1", "Go", "true", "MIT", "42"}, formatter(&RepoReport{Repository: repo}))
+}
+
+// TestSelectColumns_MultiValueColumn tests that a column rendering several cells expands in place.
+func TestSelectColumns_MultiValueColumn(t *testing.T) {
+	_, formatter, err := selectColumns(collaboratorColumns, defaultCollaboratorColumns, nil)
+	require.NoError(t, err)
+
+	r := &CollaboratorReport{
+		Repository: &github.Repository{FullName: github.Ptr("org1/repo1")},
+		Collaborators: []CollaboratorInfo{
+			{Login: "user1", ID: 1, Permission: "admin"},
+			{Login: "user2", ID: 2, Permission: "pull"},
+		},
+	}
+	row := formatter(r)
+	require.Len(t, row, 4)
+	assert.Equal(t, "org1/repo1", row[0])
+	assert.Equal(t, "ok", row[1])
+	assert.Contains(t, row[3], "user2")
+}
+
+// TestSelectColumns_Errors tests that unknown and duplicate columns, and multi-value columns
+// that are not last, are rejected.
+func TestSelectColumns_Errors(t *testing.T) {
+	_, _, err := selectColumns(userColumns, defaultUserColumns, []ColumnSpec{{Name: "Shoe Size"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown column "Shoe Size"`)
+	assert.Contains(t, err.Error(), "Login")
+
+	_, _, err = selectColumns(userColumns, defaultUserColumns, []ColumnSpec{{Name: "Login"}, {Name: "login"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "selected more than once")
+
+	_, _, err = selectColumns(collaboratorColumns, defaultCollaboratorColumns, []ColumnSpec{{Name: "Collaborators"}, {Name: "Repository"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `column "Collaborators" must be the last column`)
+}
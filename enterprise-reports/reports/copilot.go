@@ -0,0 +1,353 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/time/rate"
+)
+
+// DefaultCopilotInactiveDays is the number of days without activity after which a Copilot seat is inactive.
+const DefaultCopilotInactiveDays = 30
+
+// CopilotSeatReport represents a Copilot seat assigned in an organization.
+type CopilotSeatReport struct {
+	*github.CopilotSeatDetails
+	ItemStatus
+	Organization string // Organization the seat is assigned in
+	OrgMember    *bool  // Whether a user assignee is still an organization member; nil when unknown
+	Inactive     bool   // Whether the seat has had no activity within the inactivity window
+}
+
+// CopilotSeatSummary aggregates the Copilot seats of an organization.
+type CopilotSeatSummary struct {
+	Organization        string
+	Failed              bool // Whether the organization's seats could not be read
+	Seats               int
+	Active              int
+	Inactive            int // Seats with no activity within the inactivity window, including seats never used
+	NeverActive         int
+	PendingCancellation int
+}
+
+// CopilotReport creates a report of Copilot Business and Enterprise seat assignments across the
+// enterprise's organizations, one row per seat. Each seat lists its assignee, the team that
+// assigned it, the plan, when it was created, its last activity and the editor last used.
+//
+// A summary per organization of seats with no activity within the inactivity window in opts
+// (DefaultCopilotInactiveDays by default) is written next to the report, to a file with the
+// same name and a "_summary" suffix.
+//
+// An organization whose seats cannot be read is reported with a single row whose Status column
+// shows the failure, and is marked as failed in the summary, so it is not mistaken for an
+// organization without seats.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout
+//   - restClient: GitHub REST API client
+//   - graphQLClient: GitHub GraphQL API client
+//   - enterpriseSlug: Enterprise identifier
+//   - filename: Output CSV file path
+//   - workerCount: Number of concurrent workers for processing seats
+//   - cache: Shared cache for storing and retrieving GitHub data
+//   - opts: Report options, such as the columns to write
+func CopilotReport(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache, opts Options) error {
+	slog.Info("starting copilot report", "enterprise", enterpriseSlug, "filename", filename, "workers", workerCount)
+
+	header, formatter, err := selectColumns(copilotColumns, defaultCopilotColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("copilot report columns: %w", err)
+	}
+	formatter = recordRows(opts.Rows, formatter)
+
+	inactiveDays := opts.InactiveDays
+	if inactiveDays <= 0 {
+		inactiveDays = DefaultCopilotInactiveDays
+	}
+	cutoff := time.Now().UTC().Add(-time.Duration(inactiveDays) * 24 * time.Hour)
+
+	// Create appropriate report writer based on file extension
+	reportWriter, reportErr := NewReportWriter(filename)
+	if reportErr != nil {
+		return reportErr
+	}
+	defer func() {
+		if err := reportWriter.Close(); err != nil {
+			slog.Error("Failed to close report writer", "error", err)
+		}
+	}()
+
+	// Write header to report
+	if headerErr := reportWriter.WriteHeader(header); headerErr != nil {
+		return fmt.Errorf("failed to write header: %w", headerErr)
+	}
+
+	// Check cache for organizations or fetch from API
+	var orgs []*github.Organization
+
+	if cachedOrgs, found := cache.GetEnterpriseOrgs(); found {
+		slog.Info("using cached enterprise organizations")
+		orgs = cachedOrgs
+	} else {
+		slog.Info("fetching enterprise organizations", "enterprise", enterpriseSlug)
+		orgs, err = api.FetchEnterpriseOrgs(ctx, graphQLClient, enterpriseSlug)
+		if err != nil {
+			return fmt.Errorf("failed to fetch organizations: %w", err)
+		}
+		// Store in cache
+		cache.SetEnterpriseOrgs(orgs)
+	}
+
+	// Seats are listed per organization, so collect them before writing one row per seat
+	var seats []*CopilotSeatReport
+	for _, org := range orgs {
+		orgSeats, err := api.FetchOrgCopilotSeats(ctx, restClient, org.GetLogin())
+		if err != nil {
+			slog.Warn("failed to fetch copilot seats", "org", org.GetLogin(), "error", err)
+			failed := &CopilotSeatReport{CopilotSeatDetails: &github.CopilotSeatDetails{}, Organization: org.GetLogin()}
+			failed.fail(ctx, org.GetLogin(), "copilot seats", err)
+			seats = append(seats, failed)
+			continue
+		}
+		if len(orgSeats) == 0 {
+			continue
+		}
+		slog.Info("fetched copilot seats", "org", org.GetLogin(), "seats", len(orgSeats))
+
+		members := copilotOrgMembers(ctx, restClient, cache, org.GetLogin())
+		for _, seat := range orgSeats {
+			seats = append(seats, &CopilotSeatReport{CopilotSeatDetails: seat, Organization: org.GetLogin(), OrgMember: seatOrgMember(seat, members)})
+		}
+	}
+
+	// Processor: flag seats without activity within the window
+	processor := func(ctx context.Context, seat *CopilotSeatReport) (*CopilotSeatReport, error) {
+		if !seat.unread() {
+			seat.Inactive = seat.LastActivityAt == nil || seat.LastActivityAt.Before(cutoff)
+		}
+		return seat, nil
+	}
+
+	// Seats are fetched up front, so processing makes no API calls and is not rate limited
+	limiter := rate.NewLimiter(rate.Inf, workerCount)
+
+	if err := RunReportWithWriter(ctx, seats, processor, formatter, limiter, workerCount, reportWriter); err != nil {
+		return err
+	}
+
+	summaries := summarizeCopilotSeats(seats)
+	total := summaries[len(summaries)-1]
+	slog.Info("copilot seat summary", "seats", total.Seats, "inactive", total.Inactive, "never_active", total.NeverActive, "inactive_days", inactiveDays, "complete", !total.Failed)
+	return writeCopilotSummary(copilotSummaryFilename(filename), summaries, inactiveDays)
+}
+
+// copilotOrgMembers returns the logins of an organization's members, read from the cache when
+// another report already fetched them. It returns nil when the members cannot be fetched.
+func copilotOrgMembers(ctx context.Context, restClient *github.Client, cache *utils.SharedCache, org string) map[string]bool {
+	if members, found := cache.GetOrgMembers(org); found {
+		slog.Info("using cached organization members", "org", org)
+		logins := make(map[string]bool, len(members))
+		for _, m := range members {
+			logins[m.GetLogin()] = true
+		}
+		return logins
+	}
+
+	// The cache holds members with their roles, which this report does not need,
+	// so only list the logins and leave the cache to the reports that fill it
+	memberLogins, err := api.FetchOrganizationMemberLogins(ctx, restClient, org)
+	if err != nil {
+		slog.Warn("failed to fetch organization members, membership will be unknown", "org", org, "error", err)
+		return nil
+	}
+	logins := make(map[string]bool, len(memberLogins))
+	for _, login := range memberLogins {
+		logins[login] = true
+	}
+	return logins
+}
+
+// seatOrgMember reports whether a seat's user assignee is an organization member.
+// It returns nil for team or organization assignees and when membership is unknown.
+func seatOrgMember(seat *github.CopilotSeatDetails, members map[string]bool) *bool {
+	user, ok := seat.GetUser()
+	if !ok || members == nil {
+		return nil
+	}
+	member := members[user.GetLogin()]
+	return &member
+}
+
+// unread reports whether the row stands for an organization whose seats could not be read.
+func (r *CopilotSeatReport) unread() bool {
+	return len(r.Failed) > 0
+}
+
+// summarizeCopilotSeats aggregates seats per organization, sorted by organization,
+// followed by a "Total" entry covering every organization. Organizations whose seats could not
+// be read are marked as failed, and so is the total.
+func summarizeCopilotSeats(seats []*CopilotSeatReport) []*CopilotSeatSummary {
+	byOrg := make(map[string]*CopilotSeatSummary)
+	total := &CopilotSeatSummary{Organization: "Total"}
+	for _, seat := range seats {
+		summary, ok := byOrg[seat.Organization]
+		if !ok {
+			summary = &CopilotSeatSummary{Organization: seat.Organization}
+			byOrg[seat.Organization] = summary
+		}
+		if seat.unread() {
+			summary.Failed, total.Failed = true, true
+			continue
+		}
+		for _, s := range []*CopilotSeatSummary{summary, total} {
+			s.Seats++
+			if seat.Inactive {
+				s.Inactive++
+			} else {
+				s.Active++
+			}
+			if seat.LastActivityAt == nil {
+				s.NeverActive++
+			}
+			if seat.PendingCancellationDate != nil {
+				s.PendingCancellation++
+			}
+		}
+	}
+
+	summaries := make([]*CopilotSeatSummary, 0, len(byOrg)+1)
+	for _, s := range byOrg {
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Organization < summaries[j].Organization })
+	return append(summaries, total)
+}
+
+// copilotSummaryFilename returns the summary file path for a report file,
+// e.g. "ent_copilot.csv" becomes "ent_copilot_summary.csv".
+func copilotSummaryFilename(filename string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "_summary" + ext
+}
+
+// writeCopilotSummary writes the seat summaries in the same format as the report.
+func writeCopilotSummary(filename string, summaries []*CopilotSeatSummary, inactiveDays int) error {
+	summaryWriter, err := NewReportWriter(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := summaryWriter.Close(); err != nil {
+			slog.Error("Failed to close report writer", "error", err)
+		}
+	}()
+
+	header := []string{"Organization", "Seats", "Active", fmt.Sprintf("Inactive(%d days)", inactiveDays), "Never Active", "Pending Cancellation", "Status"}
+	if err := summaryWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, s := range summaries {
+		row := []string{
+			s.Organization,
+			strconv.Itoa(s.Seats),
+			strconv.Itoa(s.Active),
+			strconv.Itoa(s.Inactive),
+			strconv.Itoa(s.NeverActive),
+			strconv.Itoa(s.PendingCancellation),
+			"ok",
+		}
+		if s.Failed {
+			row[len(row)-1] = "failed: copilot seats"
+		}
+		if err := summaryWriter.WriteRow(row); err != nil {
+			return fmt.Errorf("failed to write summary row: %w", err)
+		}
+	}
+	return nil
+}
+
+// copilotAssignee returns the login or name of a seat's assignee and its type.
+func copilotAssignee(r *CopilotSeatReport) (string, string) {
+	if user, ok := r.GetUser(); ok {
+		return user.GetLogin(), "User"
+	}
+	if team, ok := r.GetTeam(); ok {
+		return team.GetSlug(), "Team"
+	}
+	if org, ok := r.GetOrganization(); ok {
+		return org.GetLogin(), "Organization"
+	}
+	return "N/A", "N/A"
+}
+
+// formatTimestamp renders an optional timestamp, or "N/A" when it is not set.
+func formatTimestamp(t *github.Timestamp) string {
+	if t == nil || t.IsZero() {
+		return "N/A"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// copilotColumns lists every column the copilot report can output.
+var copilotColumns = []Column[*CopilotSeatReport]{
+	{Name: "Organization", Value: func(r *CopilotSeatReport) string { return r.Organization }},
+	{Name: "Assignee", Value: func(r *CopilotSeatReport) string { login, _ := copilotAssignee(r); return login }},
+	{Name: "Assignee Type", Value: func(r *CopilotSeatReport) string { _, kind := copilotAssignee(r); return kind }},
+	{Name: "Assigning Team", Value: func(r *CopilotSeatReport) string {
+		if r.AssigningTeam == nil {
+			return "N/A"
+		}
+		return r.AssigningTeam.GetSlug()
+	}},
+	{Name: "Plan", Value: func(r *CopilotSeatReport) string {
+		if r.PlanType == nil {
+			return "N/A"
+		}
+		return *r.PlanType
+	}},
+	{Name: "Created At", Value: func(r *CopilotSeatReport) string { return formatTimestamp(r.CreatedAt) }},
+	{Name: "Last Activity", Value: func(r *CopilotSeatReport) string { return formatTimestamp(r.LastActivityAt) }},
+	{Name: "Last Editor", Value: func(r *CopilotSeatReport) string {
+		if r.LastActivityEditor == nil {
+			return "N/A"
+		}
+		return *r.LastActivityEditor
+	}},
+	{Name: "Inactive?", Value: func(r *CopilotSeatReport) string {
+		if r.unread() {
+			return "N/A"
+		}
+		return strconv.FormatBool(r.Inactive)
+	}},
+	{Name: "Org Member", Value: func(r *CopilotSeatReport) string {
+		if r.OrgMember == nil {
+			return "N/A"
+		}
+		return strconv.FormatBool(*r.OrgMember)
+	}},
+	{Name: "Updated At", Value: func(r *CopilotSeatReport) string { return formatTimestamp(r.UpdatedAt) }},
+	{Name: "Pending Cancellation", Value: func(r *CopilotSeatReport) string {
+		if r.PendingCancellationDate == nil {
+			return "N/A"
+		}
+		return *r.PendingCancellationDate
+	}},
+	statusColumn[*CopilotSeatReport](),
+}
+
+// defaultCopilotColumns is the column layout written when no columns are configured.
+var defaultCopilotColumns = []string{
+	"Organization", "Assignee", "Assigning Team", "Plan", "Created At", "Last Activity", "Last Editor", "Inactive?", "Org Member", "Status",
+}
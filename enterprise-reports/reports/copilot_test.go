@@ -0,0 +1,101 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+// This file contains tests for the copilot report functionality.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCopilotReport tests that the copilot report lists seats per organization, flags seats
+// without recent activity, and writes a per-organization summary.
+func TestCopilotReport(t *testing.T) {
+	recent := time.Now().UTC().Add(-2 * 24 * time.Hour).Truncate(time.Second)
+	stale := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var memberCalls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org1/copilot/billing/seats", func(w http.ResponseWriter, r *http.Request) {
+		_, err := fmt.Fprintf(w, `{"total_seats":3,"seats":[
+			{"assignee":{"type":"User","login":"alice"},"assigning_team":{"slug":"devs"},"plan_type":"business","created_at":"2024-01-01T00:00:00Z","last_activity_at":%q,"last_activity_editor":"vscode/1.90"},
+			{"assignee":{"type":"User","login":"bob"},"plan_type":"business","created_at":"2024-01-01T00:00:00Z","last_activity_at":%q,"last_activity_editor":"JetBrains"},
+			{"assignee":{"type":"User","login":"carol"},"plan_type":"business","created_at":"2024-01-01T00:00:00Z","pending_cancellation_date":"2024-07-01"}]}`,
+			recent.Format(time.RFC3339), stale.Format(time.RFC3339))
+		require.NoError(t, err)
+	})
+	mux.HandleFunc("/orgs/org2/copilot/billing/seats", func(w http.ResponseWriter, r *http.Request) {
+		// Copilot is not enabled for org2
+		http.Error(w, `{"message":"Copilot Business is not enabled"}`, http.StatusUnprocessableEntity)
+	})
+	mux.HandleFunc("/orgs/org3/copilot/billing/seats", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Server Error"}`, http.StatusBadGateway)
+	})
+	mux.HandleFunc("/orgs/org1/members", func(w http.ResponseWriter, r *http.Request) {
+		memberCalls++
+		_, err := fmt.Fprint(w, `[{"login":"alice"},{"login":"bob"}]`)
+		require.NoError(t, err)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	restClient := github.NewClient(srv.Client())
+	baseURL, _ := url.Parse(srv.URL + "/")
+	restClient.BaseURL = baseURL
+
+	// Organizations come from the cache, so no GraphQL client is needed
+	cache := utils.NewSharedCache()
+	cache.SetEnterpriseOrgs([]*github.Organization{{Login: github.Ptr("org1")}, {Login: github.Ptr("org2")}, {Login: github.Ptr("org3")}})
+
+	out := filepath.Join(t.TempDir(), "copilot.csv")
+	errorLog := NewErrorLog("copilot", out)
+	ctx := WithErrorLog(context.Background(), errorLog)
+	err := CopilotReport(ctx, restClient, nil, "ent", out, 2, cache, Options{InactiveDays: 14})
+	require.NoError(t, err)
+	require.NoError(t, errorLog.Close())
+	assert.Equal(t, 1, memberCalls)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "Organization,Assignee,Assigning Team,Plan,Created At,Last Activity,Last Editor,Inactive?,Org Member,Status", lines[0])
+	assert.ElementsMatch(t, []string{
+		fmt.Sprintf("org1,alice,devs,business,2024-01-01T00:00:00Z,%s,vscode/1.90,false,true,ok", recent.Format(time.RFC3339)),
+		"org1,bob,N/A,business,2024-01-01T00:00:00Z,2024-01-02T03:04:05Z,JetBrains,true,true,ok",
+		"org1,carol,N/A,business,2024-01-01T00:00:00Z,N/A,N/A,true,false,ok",
+		// Copilot is not enabled for org2, so it has no seats; org3's seats could not be read
+		"org3,N/A,N/A,N/A,N/A,N/A,N/A,N/A,N/A,failed: copilot seats",
+	}, lines[1:])
+
+	entries := readItemErrors(t, ErrorsPath(out))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "org3", entries[0].Item)
+	assert.Equal(t, "copilot seats", entries[0].Field)
+
+	summary, err := os.ReadFile(filepath.Join(filepath.Dir(out), "copilot_summary.csv"))
+	require.NoError(t, err)
+	assert.Equal(t,
+		"Organization,Seats,Active,Inactive(14 days),Never Active,Pending Cancellation,Status\n"+
+			"org1,3,1,2,1,1,ok\n"+
+			"org3,0,0,0,0,0,failed: copilot seats\n"+
+			"Total,3,1,2,1,1,failed: copilot seats\n",
+		string(summary))
+}
+
+func TestCopilotSummaryFilename(t *testing.T) {
+	assert.Equal(t, "out/ent_copilot_summary.csv", copilotSummaryFilename("out/ent_copilot.csv"))
+	assert.Equal(t, "ent_copilot_summary.xlsx", copilotSummaryFilename("ent_copilot.xlsx"))
+}
@@ -0,0 +1,210 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+)
+
+// ItemError is a failure to fetch an item of a report, or part of it, as written to the report's
+// errors file.
+type ItemError struct {
+	Time     time.Time       `json:"time"`
+	Report   string          `json:"report"`
+	Item     string          `json:"item"`               // Key of the item, e.g. "org/repo" for a repository
+	Field    string          `json:"field,omitempty"`    // Part of the item that failed, e.g. "collaborators"; empty when the whole item failed
+	Endpoint string          `json:"endpoint,omitempty"` // API request that failed, e.g. "GET /repos/org/repo/collaborators"
+	Status   int             `json:"status,omitempty"`   // HTTP status of the response, when there was one
+	Type     utils.ErrorType `json:"type"`
+	Retries  int             `json:"retries"` // Times the failed call was retried; always written, 0 when it was not
+	Message  string          `json:"message"`
+}
+
+// ErrorLog writes the item errors of a report as JSON lines to a file next to its output.
+// The file is only created once an error is recorded. Its methods are safe for concurrent use,
+// and do nothing on a nil ErrorLog, so reports run without one are not affected.
+type ErrorLog struct {
+	report string
+	path   string
+
+	mu    sync.Mutex
+	file  *os.File
+	enc   *json.Encoder
+	count int
+	err   error // First error writing the file
+}
+
+// NewErrorLog creates the error log of a report written to outputPath.
+func NewErrorLog(report, outputPath string) *ErrorLog {
+	return &ErrorLog{report: report, path: ErrorsPath(outputPath)}
+}
+
+// ErrorsPath returns the path of the errors file of a report output, e.g. "octodemo_repositories_errors.jsonl"
+// for "octodemo_repositories.csv".
+func ErrorsPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + "_errors.jsonl"
+}
+
+// Path returns the path of the errors file.
+func (l *ErrorLog) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Count returns the number of errors recorded.
+func (l *ErrorLog) Count() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.count
+}
+
+// Record writes a failure to fetch the field of an item, or the whole item when field is empty.
+func (l *ErrorLog) Record(item, field string, err error) {
+	if l == nil || err == nil {
+		return
+	}
+	entry := ItemError{
+		Time:    time.Now().UTC(),
+		Report:  l.report,
+		Item:    item,
+		Field:   field,
+		Type:    utils.ClassifyError(err),
+		Message: err.Error(),
+	}
+	entry.Endpoint, entry.Status = errorResponse(err)
+	var appErr *utils.AppError
+	if errors.As(err, &appErr) {
+		entry.Retries = appErr.Retries
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.count++
+	if l.err != nil {
+		return
+	}
+	if l.file == nil {
+		if l.file, l.err = os.Create(l.path); l.err != nil {
+			l.err = fmt.Errorf("failed to create errors file: %w", l.err)
+			return
+		}
+		l.enc = json.NewEncoder(l.file)
+	}
+	if err := l.enc.Encode(entry); err != nil {
+		l.err = fmt.Errorf("failed to write errors file: %w", err)
+	}
+}
+
+// Close closes the errors file, returning the first error writing it.
+func (l *ErrorLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		if err := l.file.Close(); err != nil && l.err == nil {
+			l.err = fmt.Errorf("failed to close errors file: %w", err)
+		}
+		l.file = nil
+	}
+	return l.err
+}
+
+// errorResponse returns the API request and HTTP status of the GitHub API response an error wraps.
+func errorResponse(err error) (endpoint string, status int) {
+	var respErr *github.ErrorResponse
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	var resp *http.Response
+	switch {
+	case errors.As(err, &respErr):
+		resp = respErr.Response
+	case errors.As(err, &rateErr):
+		resp = rateErr.Response
+	case errors.As(err, &abuseErr):
+		resp = abuseErr.Response
+	}
+	if resp == nil {
+		return "", 0
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		endpoint = resp.Request.Method + " " + resp.Request.URL.Path
+	}
+	return endpoint, resp.StatusCode
+}
+
+// itemKey identifies an item a report processes in its errors file.
+func itemKey(item any) string {
+	switch v := item.(type) {
+	case *TeamReport:
+		return v.Organization.GetLogin() + "/" + v.Team.GetSlug()
+	case *api.LicensedUser:
+		return v.GithubComLogin
+	case interface{ GetFullName() string }:
+		return v.GetFullName()
+	case interface{ GetLogin() string }:
+		return v.GetLogin()
+	}
+	return fmt.Sprintf("%v", item)
+}
+
+// errorLogKey is the context key of an ErrorLog.
+type errorLogKey struct{}
+
+// WithErrorLog returns a context carrying the error log the report run with it records its item errors to.
+func WithErrorLog(ctx context.Context, l *ErrorLog) context.Context {
+	return context.WithValue(ctx, errorLogKey{}, l)
+}
+
+// ErrorLogFrom returns the error log carried by the context, or nil.
+func ErrorLogFrom(ctx context.Context) *ErrorLog {
+	l, _ := ctx.Value(errorLogKey{}).(*ErrorLog)
+	return l
+}
+
+// ItemStatus records the parts of an item a report failed to fetch. Its Status column tells an
+// empty cell from data that could not be fetched.
+type ItemStatus struct {
+	Failed []string // Parts of the item that could not be fetched, e.g. "collaborators"
+}
+
+// Status returns "ok", or the parts of the item that could not be fetched.
+func (s *ItemStatus) Status() string {
+	if len(s.Failed) == 0 {
+		return "ok"
+	}
+	return "failed: " + strings.Join(s.Failed, ", ")
+}
+
+// fail records that the field of the item could not be fetched, in the status and in the error
+// log carried by ctx.
+func (s *ItemStatus) fail(ctx context.Context, item, field string, err error) {
+	if !slices.Contains(s.Failed, field) {
+		s.Failed = append(s.Failed, field)
+	}
+	ErrorLogFrom(ctx).Record(item, field, err)
+}
+
+// statusColumn returns the Status column of a report whose items embed ItemStatus.
+func statusColumn[R interface{ Status() string }]() Column[R] {
+	return Column[R]{Name: "Status", Value: func(r R) string { return r.Status() }}
+}
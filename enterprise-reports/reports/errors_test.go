@@ -0,0 +1,186 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+// This file contains tests for the item errors files and Status columns of the reports.
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/fakegithub"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// readItemErrors returns the entries of an errors file.
+func readItemErrors(t *testing.T, path string) []ItemError {
+	t.Helper()
+	var entries []ItemError
+	for _, line := range strings.Split(strings.TrimSpace(readFile(t, path)), "\n") {
+		var entry ItemError
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestErrorsPath tests that the errors file is named after the report output.
+func TestErrorsPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("out", "octodemo_collaborators_2025-01-01_10-00_errors.jsonl"),
+		ErrorsPath(filepath.Join("out", "octodemo_collaborators_2025-01-01_10-00.csv")))
+	assert.Equal(t, "report_errors.jsonl", ErrorsPath("report"))
+}
+
+// TestErrorLog_FakeFault tests that collaborators that fail to load are marked in the Status column,
+// telling them from a repository without collaborators, and recorded with their endpoint, status and type.
+func TestErrorLog_FakeFault(t *testing.T) {
+	srv := startDemoServer(t)
+	srv.Inject(fakegithub.Fault{Path: "/repos/octodemo-apps/website/collaborators", Status: http.StatusForbidden})
+	dir := t.TempDir()
+
+	out := filepath.Join(dir, "collaborators.csv")
+	errorLog := NewErrorLog("collaborators", out)
+	ctx := WithErrorLog(context.Background(), errorLog)
+	require.NoError(t, CollaboratorsReport(ctx, srv.RESTClient(), srv.GraphQLClient(), "octodemo", out, 2, utils.NewSharedCache(), Options{}))
+	require.NoError(t, errorLog.Close())
+
+	lines := reportLines(t, out)
+	assert.Contains(t, lines, "octodemo-apps/website,failed: collaborators,N/A")
+	assert.Contains(t, lines, "octodemo-platform/scratch,ok,N/A")
+
+	assert.Equal(t, 1, errorLog.Count())
+	entries := readItemErrors(t, filepath.Join(dir, "collaborators_errors.jsonl"))
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "collaborators", entry.Report)
+	assert.Equal(t, "octodemo-apps/website", entry.Item)
+	assert.Equal(t, "collaborators", entry.Field)
+	assert.Equal(t, "GET /api/v3/repos/octodemo-apps/website/collaborators", entry.Endpoint)
+	assert.Equal(t, http.StatusForbidden, entry.Status)
+	assert.Equal(t, utils.ErrorTypeAuth, entry.Type)
+	assert.Zero(t, entry.Retries)
+	assert.NotEmpty(t, entry.Message)
+}
+
+// TestErrorLog_ItemFailure tests that an item the report fails to process, and so has no row,
+// is recorded without a field.
+func TestErrorLog_ItemFailure(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "orgs.csv")
+	errorLog := NewErrorLog("organizations", out)
+	ctx := WithErrorLog(context.Background(), errorLog)
+	writer, err := NewReportWriter(out)
+	require.NoError(t, err)
+
+	orgs := []*github.Organization{{Login: github.Ptr("octodemo-platform")}, {Login: github.Ptr("octodemo-apps")}}
+	processor := func(_ context.Context, org *github.Organization) (*github.Organization, error) {
+		if org.GetLogin() == "octodemo-apps" {
+			return nil, assert.AnError
+		}
+		return org, nil
+	}
+	formatter := func(org *github.Organization) []string { return []string{org.GetLogin()} }
+	require.Error(t, RunReportWithWriter(ctx, orgs, processor, formatter, rate.NewLimiter(rate.Inf, 1), 1, writer))
+	require.NoError(t, writer.Close())
+	require.NoError(t, errorLog.Close())
+
+	assert.Equal(t, []string{"octodemo-platform"}, reportLines(t, out))
+	entries := readItemErrors(t, ErrorsPath(out))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "octodemo-apps", entries[0].Item)
+	assert.Empty(t, entries[0].Field)
+	assert.Equal(t, utils.ErrorTypeGeneral, entries[0].Type)
+}
+
+// TestErrorLog_NotApplicable tests that external groups and team sync groups the API does not apply
+// to the organization, answering 400 or 404, are reported as none rather than as failures.
+func TestErrorLog_NotApplicable(t *testing.T) {
+	srv := startDemoServer(t)
+	srv.Inject(fakegithub.Fault{Path: "/orgs/octodemo-apps/teams/mobile/external-groups", Status: http.StatusNotFound})
+	srv.Inject(fakegithub.Fault{Path: "/orgs/octodemo-apps/teams/mobile/team-sync/group-mappings", Status: http.StatusBadRequest})
+	dir := t.TempDir()
+
+	out := filepath.Join(dir, "teams.csv")
+	errorLog := NewErrorLog("teams", out)
+	ctx := WithErrorLog(context.Background(), errorLog)
+	require.NoError(t, TeamsReport(ctx, srv.RESTClient(), srv.GraphQLClient(), "octodemo", out, 2, utils.NewSharedCache(), Options{
+		Columns: []ColumnSpec{{Name: "Team Slug"}, {Name: "Status"}},
+	}))
+	require.NoError(t, errorLog.Close())
+
+	assert.Contains(t, reportLines(t, out), "mobile,ok")
+	assert.Zero(t, errorLog.Count())
+}
+
+// TestErrorLog_TeamSyncFailure tests that a team sync groups lookup that fails is recorded.
+func TestErrorLog_TeamSyncFailure(t *testing.T) {
+	srv := startDemoServer(t)
+	srv.Inject(fakegithub.Fault{Path: "/orgs/octodemo-apps/teams/mobile/team-sync/group-mappings", Status: http.StatusBadGateway})
+	dir := t.TempDir()
+
+	out := filepath.Join(dir, "teams.csv")
+	errorLog := NewErrorLog("teams", out)
+	ctx := WithErrorLog(context.Background(), errorLog)
+	require.NoError(t, TeamsReport(ctx, srv.RESTClient(), srv.GraphQLClient(), "octodemo", out, 2, utils.NewSharedCache(), Options{
+		Columns: []ColumnSpec{{Name: "Team Slug"}, {Name: "Status"}},
+	}))
+	require.NoError(t, errorLog.Close())
+
+	assert.Contains(t, reportLines(t, out), "mobile,failed: team sync groups")
+	entries := readItemErrors(t, ErrorsPath(out))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "octodemo-apps/mobile", entries[0].Item)
+	assert.Equal(t, "team sync groups", entries[0].Field)
+}
+
+// TestErrorLog_NoErrors tests that no errors file is written when every item loads.
+func TestErrorLog_NoErrors(t *testing.T) {
+	srv := startDemoServer(t)
+	dir := t.TempDir()
+
+	out := filepath.Join(dir, "repos.csv")
+	errorLog := NewErrorLog("repositories", out)
+	ctx := WithErrorLog(context.Background(), errorLog)
+	require.NoError(t, RepositoryReport(ctx, srv.RESTClient(), srv.GraphQLClient(), "octodemo", out, 2, utils.NewSharedCache(), Options{}))
+	require.NoError(t, errorLog.Close())
+
+	for _, line := range reportLines(t, out)[1:] {
+		assert.True(t, strings.HasSuffix(line, ",ok"), line)
+	}
+	assert.Zero(t, errorLog.Count())
+	assert.NoFileExists(t, ErrorsPath(out))
+}
+
+// TestErrorLog_Retries tests that the retry count is always written: the retries of a call made
+// with retries, and zero for one that failed on its first attempt.
+func TestErrorLog_Retries(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "users.csv")
+	errorLog := NewErrorLog("users", out)
+	retried := utils.NewAppError(utils.ErrorTypeAPI, "GitHub API error", assert.AnError)
+	retried.Retries = 3
+	errorLog.Record("mona", "email", retried)
+	errorLog.Record("hubot", "email", assert.AnError)
+	require.NoError(t, errorLog.Close())
+
+	lines := reportLines(t, ErrorsPath(out))
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"retries":3`)
+	assert.Contains(t, lines[1], `"retries":0`)
+}
+
+// TestItemStatus tests the Status of an item, which lists each part that failed once.
+func TestItemStatus(t *testing.T) {
+	var s ItemStatus
+	assert.Equal(t, "ok", s.Status())
+
+	ctx := context.Background()
+	s.fail(ctx, "org/repo", "teams", assert.AnError)
+	s.fail(ctx, "org/repo", "external groups", assert.AnError)
+	s.fail(ctx, "org/repo", "external groups", assert.AnError)
+	assert.Equal(t, "failed: teams, external groups", s.Status())
+}
@@ -0,0 +1,426 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+package reports
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+)
+
+// Rates at which the reports process their items, in items per second. The reports' limiters and
+// the cost estimate share them; the reasoning behind each rate is next to its limiter.
+const (
+	organizationsRate      = 5
+	repositoriesRate       = 2
+	teamsRate              = 2
+	collaboratorsRate      = 10
+	usersRate              = 10
+	activeRepositoriesRate = 1
+	staleRepositoriesRate  = 4
+	licensesRate           = 10
+	orgSettingsRate        = 4
+)
+
+// estimatedRequestLatency is the typical round trip of an API request, which bounds how fast the
+// workers of a report process items when its limiter does not.
+const estimatedRequestLatency = 250 * time.Millisecond
+
+// Hourly budgets assumed for a rate limit resource the API did not report.
+const (
+	defaultRESTBudget     = 5000
+	defaultGraphQLBudget  = 5000
+	defaultAuditLogBudget = 1750
+)
+
+// EstimateInput holds what the cost of the reports is estimated from.
+type EstimateInput struct {
+	Counts      *api.EnterpriseCounts
+	Workers     int                  // Concurrent workers of each report
+	Credentials int                  // Credentials sharing the requests, each with its own rate limits
+	RateLimits  *github.RateLimits   // Current rate limits of the main credential; nil assumes full budgets
+	Dormancy    utils.DormancyPolicy // Decides which activity sources the users and licenses reports read; zero uses the default
+}
+
+// ReportEstimate is the predicted cost of one report.
+type ReportEstimate struct {
+	Report   string
+	Items    int           // Items the report processes, e.g. repositories for the repositories report
+	REST     int           // REST API points
+	GraphQL  int           // GraphQL API points, counting one per query
+	AuditLog int           // Audit log API requests
+	Duration time.Duration // Wall-clock time, including waits for rate limit resets
+	Notes    []string      // Parts of the cost the counts cannot predict
+}
+
+// Estimate is the predicted cost of running a set of reports one after another.
+type Estimate struct {
+	Counts      *api.EnterpriseCounts
+	Workers     int
+	Credentials int
+	Reports     []ReportEstimate
+
+	rest, graphQL, auditLog budget // Budgets at the start of the run
+}
+
+// budget tracks the points of a rate limit resource left over the run. Times are offsets from
+// the start of the run.
+type budget struct {
+	remaining int
+	limit     int
+	reset     time.Duration
+}
+
+// newBudget returns the budget of a resource shared by the credentials. The additional credentials
+// are assumed to have their full budget.
+func newBudget(rate *github.Rate, fallback, credentials int) budget {
+	if rate == nil || rate.Limit <= 0 {
+		return budget{remaining: fallback * credentials, limit: fallback * credentials, reset: time.Hour}
+	}
+	return budget{
+		remaining: rate.Remaining + (credentials-1)*rate.Limit,
+		limit:     rate.Limit * credentials,
+		reset:     max(time.Until(rate.Reset.Time), 0),
+	}
+}
+
+// spend draws points from the budget at the given time and returns how long the run waits for
+// resets until they are all available.
+func (b *budget) spend(points int, at time.Duration) time.Duration {
+	for at >= b.reset {
+		b.remaining = b.limit
+		b.reset += time.Hour
+	}
+	var wait time.Duration
+	for points > b.remaining {
+		points -= b.remaining
+		wait = b.reset - at
+		b.remaining = b.limit
+		b.reset += time.Hour
+	}
+	b.remaining -= points
+	return wait
+}
+
+// reportCost is the cost of a report before it is spread over time.
+type reportCost struct {
+	items        int     // Items processed by the workers
+	rate         float64 // Items per second the limiter allows; 0 when not rate limited
+	restPerItem  int     // REST requests per item
+	graphPerItem int     // GraphQL queries per item
+	upfront      int     // Requests made one after another before the items are processed
+	rest         int
+	graphQL      int
+	auditLog     int
+	notes        []string
+}
+
+// listings tracks which listings an earlier report of the run has cached, so they are only
+// counted once.
+type listings map[string]bool
+
+// first reports whether the listing is not cached yet, marking it cached.
+func (l listings) first(name string) bool {
+	if l[name] {
+		return false
+	}
+	l[name] = true
+	return true
+}
+
+// pages returns the requests needed to list n items at 100 per page.
+func pages(n int) int {
+	return max((n+99)/100, 1)
+}
+
+// batches returns the queries needed to look up n items in batches of size.
+func batches(n, size int) int {
+	return (n + size - 1) / size
+}
+
+// EstimateReports predicts the API points and wall-clock time of running the named reports one
+// after another, in order, from the sizes of the enterprise and the current rate limits. Listings
+// cached by an earlier report are not counted again. The estimate assumes each report's limiter
+// or workers are the bottleneck until a rate limit runs out, after which the run waits for its reset.
+func EstimateReports(names []string, in EstimateInput) *Estimate {
+	workers := max(in.Workers, 1)
+	credentials := max(in.Credentials, 1)
+	est := &Estimate{Counts: in.Counts, Workers: workers, Credentials: credentials}
+	est.rest = newBudget(in.RateLimits.GetCore(), defaultRESTBudget, credentials)
+	est.graphQL = newBudget(in.RateLimits.GetGraphQL(), defaultGraphQLBudget, credentials)
+	est.auditLog = newBudget(in.RateLimits.GetAuditLog(), defaultAuditLogBudget, credentials)
+
+	policy := in.Dormancy
+	if policy.IsZero() {
+		policy = utils.DefaultDormancyPolicy()
+	}
+
+	rest, graphQL, auditLog := est.rest, est.graphQL, est.auditLog
+	cached := listings{}
+	var elapsed time.Duration
+	for _, name := range names {
+		cost := estimateReportCost(name, in.Counts, policy, cached)
+		cost.rest += cost.items * cost.restPerItem
+		cost.graphQL += cost.items * cost.graphPerItem
+
+		// The items are processed as fast as the limiter allows, unless the workers cannot keep up
+		processing := time.Duration(cost.items*(cost.restPerItem+cost.graphPerItem)) * estimatedRequestLatency / time.Duration(workers)
+		if cost.rate > 0 {
+			processing = max(processing, time.Duration(float64(cost.items)/cost.rate*float64(time.Second)))
+		}
+		duration := time.Duration(cost.upfront)*estimatedRequestLatency + processing
+
+		end := elapsed + duration
+		wait := max(rest.spend(cost.rest, end), graphQL.spend(cost.graphQL, end), auditLog.spend(cost.auditLog, end))
+		duration += wait
+		elapsed += duration
+
+		est.Reports = append(est.Reports, ReportEstimate{
+			Report:   name,
+			Items:    cost.items,
+			REST:     cost.rest,
+			GraphQL:  cost.graphQL,
+			AuditLog: cost.auditLog,
+			Duration: duration,
+			Notes:    cost.notes,
+		})
+	}
+	return est
+}
+
+// estimateReportCost models the requests of a report, following the API calls it makes.
+func estimateReportCost(name string, counts *api.EnterpriseCounts, policy utils.DormancyPolicy, cached listings) reportCost {
+	orgs := len(counts.Organizations)
+	repos, teams, _ := counts.Totals()
+	users := counts.Users
+
+	var c reportCost
+	listOrgs := func() {
+		if cached.first("organizations") {
+			c.graphQL += pages(orgs)
+			c.upfront += pages(orgs)
+		}
+	}
+	listRepos := func() {
+		listOrgs()
+		if cached.first("repositories") {
+			for _, org := range counts.Organizations {
+				c.rest += pages(org.Repositories)
+				c.upfront += pages(org.Repositories)
+			}
+		}
+	}
+	listUsers := func() {
+		if cached.first("users") {
+			c.graphQL += pages(users)
+			c.upfront += pages(users)
+		}
+	}
+	listMembers := func() int {
+		var n int
+		if cached.first("members") {
+			for _, org := range counts.Organizations {
+				n += pages(org.Members)
+			}
+		}
+		return n
+	}
+	// resolveActivity counts the bulk sources of the dormancy policy and returns the requests per user
+	resolveActivity := func() int {
+		if len(policy.Enabled(utils.SourceAuditLog)) > 0 {
+			// The audit log is searched once per action
+			actions := len(policy.AuditLogActions())
+			c.auditLog += actions
+			c.upfront += actions
+			c.notes = append(c.notes, "plus 1 audit log request per 100 audit log entries of the dormancy window")
+		}
+		if len(policy.Enabled(utils.SourceCopilot)) > 0 {
+			c.rest += pages(users)
+			c.upfront += pages(users)
+		}
+		if len(policy.Enabled(utils.SourceContributions)) > 0 {
+			spans := max(int((policy.Window+365*24*time.Hour-1)/(365*24*time.Hour)), 1)
+			n := batches(users, api.ContributionBatchSize) * spans
+			c.graphQL += n
+			c.upfront += n
+		}
+		if len(policy.Enabled(utils.SourceEvents)) > 0 {
+			return 1
+		}
+		return 0
+	}
+
+	switch name {
+	case "organizations":
+		// 2 REST calls per organization, and its members at 1 GraphQL point per 100
+		listOrgs()
+		c.items, c.rate, c.restPerItem = orgs, organizationsRate, 2
+		c.graphQL += listMembers()
+	case "repositories":
+		// Cost = (2 + N_new_teams) REST points/repo: every team's external groups are fetched once
+		listRepos()
+		c.items, c.rate, c.restPerItem = repos, repositoriesRate, 2
+		c.rest += teams
+	case "teams":
+		// About 5 REST calls per team for members, maintainers, IdP groups and repositories
+		listOrgs()
+		if cached.first("teams") {
+			for _, org := range counts.Organizations {
+				c.rest += pages(org.Teams)
+				c.upfront += pages(org.Teams)
+			}
+		}
+		c.items, c.rate, c.restPerItem = teams, teamsRate, 5
+	case "collaborators":
+		listRepos()
+		c.items, c.rate, c.restPerItem = repos, collaboratorsRate, 1
+	case "users":
+		// Emails are fetched in batches, the user's events one user at a time
+		listUsers()
+		c.graphQL += batches(users, api.DefaultBatchSize)
+		c.upfront += batches(users, api.DefaultBatchSize)
+		c.restPerItem = resolveActivity()
+		c.items, c.rate = users, usersRate
+		cached["dormancy"] = true
+	case "active-repositories":
+		// Active branches per repository pushed to within the window, the default branch's commits,
+		// and a comparison with the default branch for every other active branch
+		listRepos()
+		listUsers()
+		c.graphQL += batches(users, api.DefaultBatchSize)
+		c.items, c.rate, c.restPerItem, c.graphPerItem = repos, activeRepositoriesRate, 1, 1
+		c.notes = append(c.notes,
+			"upper bound: assumes every repository was pushed to within the window",
+			"plus 1 REST request per other active branch, per 100 commits it holds beyond the default branch")
+	case "stale-repositories":
+		// Up to 3 REST requests per repository, its activity in GraphQL batches per organization
+		listRepos()
+		for _, org := range counts.Organizations {
+			c.graphQL += batches(org.Repositories, api.DefaultBatchSize)
+			c.upfront += batches(org.Repositories, api.DefaultBatchSize)
+		}
+		c.items, c.rate, c.restPerItem = repos, staleRepositoriesRate, 3
+		c.notes = append(c.notes, "upper bound: assumes every repository is a fork")
+	case "licenses":
+		// At most one REST events call per user whose dormancy the users report did not resolve
+		c.rest += pages(users)
+		c.upfront += pages(users)
+		if cached.first("dormancy") {
+			c.restPerItem = resolveActivity()
+		}
+		c.items, c.rate = users, licensesRate
+	case "copilot":
+		// Seats and member logins of each organization, fetched up front
+		listOrgs()
+		for _, org := range counts.Organizations {
+			c.rest += 2 * pages(org.Members)
+			c.upfront += 2 * pages(org.Members)
+		}
+		c.items = users
+		c.notes = append(c.notes, "assumes every organization member has a seat")
+	case "audit-log":
+		c.auditLog++
+		c.upfront++
+		c.notes = append(c.notes, "plus 1 audit log request per 100 exported entries, which cannot be counted up front")
+	case "admins":
+		// Enterprise admins, then the roles of each organization and the users of each role
+		c.graphQL++
+		c.upfront++
+		listOrgs()
+		n := listMembers()
+		c.graphQL += n
+		c.rest += orgs
+		c.upfront += n + orgs
+		c.notes = append(c.notes, "plus 1 REST point per custom organization role")
+	case "org-settings":
+		// 3 REST calls and 1 GraphQL query per organization
+		c.graphQL++
+		c.upfront++
+		listOrgs()
+		c.items, c.rate, c.restPerItem, c.graphPerItem = orgs, orgSettingsRate, 3, 1
+	case "identities":
+		// Users, external identities and SCIM users, all listed up front
+		listUsers()
+		c.graphQL += pages(users)
+		c.rest += pages(users)
+		c.upfront += 2 * pages(users)
+		c.items = users
+	}
+	return c
+}
+
+// Total returns the combined cost of the reports.
+func (e *Estimate) Total() ReportEstimate {
+	total := ReportEstimate{Report: "total"}
+	for _, r := range e.Reports {
+		total.REST += r.REST
+		total.GraphQL += r.GraphQL
+		total.AuditLog += r.AuditLog
+		total.Duration += r.Duration
+	}
+	return total
+}
+
+// Write writes the estimate as a table, with the sizes and rate limits it is based on and the
+// parts of the cost it cannot predict.
+func (e *Estimate) Write(w io.Writer) error {
+	repos, teams, _ := e.Counts.Totals()
+	var b strings.Builder
+	fmt.Fprintf(&b, "Enterprise: %d organizations, %d repositories, %d teams, %d users\n",
+		len(e.Counts.Organizations), repos, teams, e.Counts.Users)
+	fmt.Fprintf(&b, "Rate limits: REST %d/%d, GraphQL %d/%d, audit log %d/%d per hour\n",
+		e.rest.remaining, e.rest.limit, e.graphQL.remaining, e.graphQL.limit, e.auditLog.remaining, e.auditLog.limit)
+	fmt.Fprintf(&b, "Workers: %d, credentials: %d\n\n", e.Workers, e.Credentials)
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "REPORT\tITEMS\tREST\tGRAPHQL\tAUDIT LOG\tTIME"); err != nil {
+		return err
+	}
+	total := e.Total()
+	for _, r := range append(e.Reports, total) {
+		// Reports fetching everything up front process no items
+		items := "-"
+		if r.Items > 0 {
+			items = fmt.Sprint(r.Items)
+		}
+		if r.Report == total.Report {
+			items = ""
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\n",
+			r.Report, items, r.REST, r.GraphQL, r.AuditLog, formatEstimateDuration(r.Duration)); err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var notes strings.Builder
+	for _, r := range e.Reports {
+		for _, note := range r.Notes {
+			fmt.Fprintf(&notes, "  %s: %s\n", r.Report, note)
+		}
+	}
+	if notes.Len() > 0 {
+		if _, err := io.WriteString(w, "\nNotes:\n"+notes.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatEstimateDuration rounds a duration to the precision an estimate has.
+func formatEstimateDuration(d time.Duration) string {
+	if d >= time.Hour {
+		return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+	}
+	return d.Round(time.Second).String()
+}
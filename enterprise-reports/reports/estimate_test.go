@@ -0,0 +1,109 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/fakegithub"
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// estimateCounts is an enterprise whose first organization's listings span several pages.
+var estimateCounts = &api.EnterpriseCounts{
+	Users: 300,
+	Organizations: []api.OrganizationCounts{
+		{Login: "big", Repositories: 150, Teams: 10, Members: 250},
+		{Login: "small", Repositories: 50, Members: 10},
+	},
+}
+
+// TestEstimateReports tests the modeled cost of the reports, with listings cached by an earlier
+// report counted once.
+func TestEstimateReports(t *testing.T) {
+	est := EstimateReports([]string{"organizations", "repositories", "collaborators"}, EstimateInput{
+		Counts:  estimateCounts,
+		Workers: 5,
+	})
+	require.Len(t, est.Reports, 3)
+
+	orgs := est.Reports[0]
+	assert.Equal(t, 2, orgs.Items)
+	assert.Equal(t, 4, orgs.REST)    // 2 REST calls per organization
+	assert.Equal(t, 5, orgs.GraphQL) // 1 organizations page, 3 + 1 member pages
+
+	// (2 + N_teams) REST points per repository, after listing 2 + 1 pages of repositories
+	repos := est.Reports[1]
+	assert.Equal(t, 200, repos.Items)
+	assert.Equal(t, 3+2*200+10, repos.REST)
+	assert.Zero(t, repos.GraphQL)
+	assert.Equal(t, 3*estimatedRequestLatency+100*time.Second, repos.Duration) // Limited to 2 repositories a second
+
+	// The repositories are already listed
+	collaborators := est.Reports[2]
+	assert.Equal(t, 200, collaborators.REST)
+	assert.Equal(t, 20*time.Second, collaborators.Duration)
+
+	total := est.Total()
+	assert.Equal(t, 4+413+200, total.REST)
+	assert.Equal(t, orgs.Duration+repos.Duration+collaborators.Duration, total.Duration)
+}
+
+// TestEstimateReports_RateLimits tests that a run spending more points than are left waits for
+// the reset, unless additional credentials cover them.
+func TestEstimateReports_RateLimits(t *testing.T) {
+	rateLimits := &github.RateLimits{
+		Core: &github.Rate{Limit: 5000, Remaining: 100, Reset: github.Timestamp{Time: time.Now().Add(30 * time.Minute)}},
+	}
+
+	est := EstimateReports([]string{"repositories"}, EstimateInput{Counts: estimateCounts, Workers: 5, RateLimits: rateLimits})
+	assert.InDelta(t, 30*time.Minute, est.Reports[0].Duration, float64(time.Minute))
+
+	est = EstimateReports([]string{"repositories"}, EstimateInput{Counts: estimateCounts, Workers: 5, RateLimits: rateLimits, Credentials: 2})
+	assert.Less(t, est.Reports[0].Duration, 2*time.Minute)
+}
+
+// TestEstimate_FakeEnterprise tests that the counts of the demo enterprise are fetched and the
+// estimate written as a table with its notes.
+func TestEstimate_FakeEnterprise(t *testing.T) {
+	srv := startDemoServer(t)
+
+	counts, err := api.FetchEnterpriseCounts(context.Background(), srv.GraphQLClient(), "octodemo", githubv4.EnterpriseUserDeploymentCloud)
+	require.NoError(t, err)
+	assert.Equal(t, 7, counts.Users)
+	require.Len(t, counts.Organizations, 2)
+	assert.Equal(t, api.OrganizationCounts{Login: "octodemo-platform", Repositories: 4, Teams: 2, Members: 5}, counts.Organizations[0])
+	repos, teams, members := counts.Totals()
+	assert.Equal(t, []int{7, 3, 9}, []int{repos, teams, members})
+
+	est := EstimateReports([]string{"teams", "audit-log"}, EstimateInput{Counts: counts, Workers: 2})
+	var out bytes.Buffer
+	require.NoError(t, est.Write(&out))
+	assert.Contains(t, out.String(), "Enterprise: 2 organizations, 7 repositories, 3 teams, 7 users")
+	assert.Regexp(t, `teams\s+3\s+17\s+1\s+0\s+`, out.String())
+	assert.Regexp(t, `audit-log\s+-\s+0\s+0\s+1\s+`, out.String())
+	assert.Contains(t, out.String(), "audit-log: plus 1 audit log request per 100 exported entries")
+}
+
+// TestEstimate_ServerDeployment tests that only the members of the queried deployment are counted.
+func TestEstimate_ServerDeployment(t *testing.T) {
+	fixture, err := fakegithub.DemoFixture()
+	require.NoError(t, err)
+	fixture.ServerVersion = "3.14.2"
+	srv := fakegithub.NewServer(fixture, fakegithub.Options{PageSize: 2})
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	counts, err := api.FetchEnterpriseCounts(context.Background(), srv.GraphQLClient(), "octodemo", githubv4.EnterpriseUserDeploymentServer)
+	require.NoError(t, err)
+	assert.Equal(t, 7, counts.Users)
+
+	counts, err = api.FetchEnterpriseCounts(context.Background(), srv.GraphQLClient(), "octodemo", githubv4.EnterpriseUserDeploymentCloud)
+	require.NoError(t, err)
+	assert.Zero(t, counts.Users)
+}
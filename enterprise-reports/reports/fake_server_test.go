@@ -0,0 +1,117 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+// This file contains end-to-end tests of the reports against the fake GitHub API.
+package reports
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/fakegithub"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startDemoServer serves the demo enterprise with pages of two items, so every listing spans pages.
+func startDemoServer(t *testing.T) *fakegithub.Server {
+	t.Helper()
+	fixture, err := fakegithub.DemoFixture()
+	require.NoError(t, err)
+	srv := fakegithub.NewServer(fixture, fakegithub.Options{PageSize: 2})
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// reportLines returns the lines of a report file.
+func reportLines(t *testing.T, path string) []string {
+	t.Helper()
+	return strings.Split(strings.TrimSpace(readFile(t, path)), "\n")
+}
+
+// TestReports_FakeEnterprise tests the organizations, teams, collaborators and repository reports
+// end to end against paginated responses of the fake API, sharing a cache as one run does.
+func TestReports_FakeEnterprise(t *testing.T) {
+	srv := startDemoServer(t)
+	ctx := context.Background()
+	restClient, graphClient := srv.RESTClient(), srv.GraphQLClient()
+	cache := utils.NewSharedCache()
+	dir := t.TempDir()
+
+	orgsFile := filepath.Join(dir, "orgs.csv")
+	require.NoError(t, OrganizationsReport(ctx, graphClient, restClient, "octodemo", orgsFile, 2, cache, Options{
+		Columns: []ColumnSpec{{Name: "Organization"}, {Name: "Total Members"}, {Name: "Members Without 2FA"}, {Name: "Pending Invitations"}},
+	}))
+	assert.ElementsMatch(t, []string{
+		"octodemo-platform,5,\"linus, dormant-dan\",\"octocat, new-hire@octodemo.example\"",
+		"octodemo-apps,4,linus,N/A",
+	}, reportLines(t, orgsFile)[1:])
+
+	teamsFile := filepath.Join(dir, "teams.csv")
+	require.NoError(t, TeamsReport(ctx, restClient, graphClient, "octodemo", teamsFile, 2, cache, Options{
+		Columns: []ColumnSpec{{Name: "Team Slug"}, {Name: "Maintainers"}, {Name: "Members"}, {Name: "Repositories"}},
+	}))
+	assert.ElementsMatch(t, []string{
+		"platform-admins,mona,\"mona, hubot\",\"octodemo-platform/api-gateway:admin, octodemo-platform/infra-modules:admin\"",
+		"developers,ada,\"ada, linus, hubot\",octodemo-platform/api-gateway:push",
+		"mobile,octocat,\"octocat, linus\",\"octodemo-apps/mobile-app:maintain, octodemo-apps/website:pull\"",
+	}, reportLines(t, teamsFile)[1:])
+
+	collaboratorsFile := filepath.Join(dir, "collaborators.csv")
+	require.NoError(t, CollaboratorsReport(ctx, restClient, graphClient, "octodemo", collaboratorsFile, 2, cache, Options{}))
+	lines := reportLines(t, collaboratorsFile)
+	require.Len(t, lines, 8)
+	assert.Contains(t, lines, `octodemo-apps/website,ok,"{""login"":""octocat"",""id"":1003,""permission"":""admin""}","{""login"":""grace"",""id"":1006,""permission"":""triage""}"`)
+	assert.Contains(t, lines, "octodemo-platform/scratch,ok,N/A")
+
+	reposFile := filepath.Join(dir, "repos.csv")
+	require.NoError(t, RepositoryReport(ctx, restClient, graphClient, "octodemo", reposFile, 2, cache, Options{
+		Columns: []ColumnSpec{{Name: "Owner"}, {Name: "Repository"}, {Name: "Archived"}, {Name: "Visibility"}, {Name: "Custom_Properties"}, {Name: "Teams"}},
+	}))
+	lines = reportLines(t, reposFile)
+	require.Len(t, lines, 8)
+	assert.Contains(t, lines, `octodemo-platform,api-gateway,false,internal,"team=platform,tier=1","platform-admins,developers"`)
+	assert.Contains(t, lines, "octodemo-platform,legacy-scripts,true,private,,")
+}
+
+// TestReports_FakeAuditLog tests that the audit log report follows the cursor pagination of the fake API.
+func TestReports_FakeAuditLog(t *testing.T) {
+	srv := startDemoServer(t)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "audit.csv")
+
+	err := AuditLogReport(context.Background(), srv.RESTClient(), "octodemo", out, Options{
+		AuditLog: AuditLogOptions{Phrase: "action:user.login", Checkpoint: filepath.Join(dir, "checkpoint.json")},
+		Columns:  []ColumnSpec{{Name: "Action"}, {Name: "Actor"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"user.login,dormant-dan",
+		"user.login,octocat",
+		"user.login,ada",
+		"user.login,hubot",
+		"user.login,mona",
+	}, reportLines(t, out)[1:])
+}
+
+// TestReports_FakeFault tests that the organizations report still reports an organization whose
+// details fail to load, and fails when the organizations cannot be listed.
+func TestReports_FakeFault(t *testing.T) {
+	srv := startDemoServer(t)
+	srv.Inject(fakegithub.Fault{Path: "/orgs/octodemo-apps", Status: http.StatusBadGateway})
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	out := filepath.Join(dir, "orgs.csv")
+	require.NoError(t, OrganizationsReport(ctx, srv.GraphQLClient(), srv.RESTClient(), "octodemo", out, 2, utils.NewSharedCache(), Options{
+		Columns: []ColumnSpec{{Name: "Organization"}, {Name: "Total Members"}},
+	}))
+	assert.ElementsMatch(t, []string{"octodemo-platform,5", "octodemo-apps,0"}, reportLines(t, out)[1:])
+
+	srv.Inject(fakegithub.Fault{Path: "/graphql", Query: "organizations(", Status: http.StatusInternalServerError})
+	err := OrganizationsReport(ctx, srv.GraphQLClient(), srv.RESTClient(), "octodemo", out, 2, utils.NewSharedCache(), Options{})
+	assert.ErrorContains(t, err, "failed to fetch organizations")
+}
@@ -0,0 +1,279 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/time/rate"
+)
+
+// Findings of the identities report, each describing drift between the enterprise's members
+// and its identity provider.
+const (
+	// IdentityFindingNoIdentity is an enterprise member without a linked external identity.
+	IdentityFindingNoIdentity = "no-linked-identity"
+	// IdentityFindingNoUser is an external identity that is not linked to an enterprise member.
+	IdentityFindingNoUser = "identity-without-user"
+	// IdentityFindingUsernameMismatch is an identity whose SAML and SCIM usernames differ.
+	IdentityFindingUsernameMismatch = "username-mismatch"
+	// IdentityFindingEmailMismatch is an identity whose SAML and SCIM emails have none in common.
+	IdentityFindingEmailMismatch = "email-mismatch"
+	// IdentityFindingDeprovisioned is an enterprise member whose SCIM user was deprovisioned.
+	IdentityFindingDeprovisioned = "deprovisioned-member"
+)
+
+// IdentityReport reconciles an enterprise member with its external identity.
+// Either User or Identity is set.
+type IdentityReport struct {
+	User     *github.User               // Enterprise member; nil for an identity not linked to a member
+	Identity *api.ExternalIdentity      // External identity; nil for a member without a linked identity
+	SCIMUser *github.SCIMUserAttributes // SCIM user of the identity; nil when unknown
+	Findings []string                   // Drift found between the member and the identity
+	ItemStatus
+}
+
+// IdentitiesReport creates a report reconciling the enterprise's members with the external identities
+// of its SAML, OIDC or SCIM identity provider, one row per member and per identity not linked to a
+// member. Each row lists its findings:
+//   - members without a linked identity,
+//   - identities not linked to an enterprise member,
+//   - identities whose SAML and SCIM usernames or emails disagree,
+//   - members whose SCIM user was deprovisioned.
+//
+// Listing external identities requires a token belonging to an enterprise owner. Deprovisioned users
+// are only visible through the enterprise SCIM API, which is limited to enterprises with managed users.
+// When it cannot be read, the failure is recorded and the Status column of every member with an
+// identity shows that its deprovisioned check was skipped.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout
+//   - restClient: GitHub REST API client
+//   - graphQLClient: GitHub GraphQL API client
+//   - enterpriseSlug: Enterprise identifier
+//   - filename: Output CSV file path
+//   - workerCount: Number of concurrent workers for writing rows
+//   - cache: Shared cache for storing and retrieving GitHub data
+//   - opts: Report options, such as the columns to write
+func IdentitiesReport(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache, opts Options) error {
+	slog.Info("starting identities report", "enterprise", enterpriseSlug, "filename", filename, "workers", workerCount)
+
+	header, formatter, err := selectColumns(identityColumns, defaultIdentityColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("identities report columns: %w", err)
+	}
+	formatter = recordRows(opts.Rows, formatter)
+
+	// Create appropriate report writer based on file extension
+	reportWriter, reportErr := NewReportWriter(filename)
+	if reportErr != nil {
+		return reportErr
+	}
+	defer func() {
+		if err := reportWriter.Close(); err != nil {
+			slog.Error("Failed to close report writer", "error", err)
+		}
+	}()
+
+	// Write header to report
+	if headerErr := reportWriter.WriteHeader(header); headerErr != nil {
+		return fmt.Errorf("failed to write header: %w", headerErr)
+	}
+
+	// Check cache for enterprise users or fetch from API
+	var users []*github.User
+
+	if cachedUsers, found := cache.GetEnterpriseUsers(); found {
+		slog.Info("using cached enterprise users")
+		users = cachedUsers
+	} else {
+		slog.Info("fetching enterprise users", "enterprise", enterpriseSlug)
+		users, err = api.FetchEnterpriseUsers(ctx, graphQLClient, enterpriseSlug, opts.Server.Deployment())
+		if err != nil {
+			return fmt.Errorf("fetching enterprise users for %q: %w", enterpriseSlug, err)
+		}
+		// Store in cache
+		cache.SetEnterpriseUsers(users)
+	}
+
+	// Without the identities there is nothing to reconcile, so fail rather than report every member
+	slog.Info("fetching external identities", "enterprise", enterpriseSlug)
+	identities, err := api.FetchEnterpriseExternalIdentities(ctx, graphQLClient, enterpriseSlug)
+	if err != nil {
+		return fmt.Errorf("failed to fetch external identities: %w", err)
+	}
+
+	slog.Info("fetching SCIM users", "enterprise", enterpriseSlug)
+	scimUsers, scimErr := api.FetchEnterpriseSCIMUsers(ctx, restClient, enterpriseSlug)
+	if scimErr != nil {
+		// The enterprise SCIM API is only available to enterprises with managed users
+		slog.Warn("failed to fetch SCIM users, skipping deprovisioned member checks", "enterprise", enterpriseSlug, "error", scimErr)
+		ErrorLogFrom(ctx).Record(enterpriseSlug, "scim users", scimErr)
+		scimUsers = nil
+	}
+
+	rows := reconcileIdentities(users, identities, scimUsers)
+	if scimErr != nil {
+		// The failure is recorded once, and shown on every member the check would have applied to
+		for _, row := range rows {
+			if row.User != nil && row.Identity != nil {
+				row.Failed = append(row.Failed, "scim users")
+			}
+		}
+	}
+	slog.Info("reconciled identities", "users", len(users), "identities", len(identities), "scim_users", len(scimUsers), "rows", len(rows))
+
+	// Processor: rows are reconciled up front, so there is nothing left to enrich
+	processor := func(ctx context.Context, row *IdentityReport) (*IdentityReport, error) {
+		return row, nil
+	}
+
+	// Identities are fetched up front, so processing makes no API calls and is not rate limited
+	limiter := rate.NewLimiter(rate.Inf, workerCount)
+
+	return RunReportWithWriter(ctx, rows, processor, formatter, limiter, workerCount, reportWriter)
+}
+
+// reconcileIdentities matches members with their external identities and SCIM users and records the
+// drift between them. Members come first, ordered by login, followed by identities not linked to a member.
+func reconcileIdentities(users []*github.User, identities []*api.ExternalIdentity, scimUsers []*github.SCIMUserAttributes) []*IdentityReport {
+	scimByID := make(map[string]*github.SCIMUserAttributes, len(scimUsers))
+	scimByUsername := make(map[string]*github.SCIMUserAttributes, len(scimUsers))
+	for _, u := range scimUsers {
+		if u.ID != nil {
+			scimByID[u.GetID()] = u
+		}
+		scimByUsername[strings.ToLower(u.UserName)] = u
+	}
+	scimUser := func(identity *api.ExternalIdentity) *github.SCIMUserAttributes {
+		if u, ok := scimByID[identity.GUID]; ok {
+			return u
+		}
+		if identity.SCIMUsername == "" {
+			return nil
+		}
+		return scimByUsername[strings.ToLower(identity.SCIMUsername)]
+	}
+
+	byLogin := make(map[string]*api.ExternalIdentity, len(identities))
+	for _, identity := range identities {
+		if identity.Login != "" {
+			byLogin[strings.ToLower(identity.Login)] = identity
+		}
+	}
+
+	rows := make([]*IdentityReport, 0, len(users)+len(identities))
+	members := make(map[string]bool, len(users))
+	for _, u := range users {
+		members[strings.ToLower(u.GetLogin())] = true
+		row := &IdentityReport{User: u, Identity: byLogin[strings.ToLower(u.GetLogin())]}
+		if row.Identity == nil {
+			row.Findings = append(row.Findings, IdentityFindingNoIdentity)
+		} else {
+			row.SCIMUser = scimUser(row.Identity)
+			row.Findings = append(row.Findings, identityMismatches(row.Identity)...)
+			if row.SCIMUser != nil && row.SCIMUser.Active != nil && !row.SCIMUser.GetActive() {
+				row.Findings = append(row.Findings, IdentityFindingDeprovisioned)
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return strings.ToLower(rows[i].User.GetLogin()) < strings.ToLower(rows[j].User.GetLogin())
+	})
+
+	for _, identity := range identities {
+		if identity.Login != "" && members[strings.ToLower(identity.Login)] {
+			continue
+		}
+		row := &IdentityReport{Identity: identity, SCIMUser: scimUser(identity)}
+		row.Findings = append([]string{IdentityFindingNoUser}, identityMismatches(identity)...)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// identityMismatches compares the SAML and SCIM attributes of an identity. Attributes that only one
+// of them provides are not compared.
+func identityMismatches(identity *api.ExternalIdentity) []string {
+	var findings []string
+	samlUsername := identity.SAMLUsername
+	if samlUsername == "" {
+		samlUsername = identity.SAMLNameID
+	}
+	if samlUsername != "" && identity.SCIMUsername != "" && !strings.EqualFold(samlUsername, identity.SCIMUsername) {
+		findings = append(findings, IdentityFindingUsernameMismatch)
+	}
+
+	if len(identity.SAMLEmails) > 0 && len(identity.SCIMEmails) > 0 &&
+		!slices.ContainsFunc(identity.SAMLEmails, func(saml string) bool {
+			return slices.ContainsFunc(identity.SCIMEmails, func(scim string) bool { return strings.EqualFold(saml, scim) })
+		}) {
+		findings = append(findings, IdentityFindingEmailMismatch)
+	}
+	return findings
+}
+
+// identityLogin returns the login of the member, or of the user linked to an identity without a member.
+func identityLogin(r *IdentityReport) string {
+	if r.User != nil {
+		return r.User.GetLogin()
+	}
+	return naIfEmpty(r.Identity.Login)
+}
+
+// identityField renders an attribute of the row's identity, or N/A when the row has no identity.
+func identityField(value func(*api.ExternalIdentity) string) func(*IdentityReport) string {
+	return func(r *IdentityReport) string {
+		if r.Identity == nil {
+			return "N/A"
+		}
+		return naIfEmpty(value(r.Identity))
+	}
+}
+
+// identityColumns lists every column the identities report can output.
+var identityColumns = []Column[*IdentityReport]{
+	{Name: "Login", Value: identityLogin},
+	{Name: "Name", Value: func(r *IdentityReport) string {
+		if r.User == nil {
+			return "N/A"
+		}
+		return naIfEmpty(r.User.GetName())
+	}},
+	{Name: "SAML Name ID", Value: identityField(func(i *api.ExternalIdentity) string { return i.SAMLNameID })},
+	{Name: "SAML Emails", Value: identityField(func(i *api.ExternalIdentity) string { return strings.Join(i.SAMLEmails, ", ") })},
+	{Name: "SCIM Username", Value: identityField(func(i *api.ExternalIdentity) string { return i.SCIMUsername })},
+	{Name: "SCIM Emails", Value: identityField(func(i *api.ExternalIdentity) string { return strings.Join(i.SCIMEmails, ", ") })},
+	{Name: "SCIM Active", Value: func(r *IdentityReport) string {
+		if r.SCIMUser == nil || r.SCIMUser.Active == nil {
+			return "N/A"
+		}
+		return fmt.Sprintf("%t", r.SCIMUser.GetActive())
+	}},
+	{Name: "Findings", Value: func(r *IdentityReport) string { return joinOrNA(r.Findings) }},
+	{Name: "User ID", Value: func(r *IdentityReport) string {
+		switch {
+		case r.User != nil && r.User.ID != nil:
+			return fmt.Sprintf("%d", r.User.GetID())
+		case r.Identity != nil && r.Identity.UserID != 0:
+			return fmt.Sprintf("%d", r.Identity.UserID)
+		}
+		return "N/A"
+	}},
+	{Name: "Identity GUID", Value: identityField(func(i *api.ExternalIdentity) string { return i.GUID })},
+	{Name: "SAML Username", Value: identityField(func(i *api.ExternalIdentity) string { return i.SAMLUsername })},
+	{Name: "Finding Count", Value: func(r *IdentityReport) string { return fmt.Sprintf("%d", len(r.Findings)) }},
+	statusColumn[*IdentityReport](),
+}
+
+// defaultIdentityColumns is the column layout written when no columns are configured.
+var defaultIdentityColumns = []string{"Login", "Name", "SAML Name ID", "SAML Emails", "SCIM Username", "SCIM Emails", "SCIM Active", "Findings", "Status"}
@@ -0,0 +1,144 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+// This file contains tests for the identities report functionality.
+package reports
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// identitiesTestServers starts GraphQL and REST servers for the identities report. The enterprise has
+// three members, alice, bob and carol, and SAML identities for alice, bob and an unlinked user.
+// The SCIM users are served by scimHandler.
+func identitiesTestServers(t *testing.T, scimHandler http.HandlerFunc) (*github.Client, *githubv4.Client) {
+	t.Helper()
+
+	muxG := http.NewServeMux()
+	muxG.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var resp string
+		switch {
+		case strings.Contains(string(body), "externalIdentities"):
+			resp = `{"data":{"enterprise":{"ownerInfo":{"oidcProvider":null,"samlIdentityProvider":{"externalIdentities":{"nodes":[
+				{"guid":"g1","user":{"login":"alice","databaseId":1},
+				 "samlIdentity":{"nameId":"alice@corp.com","username":"","emails":[{"value":"alice@corp.com"}]},
+				 "scimIdentity":{"username":"Alice@corp.com","emails":[{"value":"ALICE@corp.com"}]}},
+				{"guid":"g2","user":{"login":"bob","databaseId":2},
+				 "samlIdentity":{"nameId":"bob@corp.com","username":"","emails":[{"value":"bob@corp.com"}]},
+				 "scimIdentity":{"username":"robert@corp.com","emails":[{"value":"robert@corp.com"}]}},
+				{"guid":"g3","user":null,
+				 "samlIdentity":{"nameId":"dave@corp.com","username":"","emails":[]},
+				 "scimIdentity":null}],
+				"pageInfo":{"hasNextPage":false,"endCursor":null}}}}}}}`
+		case strings.Contains(string(body), "members("):
+			resp = `{"data":{"enterprise":{"members":{"nodes":[
+				{"login":"carol","name":"Carol","createdAt":"2024-01-01T00:00:00Z","user":{"databaseId":3}},
+				{"login":"bob","name":"Bob","createdAt":"2024-01-01T00:00:00Z","user":{"databaseId":2}},
+				{"login":"alice","name":"Alice","createdAt":"2024-01-01T00:00:00Z","user":{"databaseId":1}}],
+				"pageInfo":{"hasNextPage":false,"endCursor":null}}}}}`
+		default:
+			t.Fatalf("unexpected query: %s", body)
+		}
+		_, err = fmt.Fprint(w, resp)
+		require.NoError(t, err)
+	})
+	gSrv := httptest.NewServer(muxG)
+	t.Cleanup(gSrv.Close)
+
+	muxR := http.NewServeMux()
+	muxR.HandleFunc("/scim/v2/enterprises/ent/Users", scimHandler)
+	rSrv := httptest.NewServer(muxR)
+	t.Cleanup(rSrv.Close)
+
+	restClient := github.NewClient(rSrv.Client())
+	baseURL, _ := url.Parse(rSrv.URL + "/")
+	restClient.BaseURL = baseURL
+	return restClient, githubv4.NewEnterpriseClient(gSrv.URL+"/graphql", gSrv.Client())
+}
+
+// TestIdentitiesReport tests that the identities report flags members without identities, identities
+// without members, mismatched SAML and SCIM attributes and deprovisioned members.
+func TestIdentitiesReport(t *testing.T) {
+	restClient, graphClient := identitiesTestServers(t, func(w http.ResponseWriter, r *http.Request) {
+		// The second page is requested from the index after the first page's results
+		var resp string
+		switch r.URL.Query().Get("startIndex") {
+		case "1":
+			resp = `{"totalResults":2,"startIndex":1,"itemsPerPage":1,"Resources":[
+				{"id":"g1","userName":"alice@corp.com","active":true,"name":{},"emails":[]}]}`
+		case "2":
+			resp = `{"totalResults":2,"startIndex":2,"itemsPerPage":1,"Resources":[
+				{"id":"other","userName":"robert@corp.com","active":false,"name":{},"emails":[]}]}`
+		default:
+			t.Fatalf("unexpected SCIM page: %s", r.URL.RawQuery)
+		}
+		_, err := fmt.Fprint(w, resp)
+		require.NoError(t, err)
+	})
+
+	out := filepath.Join(t.TempDir(), "identities.csv")
+	opts := Options{Columns: []ColumnSpec{{Name: "Login"}, {Name: "SAML Name ID"}, {Name: "SCIM Username"}, {Name: "SCIM Active"}, {Name: "Findings"}}}
+	err := IdentitiesReport(context.Background(), restClient, graphClient, "ent", out, 2, utils.NewSharedCache(), opts)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(strings.NewReader(readFile(t, out))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+	assert.Equal(t, []string{"Login", "SAML Name ID", "SCIM Username", "SCIM Active", "Findings"}, records[0])
+	assert.ElementsMatch(t, [][]string{
+		{"alice", "alice@corp.com", "Alice@corp.com", "true", "N/A"},
+		{"bob", "bob@corp.com", "robert@corp.com", "false", "username-mismatch, email-mismatch, deprovisioned-member"},
+		{"carol", "N/A", "N/A", "N/A", "no-linked-identity"},
+		{"N/A", "dave@corp.com", "N/A", "N/A", "identity-without-user"},
+	}, records[1:])
+}
+
+// TestIdentitiesReport_NoSCIM tests that the report is written without SCIM status when the
+// enterprise SCIM API is unavailable, and that the skipped deprovisioned check is shown and recorded.
+func TestIdentitiesReport_NoSCIM(t *testing.T) {
+	restClient, graphClient := identitiesTestServers(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	})
+
+	// The users report already fetched the enterprise members
+	cache := utils.NewSharedCache()
+	cache.SetEnterpriseUsers([]*github.User{{Login: github.Ptr("bob"), Name: github.Ptr("Bob")}})
+
+	out := filepath.Join(t.TempDir(), "identities.csv")
+	errorLog := NewErrorLog("identities", out)
+	ctx := WithErrorLog(context.Background(), errorLog)
+	opts := Options{Columns: []ColumnSpec{{Name: "Login"}, {Name: "Name"}, {Name: "SCIM Active"}, {Name: "Findings"}, {Name: "Finding Count"}, {Name: "Status"}}}
+	err := IdentitiesReport(ctx, restClient, graphClient, "ent", out, 2, cache, opts)
+	require.NoError(t, err)
+	require.NoError(t, errorLog.Close())
+
+	records, err := csv.NewReader(strings.NewReader(readFile(t, out))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, []string{"Login", "Name", "SCIM Active", "Findings", "Finding Count", "Status"}, records[0])
+	assert.ElementsMatch(t, [][]string{
+		{"bob", "Bob", "N/A", "username-mismatch, email-mismatch", "2", "failed: scim users"},
+		{"alice", "N/A", "N/A", "identity-without-user", "1", "ok"},
+		{"N/A", "N/A", "N/A", "identity-without-user", "1", "ok"},
+	}, records[1:])
+
+	entries := readItemErrors(t, ErrorsPath(out))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "ent", entries[0].Item)
+	assert.Equal(t, "scim users", entries[0].Field)
+}
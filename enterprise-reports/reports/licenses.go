@@ -0,0 +1,244 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"log/slog"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/time/rate"
+)
+
+// LicenseReport contains a licensed user's seat consumption joined with their dormancy,
+// and whether the seat could be reclaimed.
+type LicenseReport struct {
+	*api.LicensedUser
+	ItemStatus                          // Activity sources that could not be read
+	Dormancy      *utils.DormancyResult // Nil when the user has no GitHub.com account to evaluate
+	Reclaimable   bool                  // Whether the seat could be reclaimed
+	ReclaimReason string                // Why the seat is, or is not, reclaimable
+}
+
+// LicensesReport creates a CSV report of enterprise license consumption, one row per licensed user.
+// Each user is listed with their license type, GitHub Enterprise Cloud and Server usage,
+// Visual Studio subscription linkage, SAML NameID and organizations.
+//
+// Users with a GitHub.com account are joined with the dormancy results of the users report when it
+// ran earlier with the shared cache; otherwise their activity is resolved with the dormancy policy
+// in opts. Seats of dormant users and of users who only hold pending invitations are flagged as
+// reclaimable, except for Visual Studio subscribers, whose seats are covered by their subscription,
+// and Enterprise Server users, whose server activity is not visible to this report.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout
+//   - restClient: GitHub REST API client
+//   - graphQLClient: GitHub GraphQL API client
+//   - enterpriseSlug: Enterprise identifier
+//   - filename: Output CSV file path
+//   - workerCount: Number of concurrent workers for processing users
+//   - opts: Report options, such as the columns to write
+func LicensesReport(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache, opts Options) error {
+	slog.Info("starting licenses report", "enterprise", enterpriseSlug, "filename", filename, "workers", workerCount)
+
+	header, formatter, err := selectColumns(licenseColumns, defaultLicenseColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("licenses report columns: %w", err)
+	}
+	formatter = recordRows(opts.Rows, formatter)
+
+	// Create appropriate report writer based on file extension
+	reportWriter, reportErr := NewReportWriter(filename)
+	if reportErr != nil {
+		return reportErr
+	}
+	defer func() {
+		if err := reportWriter.Close(); err != nil {
+			slog.Error("Failed to close report writer", "error", err)
+		}
+	}()
+
+	// Write header to report
+	if headerErr := reportWriter.WriteHeader(header); headerErr != nil {
+		return fmt.Errorf("failed to write header: %w", headerErr)
+	}
+
+	policy := opts.Dormancy
+	if policy.IsZero() {
+		policy = utils.DefaultDormancyPolicy()
+	}
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("licenses report: %w", err)
+	}
+
+	slog.Info("fetching consumed licenses", "enterprise", enterpriseSlug)
+	licenses, err := api.FetchConsumedLicenses(ctx, restClient, enterpriseSlug)
+	if err != nil {
+		return fmt.Errorf("fetching consumed licenses for %q: %w", enterpriseSlug, err)
+	}
+	slog.Info("fetched consumed licenses", "users", len(licenses.Users), "seats_consumed", licenses.TotalSeatsConsumed, "seats_purchased", licenses.TotalSeatsPurchased)
+
+	// Only resolve activity for users the users report has not already evaluated
+	var missing []string
+	for _, u := range licenses.Users {
+		if u.GithubComUser && u.GithubComLogin != "" {
+			if _, found := cache.GetUserDormancy(u.GithubComLogin); !found {
+				missing = append(missing, u.GithubComLogin)
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	var resolver *api.ActivityResolver
+	if len(missing) > 0 {
+		slog.Info("resolving activity for licensed users", "users", len(missing))
+		resolver = api.NewActivityResolver(restClient, graphQLClient, enterpriseSlug, policy, now)
+		if err := resolver.Prefetch(ctx, missing); err != nil {
+			return err
+		}
+	} else {
+		slog.Info("using cached dormancy results")
+	}
+
+	var reclaimable atomic.Int64
+
+	// Processor: join dormancy and decide whether the seat can be reclaimed
+	processor := func(ctx context.Context, u *api.LicensedUser) (*LicenseReport, error) {
+		slog.Debug("processing licensed user", "login", u.GithubComLogin)
+		report := &LicenseReport{LicensedUser: u}
+
+		if u.GithubComUser && u.GithubComLogin != "" {
+			result, found := cache.GetUserDormancy(u.GithubComLogin)
+			if !found {
+				activity, signalErrs := resolver.Resolve(ctx, u.GithubComLogin)
+				for _, signalErr := range signalErrs {
+					report.fail(ctx, u.GithubComLogin, string(signalErr.Source)+" activity", signalErr.Err)
+				}
+				result = policy.Evaluate(u.GithubComLogin, activity, api.UnknownSignals(signalErrs), now)
+				cache.SetUserDormancy(u.GithubComLogin, result)
+			} else if len(result.Unknown) > 0 {
+				// The users report already recorded why the activity could not be read
+				report.Failed = append(report.Failed, "activity")
+			}
+			report.Dormancy = &result
+		}
+
+		report.Reclaimable, report.ReclaimReason = reclaimSeat(report)
+		if report.Reclaimable {
+			reclaimable.Add(1)
+		}
+		return report, nil
+	}
+
+	// Create a limiter for rate limiting - aiming for ~10 users/sec
+	// (at most one REST events call per uncached user, 10 REST points/sec < 15; licenses are fetched up front)
+	// Burst matches worker count for responsiveness.
+	limiter := rate.NewLimiter(rate.Limit(licensesRate), workerCount) // e.g., 10 requests/sec, burst of workerCount
+
+	if err := RunReportWithWriter(ctx, licenses.Users, processor, formatter, limiter, workerCount, reportWriter); err != nil {
+		return err
+	}
+
+	slog.Info("licenses report completed", "users", len(licenses.Users), "reclaimable", reclaimable.Load())
+	return nil
+}
+
+// reclaimSeat decides whether a licensed user's seat could be reclaimed and explains why.
+func reclaimSeat(r *LicenseReport) (bool, string) {
+	switch {
+	case r.VisualStudioSubscriptionUser:
+		return false, "covered by Visual Studio subscription"
+	case r.Dormancy == nil && r.EnterpriseServerUser:
+		return false, "Enterprise Server activity not visible"
+	case r.Dormancy == nil && len(r.GithubComOrgsWithPendingInvites) > 0:
+		return true, "pending invitation only"
+	case r.Dormancy == nil:
+		return false, "no GitHub.com account"
+	case r.Dormancy.Undetermined:
+		return false, "activity could not be read: " + strings.Join(r.Dormancy.Unknown, ", ")
+	case !r.Dormancy.Dormant:
+		return false, "active"
+	case r.EnterpriseServerUser:
+		return false, "dormant on GitHub.com; check Enterprise Server activity"
+	case r.Dormancy.LastSignal == "":
+		return true, "dormant: no activity recorded"
+	default:
+		return true, fmt.Sprintf("dormant: last %s activity %s", r.Dormancy.LastSignal, r.Dormancy.LastActivity.UTC().Format(time.RFC3339))
+	}
+}
+
+// licenseColumns lists every column the licenses report can output.
+var licenseColumns = []Column[*LicenseReport]{
+	{Name: "Login", Value: func(r *LicenseReport) string { return naIfEmpty(r.GithubComLogin) }},
+	{Name: "Name", Value: func(r *LicenseReport) string { return naIfEmpty(r.GithubComName) }},
+	{Name: "License Type", Value: func(r *LicenseReport) string { return r.LicenseType }},
+	{Name: "GitHub.com User", Value: func(r *LicenseReport) string { return strconv.FormatBool(r.GithubComUser) }},
+	{Name: "Enterprise Server User", Value: func(r *LicenseReport) string { return strconv.FormatBool(r.EnterpriseServerUser) }},
+	{Name: "Visual Studio Subscriber", Value: func(r *LicenseReport) string { return strconv.FormatBool(r.VisualStudioSubscriptionUser) }},
+	{Name: "SAML NameID", Value: func(r *LicenseReport) string { return naIfEmpty(r.GithubComSamlNameID) }},
+	{Name: "Organizations", Value: func(r *LicenseReport) string { return joinOrNA(r.Organizations()) }},
+	{Name: "Dormant?", Value: func(r *LicenseReport) string {
+		if r.Dormancy == nil {
+			return "N/A"
+		}
+		if r.Dormancy.Undetermined {
+			return "unknown"
+		}
+		return strconv.FormatBool(r.Dormancy.Dormant)
+	}},
+	{Name: "Last Activity", Value: func(r *LicenseReport) string {
+		if r.Dormancy == nil || r.Dormancy.LastActivity.IsZero() {
+			return "N/A"
+		}
+		return r.Dormancy.LastActivity.UTC().Format(time.RFC3339)
+	}},
+	{Name: "Reclaimable", Value: func(r *LicenseReport) string { return strconv.FormatBool(r.Reclaimable) }},
+	{Name: "Reclaim Reason", Value: func(r *LicenseReport) string { return r.ReclaimReason }},
+	{Name: "Enterprise Roles", Value: func(r *LicenseReport) string { return joinOrNA(r.GithubComEnterpriseRoles) }},
+	{Name: "Pending Invitations", Value: func(r *LicenseReport) string { return joinOrNA(r.GithubComOrgsWithPendingInvites) }},
+	{Name: "Verified Domain Emails", Value: func(r *LicenseReport) string { return joinOrNA(r.GithubComVerifiedDomainEmails) }},
+	{Name: "Two Factor", Value: func(r *LicenseReport) string { return strconv.FormatBool(r.GithubComTwoFactorAuth) }},
+	{Name: "Enterprise Server User IDs", Value: func(r *LicenseReport) string { return joinOrNA(r.EnterpriseServerUserIDs) }},
+	{Name: "Enterprise Server Emails", Value: func(r *LicenseReport) string { return joinOrNA(r.EnterpriseServerEmails) }},
+	{Name: "Visual Studio Email", Value: func(r *LicenseReport) string { return naIfEmpty(r.VisualStudioSubscriptionEmail) }},
+	{Name: "Visual Studio License Status", Value: func(r *LicenseReport) string { return naIfEmpty(r.VisualStudioLicenseStatus) }},
+	{Name: "Total User Accounts", Value: func(r *LicenseReport) string { return strconv.Itoa(r.TotalUserAccounts) }},
+	{Name: "Last Activity Signal", Value: func(r *LicenseReport) string {
+		if r.Dormancy == nil || r.Dormancy.LastSignal == "" {
+			return "N/A"
+		}
+		return r.Dormancy.LastSignal
+	}},
+	{Name: "Profile", Value: func(r *LicenseReport) string { return naIfEmpty(r.GithubComProfile) }},
+	statusColumn[*LicenseReport](),
+}
+
+// defaultLicenseColumns is the column layout written when no columns are configured.
+var defaultLicenseColumns = []string{
+	"Login", "Name", "License Type", "GitHub.com User", "Enterprise Server User", "Visual Studio Subscriber",
+	"SAML NameID", "Organizations", "Dormant?", "Last Activity", "Reclaimable", "Reclaim Reason", "Status",
+}
+
+// naIfEmpty returns "N/A" for an empty value.
+func naIfEmpty(s string) string {
+	if s == "" {
+		return "N/A"
+	}
+	return s
+}
+
+// joinOrNA joins values with commas, returning "N/A" when there are none.
+func joinOrNA(values []string) string {
+	if len(values) == 0 {
+		return "N/A"
+	}
+	return strings.Join(values, ", ")
+}
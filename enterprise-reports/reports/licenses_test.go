@@ -0,0 +1,182 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+// This file contains tests for the licenses report functionality.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// consumedLicensesJSON is a consumed-licenses response with a dormant user, an active user,
+// a Visual Studio subscriber and a user who only holds a pending invitation.
+const consumedLicensesJSON = `{"total_seats_consumed":4,"total_seats_purchased":10,"users":[
+	{"github_com_login":"dormant","github_com_name":"Dormant User","github_com_user":true,"github_com_member_roles":["org1:Member","org2:Owner"],"github_com_saml_name_id":"dormant@example.com","license_type":"Enterprise","total_user_accounts":1},
+	{"github_com_login":"active","github_com_name":"Active User","github_com_user":true,"github_com_member_roles":["org1:Member"],"license_type":"Enterprise","total_user_accounts":1},
+	{"github_com_login":"vs","github_com_user":true,"visual_studio_subscription_user":true,"visual_studio_subscription_email":"vs@example.com","license_type":"Visual Studio subscription","total_user_accounts":2},
+	{"github_com_user":false,"github_com_orgs_with_pending_invites":["org1"],"license_type":"Enterprise","total_user_accounts":0}
+]}`
+
+// newLicensesTestClients starts stub servers for the licenses report. The audit log reports a
+// recent login for the "active" user only; GraphQL returns no contributions.
+func newLicensesTestClients(t *testing.T) (*github.Client, *githubv4.Client, *int) {
+	t.Helper()
+	var auditLogCalls int
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	muxR := http.NewServeMux()
+	muxR.HandleFunc("/enterprises/ent/consumed-licenses", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := fmt.Fprint(w, consumedLicensesJSON); err != nil {
+			t.Fatalf("failed to write response: %v", err)
+		}
+	})
+	muxR.HandleFunc("/enterprises/ent/audit-log", func(w http.ResponseWriter, r *http.Request) {
+		auditLogCalls++
+		body := `[]`
+		if strings.Contains(r.URL.Query().Get("phrase"), "action:user.login") {
+			body = fmt.Sprintf(`[{"action":"user.login","actor":"active","created_at":"%s"}]`, now)
+		}
+		if _, err := fmt.Fprint(w, body); err != nil {
+			t.Fatalf("failed to write response: %v", err)
+		}
+	})
+	rSrv := httptest.NewServer(muxR)
+	t.Cleanup(rSrv.Close)
+
+	muxG := http.NewServeMux()
+	muxG.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := fmt.Fprint(w, `{"data":{}}`); err != nil {
+			t.Fatalf("failed to write response: %v", err)
+		}
+	})
+	gSrv := httptest.NewServer(muxG)
+	t.Cleanup(gSrv.Close)
+
+	restClient := github.NewClient(rSrv.Client())
+	baseURL, _ := url.Parse(rSrv.URL + "/")
+	restClient.BaseURL = baseURL
+	graphClient := githubv4.NewEnterpriseClient(gSrv.URL+"/graphql", gSrv.Client())
+	return restClient, graphClient, &auditLogCalls
+}
+
+// auditLogOnlyPolicy returns the default dormancy policy with only the audit-log signals enabled,
+// so the report makes no per-user events calls.
+func auditLogOnlyPolicy() utils.DormancyPolicy {
+	policy := utils.DefaultDormancyPolicy()
+	for i := range policy.Signals {
+		if policy.Signals[i].Source != utils.SourceAuditLog {
+			policy.Signals[i].Weight = 0
+		}
+	}
+	return policy
+}
+
+// TestLicensesReport tests that the licenses report flags dormant users and pending invitations
+// as reclaimable, but not Visual Studio subscribers or active users.
+func TestLicensesReport(t *testing.T) {
+	restClient, graphClient, _ := newLicensesTestClients(t)
+
+	out := filepath.Join(t.TempDir(), "licenses.csv")
+	opts := Options{
+		Columns:  []ColumnSpec{{Name: "Login"}, {Name: "Organizations"}, {Name: "Dormant?"}, {Name: "Reclaimable"}, {Name: "Reclaim Reason"}, {Name: "Status"}},
+		Dormancy: auditLogOnlyPolicy(),
+	}
+	err := LicensesReport(context.Background(), restClient, graphClient, "ent", out, 1, utils.NewSharedCache(), opts)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "Login,Organizations,Dormant?,Reclaimable,Reclaim Reason,Status", lines[0])
+	assert.ElementsMatch(t, []string{
+		`dormant,"org1, org2",true,true,dormant: no activity recorded,ok`,
+		"active,org1,false,false,active,ok",
+		"vs,N/A,true,false,covered by Visual Studio subscription,ok",
+		"N/A,N/A,N/A,true,pending invitation only,ok",
+	}, lines[1:])
+}
+
+// TestLicensesReport_CachedDormancy tests that the licenses report reuses dormancy results
+// cached by the users report instead of reading the audit log again.
+func TestLicensesReport_CachedDormancy(t *testing.T) {
+	restClient, graphClient, auditLogCalls := newLicensesTestClients(t)
+
+	lastPush := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cache := utils.NewSharedCache()
+	cache.SetUserDormancy("dormant", utils.DormancyResult{Dormant: true, LastSignal: utils.SignalGit, LastActivity: lastPush})
+	cache.SetUserDormancy("active", utils.DormancyResult{Dormant: false, Score: 1})
+	cache.SetUserDormancy("vs", utils.DormancyResult{Dormant: true})
+
+	out := filepath.Join(t.TempDir(), "licenses.csv")
+	opts := Options{
+		Columns:  []ColumnSpec{{Name: "Login"}, {Name: "Last Activity"}, {Name: "Reclaim Reason"}, {Name: "Status"}},
+		Dormancy: auditLogOnlyPolicy(),
+	}
+	err := LicensesReport(context.Background(), restClient, graphClient, "ent", out, 1, cache, opts)
+	require.NoError(t, err)
+	assert.Zero(t, *auditLogCalls, "cached dormancy should not be resolved again")
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 5)
+	assert.Contains(t, lines[1:], "dormant,2024-01-02T03:04:05Z,dormant: last git activity 2024-01-02T03:04:05Z,ok")
+}
+
+// TestLicensesReport_ActivityFailure tests that users whose activity cannot be read are marked in
+// the Status column, and the failure recorded in the errors file unless the users report already did.
+func TestLicensesReport_ActivityFailure(t *testing.T) {
+	restClient, _, _ := newLicensesTestClients(t)
+	gSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}))
+	t.Cleanup(gSrv.Close)
+	graphClient := githubv4.NewEnterpriseClient(gSrv.URL+"/graphql", gSrv.Client())
+
+	policy := auditLogOnlyPolicy()
+	for i := range policy.Signals {
+		if policy.Signals[i].Source == utils.SourceContributions {
+			policy.Signals[i].Weight = 1
+		}
+	}
+	cache := utils.NewSharedCache()
+	cache.SetUserDormancy("vs", utils.DormancyResult{Undetermined: true, Unknown: []string{utils.SignalContributions}})
+
+	out := filepath.Join(t.TempDir(), "licenses.csv")
+	opts := Options{
+		Columns:  []ColumnSpec{{Name: "Login"}, {Name: "Dormant?"}, {Name: "Status"}},
+		Dormancy: policy,
+	}
+	errorLog := NewErrorLog("licenses", out)
+	ctx := WithErrorLog(context.Background(), errorLog)
+	require.NoError(t, LicensesReport(ctx, restClient, graphClient, "ent", out, 1, cache, opts))
+	require.NoError(t, errorLog.Close())
+
+	assert.ElementsMatch(t, []string{
+		"dormant,unknown,failed: contributions activity",
+		"active,false,failed: contributions activity",
+		"vs,unknown,failed: activity",
+		"N/A,N/A,ok",
+	}, reportLines(t, out)[1:])
+
+	entries := readItemErrors(t, ErrorsPath(out))
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, "contributions activity", entry.Field)
+		assert.NotEqual(t, "vs", entry.Item, "cached failures are recorded by the users report")
+	}
+}
@@ -0,0 +1,323 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"log/slog"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/time/rate"
+)
+
+// OrgSettingsReport contains an organization's security settings and how they compare to the baseline.
+type OrgSettingsReport struct {
+	Organization        *github.Organization
+	ActionsPermissions  *github.ActionsPermissions                    // Nil when not visible to the token
+	WorkflowPermissions *github.DefaultWorkflowPermissionOrganization // Nil when not visible to the token
+	Security            *api.SecuritySettings                         // Settings on the organization itself; nil when not visible
+	Enterprise          *api.SecuritySettings                         // Enterprise-wide settings; nil when not visible
+	Violations          []string                                      // Baseline settings the organization does not meet
+	ItemStatus                                                        // Settings that could not be fetched
+}
+
+// OrganizationSettingsReport creates a report of the security settings of every enterprise organization,
+// such as the two-factor requirement, repository creation and forking policies, GitHub Actions policies,
+// security features enabled for new repositories, the IP allow list and SAML single sign-on.
+//
+// Each organization is evaluated against opts.OrgBaseline, and the settings it does not meet are listed
+// in the Violations column. Settings the token cannot see are reported as "N/A" and, when the baseline
+// checks them, as violations, so an incomplete scan is never mistaken for a compliant one. An organization
+// whose settings cannot be read at all is reported as failed, with the violation "settings could not be read".
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout
+//   - restClient: GitHub REST API client
+//   - graphQLClient: GitHub GraphQL API client
+//   - enterpriseSlug: Enterprise identifier
+//   - filename: Output CSV file path
+//   - workerCount: Number of concurrent workers for processing organizations
+//   - cache: Shared cache for storing and retrieving GitHub data
+//   - opts: Report options, such as the columns to write and the baseline
+func OrganizationSettingsReport(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache, opts Options) error {
+	slog.Info("starting org settings report", "enterprise", enterpriseSlug, "filename", filename, "workers", workerCount)
+
+	header, formatter, err := selectColumns(orgSettingsColumns, defaultOrgSettingsColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("org-settings report columns: %w", err)
+	}
+	formatter = recordRows(opts.Rows, formatter)
+	if err := opts.OrgBaseline.Validate(); err != nil {
+		return fmt.Errorf("org-settings report: %w", err)
+	}
+
+	// Create appropriate report writer based on file extension
+	reportWriter, reportErr := NewReportWriter(filename)
+	if reportErr != nil {
+		return reportErr
+	}
+	defer func() {
+		if err := reportWriter.Close(); err != nil {
+			slog.Error("Failed to close report writer", "error", err)
+		}
+	}()
+
+	// Write header to report
+	if headerErr := reportWriter.WriteHeader(header); headerErr != nil {
+		return fmt.Errorf("failed to write header: %w", headerErr)
+	}
+
+	// Enterprise-wide settings apply to every organization, so fetch them once
+	enterprise, err := api.FetchEnterpriseSecuritySettings(ctx, graphQLClient, enterpriseSlug)
+	if err != nil {
+		slog.Warn("failed to fetch enterprise security settings, checking organization settings only", "enterprise", enterpriseSlug, "error", err)
+		enterprise = nil
+	}
+
+	// Check cache for organizations or fetch from API
+	var orgs []*github.Organization
+
+	if cachedOrgs, found := cache.GetEnterpriseOrgs(); found {
+		slog.Info("using cached enterprise organizations")
+		orgs = cachedOrgs
+	} else {
+		slog.Info("fetching enterprise organizations", "enterprise", enterpriseSlug)
+		orgs, err = api.FetchEnterpriseOrgs(ctx, graphQLClient, enterpriseSlug)
+		if err != nil {
+			return fmt.Errorf("failed to fetch organizations: %w", err)
+		}
+		// Store in cache
+		cache.SetEnterpriseOrgs(orgs)
+	}
+
+	var nonCompliant atomic.Int64
+
+	// Processor: fetch the organization's settings and evaluate them against the baseline
+	processor := func(ctx context.Context, org *github.Organization) (*OrgSettingsReport, error) {
+		slog.Debug("processing organization settings", "org", org.GetLogin())
+		report := &OrgSettingsReport{Organization: org, Enterprise: enterprise}
+		// Without the organization's own settings, its row reports them as unknown rather than dropping it
+		info, err := api.FetchOrganization(ctx, restClient, org.GetLogin())
+		if err != nil {
+			slog.Warn("failed to fetch organization settings", "org", org.GetLogin(), "error", err)
+			report.fail(ctx, org.GetLogin(), "settings", err)
+		} else {
+			report.Organization = info
+		}
+
+		// The remaining settings need organization owner access; report them as unknown without it
+		if report.ActionsPermissions, err = api.FetchOrganizationActionsPermissions(ctx, restClient, org.GetLogin()); err != nil {
+			slog.Warn("failed to fetch actions permissions", "org", org.GetLogin(), "error", err)
+			report.fail(ctx, org.GetLogin(), "actions permissions", err)
+		}
+		if report.WorkflowPermissions, err = api.FetchOrganizationWorkflowPermissions(ctx, restClient, org.GetLogin()); err != nil {
+			slog.Warn("failed to fetch workflow permissions", "org", org.GetLogin(), "error", err)
+			report.fail(ctx, org.GetLogin(), "workflow permissions", err)
+		}
+		if report.Security, err = api.FetchOrganizationSecuritySettings(ctx, graphQLClient, org.GetLogin()); err != nil {
+			slog.Warn("failed to fetch organization security settings", "org", org.GetLogin(), "error", err)
+			report.fail(ctx, org.GetLogin(), "security settings", err)
+		}
+
+		if info == nil {
+			report.Violations = append(report.Violations, "settings could not be read")
+		}
+		report.Violations = append(report.Violations, orgSettingsViolations(opts.OrgBaseline, report)...)
+		if len(report.Violations) > 0 {
+			nonCompliant.Add(1)
+		}
+		return report, nil
+	}
+
+	// Create a limiter for rate limiting - aiming for ~4 orgs/sec
+	// (Each org makes 3 REST calls, 12 points/sec below the 15 points/sec limit)
+	// Burst matches worker count for responsiveness.
+	limiter := rate.NewLimiter(rate.Limit(orgSettingsRate), workerCount)
+
+	if err := RunReportWithWriter(ctx, orgs, processor, formatter, limiter, workerCount, reportWriter); err != nil {
+		return err
+	}
+
+	slog.Info("org settings report completed", "organizations", len(orgs), "non_compliant", nonCompliant.Load(), "baseline", !opts.OrgBaseline.IsZero())
+	return nil
+}
+
+// ipAllowList reports where the IP allow list is enabled: "enterprise", "enabled" on the
+// organization, "disabled", or "" when the organization's setting is not visible.
+func (r *OrgSettingsReport) ipAllowList() string {
+	switch {
+	case r.Enterprise != nil && r.Enterprise.IPAllowListEnabled:
+		return "enterprise"
+	case r.Security == nil:
+		return ""
+	case r.Security.IPAllowListEnabled:
+		return "enabled"
+	default:
+		return "disabled"
+	}
+}
+
+// samlSSO reports where SAML single sign-on is configured: "enterprise", "organization", "none",
+// or "" when the organization's setting is not visible.
+func (r *OrgSettingsReport) samlSSO() string {
+	switch {
+	case r.Enterprise != nil && r.Enterprise.SAMLConfigured:
+		return "enterprise"
+	case r.Security == nil:
+		return ""
+	case r.Security.SAMLConfigured:
+		return "organization"
+	default:
+		return "none"
+	}
+}
+
+// orgSettingsViolations lists the settings of an organization that do not meet the baseline,
+// named after the baseline's configuration keys, e.g. "two-factor-required: false (baseline true)".
+func orgSettingsViolations(b utils.OrgBaseline, r *OrgSettingsReport) []string {
+	var violations []string
+	violate := func(key, got, want string) {
+		if got == "" {
+			got = "unknown"
+		}
+		violations = append(violations, fmt.Sprintf("%s: %s (baseline %s)", key, got, want))
+	}
+	// require flags settings that must be enabled when the baseline enables them
+	require := func(key string, want, got *bool) {
+		if want != nil && *want && (got == nil || !*got) {
+			violate(key, boolString(got), "true")
+		}
+	}
+	// forbid flags permissions that must be disabled when the baseline disables them
+	forbid := func(key string, want, got *bool) {
+		if want != nil && !*want && (got == nil || *got) {
+			violate(key, boolString(got), "false")
+		}
+	}
+	// limit flags values more permissive than the baseline level
+	limit := func(key string, levels []string, want, got string) {
+		if want != "" && utils.ExceedsLevel(levels, got, want) {
+			violate(key, got, want)
+		}
+	}
+
+	org := r.Organization
+	require("two-factor-required", b.TwoFactorRequired, org.TwoFactorRequirementEnabled)
+	limit("default-repository-permission", utils.RepositoryPermissionLevels, b.DefaultRepositoryPermission, org.GetDefaultRepoPermission())
+	forbid("members-can-create-public-repositories", b.MembersCanCreatePublicRepos, org.MembersCanCreatePublicRepos)
+	forbid("members-can-create-private-repositories", b.MembersCanCreatePrivateRepos, org.MembersCanCreatePrivateRepos)
+	forbid("members-can-create-internal-repositories", b.MembersCanCreateInternalRepos, org.MembersCanCreateInternalRepos)
+	forbid("members-can-fork-private-repositories", b.MembersCanForkPrivateRepos, org.MembersCanForkPrivateRepos)
+	limit("allowed-actions", utils.AllowedActionsLevels, b.AllowedActions, r.allowedActions())
+	limit("default-workflow-permissions", utils.WorkflowPermissionLevels, b.DefaultWorkflowPermissions, r.WorkflowPermissions.GetDefaultWorkflowPermissions())
+	forbid("actions-can-approve-pull-requests", b.ActionsCanApprovePullRequests, r.canApprovePullRequests())
+	require("advanced-security-enabled-for-new-repositories", b.AdvancedSecurityForNewRepos, org.AdvancedSecurityEnabledForNewRepos)
+	require("secret-scanning-enabled-for-new-repositories", b.SecretScanningForNewRepos, org.SecretScanningEnabledForNewRepos)
+	require("secret-scanning-push-protection-enabled-for-new-repositories", b.PushProtectionForNewRepos, org.SecretScanningPushProtectionEnabledForNewRepos)
+	require("dependabot-alerts-enabled-for-new-repositories", b.DependabotAlertsForNewRepos, org.DependabotAlertsEnabledForNewRepos)
+	require("web-commit-signoff-required", b.WebCommitSignoffRequired, org.WebCommitSignoffRequired)
+	if b.IPAllowListEnabled != nil && *b.IPAllowListEnabled {
+		if got := r.ipAllowList(); got != "enterprise" && got != "enabled" {
+			violate("ip-allow-list-enabled", got, "true")
+		}
+	}
+	if b.SSORequired != nil && *b.SSORequired {
+		if got := r.samlSSO(); got != "enterprise" && got != "organization" {
+			violate("sso-required", got, "true")
+		}
+	}
+	return violations
+}
+
+// allowedActions returns the organization's allowed actions policy, or "none" when Actions is
+// disabled for every repository, which is stricter than any policy.
+func (r *OrgSettingsReport) allowedActions() string {
+	if r.ActionsPermissions.GetEnabledRepositories() == "none" {
+		return utils.AllowedActionsLevels[0]
+	}
+	return r.ActionsPermissions.GetAllowedActions()
+}
+
+// canApprovePullRequests returns whether workflows may approve pull requests, or nil when not visible.
+func (r *OrgSettingsReport) canApprovePullRequests() *bool {
+	if r.WorkflowPermissions == nil {
+		return nil
+	}
+	return r.WorkflowPermissions.CanApprovePullRequestReviews
+}
+
+// boolString renders an optional setting, or "" when it is not visible.
+func boolString(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
+
+// boolColumn returns a column rendering an optional organization setting, or "N/A" when it is not visible.
+func boolColumn(name string, get func(*OrgSettingsReport) *bool) Column[*OrgSettingsReport] {
+	return Column[*OrgSettingsReport]{Name: name, Value: func(r *OrgSettingsReport) string { return naIfEmpty(boolString(get(r))) }}
+}
+
+// orgSettingsColumns lists every column the org-settings report can output.
+var orgSettingsColumns = []Column[*OrgSettingsReport]{
+	{Name: "Organization", Value: func(r *OrgSettingsReport) string { return r.Organization.GetLogin() }},
+	boolColumn("Two Factor Required", func(r *OrgSettingsReport) *bool { return r.Organization.TwoFactorRequirementEnabled }),
+	{Name: "Default Repository Permission", Value: func(r *OrgSettingsReport) string { return naIfEmpty(r.Organization.GetDefaultRepoPermission()) }},
+	boolColumn("Members Can Create Public Repositories", func(r *OrgSettingsReport) *bool { return r.Organization.MembersCanCreatePublicRepos }),
+	boolColumn("Members Can Create Private Repositories", func(r *OrgSettingsReport) *bool { return r.Organization.MembersCanCreatePrivateRepos }),
+	boolColumn("Members Can Create Internal Repositories", func(r *OrgSettingsReport) *bool { return r.Organization.MembersCanCreateInternalRepos }),
+	boolColumn("Members Can Fork Private Repositories", func(r *OrgSettingsReport) *bool { return r.Organization.MembersCanForkPrivateRepos }),
+	{Name: "Actions Enabled Repositories", Value: func(r *OrgSettingsReport) string {
+		return naIfEmpty(r.ActionsPermissions.GetEnabledRepositories())
+	}},
+	{Name: "Allowed Actions", Value: func(r *OrgSettingsReport) string { return naIfEmpty(r.ActionsPermissions.GetAllowedActions()) }},
+	{Name: "Default Workflow Permissions", Value: func(r *OrgSettingsReport) string {
+		return naIfEmpty(r.WorkflowPermissions.GetDefaultWorkflowPermissions())
+	}},
+	boolColumn("Actions Can Approve Pull Requests", func(r *OrgSettingsReport) *bool { return r.canApprovePullRequests() }),
+	boolColumn("Advanced Security For New Repositories", func(r *OrgSettingsReport) *bool { return r.Organization.AdvancedSecurityEnabledForNewRepos }),
+	boolColumn("Secret Scanning For New Repositories", func(r *OrgSettingsReport) *bool { return r.Organization.SecretScanningEnabledForNewRepos }),
+	boolColumn("Push Protection For New Repositories", func(r *OrgSettingsReport) *bool {
+		return r.Organization.SecretScanningPushProtectionEnabledForNewRepos
+	}),
+	boolColumn("Dependabot Alerts For New Repositories", func(r *OrgSettingsReport) *bool { return r.Organization.DependabotAlertsEnabledForNewRepos }),
+	boolColumn("Web Commit Signoff Required", func(r *OrgSettingsReport) *bool { return r.Organization.WebCommitSignoffRequired }),
+	{Name: "IP Allow List", Value: func(r *OrgSettingsReport) string { return naIfEmpty(r.ipAllowList()) }},
+	{Name: "SAML SSO", Value: func(r *OrgSettingsReport) string { return naIfEmpty(r.samlSSO()) }},
+	{Name: "Compliant", Value: func(r *OrgSettingsReport) string { return strconv.FormatBool(len(r.Violations) == 0) }},
+	{Name: "Violations", Value: func(r *OrgSettingsReport) string { return joinOrNA(r.Violations) }},
+	{Name: "Violation Count", Value: func(r *OrgSettingsReport) string { return strconv.Itoa(len(r.Violations)) }},
+	statusColumn[*OrgSettingsReport](),
+}
+
+// defaultOrgSettingsColumns is the column layout written when no columns are configured.
+var defaultOrgSettingsColumns = []string{
+	"Organization",
+	"Two Factor Required",
+	"Default Repository Permission",
+	"Members Can Create Public Repositories",
+	"Members Can Create Private Repositories",
+	"Members Can Create Internal Repositories",
+	"Members Can Fork Private Repositories",
+	"Actions Enabled Repositories",
+	"Allowed Actions",
+	"Default Workflow Permissions",
+	"Actions Can Approve Pull Requests",
+	"Advanced Security For New Repositories",
+	"Secret Scanning For New Repositories",
+	"Push Protection For New Repositories",
+	"Dependabot Alerts For New Repositories",
+	"Web Commit Signoff Required",
+	"IP Allow List",
+	"SAML SSO",
+	"Compliant",
+	"Violations",
+	"Status",
+}
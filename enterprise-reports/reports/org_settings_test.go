@@ -0,0 +1,153 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+// This file contains tests for the organization settings report functionality.
+package reports
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/fakegithub"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOrganizationSettingsReport tests that the organization settings report lists each organization's
+// settings and the baseline settings it violates, including settings the token cannot see.
+func TestOrganizationSettingsReport(t *testing.T) {
+	muxG := http.NewServeMux()
+	muxG.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var resp string
+		switch {
+		case strings.Contains(string(body), "ownerInfo"):
+			// SAML is configured for the whole enterprise
+			resp = `{"data":{"enterprise":{"ownerInfo":{"ipAllowListEnabledSetting":"DISABLED","samlIdentityProvider":{"id":"IDP"}}}}}`
+		case strings.Contains(string(body), `"org":"org1"`):
+			resp = `{"data":{"organization":{"ipAllowListEnabledSetting":"ENABLED","samlIdentityProvider":null}}}`
+		default:
+			resp = `{"data":null,"errors":[{"message":"Must have admin rights to Organization."}]}`
+		}
+		_, err = fmt.Fprint(w, resp)
+		require.NoError(t, err)
+	})
+	gSrv := httptest.NewServer(muxG)
+	defer gSrv.Close()
+
+	muxR := http.NewServeMux()
+	muxR.HandleFunc("/orgs/org1", func(w http.ResponseWriter, r *http.Request) {
+		_, err := fmt.Fprint(w, `{"login":"org1","two_factor_requirement_enabled":true,"default_repository_permission":"read",
+			"members_can_create_public_repositories":false,"members_can_fork_private_repositories":false}`)
+		require.NoError(t, err)
+	})
+	muxR.HandleFunc("/orgs/org1/actions/permissions", func(w http.ResponseWriter, r *http.Request) {
+		_, err := fmt.Fprint(w, `{"enabled_repositories":"all","allowed_actions":"selected"}`)
+		require.NoError(t, err)
+	})
+	muxR.HandleFunc("/orgs/org1/actions/permissions/workflow", func(w http.ResponseWriter, r *http.Request) {
+		_, err := fmt.Fprint(w, `{"default_workflow_permissions":"read","can_approve_pull_request_reviews":false}`)
+		require.NoError(t, err)
+	})
+	muxR.HandleFunc("/orgs/org2", func(w http.ResponseWriter, r *http.Request) {
+		_, err := fmt.Fprint(w, `{"login":"org2","two_factor_requirement_enabled":false,"default_repository_permission":"write",
+			"members_can_create_public_repositories":true}`)
+		require.NoError(t, err)
+	})
+	muxR.HandleFunc("/orgs/org2/actions/permissions", func(w http.ResponseWriter, r *http.Request) {
+		_, err := fmt.Fprint(w, `{"enabled_repositories":"all","allowed_actions":"all"}`)
+		require.NoError(t, err)
+	})
+	muxR.HandleFunc("/orgs/org2/actions/permissions/workflow", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	})
+	rSrv := httptest.NewServer(muxR)
+	defer rSrv.Close()
+
+	restClient := github.NewClient(rSrv.Client())
+	baseURL, _ := url.Parse(rSrv.URL + "/")
+	restClient.BaseURL = baseURL
+	graphClient := githubv4.NewEnterpriseClient(gSrv.URL+"/graphql", gSrv.Client())
+
+	cache := utils.NewSharedCache()
+	cache.SetEnterpriseOrgs([]*github.Organization{{Login: github.Ptr("org1")}, {Login: github.Ptr("org2")}})
+
+	out := filepath.Join(t.TempDir(), "org-settings.csv")
+	opts := Options{
+		Columns: []ColumnSpec{{Name: "Organization"}, {Name: "Allowed Actions"}, {Name: "IP Allow List"}, {Name: "SAML SSO"},
+			{Name: "Compliant"}, {Name: "Violations"}},
+		OrgBaseline: utils.OrgBaseline{
+			TwoFactorRequired:           github.Ptr(true),
+			DefaultRepositoryPermission: "read",
+			MembersCanCreatePublicRepos: github.Ptr(false),
+			AllowedActions:              "selected",
+			DefaultWorkflowPermissions:  "read",
+			IPAllowListEnabled:          github.Ptr(true),
+			SSORequired:                 github.Ptr(true),
+		},
+	}
+	err := OrganizationSettingsReport(context.Background(), restClient, graphClient, "ent", out, 2, cache, opts)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(strings.NewReader(readFile(t, out))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"Organization", "Allowed Actions", "IP Allow List", "SAML SSO", "Compliant", "Violations"}, records[0])
+	assert.ElementsMatch(t, [][]string{
+		{"org1", "selected", "enabled", "enterprise", "true", "N/A"},
+		{"org2", "all", "N/A", "enterprise", "false", "two-factor-required: false (baseline true), " +
+			"default-repository-permission: write (baseline read), " +
+			"members-can-create-public-repositories: true (baseline false), " +
+			"allowed-actions: all (baseline selected), " +
+			"default-workflow-permissions: unknown (baseline read), " +
+			"ip-allow-list-enabled: unknown (baseline true)"},
+	}, records[1:])
+}
+
+// TestOrganizationSettingsReport_SettingsUnreadable tests that an organization whose settings cannot be
+// read is reported with unknown settings, as failed and non-compliant, rather than left out.
+func TestOrganizationSettingsReport_SettingsUnreadable(t *testing.T) {
+	srv := startDemoServer(t)
+	srv.Inject(fakegithub.Fault{Path: "/orgs/octodemo-apps", Status: http.StatusBadGateway})
+	out := filepath.Join(t.TempDir(), "org-settings.csv")
+
+	err := OrganizationSettingsReport(context.Background(), srv.RESTClient(), srv.GraphQLClient(), "octodemo", out, 2, utils.NewSharedCache(), Options{
+		Columns: []ColumnSpec{{Name: "Organization"}, {Name: "Two Factor Required"}, {Name: "Compliant"}, {Name: "Violations"}, {Name: "Status"}},
+	})
+	require.NoError(t, err)
+
+	// The fake API does not serve the actions settings, so they are reported as failed too
+	assert.Contains(t, reportLines(t, out),
+		`octodemo-apps,N/A,false,settings could not be read,"failed: settings, actions permissions, workflow permissions"`)
+}
+
+// TestOrgSettingsViolations_StricterThanBaseline tests that settings stricter than the baseline,
+// and a baseline that leaves settings out, are not reported as violations.
+func TestOrgSettingsViolations_StricterThanBaseline(t *testing.T) {
+	report := &OrgSettingsReport{
+		Organization: &github.Organization{
+			DefaultRepoPermission:       github.Ptr("none"),
+			MembersCanCreatePublicRepos: github.Ptr(false),
+			TwoFactorRequirementEnabled: github.Ptr(true),
+		},
+		ActionsPermissions: &github.ActionsPermissions{EnabledRepositories: github.Ptr("none"), AllowedActions: github.Ptr("all")},
+	}
+	baseline := utils.OrgBaseline{
+		DefaultRepositoryPermission: "read",
+		MembersCanCreatePublicRepos: github.Ptr(true),
+		TwoFactorRequired:           github.Ptr(false),
+		AllowedActions:              "local_only",
+	}
+	assert.Empty(t, orgSettingsViolations(baseline, report))
+	assert.Empty(t, orgSettingsViolations(utils.OrgBaseline{}, &OrgSettingsReport{Organization: &github.Organization{}}))
+}
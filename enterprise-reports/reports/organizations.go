@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/google/go-github/v70/github"
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
@@ -19,8 +20,10 @@ import (
 // OrgReport represents an organization with its members list,
 // used to generate organization reports with membership details.
 type OrgReport struct {
-	Organization *github.Organization // Organization details
-	Members      []*github.User       // List of organization members
+	Organization       *github.Organization // Organization details
+	Members            []*github.User       // List of organization members
+	PendingInvitations []*github.Invitation // Pending invitations to join the organization
+	ItemStatus                              // Parts of the organization that could not be fetched
 }
 
 // OrgMemberInfo represents a simplified organization member for CSV output.
@@ -30,6 +33,9 @@ type OrgMemberInfo struct {
 	ID       int64  `json:"id"`       // User's numeric ID
 	Name     string `json:"name"`     // User's display name
 	RoleName string `json:"roleName"` // User's role in the organization (admin, member, etc.)
+	// TwoFactorEnabled is whether the user has two-factor authentication enabled,
+	// omitted when the token cannot see it.
+	TwoFactorEnabled *bool `json:"twoFactorEnabled,omitempty"`
 }
 
 // OrganizationsReport generates a CSV report for all enterprise organizations.
@@ -44,12 +50,24 @@ type OrgMemberInfo struct {
 //   - filename: Output CSV file path
 //   - workerCount: Number of concurrent workers for processing organizations
 //   - cache: Shared cache for storing and retrieving GitHub data
+//   - opts: Report options, such as the columns to write
 //
 // The report includes organization name, ID, default repository permission settings,
-// a JSON-encoded list of members with their details, and the total member count.
-func OrganizationsReport(ctx context.Context, graphQLClient *githubv4.Client, restClient *github.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache) error {
+// a JSON-encoded list of members with their details, and the total member count,
+// along with whether two-factor authentication is required, the members without it,
+// and the pending invitations. opts.Columns can select, reorder and rename these columns.
+//
+// Members are listed with GraphQL, 100 per query with their roles, rather than with
+// REST calls per member.
+func OrganizationsReport(ctx context.Context, graphQLClient *githubv4.Client, restClient *github.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache, opts Options) error {
 	slog.Info("starting organizations report", slog.String("enterprise", enterpriseSlug), slog.String("filename", filename), slog.Int("workers", workerCount))
 
+	header, formatter, err := selectColumns(orgColumns, defaultOrgColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("organizations report columns: %w", err)
+	}
+	formatter = recordRows(opts.Rows, formatter)
+
 	// Create appropriate report writer based on file extension
 	reportWriter, err := NewReportWriter(filename)
 	if err != nil {
@@ -61,14 +79,6 @@ func OrganizationsReport(ctx context.Context, graphQLClient *githubv4.Client, re
 		}
 	}()
 
-	header := []string{
-		"Organization",
-		"Organization ID",
-		"Organization Default Repository Permission",
-		"Members",
-		"Total Members",
-	}
-
 	// Write header to report
 	if err := reportWriter.WriteHeader(header); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
@@ -93,7 +103,7 @@ func OrganizationsReport(ctx context.Context, graphQLClient *githubv4.Client, re
 
 	// Processor: enrich organization with details and members
 	processor := func(ctx context.Context, org *github.Organization) (*OrgReport, error) {
-		slog.Info("processing organization", "org", org.GetLogin())
+		slog.Debug("processing organization", "org", org.GetLogin())
 		info, err := api.FetchOrganization(ctx, restClient, org.GetLogin())
 		if err != nil {
 			// Log the error but return a report with basic info and empty members.
@@ -101,8 +111,22 @@ func OrganizationsReport(ctx context.Context, graphQLClient *githubv4.Client, re
 			slog.Warn("failed to fetch organization details, reporting basic info", "org", org.GetLogin(), "err", err)
 			// Use the input 'org' which has at least Login and ID. Mark members as empty.
 			// The formatter will handle the missing DefaultRepoPermission.
-			return &OrgReport{Organization: org, Members: []*github.User{}}, nil // Return non-nil report, nil error
+			report := &OrgReport{Organization: org, Members: []*github.User{}}
+			report.fail(ctx, org.GetLogin(), "details", err)
+			// Members and invitations are not fetched without the details
+			report.Failed = append(report.Failed, "members", "pending invitations")
+			return report, nil // Return non-nil report, nil error
 		}
+		report := &OrgReport{Organization: info}
+
+		// Pending invitations are only visible to organization owners, so report them as unknown on failure
+		invitations, err := api.FetchOrganizationPendingInvitations(ctx, restClient, org.GetLogin())
+		if err != nil {
+			slog.Warn("failed to fetch pending invitations, reporting none", "org", org.GetLogin(), "err", err)
+			report.fail(ctx, org.GetLogin(), "pending invitations", err)
+			invitations = nil
+		}
+		report.PendingInvitations = invitations
 
 		// Check cache for organization members
 		var members []*github.User
@@ -110,68 +134,124 @@ func OrganizationsReport(ctx context.Context, graphQLClient *githubv4.Client, re
 			slog.Info("using cached organization members", "org", org.GetLogin())
 			members = cachedMembers
 		} else {
-			members, err = api.FetchOrganizationMemberships(ctx, restClient, org.GetLogin())
+			members, err = api.FetchOrganizationMembershipsWithRole(ctx, graphQLClient, org.GetLogin())
 			if err != nil {
 				// Log the error but return the fetched org details with empty members.
 				slog.Warn("failed to fetch memberships, reporting org details with empty members", "org", org.GetLogin(), "err", err)
-				return &OrgReport{Organization: info, Members: []*github.User{}}, nil // Return non-nil report, nil error
+				report.Members = []*github.User{}
+				report.fail(ctx, org.GetLogin(), "members", err)
+				return report, nil // Return non-nil report, nil error
 			}
 			// Store in cache
 			cache.SetOrgMembers(org.GetLogin(), members)
 		}
-		return &OrgReport{Organization: info, Members: members}, nil
+		report.Members = members
+		return report, nil
 	}
 
-	// Formatter: build CSV row from OrgReport
-	formatter := func(r *OrgReport) []string {
-		var membersStr string
-		var defaultPermStr string
+	// Create a limiter for rate limiting - aiming for ~5 orgs/sec
+	// (Each org makes 2 REST calls, 10 points/sec below the 15 points/sec limit;
+	// members cost 1 GraphQL point per 100)
+	// Burst matches worker count for responsiveness.
+	limiter := rate.NewLimiter(rate.Limit(organizationsRate), workerCount) // e.g., 5 requests/sec, burst of workerCount
 
-		// Check if Members slice is nil or empty
-		if len(r.Members) == 0 {
-			membersStr = "N/A" // Set to "N/A" if no members
-		} else {
-			// Only marshal if there are members
-			var membersList []OrgMemberInfo
-			for _, m := range r.Members {
-				// Add a nil check for individual members just in case
-				if m == nil {
-					continue
-				}
-				membersList = append(membersList, OrgMemberInfo{m.GetLogin(), m.GetID(), m.GetName(), m.GetRoleName()})
-			}
-			data, err := json.Marshal(membersList)
-			if err != nil {
-				slog.Error("failed to marshal members list", "org", r.Organization.GetLogin(), "err", err)
-				membersStr = "ERROR_MARSHAL" // Indicate an error during marshaling
-			} else {
-				membersStr = string(data) // Use JSON string if marshaling succeeded
-			}
-		}
+	// Run the report using the new report writer interface
+	return RunReportWithWriter(ctx, orgs, processor, formatter, limiter, workerCount, reportWriter)
+}
 
+// orgColumns lists every column the organizations report can output.
+var orgColumns = []Column[*OrgReport]{
+	{Name: "Organization", Value: func(r *OrgReport) string { return r.Organization.GetLogin() }},
+	{Name: "Organization ID", Value: func(r *OrgReport) string { return fmt.Sprintf("%d", r.Organization.GetID()) }},
+	{Name: "Organization Default Repository Permission", Value: func(r *OrgReport) string {
 		// Handle potentially missing DefaultRepoPermission
 		// GetDefaultRepoPermission returns "" if the field is nil.
-		defaultPerm := r.Organization.GetDefaultRepoPermission()
-		if defaultPerm == "" {
-			defaultPermStr = "N/A" // Use "N/A" if permission wasn't fetched or is empty
-		} else {
-			defaultPermStr = defaultPerm
+		if r.Organization.GetDefaultRepoPermission() == "" {
+			return "N/A" // Use "N/A" if permission wasn't fetched or is empty
 		}
+		return r.Organization.GetDefaultRepoPermission()
+	}},
+	{Name: "Members", Value: formatOrgMembers},
+	{Name: "Total Members", Value: func(r *OrgReport) string { return fmt.Sprintf("%d", len(r.Members)) }},
+	{Name: "Two Factor Required", Value: func(r *OrgReport) string {
+		if r.Organization.TwoFactorRequirementEnabled == nil {
+			return "N/A"
+		}
+		return fmt.Sprintf("%t", r.Organization.GetTwoFactorRequirementEnabled())
+	}},
+	{Name: "Members Without 2FA", Value: formatMembersWithout2FA},
+	{Name: "Pending Invitations", Value: func(r *OrgReport) string {
+		invitees := make([]string, 0, len(r.PendingInvitations))
+		for _, inv := range r.PendingInvitations {
+			// Invitations by email have no login until they are accepted
+			if inv.GetLogin() != "" {
+				invitees = append(invitees, inv.GetLogin())
+			} else {
+				invitees = append(invitees, inv.GetEmail())
+			}
+		}
+		return joinOrNA(invitees)
+	}},
+	{Name: "Pending Invitation Count", Value: func(r *OrgReport) string { return fmt.Sprintf("%d", len(r.PendingInvitations)) }},
+	statusColumn[*OrgReport](),
+}
 
-		return []string{
-			r.Organization.GetLogin(),
-			fmt.Sprintf("%d", r.Organization.GetID()),
-			defaultPermStr,                    // Use the determined defaultPermStr
-			membersStr,                        // Use the determined membersStr
-			fmt.Sprintf("%d", len(r.Members)), // Total members
+// defaultOrgColumns is the column layout written when no columns are configured.
+var defaultOrgColumns = []string{
+	"Organization",
+	"Organization ID",
+	"Organization Default Repository Permission",
+	"Members",
+	"Total Members",
+	"Two Factor Required",
+	"Members Without 2FA",
+	"Pending Invitations",
+	"Status",
+}
+
+// formatMembersWithout2FA renders the logins of members without two-factor authentication,
+// "none" when every member has it, or "N/A" when the status is not visible to the token.
+func formatMembersWithout2FA(r *OrgReport) string {
+	known := false
+	var logins []string
+	for _, m := range r.Members {
+		if m == nil || m.TwoFactorAuthentication == nil {
+			continue
+		}
+		known = true
+		if !m.GetTwoFactorAuthentication() {
+			logins = append(logins, m.GetLogin())
 		}
 	}
+	if !known {
+		return "N/A"
+	}
+	if len(logins) == 0 {
+		return "none"
+	}
+	return strings.Join(logins, ", ")
+}
 
-	// Create a limiter for rate limiting - aiming for ~5 orgs/sec
-	// (Consumes 10 REST points/sec, below the 15 points/sec limit)
-	// Burst matches worker count for responsiveness.
-	limiter := rate.NewLimiter(rate.Limit(5), workerCount) // e.g., 5 requests/sec, burst of workerCount
+// formatOrgMembers renders the organization's members as a JSON list, or "N/A" when there are none.
+func formatOrgMembers(r *OrgReport) string {
+	// Check if Members slice is nil or empty
+	if len(r.Members) == 0 {
+		return "N/A" // Set to "N/A" if no members
+	}
 
-	// Run the report using the new report writer interface
-	return RunReportWithWriter(ctx, orgs, processor, formatter, limiter, workerCount, reportWriter)
+	// Only marshal if there are members
+	var membersList []OrgMemberInfo
+	for _, m := range r.Members {
+		// Add a nil check for individual members just in case
+		if m == nil {
+			continue
+		}
+		membersList = append(membersList, OrgMemberInfo{m.GetLogin(), m.GetID(), m.GetName(), m.GetRoleName(), m.TwoFactorAuthentication})
+	}
+	data, err := json.Marshal(membersList)
+	if err != nil {
+		slog.Error("failed to marshal members list", "org", r.Organization.GetLogin(), "err", err)
+		return "ERROR_MARSHAL" // Indicate an error during marshaling
+	}
+	return string(data)
 }
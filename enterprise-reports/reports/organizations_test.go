@@ -7,6 +7,7 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -28,7 +29,7 @@ func TestOrganizationsReport_FileCreationError(t *testing.T) {
 	ctx := context.Background()
 	invalidPath := "/this/path/does/not/exist/report.csv"
 	cache := utils.NewSharedCache()
-	err := OrganizationsReport(ctx, nil, nil, "ent", invalidPath, 1, cache)
+	err := OrganizationsReport(ctx, nil, nil, "ent", invalidPath, 1, cache, Options{})
 	require.Error(t, err)
 }
 
@@ -46,7 +47,7 @@ func TestOrganizationsReport_GraphQLFetchError(t *testing.T) {
 	tmp := t.TempDir()
 	filePath := filepath.Join(tmp, "out.csv")
 	cache := utils.NewSharedCache()
-	err := OrganizationsReport(context.Background(), graphClient, restClient, "ent", filePath, 1, cache)
+	err := OrganizationsReport(context.Background(), graphClient, restClient, "ent", filePath, 1, cache, Options{})
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "failed to fetch organizations")
 }
@@ -74,7 +75,7 @@ func TestOrganizationsReport_NoOrgs(t *testing.T) {
 	tmp := t.TempDir()
 	filePath := filepath.Join(tmp, "out.csv")
 	cache := utils.NewSharedCache()
-	err := OrganizationsReport(context.Background(), graphClient, restClient, "ent", filePath, 1, cache)
+	err := OrganizationsReport(context.Background(), graphClient, restClient, "ent", filePath, 1, cache, Options{})
 	require.NoError(t, err)
 
 	data, readErr := os.ReadFile(filePath)
@@ -82,7 +83,7 @@ func TestOrganizationsReport_NoOrgs(t *testing.T) {
 
 	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
 	require.Len(t, lines, 1)
-	assert.Equal(t, "Organization,Organization ID,Organization Default Repository Permission,Members,Total Members", lines[0])
+	assert.Equal(t, "Organization,Organization ID,Organization Default Repository Permission,Members,Total Members,Two Factor Required,Members Without 2FA,Pending Invitations,Status", lines[0])
 }
 
 // TestOrganizationsReport_SingleOrgSingleMember tests that the OrganizationsReport function
@@ -91,47 +92,38 @@ func TestOrganizationsReport_NoOrgs(t *testing.T) {
 func TestOrganizationsReport_SingleOrgSingleMember(t *testing.T) {
 	mux := http.NewServeMux()
 
-	// GraphQL: one org
+	// GraphQL: one org, and its members with their roles and two-factor status
 	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		if _, err := fmt.Fprintln(w, `{"data":{"enterprise":{"organizations":{"nodes":[{"login":"org1","id":"ORG1ID"}],"pageInfo":{"hasNextPage":false,"endCursor":""}}}}}`); err != nil {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		resp := `{"data":{"enterprise":{"organizations":{"nodes":[{"login":"org1","id":"ORG1ID"}],"pageInfo":{"hasNextPage":false,"endCursor":""}}}}}`
+		if strings.Contains(string(body), "membersWithRole") {
+			resp = `{"data":{"organization":{"membersWithRole":{"edges":[` +
+				`{"role":"ADMIN","hasTwoFactorEnabled":false,"node":{"login":"user1","name":"User One","databaseId":123}}],` +
+				`"pageInfo":{"hasNextPage":false,"endCursor":""}}}}}`
+		}
+		if _, err := fmt.Fprintln(w, resp); err != nil {
 			t.Fatalf("failed to write response: %v", err)
 		}
 	})
 	// REST: get organization details
 	mux.HandleFunc("/orgs/org1", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		if _, err := fmt.Fprintln(w, `{"login":"org1","id":321,"default_repository_permission":"admin"}`); err != nil {
-			t.Fatalf("failed to write response: %v", err)
-		}
-	})
-	// REST: list members
-	mux.HandleFunc("/orgs/org1/members", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := fmt.Fprintln(w, `[{"login":"user1","id":123}]`); err != nil {
-			t.Fatalf("failed to write response: %v", err)
-		}
-	})
-	// REST: get membership for user1
-	mux.HandleFunc("/orgs/org1/memberships/user1", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := fmt.Fprintln(w, `{"role":"admin"}`); err != nil {
+		if _, err := fmt.Fprintln(w, `{"login":"org1","id":321,"default_repository_permission":"admin","two_factor_requirement_enabled":false}`); err != nil {
 			t.Fatalf("failed to write response: %v", err)
 		}
 	})
-	// REST: get user by id
-	mux.HandleFunc("/users/123", func(w http.ResponseWriter, r *http.Request) {
+	// REST: list pending invitations, one by login and one by email
+	mux.HandleFunc("/orgs/org1/invitations", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		if _, err := fmt.Fprintln(w, `{"login":"user1","name":"User One"}`); err != nil {
+		if _, err := fmt.Fprintln(w, `[{"login":"invitee"},{"email":"new@example.com"}]`); err != nil {
 			t.Fatalf("failed to write response: %v", err)
 		}
 	})
-	// alias path matching code's fetch URL (/user/123)
-	mux.HandleFunc("/user/123", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		if _, err := fmt.Fprintln(w, `{"login":"user1","name":"User One"}`); err != nil {
-			t.Fatalf("failed to write response: %v", err)
-		}
+	// REST: members are listed with GraphQL, so per-member lookups must not be made
+	mux.HandleFunc("/orgs/org1/memberships/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected membership lookup: %s", r.URL.Path)
 	})
 
 	srv := httptest.NewServer(mux)
@@ -148,7 +140,7 @@ func TestOrganizationsReport_SingleOrgSingleMember(t *testing.T) {
 	tmp := t.TempDir()
 	filePath := filepath.Join(tmp, "out.csv")
 	cache := utils.NewSharedCache()
-	err := OrganizationsReport(context.Background(), graphClient, restClient, "ent", filePath, 1, cache)
+	err := OrganizationsReport(context.Background(), graphClient, restClient, "ent", filePath, 1, cache, Options{})
 	require.NoError(t, err)
 
 	data, readErr := os.ReadFile(filePath)
@@ -156,7 +148,7 @@ func TestOrganizationsReport_SingleOrgSingleMember(t *testing.T) {
 
 	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
 	require.Len(t, lines, 2)
-	assert.Equal(t, "Organization,Organization ID,Organization Default Repository Permission,Members,Total Members", lines[0])
+	assert.Equal(t, "Organization,Organization ID,Organization Default Repository Permission,Members,Total Members,Two Factor Required,Members Without 2FA,Pending Invitations,Status", lines[0])
 
 	// parse the record line
 	reader := csv.NewReader(strings.NewReader(lines[1]))
@@ -167,10 +159,11 @@ func TestOrganizationsReport_SingleOrgSingleMember(t *testing.T) {
 	assert.Equal(t, "admin", record[2])
 
 	var members []struct {
-		Login    string `json:"login"`
-		ID       int64  `json:"id"`
-		Name     string `json:"name"`
-		RoleName string `json:"roleName"`
+		Login            string `json:"login"`
+		ID               int64  `json:"id"`
+		Name             string `json:"name"`
+		RoleName         string `json:"roleName"`
+		TwoFactorEnabled *bool  `json:"twoFactorEnabled"`
 	}
 	jsonErr := json.Unmarshal([]byte(record[3]), &members)
 	require.NoError(t, jsonErr)
@@ -179,5 +172,10 @@ func TestOrganizationsReport_SingleOrgSingleMember(t *testing.T) {
 	assert.Equal(t, int64(123), members[0].ID)
 	assert.Equal(t, "User One", members[0].Name)
 	assert.Equal(t, "admin", members[0].RoleName)
+	require.NotNil(t, members[0].TwoFactorEnabled)
+	assert.False(t, *members[0].TwoFactorEnabled)
 	assert.Equal(t, "1", record[4])
+	assert.Equal(t, "false", record[5])
+	assert.Equal(t, "user1", record[6])
+	assert.Equal(t, "invitee, new@example.com", record[7])
 }
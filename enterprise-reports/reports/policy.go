@@ -0,0 +1,79 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+package reports
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/policy"
+)
+
+// RowRecorder receives every processed row of a report, e.g. to evaluate policy rules over them.
+// Record is called concurrently by the report's workers.
+type RowRecorder interface {
+	Record(row any)
+}
+
+// recordRows wraps formatter so every item it formats is also passed to recorder.
+func recordRows[R any](recorder RowRecorder, formatter func(R) []string) func(R) []string {
+	if recorder == nil {
+		return formatter
+	}
+	return func(r R) []string {
+		recorder.Record(r)
+		return formatter(r)
+	}
+}
+
+// PolicyReport writes the policy rule violations found while the other reports ran,
+// one row per violation. The report is written even when there are no violations,
+// so every run leaves a record of the check.
+//
+// Parameters:
+//   - violations: Violations to write, in order
+//   - filename: Output file path
+//   - opts: Report options, such as the columns to write
+func PolicyReport(violations []*policy.Violation, filename string, opts Options) error {
+	slog.Info("starting policy report", "filename", filename, "violations", len(violations))
+
+	header, formatter, err := selectColumns(policyColumns, defaultPolicyColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("policy report columns: %w", err)
+	}
+
+	// Create appropriate report writer based on file extension
+	reportWriter, err := NewReportWriter(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := reportWriter.Close(); err != nil {
+			slog.Error("Failed to close report writer", "error", err)
+		}
+	}()
+
+	// Write header to report
+	if err := reportWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, v := range violations {
+		if err := reportWriter.WriteRow(formatter(v)); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+	return nil
+}
+
+// policyColumns lists every column the policy report can output.
+var policyColumns = []Column[*policy.Violation]{
+	{Name: "Rule ID", Value: func(v *policy.Violation) string { return v.RuleID }},
+	{Name: "Severity", Value: func(v *policy.Violation) string { return v.Severity }},
+	{Name: "Resource", Value: func(v *policy.Violation) string { return v.Resource }},
+	{Name: "Message", Value: func(v *policy.Violation) string { return v.Message }},
+	{Name: "Report", Value: func(v *policy.Violation) string { return v.Report }},
+	{Name: "Description", Value: func(v *policy.Violation) string { return naIfEmpty(v.Description) }},
+}
+
+// defaultPolicyColumns is the column layout written when no columns are configured.
+var defaultPolicyColumns = []string{"Rule ID", "Severity", "Resource", "Message"}
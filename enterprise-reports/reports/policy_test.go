@@ -0,0 +1,56 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+// This file contains tests for the policy report functionality.
+package reports
+
+import (
+	"encoding/csv"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceRecorder is a RowRecorder that keeps the rows it records.
+type sliceRecorder struct {
+	mu   sync.Mutex
+	rows []any
+}
+
+func (r *sliceRecorder) Record(row any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rows = append(r.rows, row)
+}
+
+// TestPolicyReport tests that the policy report writes one row per violation with the selected columns.
+func TestPolicyReport(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "policy.csv")
+	violations := []*policy.Violation{
+		{RuleID: "public-repo-topic", Severity: "high", Report: "repositories", Resource: "org/b", Message: "missing topic"},
+		{RuleID: "repo-without-team", Severity: "low", Report: "repositories", Resource: "org/a", Message: "no team"},
+	}
+	opts := Options{Columns: []ColumnSpec{{Name: "Rule ID"}, {Name: "Severity"}, {Name: "Resource"}, {Name: "Report"}, {Name: "Description"}}}
+	require.NoError(t, PolicyReport(violations, out, opts))
+
+	records, err := csv.NewReader(strings.NewReader(readFile(t, out))).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Rule ID", "Severity", "Resource", "Report", "Description"},
+		{"public-repo-topic", "high", "org/b", "repositories", "N/A"},
+		{"repo-without-team", "low", "org/a", "repositories", "N/A"},
+	}, records)
+}
+
+// TestRecordRows tests that a report's rows are passed to the recorder as they are formatted.
+func TestRecordRows(t *testing.T) {
+	formatter := func(s string) []string { return []string{s} }
+	assert.Equal(t, []string{"a"}, recordRows(nil, formatter)("a"))
+
+	recorder := &sliceRecorder{}
+	assert.Equal(t, []string{"b"}, recordRows(recorder, formatter)("b"))
+	assert.Equal(t, []any{"b"}, recorder.rows)
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
 
 	"github.com/google/go-github/v70/github"
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
@@ -22,6 +23,7 @@ type RepoReport struct {
 	*github.Repository
 	Teams            []*repoTeam                   // Teams with access to the repository
 	CustomProperties []*github.CustomPropertyValue // Custom properties set on the repository
+	ItemStatus                                     // Parts of the repository that could not be fetched
 }
 
 // repoTeam represents a team with access to a repository,
@@ -42,12 +44,20 @@ type repoTeam struct {
 //   - filename: Output CSV file path
 //   - workerCount: Number of concurrent workers for processing repositories
 //   - cache: Shared cache for storing and retrieving GitHub data
+//   - opts: Report options, such as the columns to write
 //
 // The report includes repository owner organization, name, archive status, visibility,
 // timestamps, topics, custom properties, and associated teams with their external groups.
-func RepositoryReport(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache) error {
+// Additional repository fields such as description, size and license can be selected through opts.Columns.
+func RepositoryReport(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache, opts Options) error {
 	slog.Info("starting repository report", slog.String("enterprise", enterpriseSlug), slog.String("filename", filename), slog.Int("workers", workerCount))
 
+	header, formatter, err := selectColumns(repoColumns, defaultRepoColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("repositories report columns: %w", err)
+	}
+	formatter = recordRows(opts.Rows, formatter)
+
 	// Create appropriate report writer based on file extension
 	reportWriter, reportErr := NewReportWriter(filename)
 	if reportErr != nil {
@@ -59,25 +69,12 @@ func RepositoryReport(ctx context.Context, restClient *github.Client, graphQLCli
 		}
 	}()
 
-	header := []string{
-		"Owner",
-		"Repository",
-		"Archived",
-		"Visibility",
-		"Pushed_At",
-		"Created_At",
-		"Topics",
-		"Custom_Properties",
-		"Teams",
-	}
-
 	// Write header to report
 	if headerErr := reportWriter.WriteHeader(header); headerErr != nil {
 		return fmt.Errorf("failed to write header: %w", headerErr)
 	}
 	// Check cache for organizations or fetch from API
 	var orgs []*github.Organization
-	var err error
 
 	if cachedOrgs, found := cache.GetEnterpriseOrgs(); found {
 		slog.Info("using cached enterprise organizations")
@@ -113,9 +110,13 @@ func RepositoryReport(ctx context.Context, restClient *github.Client, graphQLCli
 		}
 		reposList = append(reposList, repos...)
 	}
+	// External group lookups that failed, by team, so they are not retried for every repository
+	var failedExternalGroups sync.Map
+
 	// Processor: enrich repository with teams and custom properties
 	processor := func(ctx context.Context, repo *github.Repository) (*RepoReport, error) {
-		slog.Info("processing repository", "repo", repo.GetFullName())
+		slog.Debug("processing repository", "repo", repo.GetFullName())
+		report := &RepoReport{Repository: repo}
 
 		// Check cache for repository teams
 		var teams []*github.Team
@@ -124,20 +125,39 @@ func RepositoryReport(ctx context.Context, restClient *github.Client, graphQLCli
 			teams = cachedTeams
 		} else {
 			// Fetch teams and external groups
+			var err error
 			teams, err = api.FetchTeams(ctx, restClient, repo.GetOwner().GetLogin(), repo.GetName())
 			if err != nil {
 				slog.Debug("failed to fetch teams", "repo", repo.GetFullName(), "err", err)
+				report.fail(ctx, repo.GetFullName(), "teams", err)
 				teams = []*github.Team{} // Initialize to empty slice on error
+			} else {
+				// Store in cache
+				cache.SetRepoTeams(repo.GetFullName(), teams)
 			}
-			// Store in cache
-			cache.SetRepoTeams(repo.GetFullName(), teams)
 		}
 
 		var repoTeams []*repoTeam
 		for _, t := range teams {
-			eg, err := api.FetchExternalGroups(ctx, restClient, repo.GetOwner().GetLogin(), t.GetSlug())
-			if err != nil {
-				slog.Debug("failed to fetch external groups", "team", t.GetSlug(), "err", err)
+			// External groups are only available over REST, so fetch them once per team
+			// rather than once per team per repository.
+			teamKey := fmt.Sprintf("%s/%s", repo.GetOwner().GetLogin(), t.GetSlug())
+			eg, found := cache.GetTeamExternalGroups(teamKey)
+			if !found {
+				// A team whose lookup failed is not fetched again for each of its other repositories
+				if egErr, failed := failedExternalGroups.Load(teamKey); failed {
+					report.fail(ctx, repo.GetFullName(), "external groups", egErr.(error))
+				} else {
+					var egErr error
+					eg, egErr = api.FetchExternalGroups(ctx, restClient, repo.GetOwner().GetLogin(), t.GetSlug())
+					if egErr != nil {
+						slog.Debug("failed to fetch external groups", "team", t.GetSlug(), "err", egErr)
+						failedExternalGroups.Store(teamKey, egErr)
+						report.fail(ctx, repo.GetFullName(), "external groups", egErr)
+					} else {
+						cache.SetTeamExternalGroups(teamKey, eg)
+					}
+				}
 			}
 			if eg == nil {
 				eg = &github.ExternalGroupList{}
@@ -148,18 +168,42 @@ func RepositoryReport(ctx context.Context, restClient *github.Client, graphQLCli
 		props, err := api.FetchCustomProperties(ctx, restClient, repo.GetOwner().GetLogin(), repo.GetName())
 		if err != nil {
 			slog.Debug("failed to fetch custom properties", "repo", repo.GetFullName(), "err", err)
+			report.fail(ctx, repo.GetFullName(), "custom properties", err)
 		}
 		if props == nil {
 			props = []*github.CustomPropertyValue{}
 		}
-		return &RepoReport{Repository: repo, Teams: repoTeams, CustomProperties: props}, nil
+		report.Teams = repoTeams
+		report.CustomProperties = props
+		return report, nil
 	}
-	// Formatter: format RepoReport into CSV row
-	formatter := func(r *RepoReport) []string {
+	// Create a limiter for rate limiting - aiming for ~2-3 repos/sec due to variable cost per repo
+	// (Fetching external groups for each team not seen before adds points).
+	// Cost = (2 + N_new_teams) REST points/repo. 5 repos/sec could exceed 15 points/sec limit if N_new_teams > 1.
+	// Burst matches worker count for responsiveness.
+	limiter := rate.NewLimiter(rate.Limit(repositoriesRate), workerCount) // e.g., 2 requests/sec, burst of workerCount
+
+	// Run the report using the new report writer interface
+	return RunReportWithWriter(ctx, reposList, processor, formatter, limiter, workerCount, reportWriter)
+}
+
+// repoColumns lists every column the repositories report can output.
+var repoColumns = []Column[*RepoReport]{
+	{Name: "Owner", Value: func(r *RepoReport) string { return r.GetOwner().GetLogin() }},
+	{Name: "Repository", Value: func(r *RepoReport) string { return r.GetName() }},
+	{Name: "Archived", Value: func(r *RepoReport) string { return fmt.Sprintf("%t", r.GetArchived()) }},
+	{Name: "Visibility", Value: func(r *RepoReport) string { return r.GetVisibility() }},
+	{Name: "Pushed_At", Value: func(r *RepoReport) string { return r.GetPushedAt().String() }},
+	{Name: "Created_At", Value: func(r *RepoReport) string { return r.GetCreatedAt().String() }},
+	{Name: "Topics", Value: func(r *RepoReport) string { return fmt.Sprintf("%v", r.Topics) }},
+	{Name: "Custom_Properties", Value: func(r *RepoReport) string {
 		propStrs := make([]string, len(r.CustomProperties))
 		for i, cp := range r.CustomProperties {
 			propStrs[i] = fmt.Sprintf("%s=%v", cp.PropertyName, cp.Value)
 		}
+		return strings.Join(propStrs, ",")
+	}},
+	{Name: "Teams", Value: func(r *RepoReport) string {
 		var teams []string
 		for _, t := range r.Teams {
 			name := t.GetSlug()
@@ -170,25 +214,32 @@ func RepositoryReport(ctx context.Context, restClient *github.Client, graphQLCli
 			}
 			teams = append(teams, name)
 		}
-		return []string{
-			r.GetOwner().GetLogin(),
-			r.GetName(),
-			fmt.Sprintf("%t", r.GetArchived()),
-			r.GetVisibility(),
-			r.GetPushedAt().String(),
-			r.GetCreatedAt().String(),
-			fmt.Sprintf("%v", r.Topics),
-			strings.Join(propStrs, ","),
-			strings.Join(teams, ","),
+		return strings.Join(teams, ",")
+	}},
+	{Name: "Description", Value: func(r *RepoReport) string { return r.GetDescription() }},
+	{Name: "Size", Value: func(r *RepoReport) string { return fmt.Sprintf("%d", r.GetSize()) }},
+	{Name: "Default_Branch", Value: func(r *RepoReport) string { return r.GetDefaultBranch() }},
+	{Name: "Language", Value: func(r *RepoReport) string { return r.GetLanguage() }},
+	{Name: "Fork", Value: func(r *RepoReport) string { return fmt.Sprintf("%t", r.GetFork()) }},
+	{Name: "License", Value: func(r *RepoReport) string {
+		if r.GetLicense().GetSPDXID() != "" {
+			return r.GetLicense().GetSPDXID()
 		}
-	}
-
-	// Create a limiter for rate limiting - aiming for ~2-3 repos/sec due to variable cost per repo
-	// (Fetching external groups for each team adds points).
-	// Cost = (2 + N_teams) REST points/repo. 5 repos/sec could exceed 15 points/sec limit if N_teams > 1.
-	// Burst matches worker count for responsiveness.
-	limiter := rate.NewLimiter(rate.Limit(2), workerCount) // e.g., 2 requests/sec, burst of workerCount
+		return r.GetLicense().GetName()
+	}},
+	statusColumn[*RepoReport](),
+}
 
-	// Run the report using the new report writer interface
-	return RunReportWithWriter(ctx, reposList, processor, formatter, limiter, workerCount, reportWriter)
+// defaultRepoColumns is the column layout written when no columns are configured.
+var defaultRepoColumns = []string{
+	"Owner",
+	"Repository",
+	"Archived",
+	"Visibility",
+	"Pushed_At",
+	"Created_At",
+	"Topics",
+	"Custom_Properties",
+	"Teams",
+	"Status",
 }
@@ -16,6 +16,7 @@ import (
 	"testing"
 
 	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/fakegithub"
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
 	"github.com/shurcooL/githubv4"
 	"github.com/stretchr/testify/assert"
@@ -26,7 +27,7 @@ import (
 // returns an error when given an invalid output file path.
 func TestRepositoryReport_FileCreationError(t *testing.T) {
 	cache := utils.NewSharedCache()
-	err := RepositoryReport(context.Background(), nil, nil, "ent", "/no/such/dir/out.csv", 1, cache)
+	err := RepositoryReport(context.Background(), nil, nil, "ent", "/no/such/dir/out.csv", 1, cache, Options{})
 	require.Error(t, err)
 }
 
@@ -40,7 +41,7 @@ func TestRepositoryReport_GraphQLFetchError(t *testing.T) {
 
 	graphClient := githubv4.NewEnterpriseClient(srv.URL+"/graphql", srv.Client())
 	cache := utils.NewSharedCache()
-	err := RepositoryReport(context.Background(), nil, graphClient, "ent", filepath.Join(t.TempDir(), "out.csv"), 1, cache)
+	err := RepositoryReport(context.Background(), nil, graphClient, "ent", filepath.Join(t.TempDir(), "out.csv"), 1, cache, Options{})
 	require.Error(t, err)
 }
 
@@ -64,7 +65,7 @@ func TestRepositoryReport_NoRepos(t *testing.T) {
 
 	out := filepath.Join(t.TempDir(), "out.csv")
 	cache := utils.NewSharedCache()
-	err := RepositoryReport(context.Background(), restClient, graphClient, "ent", out, 1, cache)
+	err := RepositoryReport(context.Background(), restClient, graphClient, "ent", out, 1, cache, Options{})
 	require.NoError(t, err)
 
 	bs, err := os.ReadFile(out)
@@ -72,7 +73,7 @@ func TestRepositoryReport_NoRepos(t *testing.T) {
 	lines := strings.Split(strings.TrimSpace(string(bs)), "\n")
 	require.Len(t, lines, 1)
 	assert.Equal(t,
-		"Owner,Repository,Archived,Visibility,Pushed_At,Created_At,Topics,Custom_Properties,Teams",
+		"Owner,Repository,Archived,Visibility,Pushed_At,Created_At,Topics,Custom_Properties,Teams,Status",
 		lines[0],
 	)
 }
@@ -125,7 +126,7 @@ func TestRepositoryReport_SingleRepoSingleTeamSingleCustomProp(t *testing.T) {
 
 	out := filepath.Join(t.TempDir(), "out.csv")
 	cache := utils.NewSharedCache()
-	err := RepositoryReport(context.Background(), restClient, graphClient, "ent", out, 1, cache)
+	err := RepositoryReport(context.Background(), restClient, graphClient, "ent", out, 1, cache, Options{})
 	require.NoError(t, err)
 
 	data, err := os.ReadFile(out)
@@ -134,14 +135,14 @@ func TestRepositoryReport_SingleRepoSingleTeamSingleCustomProp(t *testing.T) {
 	require.Len(t, lines, 2)
 	// header
 	assert.Equal(t,
-		"Owner,Repository,Archived,Visibility,Pushed_At,Created_At,Topics,Custom_Properties,Teams",
+		"Owner,Repository,Archived,Visibility,Pushed_At,Created_At,Topics,Custom_Properties,Teams,Status",
 		lines[0],
 	)
 	// record
 	r := csv.NewReader(strings.NewReader(lines[1]))
 	record, err := r.Read()
 	require.NoError(t, err)
-	require.Len(t, record, 9)
+	require.Len(t, record, 10)
 
 	assert.Equal(t, "org1", record[0])
 	assert.Equal(t, "repo1", record[1])
@@ -155,6 +156,7 @@ func TestRepositoryReport_SingleRepoSingleTeamSingleCustomProp(t *testing.T) {
 	assert.Equal(t, `prop1=val1`, record[7])
 	// Assert the formatted team string with external group
 	assert.Equal(t, `team1 (group1)`, record[8])
+	assert.Equal(t, "ok", record[9])
 	var cpvs []github.CustomPropertyValue
 	err = json.Unmarshal([]byte(record[7]), &cpvs)
 
@@ -178,3 +180,26 @@ func (h headerRT) RoundTrip(req *http.Request) (*http.Response, error) {
 	resp.Header.Set("X-RateLimit-Limit", "200")
 	return resp, nil
 }
+
+// TestRepositoryReport_ExternalGroupsFailureCached tests that a team whose external groups fail to load
+// is fetched once, and the failure marked on each of its repositories.
+func TestRepositoryReport_ExternalGroupsFailureCached(t *testing.T) {
+	srv := startDemoServer(t)
+	srv.Inject(fakegithub.Fault{Path: "/orgs/octodemo-apps/teams/mobile/external-groups", Status: http.StatusBadGateway})
+	out := filepath.Join(t.TempDir(), "repos.csv")
+
+	require.NoError(t, RepositoryReport(context.Background(), srv.RESTClient(), srv.GraphQLClient(), "octodemo", out, 1, utils.NewSharedCache(), Options{
+		Columns: []ColumnSpec{{Name: "Repository"}, {Name: "Status"}},
+	}))
+
+	lines := reportLines(t, out)
+	assert.Contains(t, lines, "mobile-app,failed: external groups")
+	assert.Contains(t, lines, "website,failed: external groups")
+	var fetches int
+	for _, req := range srv.Requests() {
+		if strings.Contains(req, "/teams/mobile/external-groups") {
+			fetches++
+		}
+	}
+	assert.Equal(t, 1, fetches)
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/progress"
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
 	"golang.org/x/time/rate"
 )
@@ -40,6 +41,12 @@ func RunReportWithWriter[T any, R any](
 		return nil
 	}
 
+	// Progress of the report, when run with a tracker
+	progressReport := progress.ReportFrom(ctx)
+	progressReport.AddTotal(len(items))
+	// Errors file of the report, when run with one
+	errorLog := ErrorLogFrom(ctx)
+
 	// Set up concurrency control
 	var wg sync.WaitGroup
 	itemChan := make(chan T)
@@ -71,6 +78,8 @@ func RunReportWithWriter[T any, R any](
 				result, err := processor(ctx, item)
 				if err != nil {
 					errorCount.Add(1)
+					progressReport.Failed()
+					errorLog.Record(itemKey(item), "", err)
 					errorsChan <- err
 					continue
 				}
@@ -78,6 +87,7 @@ func RunReportWithWriter[T any, R any](
 				// Format the result into a CSV row and send it to the result channel
 				row := formatter(result)
 				processedCount.Add(1)
+				progressReport.Processed()
 				select {
 				case resultChan <- row:
 					// Row sent successfully
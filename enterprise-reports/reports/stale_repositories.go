@@ -0,0 +1,336 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+	"golang.org/x/time/rate"
+)
+
+// DefaultStaleRepositoryDays is the number of days without activity after which a repository is stale.
+const DefaultStaleRepositoryDays = 365
+
+// Recommendations of the stale repositories report.
+const (
+	// StaleRecommendationArchive marks a repository that should be archived.
+	StaleRecommendationArchive = "archive"
+	// StaleRecommendationReview marks a repository whose owners should confirm it is still needed.
+	StaleRecommendationReview = "review"
+	// StaleRecommendationKeep marks a repository that is in use.
+	StaleRecommendationKeep = "keep"
+)
+
+// Scoring of the stale repositories report. Each staleness signal adds its weight to a repository's
+// score, and the score decides the recommendation. A push older than the window alone is not enough
+// to recommend archiving; it needs the other signals to agree.
+const (
+	staleWeightEmpty       = 8 // The repository has no commits
+	staleWeightPush        = 4 // No push within the window
+	staleWeightForkUnmoved = 4 // A fork without commits its parent lacks
+	staleWeightOpenItems   = 1 // No open pull request or issue updated within the window
+	staleWeightRelease     = 1 // No release within the window
+	staleWeightWorkflowRun = 1 // No workflow run within the window
+	staleWeightNoTeam      = 1 // No team has access
+
+	staleArchiveScore = 7 // Lowest score recommended for archiving
+	staleReviewScore  = 5 // Lowest score recommended for review
+)
+
+// Signals the stale repositories report lists as unchecked when they could not be fetched.
+const (
+	staleSignalActivity    = "activity"
+	staleSignalWorkflowRun = "workflow-runs"
+	staleSignalTeams       = "teams"
+	staleSignalFork        = "fork-divergence"
+)
+
+// StaleRepoReport scores a repository as a candidate for archival.
+type StaleRepoReport struct {
+	*github.Repository
+	Activity        *api.RepositoryActivity // Pull request, issue and release activity; nil when it could not be fetched
+	LastWorkflowRun time.Time               // Creation of the latest workflow run; zero when none ran
+	AheadOfParent   *int                    // Commits a fork has that its parent lacks; nil unless compared
+	Teams           []string                // Slugs of the teams with access to the repository
+	Score           int                     // Sum of the weights of the staleness signals found
+	Recommendation  string                  // archive, review or keep
+	Reasons         []string                // Staleness signals found
+	Unchecked       []string                // Signals that could not be checked because fetching them failed
+}
+
+// StaleRepositoriesReport generates a report scoring every unarchived repository of the enterprise
+// for archival. A repository scores for each of these signals:
+//   - it is empty,
+//   - it was not pushed to within the window,
+//   - it is a fork without commits its parent lacks,
+//   - none of its open pull requests or issues were updated within the window,
+//   - it has no release within the window,
+//   - it has no workflow run within the window,
+//   - no team has access to it.
+//
+// The window is opts.StaleDays (DefaultStaleRepositoryDays by default). Each row lists the score,
+// a recommendation and the signals found; signals that could not be fetched are listed as unchecked
+// and do not add to the score.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout
+//   - restClient: GitHub REST API client
+//   - graphQLClient: GitHub GraphQL API client
+//   - enterpriseSlug: Enterprise identifier
+//   - filename: Output CSV file path
+//   - workerCount: Number of concurrent workers for processing repositories
+//   - cache: Shared cache for storing and retrieving GitHub data
+//   - opts: Report options, such as the columns to write
+func StaleRepositoriesReport(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache, opts Options) error {
+	slog.Info("starting stale repositories report", "enterprise", enterpriseSlug, "filename", filename, "workers", workerCount)
+
+	header, formatter, err := selectColumns(staleRepoColumns, defaultStaleRepoColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("stale repositories report columns: %w", err)
+	}
+	formatter = recordRows(opts.Rows, formatter)
+
+	staleDays := opts.StaleDays
+	if staleDays <= 0 {
+		staleDays = DefaultStaleRepositoryDays
+	}
+	cutoff := time.Now().UTC().AddDate(0, 0, -staleDays)
+
+	// Create appropriate report writer based on file extension
+	reportWriter, reportErr := NewReportWriter(filename)
+	if reportErr != nil {
+		return reportErr
+	}
+	defer func() {
+		if err := reportWriter.Close(); err != nil {
+			slog.Error("Failed to close report writer", "error", err)
+		}
+	}()
+
+	// Write header to report
+	if headerErr := reportWriter.WriteHeader(header); headerErr != nil {
+		return fmt.Errorf("failed to write header: %w", headerErr)
+	}
+
+	// Check cache for organizations or fetch from API
+	var orgs []*github.Organization
+
+	if cachedOrgs, found := cache.GetEnterpriseOrgs(); found {
+		slog.Info("using cached enterprise organizations")
+		orgs = cachedOrgs
+	} else {
+		slog.Info("fetching enterprise organizations", "enterprise", enterpriseSlug)
+		orgs, err = api.FetchEnterpriseOrgs(ctx, graphQLClient, enterpriseSlug)
+		if err != nil {
+			return fmt.Errorf("failed to fetch organizations: %w", err)
+		}
+		// Store in cache
+		cache.SetEnterpriseOrgs(orgs)
+	}
+
+	// Collect the repositories that are not archived yet
+	var reposList []*github.Repository
+	var archived int
+	for _, org := range orgs {
+		var repos []*github.Repository
+		if cachedRepos, found := cache.GetOrgRepositories(org.GetLogin()); found {
+			slog.Info("using cached repositories for org", "org", org.GetLogin())
+			repos = cachedRepos
+		} else {
+			slog.Info("fetching repositories for org", "org", org.GetLogin())
+			repos, err = api.FetchOrganizationRepositories(ctx, restClient, org.GetLogin())
+			if err != nil {
+				slog.Warn("failed to fetch repositories for org", "org", org.GetLogin(), "err", err)
+				continue
+			}
+			// Store in cache
+			cache.SetOrgRepositories(org.GetLogin(), repos)
+		}
+		for _, repo := range repos {
+			if repo.GetArchived() {
+				archived++
+				continue
+			}
+			reposList = append(reposList, repo)
+		}
+	}
+	slog.Info("collected repositories", "repositories", len(reposList), "archived_skipped", archived)
+
+	// Pull request, issue and release activity is looked up for many repositories per query
+	fullNames := make([]string, len(reposList))
+	for i, repo := range reposList {
+		fullNames[i] = repo.GetFullName()
+	}
+	activity, err := api.FetchRepositoriesActivity(ctx, graphQLClient, fullNames)
+	if err != nil {
+		return fmt.Errorf("failed to fetch repository activity: %w", err)
+	}
+
+	// Processor: fetch the workflow runs, teams and fork divergence of each repository and score it
+	processor := func(ctx context.Context, repo *github.Repository) (*StaleRepoReport, error) {
+		slog.Debug("processing repository", "repo", repo.GetFullName())
+		owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+		row := &StaleRepoReport{Repository: repo, Activity: activity[repo.GetFullName()]}
+		if row.Activity == nil {
+			// BatchQuery leaves failed lookups out without their error
+			row.Unchecked = append(row.Unchecked, staleSignalActivity)
+			ErrorLogFrom(ctx).Record(repo.GetFullName(), staleSignalActivity, fmt.Errorf("activity of %q could not be read", repo.GetFullName()))
+		}
+
+		if lastRun, err := api.FetchLatestWorkflowRun(ctx, restClient, owner, name); err != nil {
+			slog.Warn("failed to fetch workflow runs", "repo", repo.GetFullName(), "err", err)
+			row.Unchecked = append(row.Unchecked, staleSignalWorkflowRun)
+			ErrorLogFrom(ctx).Record(repo.GetFullName(), staleSignalWorkflowRun, err)
+		} else {
+			row.LastWorkflowRun = lastRun
+		}
+
+		if teams, err := api.FetchTeams(ctx, restClient, owner, name); err != nil {
+			slog.Warn("failed to fetch teams", "repo", repo.GetFullName(), "err", err)
+			row.Unchecked = append(row.Unchecked, staleSignalTeams)
+			ErrorLogFrom(ctx).Record(repo.GetFullName(), staleSignalTeams, err)
+		} else {
+			for _, team := range teams {
+				row.Teams = append(row.Teams, team.GetSlug())
+			}
+		}
+
+		// An empty fork has nothing to compare
+		if repo.GetFork() && row.Activity != nil && !row.Activity.IsEmpty {
+			a := row.Activity
+			if a.Parent == "" {
+				// The parent was deleted or is not visible, so the fork is the only copy
+				slog.Debug("fork parent is not visible", "repo", repo.GetFullName())
+			} else if aheadBy, err := api.FetchForkAheadBy(ctx, restClient, a.Parent, a.ParentDefaultBranch, repo.GetFullName(), a.DefaultBranch); err != nil {
+				slog.Warn("failed to compare fork with its parent", "repo", repo.GetFullName(), "err", err)
+				row.Unchecked = append(row.Unchecked, staleSignalFork)
+				ErrorLogFrom(ctx).Record(repo.GetFullName(), staleSignalFork, err)
+			} else {
+				row.AheadOfParent = &aheadBy
+			}
+		}
+
+		scoreStaleRepository(row, cutoff, staleDays)
+		return row, nil
+	}
+
+	// Create a limiter for rate limiting - aiming for ~4 repos/sec as each repo costs up to 3 requests
+	limiter := rate.NewLimiter(rate.Limit(staleRepositoriesRate), workerCount)
+
+	return RunReportWithWriter(ctx, reposList, processor, formatter, limiter, workerCount, reportWriter)
+}
+
+// scoreStaleRepository adds up the staleness signals of a repository and sets its score,
+// recommendation and reasons. Activity before cutoff is outside the window of staleDays.
+func scoreStaleRepository(r *StaleRepoReport, cutoff time.Time, staleDays int) {
+	r.Score = 0
+	r.Reasons = nil
+	add := func(weight int, reason string) {
+		r.Score += weight
+		r.Reasons = append(r.Reasons, reason)
+	}
+	a := r.Activity
+
+	if a != nil && a.IsEmpty {
+		add(staleWeightEmpty, "repository is empty")
+	}
+	if r.PushedAt == nil || r.GetPushedAt().Before(cutoff) {
+		add(staleWeightPush, fmt.Sprintf("no push in %d days", staleDays))
+	}
+	if r.AheadOfParent != nil && *r.AheadOfParent == 0 {
+		add(staleWeightForkUnmoved, "fork has no commits its parent lacks")
+	}
+	if a != nil {
+		if lastOpenActivity(a).Before(cutoff) {
+			add(staleWeightOpenItems, fmt.Sprintf("no open pull request or issue activity in %d days", staleDays))
+		}
+		switch {
+		case a.LatestRelease.IsZero():
+			add(staleWeightRelease, "never released")
+		case a.LatestRelease.Before(cutoff):
+			add(staleWeightRelease, fmt.Sprintf("no release in %d days", staleDays))
+		}
+	}
+	if !slices.Contains(r.Unchecked, staleSignalWorkflowRun) {
+		switch {
+		case r.LastWorkflowRun.IsZero():
+			add(staleWeightWorkflowRun, "no workflow runs")
+		case r.LastWorkflowRun.Before(cutoff):
+			add(staleWeightWorkflowRun, fmt.Sprintf("no workflow run in %d days", staleDays))
+		}
+	}
+	if !slices.Contains(r.Unchecked, staleSignalTeams) && len(r.Teams) == 0 {
+		add(staleWeightNoTeam, "no team access")
+	}
+
+	switch {
+	case r.Score >= staleArchiveScore:
+		r.Recommendation = StaleRecommendationArchive
+	case r.Score >= staleReviewScore:
+		r.Recommendation = StaleRecommendationReview
+	default:
+		r.Recommendation = StaleRecommendationKeep
+	}
+}
+
+// lastOpenActivity returns the last update of an open pull request or issue, or the zero time when none are open.
+func lastOpenActivity(a *api.RepositoryActivity) time.Time {
+	if a.LastOpenIssueEdit.After(a.LastOpenPullRequestEdit) {
+		return a.LastOpenIssueEdit
+	}
+	return a.LastOpenPullRequestEdit
+}
+
+// formatTime renders a time, or "N/A" when it is zero.
+func formatTime(t time.Time) string {
+	return formatTimestamp(&github.Timestamp{Time: t})
+}
+
+// staleActivityColumn renders a value of the repository's activity, or N/A when it could not be fetched.
+func staleActivityColumn(name string, value func(*api.RepositoryActivity) string) Column[*StaleRepoReport] {
+	return Column[*StaleRepoReport]{Name: name, Value: func(r *StaleRepoReport) string {
+		if r.Activity == nil {
+			return "N/A"
+		}
+		return value(r.Activity)
+	}}
+}
+
+// staleRepoColumns lists every column the stale repositories report can output.
+var staleRepoColumns = []Column[*StaleRepoReport]{
+	{Name: "Owner", Value: func(r *StaleRepoReport) string { return r.GetOwner().GetLogin() }},
+	{Name: "Repository", Value: func(r *StaleRepoReport) string { return r.GetName() }},
+	{Name: "Visibility", Value: func(r *StaleRepoReport) string { return r.GetVisibility() }},
+	{Name: "Pushed_At", Value: func(r *StaleRepoReport) string { return formatTimestamp(r.PushedAt) }},
+	{Name: "Score", Value: func(r *StaleRepoReport) string { return strconv.Itoa(r.Score) }},
+	{Name: "Recommendation", Value: func(r *StaleRepoReport) string { return r.Recommendation }},
+	{Name: "Reasons", Value: func(r *StaleRepoReport) string { return joinOrNA(r.Reasons) }},
+	staleActivityColumn("Last_Open_Activity", func(a *api.RepositoryActivity) string { return formatTime(lastOpenActivity(a)) }),
+	staleActivityColumn("Open_Pull_Requests", func(a *api.RepositoryActivity) string { return strconv.Itoa(a.OpenPullRequests) }),
+	staleActivityColumn("Open_Issues", func(a *api.RepositoryActivity) string { return strconv.Itoa(a.OpenIssues) }),
+	staleActivityColumn("Last_Release", func(a *api.RepositoryActivity) string { return formatTime(a.LatestRelease) }),
+	staleActivityColumn("Empty", func(a *api.RepositoryActivity) string { return strconv.FormatBool(a.IsEmpty) }),
+	staleActivityColumn("Parent", func(a *api.RepositoryActivity) string { return naIfEmpty(a.Parent) }),
+	{Name: "Last_Workflow_Run", Value: func(r *StaleRepoReport) string { return formatTime(r.LastWorkflowRun) }},
+	{Name: "Fork_Ahead_By", Value: func(r *StaleRepoReport) string {
+		if r.AheadOfParent == nil {
+			return "N/A"
+		}
+		return strconv.Itoa(*r.AheadOfParent)
+	}},
+	{Name: "Teams", Value: func(r *StaleRepoReport) string { return joinOrNA(r.Teams) }},
+	{Name: "Unchecked", Value: func(r *StaleRepoReport) string { return joinOrNA(r.Unchecked) }},
+	// Status reports the unchecked signals like the Status column of the other reports
+	{Name: "Status", Value: func(r *StaleRepoReport) string { return (&ItemStatus{Failed: r.Unchecked}).Status() }},
+}
+
+// defaultStaleRepoColumns is the column layout written when no columns are configured.
+var defaultStaleRepoColumns = []string{"Owner", "Repository", "Pushed_At", "Score", "Recommendation", "Reasons", "Status"}
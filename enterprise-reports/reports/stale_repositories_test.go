@@ -0,0 +1,236 @@
+// Package reports implements various report generation functionalities for GitHub Enterprise.
+// This file contains tests for the stale repositories report functionality.
+package reports
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/api"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
+	"github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScoreStaleRepository tests how staleness signals add up to a recommendation.
+func TestScoreStaleRepository(t *testing.T) {
+	now := time.Now().UTC()
+	cutoff := now.AddDate(0, 0, -365)
+	recent, old := now.AddDate(0, 0, -10), now.AddDate(-2, 0, 0)
+	zero := 0
+
+	tests := []struct {
+		name           string
+		row            *StaleRepoReport
+		score          int
+		recommendation string
+		reasons        []string
+	}{
+		{
+			name: "active repository",
+			row: &StaleRepoReport{
+				Repository:      &github.Repository{PushedAt: &github.Timestamp{Time: recent}},
+				Activity:        &api.RepositoryActivity{LatestRelease: recent, LastOpenIssueEdit: recent},
+				LastWorkflowRun: recent,
+				Teams:           []string{"eng"},
+			},
+			score:          0,
+			recommendation: StaleRecommendationKeep,
+		},
+		{
+			name: "recent push without releases, workflows or teams",
+			row: &StaleRepoReport{
+				Repository: &github.Repository{PushedAt: &github.Timestamp{Time: recent}},
+				Activity:   &api.RepositoryActivity{},
+			},
+			score:          4,
+			recommendation: StaleRecommendationKeep,
+			reasons:        []string{"no open pull request or issue activity in 365 days", "never released", "no workflow runs", "no team access"},
+		},
+		{
+			name: "old push with a recent open pull request",
+			row: &StaleRepoReport{
+				Repository:      &github.Repository{PushedAt: &github.Timestamp{Time: old}},
+				Activity:        &api.RepositoryActivity{LastOpenPullRequestEdit: recent, LatestRelease: old},
+				LastWorkflowRun: recent,
+				Teams:           []string{"eng"},
+			},
+			score:          5,
+			recommendation: StaleRecommendationReview,
+			reasons:        []string{"no push in 365 days", "no release in 365 days"},
+		},
+		{
+			name: "unmoved fork",
+			row: &StaleRepoReport{
+				Repository:      &github.Repository{PushedAt: &github.Timestamp{Time: old}, Fork: github.Ptr(true)},
+				Activity:        &api.RepositoryActivity{LatestRelease: recent},
+				LastWorkflowRun: old,
+				AheadOfParent:   &zero,
+				Teams:           []string{"eng"},
+			},
+			score:          10,
+			recommendation: StaleRecommendationArchive,
+			reasons:        []string{"no push in 365 days", "fork has no commits its parent lacks", "no open pull request or issue activity in 365 days", "no workflow run in 365 days"},
+		},
+		{
+			name: "unchecked signals do not score",
+			row: &StaleRepoReport{
+				Repository: &github.Repository{PushedAt: &github.Timestamp{Time: old}},
+				Unchecked:  []string{staleSignalActivity, staleSignalWorkflowRun, staleSignalTeams},
+			},
+			score:          4,
+			recommendation: StaleRecommendationKeep,
+			reasons:        []string{"no push in 365 days"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scoreStaleRepository(tt.row, cutoff, 365)
+			assert.Equal(t, tt.score, tt.row.Score)
+			assert.Equal(t, tt.recommendation, tt.row.Recommendation)
+			assert.Equal(t, tt.reasons, tt.row.Reasons)
+		})
+	}
+}
+
+// TestStaleRepositoriesReport tests that the report fetches the activity of every unarchived repository
+// and writes its score and recommendation.
+func TestStaleRepositoriesReport(t *testing.T) {
+	recent := time.Now().UTC().AddDate(0, 0, -10).Format(time.RFC3339)
+	old := time.Now().UTC().AddDate(-2, 0, 0)
+
+	// GraphQL returns the activity of each aliased repository lookup
+	activity := map[string]string{
+		"busy":  `{"isEmpty":false,"defaultBranchRef":{"name":"main"},"parent":null,"latestRelease":{"publishedAt":"` + recent + `"},"pullRequests":{"totalCount":1,"nodes":[{"updatedAt":"` + recent + `"}]},"issues":{"totalCount":0,"nodes":[]}}`,
+		"empty": `{"isEmpty":true,"defaultBranchRef":null,"parent":null,"latestRelease":null,"pullRequests":{"totalCount":0,"nodes":[]},"issues":{"totalCount":0,"nodes":[]}}`,
+		"fork":  `{"isEmpty":false,"defaultBranchRef":{"name":"main"},"parent":{"nameWithOwner":"upstream/fork","defaultBranchRef":{"name":"trunk"}},"latestRelease":null,"pullRequests":{"totalCount":0,"nodes":[]},"issues":{"totalCount":0,"nodes":[]}}`,
+	}
+	gSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var fields []string
+		for i := 0; ; i++ {
+			name, ok := req.Variables[fmt.Sprintf("name%d", i)].(string)
+			if !ok {
+				break
+			}
+			fields = append(fields, fmt.Sprintf(`"r%d":%s`, i, activity[name]))
+		}
+		_, err := fmt.Fprintf(w, `{"data":{%s,"rateLimit":{"cost":1,"limit":5000,"remaining":4999,"resetAt":"2030-01-01T00:00:00Z"}}}`, strings.Join(fields, ","))
+		require.NoError(t, err)
+	}))
+	t.Cleanup(gSrv.Close)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/{repo}/actions/runs", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("repo") == "busy" {
+			fmt.Fprintf(w, `{"total_count":1,"workflow_runs":[{"id":1,"created_at":%q}]}`, recent)
+			return
+		}
+		fmt.Fprint(w, `{"total_count":0,"workflow_runs":[]}`)
+	})
+	mux.HandleFunc("/repos/org/{repo}/teams", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("repo") == "busy" {
+			fmt.Fprint(w, `[{"slug":"eng"}]`)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/upstream/fork/compare/{spec}", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "trunk...org:main", r.PathValue("spec"))
+		fmt.Fprint(w, `{"ahead_by":0,"behind_by":3}`)
+	})
+	rSrv := httptest.NewServer(mux)
+	t.Cleanup(rSrv.Close)
+
+	restClient := github.NewClient(rSrv.Client())
+	baseURL, _ := url.Parse(rSrv.URL + "/")
+	restClient.BaseURL = baseURL
+	graphClient := githubv4.NewEnterpriseClient(gSrv.URL, gSrv.Client())
+
+	repo := func(name string, pushedAt time.Time, fork, archived bool) *github.Repository {
+		return &github.Repository{
+			Name:     github.Ptr(name),
+			FullName: github.Ptr("org/" + name),
+			Owner:    &github.User{Login: github.Ptr("org")},
+			PushedAt: &github.Timestamp{Time: pushedAt},
+			Fork:     github.Ptr(fork),
+			Archived: github.Ptr(archived),
+		}
+	}
+	cache := utils.NewSharedCache()
+	cache.SetEnterpriseOrgs([]*github.Organization{{Login: github.Ptr("org")}})
+	cache.SetOrgRepositories("org", []*github.Repository{
+		repo("busy", time.Now().AddDate(0, 0, -1), false, false),
+		repo("empty", old, false, false),
+		repo("fork", old, true, false),
+		repo("archived", old, false, true),
+	})
+
+	out := filepath.Join(t.TempDir(), "stale-repositories.csv")
+	opts := Options{Columns: []ColumnSpec{{Name: "Repository"}, {Name: "Score"}, {Name: "Recommendation"}, {Name: "Fork_Ahead_By"}, {Name: "Teams"}}}
+	err := StaleRepositoriesReport(context.Background(), restClient, graphClient, "ent", out, 2, cache, opts)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(strings.NewReader(readFile(t, out))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, []string{"Repository", "Score", "Recommendation", "Fork_Ahead_By", "Teams"}, records[0])
+	assert.ElementsMatch(t, [][]string{
+		{"busy", "0", "keep", "N/A", "eng"},
+		{"empty", "16", "archive", "N/A", "N/A"},
+		{"fork", "12", "archive", "0", "N/A"},
+	}, records[1:])
+}
+
+// TestStaleRepositoriesReport_ActivityUnreadable tests that a repository whose activity cannot be
+// read lists the activity signal as unchecked and records it in the errors file.
+func TestStaleRepositoriesReport_ActivityUnreadable(t *testing.T) {
+	srv := startDemoServer(t)
+	cache := utils.NewSharedCache()
+	cache.SetEnterpriseOrgs([]*github.Organization{{Login: github.Ptr("octodemo-platform")}})
+	repos, err := api.FetchOrganizationRepositories(context.Background(), srv.RESTClient(), "octodemo-platform")
+	require.NoError(t, err)
+	repos = append(repos, &github.Repository{
+		Name:     github.Ptr("ghost"),
+		FullName: github.Ptr("octodemo-platform/ghost"),
+		Owner:    &github.User{Login: github.Ptr("octodemo-platform")},
+	})
+	cache.SetOrgRepositories("octodemo-platform", repos)
+
+	out := filepath.Join(t.TempDir(), "stale-repositories.csv")
+	opts := Options{Columns: []ColumnSpec{{Name: "Repository"}, {Name: "Unchecked"}}}
+	errorLog := NewErrorLog("stale-repositories", out)
+	ctx := WithErrorLog(context.Background(), errorLog)
+	require.NoError(t, StaleRepositoriesReport(ctx, srv.RESTClient(), srv.GraphQLClient(), "octodemo", out, 1, cache, opts))
+	require.NoError(t, errorLog.Close())
+
+	var ghost string
+	for _, line := range reportLines(t, out) {
+		if strings.HasPrefix(line, "ghost,") {
+			ghost = line
+		}
+	}
+	assert.True(t, strings.HasPrefix(ghost, `ghost,"activity,`), ghost)
+
+	var fields []string
+	for _, entry := range readItemErrors(t, ErrorsPath(out)) {
+		assert.Equal(t, "octodemo-platform/ghost", entry.Item)
+		fields = append(fields, entry.Field)
+	}
+	assert.Contains(t, fields, "activity")
+}
@@ -6,6 +6,7 @@ package reports
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"log/slog"
@@ -20,10 +21,44 @@ import (
 // TeamReport represents a team with its associated organization,
 // external groups, and members for generating team reports.
 type TeamReport struct {
-	*github.Team                                   // Team details
-	*github.Organization                           // Parent organization
-	ExternalGroups       *github.ExternalGroupList // External identity provider groups linked to this team
-	Members              []*github.User            // Team members
+	*github.Team                                    // Team details
+	*github.Organization                            // Parent organization
+	ExternalGroups        *github.ExternalGroupList // External identity provider groups linked to this team
+	IDPGroups             *github.IDPGroupList      // Identity provider groups connected through team synchronization
+	Members               []*github.User            // Team members, including maintainers
+	Maintainers           []*github.User            // Team maintainers
+	ChildTeams            []*github.Team            // Teams nested directly under this team
+	Repositories          []*TeamRepositoryAccess   // Repositories the team was granted access to
+	InheritedRepositories []*TeamRepositoryAccess   // Repositories the team can access through its ancestors
+	ItemStatus                                      // Parts of the team that could not be fetched
+}
+
+// TeamRepositoryAccess describes a team's access to a repository.
+type TeamRepositoryAccess struct {
+	Repository string // Repository full name, e.g. "org/repo"
+	Permission string // Highest permission: admin, maintain, push, triage or pull
+	Source     string // Slug of the team the access was granted to
+}
+
+// repositoryPermissions lists repository permissions from highest to lowest.
+var repositoryPermissions = []string{"admin", "maintain", "push", "triage", "pull"}
+
+// repositoryPermission returns the highest permission in a repository's permissions map.
+func repositoryPermission(repo *github.Repository) string {
+	for _, p := range repositoryPermissions {
+		if repo.GetPermissions()[p] {
+			return p
+		}
+	}
+	return "none"
+}
+
+// permissionRank orders permissions so that higher permissions rank lower, with unknown permissions last.
+func permissionRank(permission string) int {
+	if i := slices.Index(repositoryPermissions, permission); i >= 0 {
+		return i
+	}
+	return len(repositoryPermissions)
 }
 
 // TeamsReport generates a CSV report of all teams across all organizations in an enterprise.
@@ -38,12 +73,21 @@ type TeamReport struct {
 //   - filename: Output CSV file path
 //   - workerCount: Number of concurrent workers for processing teams
 //   - cache: Shared cache for storing and retrieving GitHub data
+//   - opts: Report options, such as the columns to write
 //
-// The report includes team ID, organization name, team name and slug,
-// external group associations, and team membership.
-func TeamsReport(ctx context.Context, restClient *github.Client, graphqlClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache) error {
+// The report includes team ID, organization name, team name and slug, the parent and child teams,
+// privacy and notification settings, identity provider groups from external groups or team
+// synchronization, maintainers and members, and the repositories the team can access directly
+// or inherits from its ancestor teams, each with the permission granted.
+func TeamsReport(ctx context.Context, restClient *github.Client, graphqlClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache, opts Options) error {
 	slog.Info("starting teams report", slog.String("enterprise", enterpriseSlug), slog.String("filename", filename), slog.Int("workers", workerCount))
 
+	header, formatter, err := selectColumns(teamColumns, defaultTeamColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("teams report columns: %w", err)
+	}
+	formatter = recordRows(opts.Rows, formatter)
+
 	// Create appropriate report writer based on file extension
 	reportWriter, reportErr := NewReportWriter(filename)
 	if reportErr != nil {
@@ -55,22 +99,12 @@ func TeamsReport(ctx context.Context, restClient *github.Client, graphqlClient *
 		}
 	}()
 
-	header := []string{
-		"Team ID",
-		"Owner",
-		"Team Name",
-		"Team Slug",
-		"External Group",
-		"Members",
-	}
-
 	// Write header to report
 	if headerErr := reportWriter.WriteHeader(header); headerErr != nil {
 		return fmt.Errorf("failed to write header: %w", headerErr)
 	}
 	// Check cache for organizations or fetch from API
 	var orgs []*github.Organization
-	var err error
 
 	if cachedOrgs, found := cache.GetEnterpriseOrgs(); found {
 		slog.Info("using cached enterprise organizations")
@@ -86,8 +120,9 @@ func TeamsReport(ctx context.Context, restClient *github.Client, graphqlClient *
 		cache.SetEnterpriseOrgs(orgs)
 	}
 
-	// Prepare initial items: organization teams
+	// Prepare initial items: organization teams, indexed by org and ID to walk team hierarchies
 	var items []*TeamReport
+	teamsByID := make(map[string]map[int64]*github.Team)
 	for _, org := range orgs {
 		// Check cache for teams
 		var teams []*github.Team
@@ -104,13 +139,46 @@ func TeamsReport(ctx context.Context, restClient *github.Client, graphqlClient *
 			// Store in cache
 			cache.SetOrgTeams(org.GetLogin(), teams)
 		}
+		byID := make(map[int64]*github.Team, len(teams))
 		for _, t := range teams {
-			items = append(items, &TeamReport{Team: t, Organization: org})
+			byID[t.GetID()] = t
 		}
+		teamsByID[org.GetLogin()] = byID
+
+		for _, t := range teams {
+			tr := &TeamReport{Team: t, Organization: org}
+			for _, child := range teams {
+				if child.GetParent().GetID() == t.GetID() {
+					tr.ChildTeams = append(tr.ChildTeams, child)
+				}
+			}
+			items = append(items, tr)
+		}
+	}
+
+	// teamRepositories returns the repositories a team was granted access to, from the cache when
+	// the team, or a descendant walking its ancestors, already fetched them.
+	teamRepositories := func(ctx context.Context, org string, team *github.Team) ([]*TeamRepositoryAccess, error) {
+		teamKey := fmt.Sprintf("%s/%s", org, team.GetSlug())
+		repos, found := cache.GetTeamRepositories(teamKey)
+		if !found {
+			var err error
+			repos, err = api.FetchTeamRepositories(ctx, restClient, org, team.GetSlug())
+			if err != nil {
+				return nil, err
+			}
+			cache.SetTeamRepositories(teamKey, repos)
+		}
+		access := make([]*TeamRepositoryAccess, 0, len(repos))
+		for _, r := range repos {
+			access = append(access, &TeamRepositoryAccess{Repository: r.GetFullName(), Permission: repositoryPermission(r), Source: team.GetSlug()})
+		}
+		return access, nil
 	}
+
 	// Processor: fetch members and external groups
 	processor := func(ctx context.Context, tr *TeamReport) (*TeamReport, error) {
-		slog.Info("processing team", "team", tr.GetSlug())
+		slog.Debug("processing team", "team", tr.GetSlug())
 
 		// Generate team key for cache
 		teamKey := fmt.Sprintf("%s/%s", tr.GetLogin(), tr.GetSlug())
@@ -125,6 +193,7 @@ func TeamsReport(ctx context.Context, restClient *github.Client, graphqlClient *
 			members, err = api.FetchTeamMembers(ctx, restClient, tr.Team, tr.GetLogin())
 			if err != nil {
 				slog.Debug("skipping membership fetch", "team", tr.GetSlug(), "err", err)
+				tr.fail(ctx, teamKey, "members", err)
 				members = []*github.User{} // Initialize to empty slice on error
 			} else {
 				// Store in cache
@@ -133,51 +202,178 @@ func TeamsReport(ctx context.Context, restClient *github.Client, graphqlClient *
 		}
 		tr.Members = members
 
-		ext, err := api.FetchExternalGroups(ctx, restClient, tr.GetLogin(), tr.GetSlug())
+		maintainers, err := api.FetchTeamMembersByRole(ctx, restClient, tr.Team, tr.GetLogin(), "maintainer")
 		if err != nil {
-			slog.Debug("skipping external groups fetch", "team", tr.GetSlug(), "err", err)
-			tr.ExternalGroups = &github.ExternalGroupList{} // Initialize to empty struct on error
-		} else {
-			tr.ExternalGroups = ext // Assign fetched external groups if successful
+			slog.Debug("skipping maintainers fetch", "team", tr.GetSlug(), "err", err)
+			tr.fail(ctx, teamKey, "maintainers", err)
 		}
+		tr.Maintainers = maintainers
 
-		return tr, nil
-	}
-	// Formatter: build CSV row
-	formatter := func(tr *TeamReport) []string {
-		// members
-		mem := "N/A"
-		if len(tr.Members) > 0 {
-			var logins []string
-			for _, m := range tr.Members {
-				logins = append(logins, m.GetLogin())
+		// Check cache for team external groups
+		if cachedGroups, found := cache.GetTeamExternalGroups(teamKey); found {
+			tr.ExternalGroups = cachedGroups
+		} else {
+			ext, err := api.FetchExternalGroups(ctx, restClient, tr.GetLogin(), tr.GetSlug())
+			if err != nil {
+				slog.Debug("skipping external groups fetch", "team", tr.GetSlug(), "err", err)
+				tr.fail(ctx, teamKey, "external groups", err)
+				tr.ExternalGroups = &github.ExternalGroupList{} // Initialize to empty struct on error
+			} else {
+				tr.ExternalGroups = ext // Assign fetched external groups if successful
+				// Store in cache
+				cache.SetTeamExternalGroups(teamKey, ext)
 			}
-			mem = strings.Join(logins, ", ")
-		}
-		// external groups
-		eg := "N/A"
-		if tr.ExternalGroups != nil && len(tr.ExternalGroups.Groups) > 0 {
-			var names []string
-			for _, g := range tr.ExternalGroups.Groups {
-				names = append(names, g.GetGroupName())
+		}
+
+		// Team synchronization only applies outside Enterprise Managed Users, where teams have no external groups
+		if len(tr.ExternalGroups.Groups) == 0 {
+			idp, err := api.FetchTeamIDPGroups(ctx, restClient, tr.GetLogin(), tr.GetSlug())
+			if err != nil {
+				slog.Debug("skipping team sync groups fetch", "team", tr.GetSlug(), "err", err)
+				tr.fail(ctx, teamKey, "team sync groups", err)
 			}
-			eg = strings.Join(names, ", ")
+			tr.IDPGroups = idp
 		}
-		return []string{
-			fmt.Sprintf("%d", tr.Team.GetID()),
-			tr.GetLogin(),
-			tr.Team.GetName(),
-			tr.GetSlug(),
-			eg,
-			mem,
+
+		// Repositories granted to the team itself
+		direct, err := teamRepositories(ctx, tr.GetLogin(), tr.Team)
+		if err != nil {
+			slog.Warn("failed to fetch team repositories", "team", tr.GetSlug(), "err", err)
+			tr.fail(ctx, teamKey, "repositories", err)
 		}
-	}
+		tr.Repositories = direct
 
-	// Create a limiter for rate limiting - aiming for ~5 teams/sec
-	// (Consumes 10 REST points/sec, below the 15 points/sec limit)
+		// Repositories inherited from ancestors, where they grant more than the team has itself
+		effective := make(map[string]string, len(direct))
+		for _, a := range direct {
+			effective[a.Repository] = a.Permission
+		}
+		byID := teamsByID[tr.GetLogin()]
+		seen := map[int64]bool{tr.Team.GetID(): true}
+		for parent := byID[tr.Team.GetParent().GetID()]; parent != nil && !seen[parent.GetID()]; parent = byID[parent.GetParent().GetID()] {
+			seen[parent.GetID()] = true
+			inherited, err := teamRepositories(ctx, tr.GetLogin(), parent)
+			if err != nil {
+				slog.Warn("failed to fetch parent team repositories", "team", tr.GetSlug(), "parent", parent.GetSlug(), "err", err)
+				tr.fail(ctx, teamKey, "inherited repositories", err)
+				continue
+			}
+			for _, a := range inherited {
+				if current, ok := effective[a.Repository]; ok && permissionRank(current) <= permissionRank(a.Permission) {
+					continue
+				}
+				effective[a.Repository] = a.Permission
+				tr.InheritedRepositories = append(tr.InheritedRepositories, a)
+			}
+		}
+
+		return tr, nil
+	}
+	// Create a limiter for rate limiting - aiming for ~2 teams/sec
+	// (each team makes about 5 REST calls for members, maintainers, IdP groups and repositories,
+	// consuming about 10 REST points/sec, below the 15 points/sec limit)
 	// Burst matches worker count for responsiveness.
-	limiter := rate.NewLimiter(rate.Limit(5), workerCount) // e.g., 5 requests/sec, burst of workerCount
+	limiter := rate.NewLimiter(rate.Limit(teamsRate), workerCount) // e.g., 2 requests/sec, burst of workerCount
 
 	// Run the report using the new report writer interface
 	return RunReportWithWriter(ctx, items, processor, formatter, limiter, workerCount, reportWriter)
 }
+
+// teamColumns lists every column the teams report can output.
+var teamColumns = []Column[*TeamReport]{
+	{Name: "Team ID", Value: func(tr *TeamReport) string { return fmt.Sprintf("%d", tr.Team.GetID()) }},
+	{Name: "Owner", Value: func(tr *TeamReport) string { return tr.GetLogin() }},
+	{Name: "Team Name", Value: func(tr *TeamReport) string { return tr.Team.GetName() }},
+	{Name: "Team Slug", Value: func(tr *TeamReport) string { return tr.GetSlug() }},
+	{Name: "Description", Value: func(tr *TeamReport) string { return tr.Team.GetDescription() }},
+	{Name: "External Group", Value: func(tr *TeamReport) string {
+		if tr.ExternalGroups == nil || len(tr.ExternalGroups.Groups) == 0 {
+			return "N/A"
+		}
+		var names []string
+		for _, g := range tr.ExternalGroups.Groups {
+			names = append(names, g.GetGroupName())
+		}
+		return strings.Join(names, ", ")
+	}},
+	{Name: "Members", Value: func(tr *TeamReport) string {
+		if len(tr.Members) == 0 {
+			return "N/A"
+		}
+		var logins []string
+		for _, m := range tr.Members {
+			logins = append(logins, m.GetLogin())
+		}
+		return strings.Join(logins, ", ")
+	}},
+	{Name: "Member Count", Value: func(tr *TeamReport) string { return fmt.Sprintf("%d", len(tr.Members)) }},
+	{Name: "Parent Team", Value: func(tr *TeamReport) string {
+		if tr.Team.Parent == nil {
+			return "N/A"
+		}
+		return tr.Team.Parent.GetSlug()
+	}},
+	{Name: "Child Teams", Value: func(tr *TeamReport) string {
+		if len(tr.ChildTeams) == 0 {
+			return "N/A"
+		}
+		var slugs []string
+		for _, c := range tr.ChildTeams {
+			slugs = append(slugs, c.GetSlug())
+		}
+		return strings.Join(slugs, ", ")
+	}},
+	{Name: "Privacy", Value: func(tr *TeamReport) string { return tr.Team.GetPrivacy() }},
+	{Name: "Notification Setting", Value: func(tr *TeamReport) string { return tr.Team.GetNotificationSetting() }},
+	{Name: "IdP Groups", Value: func(tr *TeamReport) string {
+		if tr.IDPGroups == nil || len(tr.IDPGroups.Groups) == 0 {
+			return "N/A"
+		}
+		var names []string
+		for _, g := range tr.IDPGroups.Groups {
+			names = append(names, g.GetGroupName())
+		}
+		return strings.Join(names, ", ")
+	}},
+	{Name: "Maintainers", Value: func(tr *TeamReport) string {
+		if len(tr.Maintainers) == 0 {
+			return "N/A"
+		}
+		var logins []string
+		for _, m := range tr.Maintainers {
+			logins = append(logins, m.GetLogin())
+		}
+		return strings.Join(logins, ", ")
+	}},
+	{Name: "Repositories", Value: func(tr *TeamReport) string {
+		if len(tr.Repositories) == 0 {
+			return "N/A"
+		}
+		var repos []string
+		for _, a := range tr.Repositories {
+			repos = append(repos, fmt.Sprintf("%s:%s", a.Repository, a.Permission))
+		}
+		return strings.Join(repos, ", ")
+	}},
+	{Name: "Inherited Repositories", Value: func(tr *TeamReport) string {
+		if len(tr.InheritedRepositories) == 0 {
+			return "N/A"
+		}
+		var repos []string
+		for _, a := range tr.InheritedRepositories {
+			repos = append(repos, fmt.Sprintf("%s:%s (from %s)", a.Repository, a.Permission, a.Source))
+		}
+		return strings.Join(repos, ", ")
+	}},
+	{Name: "Repository Count", Value: func(tr *TeamReport) string {
+		return fmt.Sprintf("%d", len(tr.Repositories)+len(tr.InheritedRepositories))
+	}},
+	statusColumn[*TeamReport](),
+}
+
+// defaultTeamColumns is the column layout written when no columns are configured.
+var defaultTeamColumns = []string{
+	"Team ID", "Owner", "Team Name", "Team Slug", "Parent Team", "Child Teams", "Privacy", "Notification Setting",
+	"External Group", "IdP Groups", "Maintainers", "Members", "Repositories", "Inherited Repositories",
+	"Status",
+}
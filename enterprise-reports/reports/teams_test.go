@@ -25,7 +25,7 @@ import (
 // returns an error when given an invalid output file path.
 func TestTeamsReport_FileCreationError(t *testing.T) {
 	cache := utils.NewSharedCache()
-	err := TeamsReport(context.Background(), nil, nil, "ent", "/no/such/dir/out.csv", 1, cache)
+	err := TeamsReport(context.Background(), nil, nil, "ent", "/no/such/dir/out.csv", 1, cache, Options{})
 	require.Error(t, err)
 }
 
@@ -39,7 +39,7 @@ func TestTeamsReport_GraphQLFetchError(t *testing.T) {
 
 	graphClient := githubv4.NewEnterpriseClient(srv.URL+"/graphql", srv.Client())
 	cache := utils.NewSharedCache()
-	err := TeamsReport(context.Background(), nil, graphClient, "ent", filepath.Join(t.TempDir(), "out.csv"), 1, cache)
+	err := TeamsReport(context.Background(), nil, graphClient, "ent", filepath.Join(t.TempDir(), "out.csv"), 1, cache, Options{})
 	require.Error(t, err)
 }
 
@@ -61,7 +61,7 @@ func TestTeamsReport_NoTeams(t *testing.T) {
 
 	out := filepath.Join(t.TempDir(), "out.csv")
 	cache := utils.NewSharedCache()
-	err := TeamsReport(context.Background(), restClient, graphClient, "ent", out, 1, cache)
+	err := TeamsReport(context.Background(), restClient, graphClient, "ent", out, 1, cache, Options{})
 	require.NoError(t, err)
 
 	data, err := os.ReadFile(out)
@@ -69,7 +69,7 @@ func TestTeamsReport_NoTeams(t *testing.T) {
 	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
 	require.Len(t, lines, 1)
 	assert.Equal(t,
-		"Team ID,Owner,Team Name,Team Slug,External Group,Members",
+		"Team ID,Owner,Team Name,Team Slug,Parent Team,Child Teams,Privacy,Notification Setting,External Group,IdP Groups,Maintainers,Members,Repositories,Inherited Repositories,Status",
 		lines[0],
 	)
 }
@@ -85,11 +85,19 @@ func TestTeamsReport_SingleTeam(t *testing.T) {
 	})
 	// REST: list teams for org
 	mux.HandleFunc("/orgs/org1/teams", func(w http.ResponseWriter, r *http.Request) {
-		_, _ = fmt.Fprintln(w, `[{"id":1,"slug":"team1","name":"Team One"}]`)
+		_, _ = fmt.Fprintln(w, `[{"id":1,"slug":"team1","name":"Team One","privacy":"closed","notification_setting":"notifications_enabled"}]`)
 	})
-	// REST: list members by slug
+	// REST: list members by slug; user2 is a maintainer
 	mux.HandleFunc("/orgs/org1/teams/team1/members", func(w http.ResponseWriter, r *http.Request) {
-		_, _ = fmt.Fprintln(w, `[{"login":"user1"}]`)
+		if r.URL.Query().Get("role") == "maintainer" {
+			_, _ = fmt.Fprintln(w, `[{"login":"user2"}]`)
+			return
+		}
+		_, _ = fmt.Fprintln(w, `[{"login":"user1"},{"login":"user2"}]`)
+	})
+	// REST: list team repositories
+	mux.HandleFunc("/orgs/org1/teams/team1/repos", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintln(w, `[{"full_name":"org1/repo1","permissions":{"admin":false,"maintain":false,"push":true,"triage":true,"pull":true}}]`)
 	})
 	// REST: list external groups
 	mux.HandleFunc("/orgs/org1/teams/team1/external-groups", func(w http.ResponseWriter, r *http.Request) {
@@ -107,7 +115,7 @@ func TestTeamsReport_SingleTeam(t *testing.T) {
 
 	out := filepath.Join(t.TempDir(), "out.csv")
 	cache := utils.NewSharedCache()
-	err := TeamsReport(context.Background(), restClient, graphClient, "ent", out, 1, cache)
+	err := TeamsReport(context.Background(), restClient, graphClient, "ent", out, 1, cache, Options{})
 	require.NoError(t, err)
 
 	data, err := os.ReadFile(out)
@@ -116,18 +124,81 @@ func TestTeamsReport_SingleTeam(t *testing.T) {
 	require.Len(t, lines, 2)
 	// header
 	assert.Equal(t,
-		"Team ID,Owner,Team Name,Team Slug,External Group,Members",
+		"Team ID,Owner,Team Name,Team Slug,Parent Team,Child Teams,Privacy,Notification Setting,External Group,IdP Groups,Maintainers,Members,Repositories,Inherited Repositories,Status",
 		lines[0],
 	)
 	// record
 	r := csv.NewReader(strings.NewReader(lines[1]))
 	record, err := r.Read()
 	require.NoError(t, err)
-	require.Len(t, record, 6)
-	assert.Equal(t, "1", record[0])
-	assert.Equal(t, "org1", record[1])
-	assert.Equal(t, "Team One", record[2])
-	assert.Equal(t, "team1", record[3])
-	assert.Equal(t, "groupX", record[4])
-	assert.Equal(t, "user1", record[5])
+	assert.Equal(t, []string{
+		"1", "org1", "Team One", "team1", "N/A", "N/A", "closed", "notifications_enabled",
+		"groupX", "N/A", "user2", "user1, user2", "org1/repo1:push", "N/A", "ok",
+	}, record)
+}
+
+// TestTeamsReport_Hierarchy tests that the TeamsReport function reports parent and child teams,
+// team sync groups, and repository access a nested team inherits from its ancestors.
+func TestTeamsReport_Hierarchy(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org1/teams", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintln(w, `[{"id":1,"slug":"eng","name":"Eng"},`+
+			`{"id":2,"slug":"platform","name":"Platform","parent":{"id":1,"slug":"eng"}},`+
+			`{"id":3,"slug":"sre","name":"SRE","parent":{"id":2,"slug":"platform"}}]`)
+	})
+	repos := map[string]string{
+		"eng":      `[{"full_name":"org1/app","permissions":{"pull":true}},{"full_name":"org1/infra","permissions":{"admin":true,"pull":true}}]`,
+		"platform": `[{"full_name":"org1/app","permissions":{"push":true,"pull":true}}]`,
+		"sre":      `[]`,
+	}
+	var repoCalls int
+	for slug, body := range repos {
+		mux.HandleFunc("/orgs/org1/teams/"+slug+"/repos", func(w http.ResponseWriter, r *http.Request) {
+			repoCalls++
+			_, _ = fmt.Fprintln(w, body)
+		})
+		mux.HandleFunc("/orgs/org1/teams/"+slug+"/members", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintln(w, `[]`)
+		})
+		mux.HandleFunc("/orgs/org1/teams/"+slug+"/external-groups", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintln(w, `{"groups":[]}`)
+		})
+		mux.HandleFunc("/orgs/org1/teams/"+slug+"/team-sync/group-mappings", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"groups":[{"group_id":"1","group_name":"idp-%s"}]}`, slug)
+		})
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	restClient := github.NewClient(srv.Client())
+	baseURL, _ := url.Parse(srv.URL + "/")
+	restClient.BaseURL = baseURL
+
+	// Organizations come from the cache, so no GraphQL client is needed
+	cache := utils.NewSharedCache()
+	cache.SetEnterpriseOrgs([]*github.Organization{{Login: github.Ptr("org1")}})
+
+	out := filepath.Join(t.TempDir(), "out.csv")
+	opts := Options{Columns: []ColumnSpec{
+		{Name: "Team Slug"}, {Name: "Parent Team"}, {Name: "Child Teams"}, {Name: "IdP Groups"},
+		{Name: "Repositories"}, {Name: "Inherited Repositories"},
+	}}
+	err := TeamsReport(context.Background(), restClient, nil, "ent", out, 1, cache, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 3, repoCalls, "each team's repositories should be fetched once")
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	bySlug := make(map[string][]string)
+	for _, rec := range records[1:] {
+		bySlug[rec[0]] = rec
+	}
+	assert.Equal(t, []string{"eng", "N/A", "platform", "idp-eng", "org1/app:pull, org1/infra:admin", "N/A"}, bySlug["eng"])
+	// platform's own push on app beats the inherited pull
+	assert.Equal(t, []string{"platform", "eng", "sre", "idp-platform", "org1/app:push", "org1/infra:admin (from eng)"}, bySlug["platform"])
+	assert.Equal(t, []string{"sre", "platform", "N/A", "idp-sre", "N/A", "org1/app:push (from platform), org1/infra:admin (from eng)"}, bySlug["sre"])
 }
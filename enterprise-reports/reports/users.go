@@ -6,6 +6,7 @@ package reports
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"log/slog"
@@ -21,13 +22,18 @@ import (
 // about their activity status, including their last login time and dormancy flag.
 type UserReport struct {
 	*github.User
-	LastLogin time.Time // Last login time within the last 90 days
-	Dormant   bool      // Whether the user is considered dormant
+	LastLogin     time.Time // Last login time within the dormancy window
+	Dormant       bool      // Whether the user is considered dormant
+	Undetermined  bool      // Whether activity that could not be read leaves dormancy unknown
+	ActivityScore float64   // Summed weight of the signals active within the dormancy window
+	LastSignal    string    // Dormancy signal that last showed activity
+	LastActivity  time.Time // When LastSignal last showed activity
+	ItemStatus              // Email and activity sources that could not be read
 }
 
 // UsersReport creates a CSV report containing enterprise user details, including email and dormant status.
 // It fetches all enterprise users, their email addresses, last login times, and determines dormancy
-// based on login activity, contributions, and events within the inactivity threshold (90 days).
+// from the weighted activity signals of the dormancy policy in opts (90 days of any activity by default).
 //
 // Parameters:
 //   - ctx: Context for cancellation and timeout
@@ -36,12 +42,20 @@ type UserReport struct {
 //   - enterpriseSlug: Enterprise identifier
 //   - filename: Output CSV file path
 //   - workerCount: Number of concurrent workers for processing users
+//   - opts: Report options, such as the columns to write
 //
 // The report includes user ID, login name, display name, email address, last login time,
-// and dormancy status.
-func UsersReport(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache) error {
+// dormancy status, and the signal that last showed activity by default;
+// opts.Columns can select from userColumns instead.
+func UsersReport(ctx context.Context, restClient *github.Client, graphQLClient *githubv4.Client, enterpriseSlug, filename string, workerCount int, cache *utils.SharedCache, opts Options) error {
 	slog.Info("starting users report", "enterprise", enterpriseSlug, "filename", filename, "workers", workerCount)
 
+	header, formatter, err := selectColumns(userColumns, defaultUserColumns, opts.Columns)
+	if err != nil {
+		return fmt.Errorf("users report columns: %w", err)
+	}
+	formatter = recordRows(opts.Rows, formatter)
+
 	// Create appropriate report writer based on file extension
 	reportWriter, reportErr := NewReportWriter(filename)
 	if reportErr != nil {
@@ -53,30 +67,20 @@ func UsersReport(ctx context.Context, restClient *github.Client, graphQLClient *
 		}
 	}()
 
-	header := []string{
-		"ID",
-		"Login",
-		"Name",
-		"Email",
-		"Last Login(90 days)",
-		"Dormant?",
-	}
-
 	// Write header to report
 	if headerErr := reportWriter.WriteHeader(header); headerErr != nil {
 		return fmt.Errorf("failed to write header: %w", headerErr)
 	}
 
-	// Inactivity threshold and fetch user logins
-	const inactivityThreshold = 90 * 24 * time.Hour
-	referenceTime := time.Now().UTC().Add(-inactivityThreshold)
-
-	// Fetch user logins
-	slog.Info("fetching user logins", "enterprise", enterpriseSlug)
-	userLogins, err := api.FetchUserLogins(ctx, restClient, enterpriseSlug, referenceTime)
-	if err != nil {
-		return fmt.Errorf("fetching user logins for enterprise %q: %w", enterpriseSlug, err)
+	policy := opts.Dormancy
+	if policy.IsZero() {
+		policy = utils.DefaultDormancyPolicy()
 	}
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("users report: %w", err)
+	}
+	now := time.Now().UTC()
+	resolver := api.NewActivityResolver(restClient, graphQLClient, enterpriseSlug, policy, now)
 
 	// Check cache for enterprise users or fetch from API
 	var users []*github.User
@@ -87,7 +91,7 @@ func UsersReport(ctx context.Context, restClient *github.Client, graphQLClient *
 	} else {
 		// Fetch enterprise users
 		slog.Info("fetching enterprise users", "enterprise", enterpriseSlug)
-		users, err = api.FetchEnterpriseUsers(ctx, graphQLClient, enterpriseSlug)
+		users, err = api.FetchEnterpriseUsers(ctx, graphQLClient, enterpriseSlug, opts.Server.Deployment())
 		if err != nil {
 			return fmt.Errorf("fetching enterprise users for %q: %w", enterpriseSlug, err)
 		}
@@ -95,69 +99,98 @@ func UsersReport(ctx context.Context, restClient *github.Client, graphQLClient *
 		cache.SetEnterpriseUsers(users)
 	}
 
-	// Processor: fetch email, last login, and dormancy
+	// Fetch the activity sources that are available in bulk before processing users
+	logins := make([]string, len(users))
+	for i, u := range users {
+		logins[i] = u.GetLogin()
+	}
+	if err := resolver.Prefetch(ctx, logins); err != nil {
+		return err
+	}
+
+	// Fetch emails in batches rather than one query per user
+	slog.Info("fetching user emails", "users", len(logins))
+	emails, emailErr := api.FetchUserEmails(ctx, graphQLClient, enterpriseSlug, logins)
+	if emailErr != nil {
+		slog.Warn("failed to fetch user emails, continuing without them", "error", emailErr)
+	}
+
+	// Processor: per-user activity signals and dormancy
 	processor := func(ctx context.Context, u *github.User) (*UserReport, error) {
-		// Email
-		slog.Info("processing user", "login", u.GetLogin())
-		email, err := api.FetchUserEmail(ctx, graphQLClient, enterpriseSlug, u.GetLogin())
-		if err != nil {
-			slog.Debug("failed to fetch email", "user", u.GetLogin(), "error", err)
-			// Continue without email
-			email = ""
+		slog.Debug("processing user", "login", u.GetLogin())
+		report := &UserReport{User: u}
+		email, found := emails[u.GetLogin()] // Users whose lookup failed are left out
+		if !found {
+			err := emailErr
+			if err == nil {
+				err = fmt.Errorf("email lookup for %q failed", u.GetLogin())
+			}
+			report.fail(ctx, u.GetLogin(), "email", err)
 		}
-		// Last login
-		lastLogin := userLogins[u.GetLogin()]
-		recentLogin := lastLogin.After(referenceTime)
 
-		// Recent activity checks
-		recentEvents, err := api.HasRecentEvents(ctx, restClient, u.GetLogin(), referenceTime)
-		if err != nil {
-			slog.Debug("failed to fetch recent events", "user", u.GetLogin(), "error", err)
-			recentEvents = false // Default to false if error occurs
-		}
-		recentContributions, err := api.HasRecentContributions(ctx, graphQLClient, u.GetLogin(), referenceTime)
-		if err != nil {
-			slog.Debug("failed to fetch recent contributions", "user", u.GetLogin(), "error", err)
-			recentContributions = false // Default to false if error occurs
+		// Most recent activity per signal, and the sources that could not be read
+		activity, signalErrs := resolver.Resolve(ctx, u.GetLogin())
+		for _, signalErr := range signalErrs {
+			report.fail(ctx, u.GetLogin(), string(signalErr.Source)+" activity", signalErr.Err)
 		}
 
 		// Dormancy check
-		dormant, err := utils.IsDormant(u.GetLogin(), recentEvents, recentContributions, recentLogin)
-		if err != nil {
-			slog.Debug("dormancy check failed", "user", u.GetLogin(), "error", err)
-			dormant = false // Default to false if error occurs
-		}
+		result := policy.Evaluate(u.GetLogin(), activity, api.UnknownSignals(signalErrs), now)
+		cache.SetUserDormancy(u.GetLogin(), result)
 		u.Email = &email // Set email directly on the User struct
-		report := &UserReport{
-			User:      u,
-			LastLogin: lastLogin,
-			Dormant:   dormant,
-		}
+		report.LastLogin = activity[utils.SignalLogin]
+		report.Dormant = result.Dormant
+		report.Undetermined = result.Undetermined
+		report.ActivityScore = result.Score
+		report.LastSignal = result.LastSignal
+		report.LastActivity = result.LastActivity
 		return report, nil
 	}
 
-	// Formatter: build CSV row
-	formatter := func(r *UserReport) []string {
-		// Use GetEmail() which accesses the embedded User's email field
-		emailStr := r.GetEmail()
-		if emailStr == "" {
-			emailStr = "N/A" // Ensure N/A if email is empty/nil
-		}
-		return []string{
-			fmt.Sprintf("%d", r.GetID()),
-			r.GetLogin(),
-			r.GetName(),
-			emailStr,
-			r.LastLogin.UTC().Format(time.RFC3339),
-			fmt.Sprintf("%t", r.Dormant),
-		}
-	}
-
 	// Create a limiter for rate limiting - aiming for ~10 users/sec
-	// (10 REST points/sec < 15, 20 GQL points/sec < 33)
+	// (one REST events call per user, 10 REST points/sec < 15; emails and contributions are prefetched in batches)
 	// Burst matches worker count for responsiveness.
-	limiter := rate.NewLimiter(rate.Limit(10), workerCount) // e.g., 10 requests/sec, burst of workerCount
+	limiter := rate.NewLimiter(rate.Limit(usersRate), workerCount) // e.g., 10 requests/sec, burst of workerCount
 
 	// Run the report using the new report writer interface
 	return RunReportWithWriter(ctx, users, processor, formatter, limiter, workerCount, reportWriter)
 }
+
+// userColumns lists every column the users report can output.
+var userColumns = []Column[*UserReport]{
+	{Name: "ID", Value: func(r *UserReport) string { return fmt.Sprintf("%d", r.GetID()) }},
+	{Name: "Login", Value: func(r *UserReport) string { return r.GetLogin() }},
+	{Name: "Name", Value: func(r *UserReport) string { return r.GetName() }},
+	{Name: "Email", Value: func(r *UserReport) string {
+		// Use GetEmail() which accesses the embedded User's email field
+		if r.GetEmail() == "" {
+			return "N/A" // Ensure N/A if email is empty/nil
+		}
+		return r.GetEmail()
+	}},
+	{Name: "Created At", Value: func(r *UserReport) string { return r.GetCreatedAt().UTC().Format(time.RFC3339) }},
+	{Name: "Last Login", Value: func(r *UserReport) string { return r.LastLogin.UTC().Format(time.RFC3339) }},
+	{Name: "Dormant?", Value: func(r *UserReport) string {
+		if r.Undetermined {
+			return "unknown"
+		}
+		return fmt.Sprintf("%t", r.Dormant)
+	}},
+	{Name: "Last Activity Signal", Value: func(r *UserReport) string {
+		if r.LastSignal == "" {
+			return "N/A"
+		}
+		return r.LastSignal
+	}},
+	{Name: "Last Activity", Value: func(r *UserReport) string {
+		if r.LastActivity.IsZero() {
+			return "N/A"
+		}
+		return r.LastActivity.UTC().Format(time.RFC3339)
+	}},
+	{Name: "Activity Score", Value: func(r *UserReport) string { return strconv.FormatFloat(r.ActivityScore, 'f', -1, 64) }},
+	statusColumn[*UserReport](),
+}
+
+// defaultUserColumns is the column layout written when no columns are configured.
+var defaultUserColumns = []string{"ID", "Login", "Name", "Email", "Last Login", "Dormant?", "Last Activity Signal", "Last Activity", "Status"}
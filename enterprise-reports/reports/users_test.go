@@ -5,16 +5,19 @@ package reports
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-github/v70/github"
+	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/fakegithub"
 	"github.com/kuhlman-labs/gh-enterprise-reports/enterprise-reports/utils"
 	"github.com/shurcooL/githubv4"
 	"github.com/stretchr/testify/assert"
@@ -25,7 +28,7 @@ import (
 // returns an error when given an invalid output file path.
 func TestUsersReport_FileCreationError(t *testing.T) {
 	cache := utils.NewSharedCache()
-	err := UsersReport(context.Background(), nil, nil, "ent", "/no/such/dir/out.csv", 1, cache)
+	err := UsersReport(context.Background(), nil, nil, "ent", "/no/such/dir/out.csv", 1, cache, Options{})
 	require.Error(t, err)
 }
 
@@ -59,7 +62,7 @@ func TestUsersReport_NoUsers(t *testing.T) {
 
 	out := filepath.Join(t.TempDir(), "users.csv")
 	cache := utils.NewSharedCache()
-	err := UsersReport(context.Background(), restClient, graphClient, "ent", out, 1, cache)
+	err := UsersReport(context.Background(), restClient, graphClient, "ent", out, 1, cache, Options{})
 	require.NoError(t, err)
 
 	data, err := os.ReadFile(out)
@@ -68,7 +71,7 @@ func TestUsersReport_NoUsers(t *testing.T) {
 	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
 	require.Len(t, lines, 1)
 	assert.Equal(t,
-		"ID,Login,Name,Email,Last Login(90 days),Dormant?",
+		"ID,Login,Name,Email,Last Login,Dormant?,Last Activity Signal,Last Activity,Status",
 		lines[0],
 	)
 }
@@ -78,7 +81,13 @@ func TestUsersReport_NoUsers(t *testing.T) {
 func TestUsersReport_SingleUser(t *testing.T) {
 	muxG := http.NewServeMux()
 	muxG.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
-		if _, err := fmt.Fprintln(w, `{"data":{"enterprise":{"members":{"nodes":[{"login":"user1","name":"User One","createdAt":"2022-01-01T00:00:00Z","user":{"databaseId":1}}],"pageInfo":{"hasNextPage":false,"endCursor":""}}}}}`); err != nil {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		resp := `{"data":{}}` // No email or contributions
+		if strings.Contains(string(body), "members") {
+			resp = `{"data":{"enterprise":{"members":{"nodes":[{"login":"user1","name":"User One","createdAt":"2022-01-01T00:00:00Z","user":{"databaseId":1}}],"pageInfo":{"hasNextPage":false,"endCursor":""}}}}}`
+		}
+		if _, err := fmt.Fprintln(w, resp); err != nil {
 			t.Fatalf("failed to write response: %v", err)
 		}
 	})
@@ -93,6 +102,11 @@ func TestUsersReport_SingleUser(t *testing.T) {
 			t.Fatalf("failed to write response: %v", err)
 		}
 	})
+	muxR.HandleFunc("/users/user1/events", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := fmt.Fprint(w, `[]`); err != nil {
+			t.Fatalf("failed to write response: %v", err)
+		}
+	})
 	rSrv := httptest.NewServer(muxR)
 	defer rSrv.Close()
 
@@ -105,7 +119,7 @@ func TestUsersReport_SingleUser(t *testing.T) {
 	// run report
 	out := filepath.Join(t.TempDir(), "users.csv")
 	cache := utils.NewSharedCache()
-	err := UsersReport(context.Background(), restClient, graphClient, "ent", out, 1, cache)
+	err := UsersReport(context.Background(), restClient, graphClient, "ent", out, 1, cache, Options{})
 	require.NoError(t, err)
 
 	// verify CSV contents
@@ -113,7 +127,164 @@ func TestUsersReport_SingleUser(t *testing.T) {
 	require.NoError(t, err)
 	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
 	require.Len(t, lines, 2)
-	assert.Equal(t, "ID,Login,Name,Email,Last Login(90 days),Dormant?", lines[0])
-	expected := fmt.Sprintf("1,user1,User One,N/A,%s,false", now)
+	assert.Equal(t, "ID,Login,Name,Email,Last Login,Dormant?,Last Activity Signal,Last Activity,Status", lines[0])
+	expected := fmt.Sprintf("1,user1,User One,N/A,%s,false,login,%s,ok", now, now)
 	assert.Equal(t, expected, lines[1])
 }
+
+// TestUsersReport_DormancyPolicy tests that the users report applies a configured dormancy
+// policy, weighting audit-log signals and reporting the signal that last showed activity.
+func TestUsersReport_DormancyPolicy(t *testing.T) {
+	muxG := http.NewServeMux()
+	muxG.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := fmt.Fprintln(w, `{"data":{"enterprise":{"members":{"nodes":[`+
+			`{"login":"user1","name":"User One","createdAt":"2022-01-01T00:00:00Z","user":{"databaseId":1}},`+
+			`{"login":"user2","name":"User Two","createdAt":"2022-01-01T00:00:00Z","user":{"databaseId":2}}],`+
+			`"pageInfo":{"hasNextPage":false,"endCursor":""}}}}}`); err != nil {
+			t.Fatalf("failed to write response: %v", err)
+		}
+	})
+	gSrv := httptest.NewServer(muxG)
+	defer gSrv.Close()
+
+	// user1 pushed with a token 10 days ago; both users last logged in 40 days ago
+	pushed := time.Now().UTC().Add(-10 * 24 * time.Hour).Format(time.RFC3339)
+	loggedIn := time.Now().UTC().Add(-40 * 24 * time.Hour).Format(time.RFC3339)
+	muxR := http.NewServeMux()
+	muxR.HandleFunc("/enterprises/ent/audit-log", func(w http.ResponseWriter, r *http.Request) {
+		phrase := r.URL.Query().Get("phrase")
+		if strings.Contains(phrase, "action:git.") {
+			assert.Equal(t, "git", r.URL.Query().Get("include"))
+		} else {
+			assert.Equal(t, "web", r.URL.Query().Get("include"))
+		}
+		var body string
+		switch {
+		case strings.Contains(phrase, "action:git.push"):
+			body = fmt.Sprintf(`[{"action":"git.push","actor":"user1","created_at":"%s","hashed_token":"abc"}]`, pushed)
+		case strings.Contains(phrase, "action:user.login"):
+			body = fmt.Sprintf(`[{"action":"user.login","actor":"user1","created_at":"%[1]s"},{"action":"user.login","actor":"user2","created_at":"%[1]s"}]`, loggedIn)
+		default:
+			body = `[]`
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatalf("failed to write response: %v", err)
+		}
+	})
+	rSrv := httptest.NewServer(muxR)
+	defer rSrv.Close()
+
+	restClient := github.NewClient(rSrv.Client())
+	baseURL, _ := url.Parse(rSrv.URL + "/")
+	restClient.BaseURL = baseURL
+	graphClient := githubv4.NewEnterpriseClient(gSrv.URL+"/graphql", gSrv.Client())
+
+	signals := utils.DefaultDormancySignals()[:4] // login, git, pull-request-review, token
+	opts := Options{
+		Columns: []ColumnSpec{{Name: "Login"}, {Name: "Dormant?"}, {Name: "Last Activity Signal"}, {Name: "Activity Score"}},
+		Dormancy: utils.DormancyPolicy{
+			Window:    30 * 24 * time.Hour,
+			Threshold: 2,
+			Signals:   signals,
+		},
+	}
+
+	out := filepath.Join(t.TempDir(), "users.csv")
+	err := UsersReport(context.Background(), restClient, graphClient, "ent", out, 1, utils.NewSharedCache(), opts)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "Login,Dormant?,Last Activity Signal,Activity Score", lines[0])
+	rows := lines[1:]
+	sort.Strings(rows)
+	// git and token count within the window; the login is too old to count but is still reported
+	assert.Equal(t, "user1,false,git,2", rows[0])
+	assert.Equal(t, "user2,true,login,0", rows[1])
+}
+
+// TestUsersReport_PrivateContributions tests that a user whose only recent activity is restricted
+// contributions to private repositories is not reported as dormant.
+func TestUsersReport_PrivateContributions(t *testing.T) {
+	today := time.Now().UTC().Format("2006-01-02")
+	muxG := http.NewServeMux()
+	muxG.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var resp string
+		switch {
+		case strings.Contains(string(body), "members"):
+			resp = `{"data":{"enterprise":{"members":{"nodes":[{"login":"user1","name":"User One","createdAt":"2022-01-01T00:00:00Z","user":{"databaseId":1}}],"pageInfo":{"hasNextPage":false,"endCursor":""}}}}}`
+		case strings.Contains(string(body), "contributionsCollection"):
+			resp = fmt.Sprintf(`{"data":{"u0":{"contributionsCollection":{"latestRestrictedContributionDate":%q,"contributionCalendar":{"weeks":[]}}}}}`, today)
+		default:
+			resp = `{"data":{}}`
+		}
+		if _, err := fmt.Fprintln(w, resp); err != nil {
+			t.Fatalf("failed to write response: %v", err)
+		}
+	})
+	gSrv := httptest.NewServer(muxG)
+	defer gSrv.Close()
+	graphClient := githubv4.NewEnterpriseClient(gSrv.URL+"/graphql", gSrv.Client())
+
+	// Only the contributions signal is enabled, so no REST calls are made
+	policy := utils.DefaultDormancyPolicy()
+	for i := range policy.Signals {
+		if policy.Signals[i].Name != utils.SignalContributions {
+			policy.Signals[i].Weight = 0
+		}
+	}
+	opts := Options{
+		Columns:  []ColumnSpec{{Name: "Login"}, {Name: "Dormant?"}, {Name: "Last Activity Signal"}, {Name: "Last Activity"}},
+		Dormancy: policy,
+	}
+
+	out := filepath.Join(t.TempDir(), "users.csv")
+	err := UsersReport(context.Background(), github.NewClient(nil), graphClient, "ent", out, 1, utils.NewSharedCache(), opts)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, fmt.Sprintf("user1,false,contributions,%sT00:00:00Z", today), lines[1])
+}
+
+// TestUsersReport_EventsFailure tests that a user whose events cannot be read is reported with an
+// unknown dormancy rather than as dormant, and the failure recorded in the Status column and errors file.
+func TestUsersReport_EventsFailure(t *testing.T) {
+	srv := startDemoServer(t)
+	out := filepath.Join(t.TempDir(), "users.csv")
+	opts := Options{Columns: []ColumnSpec{{Name: "Login"}, {Name: "Dormant?"}, {Name: "Status"}}}
+
+	require.NoError(t, UsersReport(context.Background(), srv.RESTClient(), srv.GraphQLClient(), "octodemo", out, 2, utils.NewSharedCache(), opts))
+	assert.Contains(t, reportLines(t, out), "dormant-dan,true,ok")
+
+	srv.Inject(fakegithub.Fault{Path: "/users/dormant-dan/events", Status: http.StatusBadGateway})
+	errorLog := NewErrorLog("users", out)
+	ctx := WithErrorLog(context.Background(), errorLog)
+	require.NoError(t, UsersReport(ctx, srv.RESTClient(), srv.GraphQLClient(), "octodemo", out, 2, utils.NewSharedCache(), opts))
+	require.NoError(t, errorLog.Close())
+	assert.Contains(t, reportLines(t, out), "dormant-dan,unknown,failed: events activity")
+
+	entries := readItemErrors(t, ErrorsPath(out))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "dormant-dan", entries[0].Item)
+	assert.Equal(t, "events activity", entries[0].Field)
+	assert.Equal(t, http.StatusBadGateway, entries[0].Status)
+}
+
+// TestUsersReport_EmailFailure tests that users whose email cannot be looked up are marked in the Status column.
+func TestUsersReport_EmailFailure(t *testing.T) {
+	srv := startDemoServer(t)
+	srv.Inject(fakegithub.Fault{Path: "/graphql", Query: "externalIdentities", Status: http.StatusBadGateway})
+	out := filepath.Join(t.TempDir(), "users.csv")
+	opts := Options{Columns: []ColumnSpec{{Name: "Login"}, {Name: "Email"}, {Name: "Status"}}}
+
+	require.NoError(t, UsersReport(context.Background(), srv.RESTClient(), srv.GraphQLClient(), "octodemo", out, 2, utils.NewSharedCache(), opts))
+	assert.Contains(t, reportLines(t, out), "mona,N/A,failed: email")
+}
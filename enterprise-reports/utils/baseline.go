@@ -0,0 +1,77 @@
+// Package utils provides utility functions and types for the GitHub Enterprise Reports application.
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Ordered values of the baseline settings that limit how permissive an organization may be,
+// from least to most permissive.
+var (
+	RepositoryPermissionLevels = []string{"none", "read", "write", "admin"}
+	AllowedActionsLevels       = []string{"local_only", "selected", "all"}
+	WorkflowPermissionLevels   = []string{"read", "write"}
+)
+
+// OrgBaseline declares the organization settings an enterprise expects every organization to meet.
+// Nil and empty fields are not checked.
+//
+// Requirement settings, such as TwoFactorRequired, are only enforced when set to true: an organization
+// that requires more than the baseline does not violate it. Likewise, permissions such as
+// MembersCanCreatePublicRepos are only enforced when set to false, and the level settings name the
+// most permissive value allowed.
+type OrgBaseline struct {
+	TwoFactorRequired             *bool
+	DefaultRepositoryPermission   string // Most permissive default repository permission; see RepositoryPermissionLevels
+	MembersCanCreatePublicRepos   *bool
+	MembersCanCreatePrivateRepos  *bool
+	MembersCanCreateInternalRepos *bool
+	MembersCanForkPrivateRepos    *bool
+	AllowedActions                string // Most permissive actions policy; see AllowedActionsLevels
+	DefaultWorkflowPermissions    string // Most permissive GITHUB_TOKEN permissions; see WorkflowPermissionLevels
+	ActionsCanApprovePullRequests *bool
+	AdvancedSecurityForNewRepos   *bool
+	SecretScanningForNewRepos     *bool
+	PushProtectionForNewRepos     *bool
+	DependabotAlertsForNewRepos   *bool
+	WebCommitSignoffRequired      *bool
+	IPAllowListEnabled            *bool
+	SSORequired                   *bool
+}
+
+// IsZero reports whether the baseline checks nothing.
+func (b OrgBaseline) IsZero() bool {
+	return b == OrgBaseline{}
+}
+
+// Validate checks that the level settings name known values.
+func (b OrgBaseline) Validate() error {
+	var errs []string
+	for _, level := range []struct {
+		name   string
+		value  string
+		levels []string
+	}{
+		{"default-repository-permission", b.DefaultRepositoryPermission, RepositoryPermissionLevels},
+		{"allowed-actions", b.AllowedActions, AllowedActionsLevels},
+		{"default-workflow-permissions", b.DefaultWorkflowPermissions, WorkflowPermissionLevels},
+	} {
+		if level.value != "" && !slices.Contains(level.levels, level.value) {
+			errs = append(errs, fmt.Sprintf("%s must be one of %s, got %q", level.name, strings.Join(level.levels, ", "), level.value))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid organization baseline: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ExceedsLevel reports whether value is more permissive than limit within levels.
+// Values that are not listed in levels are treated as exceeding the limit.
+func ExceedsLevel(levels []string, value, limit string) bool {
+	v := slices.Index(levels, value)
+	return v < 0 || v > slices.Index(levels, limit)
+}
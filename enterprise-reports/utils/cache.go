@@ -19,6 +19,9 @@ type SharedCache struct {
 	repoTeams              map[string][]*github.Team
 	repoCollaborators      map[string][]*github.User
 	teamMembers            map[string][]*github.User
+	teamExternalGroups     map[string]*github.ExternalGroupList
+	userDormancy           map[string]DormancyResult
+	teamRepositories       map[string][]*github.Repository
 	enterpriseOrgsFetched  bool
 	enterpriseUsersFetched bool
 }
@@ -26,12 +29,15 @@ type SharedCache struct {
 // NewSharedCache creates a new shared cache for GitHub data.
 func NewSharedCache() *SharedCache {
 	return &SharedCache{
-		orgRepositories:   make(map[string][]*github.Repository),
-		orgMembers:        make(map[string][]*github.User),
-		orgTeams:          make(map[string][]*github.Team),
-		repoTeams:         make(map[string][]*github.Team),
-		repoCollaborators: make(map[string][]*github.User),
-		teamMembers:       make(map[string][]*github.User),
+		orgRepositories:    make(map[string][]*github.Repository),
+		orgMembers:         make(map[string][]*github.User),
+		orgTeams:           make(map[string][]*github.Team),
+		repoTeams:          make(map[string][]*github.Team),
+		repoCollaborators:  make(map[string][]*github.User),
+		teamMembers:        make(map[string][]*github.User),
+		teamExternalGroups: make(map[string]*github.ExternalGroupList),
+		userDormancy:       make(map[string]DormancyResult),
+		teamRepositories:   make(map[string][]*github.Repository),
 	}
 }
 
@@ -154,3 +160,48 @@ func (c *SharedCache) SetTeamMembers(teamKey string, members []*github.User) {
 	defer c.mu.Unlock()
 	c.teamMembers[teamKey] = members
 }
+
+// GetTeamExternalGroups returns cached external groups for a team or false if not cached
+func (c *SharedCache) GetTeamExternalGroups(teamKey string) (*github.ExternalGroupList, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	groups, exists := c.teamExternalGroups[teamKey]
+	return groups, exists
+}
+
+// SetTeamExternalGroups caches external groups for a team
+func (c *SharedCache) SetTeamExternalGroups(teamKey string, groups *github.ExternalGroupList) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.teamExternalGroups[teamKey] = groups
+}
+
+// GetUserDormancy returns the cached dormancy result for a user or false if not cached
+func (c *SharedCache) GetUserDormancy(login string) (DormancyResult, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	result, exists := c.userDormancy[login]
+	return result, exists
+}
+
+// SetUserDormancy caches the dormancy result for a user
+func (c *SharedCache) SetUserDormancy(login string, result DormancyResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.userDormancy[login] = result
+}
+
+// GetTeamRepositories returns cached repositories a team has direct access to or false if not cached
+func (c *SharedCache) GetTeamRepositories(teamKey string) ([]*github.Repository, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	repos, exists := c.teamRepositories[teamKey]
+	return repos, exists
+}
+
+// SetTeamRepositories caches the repositories a team has direct access to
+func (c *SharedCache) SetTeamRepositories(teamKey string, repos []*github.Repository) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.teamRepositories[teamKey] = repos
+}
@@ -0,0 +1,241 @@
+// Package utils provides utility functions and types for the GitHub Enterprise Reports application.
+package utils
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SignalSource identifies where the activity behind a dormancy signal is read from.
+type SignalSource string
+
+const (
+	// SourceAuditLog reads activity from enterprise audit-log entries matching the signal's actions.
+	SourceAuditLog SignalSource = "audit-log"
+	// SourceEvents reads the most recent event from the user's event stream.
+	SourceEvents SignalSource = "events"
+	// SourceContributions reads the most recent day with contributions from the user's contribution calendar.
+	SourceContributions SignalSource = "contributions"
+	// SourceCopilot reads the last activity recorded on the user's Copilot seat.
+	SourceCopilot SignalSource = "copilot"
+)
+
+// Built-in dormancy signal names.
+const (
+	SignalLogin             = "login"
+	SignalGit               = "git"
+	SignalPullRequestReview = "pull-request-review"
+	SignalToken             = "token"
+	SignalCopilot           = "copilot"
+	SignalEvents            = "events"
+	SignalContributions     = "contributions"
+)
+
+// DefaultDormancyWindow is the look-back window used when none is configured.
+const DefaultDormancyWindow = 90 * 24 * time.Hour
+
+// GitEventRetention is how long the audit log keeps git events, such as git.push and git.clone.
+// Other audit log events are kept for months.
+const GitEventRetention = 7 * 24 * time.Hour
+
+// ActivitySignal is a single source of evidence that a user is active.
+// A signal counts towards a user's activity score with its Weight when the
+// user shows activity for it within the policy window.
+type ActivitySignal struct {
+	Name   string
+	Weight float64
+	Source SignalSource
+	// Actions lists the audit-log actions that count for SourceAuditLog signals.
+	Actions []string
+	// TokenOnly restricts an audit-log signal to entries performed with an access token.
+	TokenOnly bool
+}
+
+// DefaultDormancySignals returns the built-in signals, each with a weight of 1.
+// Audit-log git events require the audit log to be queried with git events included, and are
+// only kept for GitEventRetention, so the git signal, and the token signal for git activity,
+// cannot see further back. The token signal counts any matched audit-log entry that was
+// authenticated with a token.
+func DefaultDormancySignals() []ActivitySignal {
+	return []ActivitySignal{
+		{Name: SignalLogin, Weight: 1, Source: SourceAuditLog, Actions: []string{"user.login"}},
+		{Name: SignalGit, Weight: 1, Source: SourceAuditLog, Actions: []string{"git.push", "git.clone", "git.fetch"}},
+		{Name: SignalPullRequestReview, Weight: 1, Source: SourceAuditLog, Actions: []string{"pull_request_review.submit", "pull_request_review_comment.create"}},
+		{Name: SignalToken, Weight: 1, Source: SourceAuditLog, Actions: []string{"git.push", "git.clone", "git.fetch", "pull_request_review.submit", "pull_request_review_comment.create"}, TokenOnly: true},
+		{Name: SignalCopilot, Weight: 1, Source: SourceCopilot},
+		{Name: SignalEvents, Weight: 1, Source: SourceEvents},
+		{Name: SignalContributions, Weight: 1, Source: SourceContributions},
+	}
+}
+
+// DormancyPolicy decides whether a user is dormant from the activity signals observed for them.
+// A user is dormant when the summed weight of signals with activity inside Window is below Threshold.
+// The zero value is not usable; use DefaultDormancyPolicy or populate every field.
+type DormancyPolicy struct {
+	Window    time.Duration
+	Threshold float64
+	Signals   []ActivitySignal
+}
+
+// DefaultDormancyPolicy returns the policy used when no dormancy settings are configured:
+// a 90-day window in which activity on any built-in signal keeps a user active.
+func DefaultDormancyPolicy() DormancyPolicy {
+	return DormancyPolicy{
+		Window:    DefaultDormancyWindow,
+		Threshold: 1,
+		Signals:   DefaultDormancySignals(),
+	}
+}
+
+// IsZero reports whether the policy has not been configured.
+func (p DormancyPolicy) IsZero() bool {
+	return p.Window == 0 && p.Threshold == 0 && len(p.Signals) == 0
+}
+
+// Validate checks that the policy is internally consistent.
+func (p DormancyPolicy) Validate() error {
+	var errs []string
+
+	if p.Window <= 0 {
+		errs = append(errs, "window must be positive")
+	}
+	if p.Threshold <= 0 {
+		errs = append(errs, "threshold must be positive")
+	}
+
+	seen := make(map[string]bool, len(p.Signals))
+	var total float64
+	for _, s := range p.Signals {
+		if s.Name == "" {
+			errs = append(errs, "signal name is required")
+			continue
+		}
+		if seen[s.Name] {
+			errs = append(errs, fmt.Sprintf("signal %q is defined more than once", s.Name))
+		}
+		seen[s.Name] = true
+		if s.Weight < 0 {
+			errs = append(errs, fmt.Sprintf("signal %q has a negative weight", s.Name))
+		}
+		if s.Source == SourceAuditLog && len(s.Actions) == 0 {
+			errs = append(errs, fmt.Sprintf("signal %q needs at least one audit-log action", s.Name))
+		}
+		total += s.Weight
+	}
+
+	if p.Threshold > 0 && total < p.Threshold {
+		errs = append(errs, fmt.Sprintf("signal weights add up to %g, below the threshold of %g: every user would be dormant", total, p.Threshold))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid dormancy policy: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Enabled returns the signals with a positive weight, optionally limited to the given source.
+// Passing an empty source returns every enabled signal.
+func (p DormancyPolicy) Enabled(source SignalSource) []ActivitySignal {
+	var signals []ActivitySignal
+	for _, s := range p.Signals {
+		if s.Weight <= 0 {
+			continue
+		}
+		if source != "" && s.Source != source {
+			continue
+		}
+		signals = append(signals, s)
+	}
+	return signals
+}
+
+// RetentionLimited returns the names of the enabled audit-log signals that read git events when the
+// window is longer than GitEventRetention. They can only show activity from the last seven days of
+// the window, so a user active on them earlier in the window is not seen.
+func (p DormancyPolicy) RetentionLimited() []string {
+	if p.Window <= GitEventRetention {
+		return nil
+	}
+	var names []string
+	for _, s := range p.Enabled(SourceAuditLog) {
+		if slices.ContainsFunc(s.Actions, func(a string) bool { return strings.HasPrefix(a, "git.") }) {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// AuditLogActions returns the distinct audit-log actions needed by the enabled signals, sorted.
+func (p DormancyPolicy) AuditLogActions() []string {
+	set := make(map[string]bool)
+	for _, s := range p.Enabled(SourceAuditLog) {
+		for _, a := range s.Actions {
+			set[a] = true
+		}
+	}
+	actions := make([]string, 0, len(set))
+	for a := range set {
+		actions = append(actions, a)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// DormancyResult explains a dormancy decision.
+type DormancyResult struct {
+	Dormant      bool
+	Undetermined bool      // Whether the unknown signals could decide the result, in which case Dormant is false
+	Unknown      []string  // Enabled signals whose activity could not be read
+	Score        float64   // Summed weight of signals active within the window
+	LastSignal   string    // Name of the signal with the most recent activity, if any
+	LastActivity time.Time // Time of the most recent activity across enabled signals
+}
+
+// Evaluate scores the last activity time observed per signal name against the policy.
+// Signals missing from activity, or whose activity is older than the window, do not count.
+// Signals listed in unknown could not be read: a user they could make active is undetermined
+// rather than dormant, so a failed lookup never makes a user look dormant.
+func (p DormancyPolicy) Evaluate(user string, activity map[string]time.Time, unknown []string, now time.Time) DormancyResult {
+	since := now.Add(-p.Window)
+
+	var result DormancyResult
+	var unknownWeight float64
+	for _, s := range p.Enabled("") {
+		if slices.Contains(unknown, s.Name) {
+			result.Unknown = append(result.Unknown, s.Name)
+			unknownWeight += s.Weight
+		}
+		t, ok := activity[s.Name]
+		if !ok || t.IsZero() {
+			continue
+		}
+		if t.After(since) {
+			result.Score += s.Weight
+		}
+		if t.After(result.LastActivity) {
+			result.LastActivity = t
+			result.LastSignal = s.Name
+		}
+	}
+	result.Dormant = result.Score < p.Threshold
+	if result.Dormant && result.Score+unknownWeight >= p.Threshold {
+		result.Dormant = false
+		result.Undetermined = true
+	}
+
+	slog.Debug("dormant check result",
+		"user", user,
+		"score", result.Score,
+		"threshold", p.Threshold,
+		"lastSignal", result.LastSignal,
+		"lastActivity", result.LastActivity,
+		"dormant", result.Dormant,
+		"undetermined", result.Undetermined,
+	)
+
+	return result
+}
@@ -2,8 +2,15 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
 	"time"
+
+	"github.com/google/go-github/v70/github"
 )
 
 // ErrorType represents different categories of errors that can occur in the application.
@@ -24,12 +31,80 @@ const (
 	ErrorTypeIO
 )
 
+// errorTypeNames are the names of the error types, as written to error files.
+var errorTypeNames = map[ErrorType]string{
+	ErrorTypeGeneral:   "general",
+	ErrorTypeAPI:       "api",
+	ErrorTypeRateLimit: "rate_limit",
+	ErrorTypeAuth:      "auth",
+	ErrorTypeConfig:    "config",
+	ErrorTypeIO:        "io",
+}
+
+// String returns the name of the error type, e.g. "rate_limit".
+func (t ErrorType) String() string {
+	if name, ok := errorTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("ErrorType(%d)", int(t))
+}
+
+// MarshalText encodes the error type as its name.
+func (t ErrorType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText decodes an error type from its name.
+func (t *ErrorType) UnmarshalText(text []byte) error {
+	for errType, name := range errorTypeNames {
+		if name == string(text) {
+			*t = errType
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown error type %q", text)
+}
+
+// ClassifyError returns the type of an error: the type of the AppError it wraps, or the type
+// matching the GitHub API response, network or file error it wraps.
+func ClassifyError(err error) ErrorType {
+	var appErr *AppError
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	var respErr *github.ErrorResponse
+	var netErr net.Error
+	var pathErr *os.PathError
+	switch {
+	case errors.As(err, &appErr):
+		return appErr.Type
+	case errors.As(err, &rateErr), errors.As(err, &abuseErr):
+		return ErrorTypeRateLimit
+	case errors.As(err, &respErr) && respErr.Response != nil:
+		switch respErr.Response.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return ErrorTypeAuth
+		case http.StatusTooManyRequests:
+			return ErrorTypeRateLimit
+		}
+		return ErrorTypeAPI
+	case errors.As(err, &netErr):
+		return ErrorTypeAPI
+	case errors.As(err, &pathErr):
+		return ErrorTypeIO
+	case err != nil && strings.Contains(err.Error(), "rate limit exceeded"):
+		// GraphQL errors only carry their message
+		return ErrorTypeRateLimit
+	}
+	return ErrorTypeGeneral
+}
+
 // AppError represents an application-specific error with type classification.
 type AppError struct {
 	Type      ErrorType
 	Message   string
 	Cause     error
 	Retryable bool
+	Retries   int // Times the failed call was retried
 }
 
 // Error implements the error interface.